@@ -0,0 +1,102 @@
+// Command migrate applies, reverts and lists the recipeDB migrations.
+//
+//	go run ./cmd/migrate up        apply the pending migrations
+//	go run ./cmd/migrate down [n]  revert the last n applied migrations, 1 by default
+//	go run ./cmd/migrate status    list the migrations and when they were applied
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"framework-api/migrations"
+	"framework-api/utils"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+const usage = "usage: migrate up | down [n] | status"
+
+func main() {
+	logger, loggerCleanup, err := utils.InitLogger()
+	if err != nil {
+		panic(err)
+	}
+	defer loggerCleanup()
+	zap.ReplaceGlobals(logger)
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		logger.Error("Migration failed", zap.Error(err))
+		loggerCleanup()
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	mongoDBUri := os.Getenv("MONGODB_URI")
+	if mongoDBUri == "" {
+		return errors.New("MONGODB_URI is not set")
+	}
+	client, err := mongo.Connect(options.Client().ApplyURI(mongoDBUri))
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(ctx)
+	db := client.Database("recipeDB")
+	migrator, err := migrations.New(db, migrations.NewMongoRecordStore(db), migrations.RecipeDB)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied %d %s\n", m.Version, m.Description)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("Nothing to apply")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("down takes a positive number of migrations, got %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("Reverted %d %s\n", m.Version, m.Description)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tAPPLIED AT\tDESCRIPTION")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			description := s.Description
+			if s.Unknown {
+				description += " (unknown to this build)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, appliedAt, description)
+		}
+		return w.Flush()
+	default:
+		return errors.New(usage)
+	}
+}
@@ -0,0 +1,144 @@
+// Command seed loads the fixtures users, recipes and reviews into MongoDB, Redis and Elasticsearch, for local
+// development. Seeding again updates the same documents, their IDs are derived from the fixture keys.
+//
+//	go run ./cmd/seed            seed the latest fixtures
+//	go run ./cmd/seed -reset     delete the fixture users and reviews and the default tenant's recipes first
+//	go run ./cmd/seed -version 1 seed an older fixture version
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"framework-api/fixtures"
+	"framework-api/handlers"
+	"framework-api/i18n"
+	"framework-api/migrations"
+	"framework-api/utils"
+	"os"
+
+	"github.com/elastic/go-elasticsearch/v9"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+func main() {
+	reset := flag.Bool("reset", false, "delete the fixture users and reviews and every recipe of the default tenant before seeding")
+	version := flag.Int("version", fixtures.Latest, "fixture version to seed")
+	flag.Parse()
+	logger, loggerCleanup, err := utils.InitLogger()
+	if err != nil {
+		panic(err)
+	}
+	defer loggerCleanup()
+	zap.ReplaceGlobals(logger)
+	if err := run(context.Background(), *version, *reset); err != nil {
+		logger.Error("Seeding failed", zap.Error(err))
+		loggerCleanup()
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, version int, reset bool) error {
+	set, err := fixtures.Load(version)
+	if err != nil {
+		return err
+	}
+	mongoDBUri := os.Getenv("MONGODB_URI")
+	if mongoDBUri == "" {
+		return errors.New("MONGODB_URI is not set")
+	}
+	client, err := mongo.Connect(options.Client().ApplyURI(mongoDBUri))
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(ctx)
+	db := client.Database("recipeDB")
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		return err
+	}
+	elasticsearchClient, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{os.Getenv("ELASTICSEARCH_URI")}})
+	if err != nil {
+		return err
+	}
+	recipeHandler := handlers.NewRecipesHandler(ctx, handlers.NewMongoRecipeStore(db.Collection("recipes")), redisClient, elasticsearchClient)
+	locales, err := i18n.NewMatcher(utils.GetEnvList("SUPPORTED_LOCALES", i18n.DefaultSupported))
+	if err != nil {
+		return err
+	}
+	recipeHandler.SetLocales(locales)
+
+	//The unique username index must exist before users are written
+	migrator, err := migrations.New(db, migrations.NewMongoRecordStore(db), migrations.RecipeDB)
+	if err != nil {
+		return err
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return err
+	}
+	if err := recipeHandler.EnsureElasticIndex(ctx); err != nil {
+		return err
+	}
+	if reset {
+		if err := resetFixtures(ctx, db, set); err != nil {
+			return err
+		}
+		//The fixtures are seeded in the default tenant, the recipes of other tenants are kept
+		fmt.Fprintln(os.Stderr, "Deleting every recipe of the default tenant")
+		if err := recipeHandler.ResetRecipes(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, user := range set.Users {
+		if err := upsert(ctx, db.Collection("users"), user.ID, user); err != nil {
+			return fmt.Errorf("user %s: %w", user.Username, err)
+		}
+	}
+	if err := recipeHandler.SeedRecipes(ctx, set.Recipes); err != nil {
+		return err
+	}
+	for _, review := range set.Reviews {
+		if err := upsert(ctx, db.Collection("reviews"), review.ID, review); err != nil {
+			return fmt.Errorf("review %s: %w", review.Key, err)
+		}
+	}
+	fmt.Printf("Seeded fixtures v%d: %d users, %d recipes, %d reviews\n", set.Version, len(set.Users), len(set.Recipes), len(set.Reviews))
+	return nil
+}
+
+// resetFixtures deletes the fixture users, with the users signed up under a fixture username, and the reviews of
+// the fixture recipes in the default tenant. Other users and the reviews of other tenants are kept.
+func resetFixtures(ctx context.Context, db *mongo.Database, set *fixtures.Set) error {
+	userIDs := make([]bson.ObjectID, 0, len(set.Users))
+	usernames := make([]string, 0, len(set.Users))
+	for _, user := range set.Users {
+		userIDs = append(userIDs, user.ID)
+		usernames = append(usernames, user.Username)
+	}
+	users := bson.M{"$or": bson.A{bson.M{"_id": bson.M{"$in": userIDs}}, bson.M{"username": bson.M{"$in": usernames}}}}
+	if _, err := db.Collection("users").DeleteMany(ctx, users); err != nil {
+		return err
+	}
+	recipeIDs := make([]bson.ObjectID, 0, len(set.Recipes))
+	for _, recipe := range set.Recipes {
+		recipeIDs = append(recipeIDs, recipe.ID)
+	}
+	reviews := bson.M{"tenant": nil, "recipeId": bson.M{"$in": recipeIDs}}
+	_, err := db.Collection("reviews").DeleteMany(ctx, reviews)
+	return err
+}
+
+// upsert replaces the document with id. A user signed up with a fixture username has another ID, -reset removes it.
+func upsert(ctx context.Context, collection *mongo.Collection, id bson.ObjectID, doc interface{}) error {
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": id}, doc, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w, seed with -reset to replace it", err)
+	}
+	return err
+}
@@ -0,0 +1,180 @@
+// Package dietary derives allergens and diets of recipes from their ingredient lines,
+// using an ingredient rule table bundled with the binary (rules.json).
+package dietary
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"framework-api/models"
+	"framework-api/nutrition"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed rules.json
+var bundledRules []byte
+
+// Rules is the rule table format. Ingredients map names to categories, allergens are the categories
+// reported to clients and every diet lists the categories it excludes.
+type Rules struct {
+	Allergens   []string            `json:"allergens"`
+	Diets       map[string][]string `json:"diets"`
+	Ingredients []Rule              `json:"ingredients"`
+}
+
+// Rule tags the ingredients called one of Names. An empty Contains marks an exception,
+// like "coconut milk" which would otherwise be dairy.
+type Rule struct {
+	Names    []string `json:"names"`
+	Contains []string `json:"contains"`
+}
+
+// Classifier matches ingredient lines against the rules, longer names win over the names they contain.
+type Classifier struct {
+	rules Rules
+	keys  []ruleKey
+}
+
+type ruleKey struct {
+	words string
+	rule  int
+}
+
+// Classification is what Classify derives for a recipe.
+type Classification struct {
+	Allergens []string
+	Diets     []string
+	Conflicts []models.TagConflict
+}
+
+var defaultClassifier = sync.OnceValue(func() *Classifier {
+	classifier, err := Load(bytes.NewReader(bundledRules))
+	if err != nil {
+		panic("dietary: invalid bundled rules.json: " + err.Error())
+	}
+	return classifier
+})
+
+// Default returns the classifier with the bundled rules.
+func Default() *Classifier {
+	return defaultClassifier()
+}
+
+// Load reads rules in the rules.json format.
+func Load(r io.Reader) (*Classifier, error) {
+	var rules Rules
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, err
+	}
+	c := &Classifier{rules: rules}
+	for i, rule := range rules.Ingredients {
+		for _, name := range rule.Names {
+			if words := nutrition.Normalize(name); words != "" {
+				c.keys = append(c.keys, ruleKey{words: words, rule: i})
+			}
+		}
+	}
+	sort.SliceStable(c.keys, func(i, j int) bool { return len(c.keys[i].words) > len(c.keys[j].words) })
+	return c, nil
+}
+
+// IsAllergen reports whether name is one of the allergens of the table.
+func (c *Classifier) IsAllergen(name string) bool {
+	return slices.Contains(c.rules.Allergens, name)
+}
+
+// Allergens returns the allergens of the table.
+func (c *Classifier) Allergens() []string {
+	return slices.Clone(c.rules.Allergens)
+}
+
+// Categories returns the categories of an ingredient line. A line can name several foods
+// ("salt and butter"), every matched span is removed so "peanut butter" is not also "butter".
+func (c *Classifier) Categories(text string) []string {
+	words := " " + nutrition.Normalize(text) + " "
+	categories := make([]string, 0)
+	for _, key := range c.keys {
+		needle := " " + key.words + " "
+		if !strings.Contains(words, needle) {
+			continue
+		}
+		words = strings.ReplaceAll(words, needle, " | ")
+		for _, category := range c.rules.Ingredients[key.rule].Contains {
+			if !slices.Contains(categories, category) {
+				categories = append(categories, category)
+			}
+		}
+	}
+	return categories
+}
+
+// Classify derives the allergens and diets of a recipe and reports the tags naming a diet the
+// ingredients break. A recipe without ingredients has no diets, there is nothing to vouch for.
+func (c *Classifier) Classify(ingredients []string, tags []string) Classification {
+	result := Classification{Allergens: make([]string, 0), Diets: make([]string, 0), Conflicts: make([]models.TagConflict, 0)}
+	//Categories found in the recipe with the ingredient lines they come from
+	found := map[string][]string{}
+	for _, line := range ingredients {
+		for _, category := range c.Categories(line) {
+			found[category] = append(found[category], line)
+		}
+	}
+	for _, allergen := range c.rules.Allergens {
+		if _, ok := found[allergen]; ok {
+			result.Allergens = append(result.Allergens, allergen)
+		}
+	}
+	if len(ingredients) > 0 {
+		for _, diet := range c.dietNames() {
+			if len(breaking(c.rules.Diets[diet], found)) == 0 {
+				result.Diets = append(result.Diets, diet)
+			}
+		}
+	}
+	for _, tag := range tags {
+		diet := normalizeTag(tag)
+		excluded, ok := c.rules.Diets[diet]
+		if !ok || len(ingredients) == 0 || slices.Contains(result.Diets, diet) {
+			continue
+		}
+		conflict := models.TagConflict{Tag: tag, Contains: breaking(excluded, found), Ingredients: make([]string, 0)}
+		for _, category := range conflict.Contains {
+			for _, line := range found[category] {
+				if !slices.Contains(conflict.Ingredients, line) {
+					conflict.Ingredients = append(conflict.Ingredients, line)
+				}
+			}
+		}
+		result.Conflicts = append(result.Conflicts, conflict)
+	}
+	return result
+}
+
+func (c *Classifier) dietNames() []string {
+	names := make([]string, 0, len(c.rules.Diets))
+	for name := range c.rules.Diets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// breaking returns the excluded categories present in the recipe.
+func breaking(excluded []string, found map[string][]string) []string {
+	res := make([]string, 0)
+	for _, category := range excluded {
+		if _, ok := found[category]; ok {
+			res = append(res, category)
+		}
+	}
+	return res
+}
+
+// normalizeTag lets "Gluten Free" and "gluten_free" name the gluten-free diet.
+func normalizeTag(tag string) string {
+	return strings.NewReplacer(" ", "-", "_", "-").Replace(strings.ToLower(strings.TrimSpace(tag)))
+}
@@ -0,0 +1,97 @@
+package dietary
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCategories(t *testing.T) {
+	classifier := Default()
+	ts := []struct {
+		text       string
+		categories []string
+	}{
+		{text: "2 tbsp butter", categories: []string{"dairy"}},
+		{text: "3 tbsp peanut butter", categories: []string{"peanuts"}},
+		{text: "1 can coconut milk", categories: []string{}},
+		{text: "200g egg noodles", categories: []string{"eggs", "gluten", "grains"}},
+		{text: "1 eggplant, diced", categories: []string{}},
+		{text: "2 tbsp soy sauce", categories: []string{"soy", "gluten"}},
+		{text: "Salt and butter to taste", categories: []string{"dairy"}},
+		{text: "4 large tomatoes", categories: []string{}},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		categories := classifier.Categories(tc.text)
+		slices.Sort(categories)
+		slices.Sort(tc.categories)
+		if !slices.Equal(categories, tc.categories) {
+			t.Errorf("Expected %v, got %v", tc.categories, categories)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	classifier := Default()
+	ts := []struct {
+		text        string
+		ingredients []string
+		tags        []string
+		allergens   []string
+		diets       []string
+		conflicts   []string
+	}{
+		{
+			text:        "vegan lentil soup",
+			ingredients: []string{"1 cup red lentils", "1 onion", "2 cups vegetable stock"},
+			tags:        []string{"soup", "Vegan"},
+			allergens:   []string{},
+			diets:       []string{"dairy-free", "gluten-free", "nut-free", "vegan", "vegetarian", "pescatarian"},
+			conflicts:   []string{},
+		},
+		{
+			text:        "buttered pasta tagged vegan and gluten free",
+			ingredients: []string{"200g spaghetti", "2 tbsp butter", "50g parmesan"},
+			tags:        []string{"vegan", "gluten_free", "quick"},
+			allergens:   []string{"gluten", "dairy"},
+			diets:       []string{"nut-free", "pescatarian", "vegetarian"},
+			conflicts:   []string{"vegan", "gluten_free"},
+		},
+		{
+			text:        "keto steak",
+			ingredients: []string{"1 steak", "1 tbsp butter", "Salt to taste"},
+			tags:        []string{"keto"},
+			allergens:   []string{"dairy"},
+			diets:       []string{"gluten-free", "keto", "nut-free"},
+			conflicts:   []string{},
+		},
+		{
+			text:      "no ingredients",
+			tags:      []string{"vegan"},
+			allergens: []string{},
+			diets:     []string{},
+			conflicts: []string{},
+		},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		result := classifier.Classify(tc.ingredients, tc.tags)
+		if !slices.Equal(result.Allergens, tc.allergens) {
+			t.Errorf("Expected allergens %v, got %v", tc.allergens, result.Allergens)
+		}
+		slices.Sort(tc.diets)
+		if !slices.Equal(result.Diets, tc.diets) {
+			t.Errorf("Expected diets %v, got %v", tc.diets, result.Diets)
+		}
+		conflicts := make([]string, 0)
+		for _, conflict := range result.Conflicts {
+			conflicts = append(conflicts, conflict.Tag)
+			if len(conflict.Contains) == 0 || len(conflict.Ingredients) == 0 {
+				t.Errorf("Expected the conflict on %s to name categories and ingredients, got %+v", conflict.Tag, conflict)
+			}
+		}
+		if !slices.Equal(conflicts, tc.conflicts) {
+			t.Errorf("Expected conflicts %v, got %v", tc.conflicts, conflicts)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package export renders recipes for printing and sharing outside the app: Markdown, plain text and PDF,
+// either one recipe or a cookbook of several with a table of contents.
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"framework-api/models"
+	"io"
+	"strings"
+)
+
+// Formats of a single recipe export, the batch export is always PDF.
+const (
+	FormatPDF      = "pdf"
+	FormatMarkdown = "md"
+	FormatText     = "txt"
+)
+
+// ContentTypes of the formats.
+var ContentTypes = map[string]string{
+	FormatPDF:      "application/pdf",
+	FormatMarkdown: "text/markdown; charset=utf-8",
+	FormatText:     "text/plain; charset=utf-8",
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+// EscapeMarkdown escapes the characters that would turn recipe text into markup, links or HTML.
+func EscapeMarkdown(text string) string {
+	return markdownEscaper.Replace(strings.Join(strings.Fields(text), " "))
+}
+
+// Markdown writes the recipe as a Markdown document, imageURL is left out when empty.
+func Markdown(w io.Writer, recipe models.Recipe, imageURL string) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# %s\n\n", EscapeMarkdown(recipe.Name))
+	if imageURL != "" {
+		fmt.Fprintf(b, "![%s](<%s>)\n\n", EscapeMarkdown(recipe.Name), strings.NewReplacer("<", "%3C", ">", "%3E").Replace(imageURL))
+	}
+	for _, line := range details(recipe) {
+		fmt.Fprintf(b, "%s  \n", EscapeMarkdown(line))
+	}
+	b.WriteString("\n## Ingredients\n\n")
+	for _, ingredient := range recipe.Ingredients {
+		fmt.Fprintf(b, "- %s\n", EscapeMarkdown(ingredient))
+	}
+	b.WriteString("\n## Instructions\n\n")
+	for i, step := range recipe.Instructions {
+		fmt.Fprintf(b, "%d. %s\n", i+1, EscapeMarkdown(step))
+	}
+	if recipe.Nutrition != nil {
+		b.WriteString("\n## Nutrition per serving\n\n| | |\n|---|---|\n")
+		for _, row := range nutritionRows(recipe.Nutrition.PerServing) {
+			fmt.Fprintf(b, "| %s | %s |\n", row[0], row[1])
+		}
+	}
+	return b.Flush()
+}
+
+// Text writes the recipe as plain text, for pasting into messages and notes.
+func Text(w io.Writer, recipe models.Recipe) error {
+	b := bufio.NewWriter(w)
+	name := strings.Join(strings.Fields(recipe.Name), " ")
+	fmt.Fprintf(b, "%s\n%s\n\n", name, strings.Repeat("=", len([]rune(name))))
+	for _, line := range details(recipe) {
+		fmt.Fprintf(b, "%s\n", line)
+	}
+	b.WriteString("\nIngredients\n-----------\n")
+	for _, ingredient := range recipe.Ingredients {
+		fmt.Fprintf(b, "- %s\n", ingredient)
+	}
+	b.WriteString("\nInstructions\n------------\n")
+	for i, step := range recipe.Instructions {
+		fmt.Fprintf(b, "%d. %s\n", i+1, step)
+	}
+	if recipe.Nutrition != nil {
+		b.WriteString("\nNutrition per serving\n---------------------\n")
+		for _, row := range nutritionRows(recipe.Nutrition.PerServing) {
+			fmt.Fprintf(b, "%-14s %s\n", row[0]+":", row[1])
+		}
+	}
+	return b.Flush()
+}
+
+// details are the short lines under the title: servings, tags, diets and allergens.
+func details(recipe models.Recipe) []string {
+	var lines []string
+	if recipe.Servings > 0 {
+		lines = append(lines, fmt.Sprintf("Serves %d", recipe.Servings))
+	}
+	if len(recipe.Tags) > 0 {
+		lines = append(lines, "Tags: "+strings.Join(recipe.Tags, ", "))
+	}
+	if len(recipe.Diets) > 0 {
+		lines = append(lines, "Suitable for: "+strings.Join(recipe.Diets, ", "))
+	}
+	if len(recipe.Allergens) > 0 {
+		lines = append(lines, "Contains: "+strings.Join(recipe.Allergens, ", "))
+	}
+	return lines
+}
+
+func nutritionRows(facts models.NutritionFacts) [][2]string {
+	return [][2]string{
+		{"Calories", fmt.Sprintf("%.0f kcal", facts.Calories)},
+		{"Protein", fmt.Sprintf("%.1f g", facts.Protein)},
+		{"Carbohydrates", fmt.Sprintf("%.1f g", facts.Carbohydrates)},
+		{"Fat", fmt.Sprintf("%.1f g", facts.Fat)},
+		{"Fiber", fmt.Sprintf("%.1f g", facts.Fiber)},
+	}
+}
@@ -0,0 +1,175 @@
+package export
+
+import (
+	"bytes"
+	"framework-api/models"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func testRecipe() models.Recipe {
+	return models.Recipe{
+		Name:         "Mom's *best* soup",
+		Servings:     4,
+		Tags:         []string{"soup", "winter"},
+		Diets:        []string{"vegan"},
+		Ingredients:  []string{"4 tomatoes", "1 onion"},
+		Instructions: []string{"Chop everything", "Simmer for 20 minutes"},
+		Nutrition:    &models.Nutrition{PerServing: models.NutritionFacts{Calories: 180, Protein: 4.25}},
+	}
+}
+
+// testPNG is a half transparent PNG.
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 20; y++ {
+			img.Set(x, y, color.NRGBA{R: 200, A: uint8(x * 6)})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Unexpected error encoding PNG: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func TestMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Markdown(&buf, testRecipe(), "https://recipes.example.com/static/images/soup.jpg"); err != nil {
+		t.Fatalf("Unexpected error writing Markdown: %s", err)
+	}
+	out := buf.String()
+	for _, exp := range []string{
+		"# Mom's \\*best\\* soup\n",
+		"![Mom's \\*best\\* soup](<https://recipes.example.com/static/images/soup.jpg>)\n",
+		"Serves 4  \nTags: soup, winter  \nSuitable for: vegan  \n",
+		"## Ingredients\n\n- 4 tomatoes\n- 1 onion\n",
+		"## Instructions\n\n1. Chop everything\n2. Simmer for 20 minutes\n",
+		"| Calories | 180 kcal |\n| Protein | 4.2 g |\n",
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %q in %s", exp, out)
+		}
+	}
+
+	buf.Reset()
+	recipe := testRecipe()
+	recipe.Nutrition = nil
+	Markdown(&buf, recipe, "")
+	if strings.Contains(buf.String(), "![") || strings.Contains(buf.String(), "Nutrition") {
+		t.Errorf("Expected no image and no nutrition, got %s", buf.String())
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	ts := []struct {
+		text string
+		exp  string
+	}{
+		{text: "plain text", exp: "plain text"},
+		{text: "[click](javascript:alert(1))", exp: `\[click\](javascript:alert(1))`},
+		{text: "<script>x</script>", exp: `\<script\>x\</script\>`},
+		{text: "# not a heading\n\nnor a paragraph", exp: `\# not a heading nor a paragraph`},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if got := EscapeMarkdown(tc.text); got != tc.exp {
+			t.Errorf("Expected %s, got %s", tc.exp, got)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Text(&buf, testRecipe()); err != nil {
+		t.Fatalf("Unexpected error writing text: %s", err)
+	}
+	exp := "Mom's *best* soup\n=================\n\nServes 4\nTags: soup, winter\nSuitable for: vegan\n\n" +
+		"Ingredients\n-----------\n- 4 tomatoes\n- 1 onion\n\n" +
+		"Instructions\n------------\n1. Chop everything\n2. Simmer for 20 minutes\n\n" +
+		"Nutrition per serving\n---------------------\nCalories:      180 kcal\nProtein:       4.2 g\nCarbohydrates: 0.0 g\nFat:           0.0 g\nFiber:         0.0 g\n"
+	if buf.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, buf.String())
+	}
+}
+
+func TestNewImage(t *testing.T) {
+	img, err := NewImage(testPNG(t))
+	if err != nil {
+		t.Fatalf("Unexpected error converting PNG: %s", err)
+	}
+	if img.width != 40 || img.height != 20 || !bytes.HasPrefix(img.jpeg, []byte{0xff, 0xd8}) {
+		t.Errorf("Expected a 40x20 JPEG, got %dx%d", img.width, img.height)
+	}
+	if _, err := NewImage([]byte("<svg></svg>")); err == nil {
+		t.Errorf("Expected an error for an unsupported format")
+	}
+}
+
+var pagePattern = regexp.MustCompile(`/Type /Page\b[^s]`)
+
+// utf16 encodes ASCII text the way it appears in an uncompressed page.
+func utf16(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		b.WriteByte(0)
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func TestRecipePDF(t *testing.T) {
+	img, err := NewImage(testPNG(t))
+	if err != nil {
+		t.Fatalf("Unexpected error converting PNG: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := RecipePDF(&buf, Page{Recipe: testRecipe(), Image: img}); err != nil {
+		t.Fatalf("Unexpected error writing PDF: %s", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-") || len(pagePattern.FindAllString(out, -1)) != 1 || !strings.Contains(out, "/Subtype /Image") {
+		t.Errorf("Expected a one page PDF with an image")
+	}
+}
+
+func TestCookbookPDF(t *testing.T) {
+	pages := []Page{{Recipe: testRecipe()}, {Recipe: testRecipe()}, {Recipe: testRecipe()}}
+	pages[1].Recipe.Name = "Суп"
+	pages[2].Recipe.Instructions = make([]string, 30)
+	for i := range pages[2].Recipe.Instructions {
+		pages[2].Recipe.Instructions[i] = "Stir"
+	}
+	d := cookbook("Winter soups", pages)
+	d.pdf.SetCompression(false)
+	var buf bytes.Buffer
+	if err := d.pdf.Output(&buf); err != nil {
+		t.Fatalf("Unexpected error writing PDF: %s", err)
+	}
+	out := buf.String()
+	//Cover, contents, one page each for the first two recipes and two for the long one
+	if n := len(pagePattern.FindAllString(out, -1)); n != 6 {
+		t.Errorf("Expected 6 pages, got %d", n)
+	}
+	if strings.Contains(out, utf16("{toc:")) {
+		t.Errorf("Expected the page number aliases to be replaced")
+	}
+	for _, exp := range []string{"Winter soups", "Contents", "1. Mom's *best* soup", "2. "} {
+		if !strings.Contains(out, utf16(exp)) {
+			t.Errorf("Expected %s in the PDF", exp)
+		}
+	}
+	//Bookmarks are UTF-16BE text strings
+	if !strings.Contains(out, "\xfe\xff\x04\x21\x04\x43\x04\x3f") {
+		t.Errorf("Expected a bookmark for Суп")
+	}
+	if n := strings.Count(out, "/Title "); n != 4 {
+		t.Errorf("Expected the document title and 3 bookmarks, got %d titles", n)
+	}
+}
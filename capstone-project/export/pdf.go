@@ -0,0 +1,239 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"framework-api/models"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// Page layout in millimeters, A4 portrait.
+const (
+	margin         = 20.0
+	contentWidth   = 210 - 2*margin
+	maxImageHeight = 90.0
+	fontFamily     = "go"
+	// maxImagePixels rejects images that would take too much memory to decode, 40 megapixels.
+	maxImagePixels = 40_000_000
+)
+
+// ErrImageTooLarge is returned by NewImage for images above 40 megapixels.
+var ErrImageTooLarge = errors.New("Image is too large")
+
+// Image is a recipe picture ready to be placed in a PDF.
+type Image struct {
+	jpeg          []byte
+	width, height int
+}
+
+// NewImage decodes a JPEG, PNG or GIF and re-encodes it as a JPEG on a white background,
+// so transparent and interlaced images print like any other.
+func NewImage(data []byte) (*Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), img, bounds.Min, draw.Over)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, canvas, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return &Image{jpeg: buf.Bytes(), width: bounds.Dx(), height: bounds.Dy()}, nil
+}
+
+// Page is one recipe of a PDF, Image is left out when nil.
+type Page struct {
+	Recipe models.Recipe
+	Image  *Image
+}
+
+// RecipePDF writes one recipe as a PDF.
+func RecipePDF(w io.Writer, page Page) error {
+	return recipeDocument(page).pdf.Output(w)
+}
+
+// CookbookPDF writes the recipes as a cookbook: a cover with the title, a table of contents linking
+// to each recipe with its page number, then every recipe on a new page. PDF readers also list the
+// recipes as bookmarks.
+func CookbookPDF(w io.Writer, title string, pages []Page) error {
+	return cookbook(title, pages).pdf.Output(w)
+}
+
+func recipeDocument(page Page) *document {
+	d := newDocument(page.Recipe.Name, 1)
+	d.pdf.AddPage()
+	d.recipe(page)
+	return d
+}
+
+func cookbook(title string, pages []Page) *document {
+	if title == "" {
+		title = "Cookbook"
+	}
+	d := newDocument(title, 2)
+	pdf := d.pdf
+	pdf.AddPage()
+	pdf.SetY(100)
+	pdf.SetFont(fontFamily, "B", 28)
+	pdf.MultiCell(0, 12, title, "", "C", false)
+	pdf.SetFont(fontFamily, "", 12)
+	pdf.CellFormat(0, 10, recipeCount(len(pages)), "", 1, "C", false, 0, "")
+
+	pdf.AddPage()
+	d.heading("Contents")
+	pdf.SetFont(fontFamily, "", 11)
+	links := make([]int, len(pages))
+	for i, page := range pages {
+		links[i] = pdf.AddLink()
+		name := d.fit(fmt.Sprintf("%d. %s", i+1, page.Recipe.Name), contentWidth-20)
+		pdf.CellFormat(contentWidth-15, 7, name, "", 0, "L", false, links[i], "")
+		//Page numbers are only known once the recipes are laid out, the alias is replaced on output
+		pdf.CellFormat(15, 7, pageAlias(i), "", 1, "L", false, links[i], "")
+	}
+
+	for i, page := range pages {
+		pdf.AddPage()
+		pdf.SetLink(links[i], 0, -1)
+		pdf.Bookmark(page.Recipe.Name, 0, -1)
+		pdf.RegisterAlias(pageAlias(i), strconv.Itoa(pdf.PageNo()))
+		d.recipe(page)
+	}
+	return d
+}
+
+type document struct {
+	pdf    *fpdf.Fpdf
+	images int
+}
+
+// newDocument sets up an A4 document with the Go fonts, which cover Latin, Greek and Cyrillic text.
+// Pages from firstNumbered on get their number in the footer.
+func newDocument(title string, firstNumbered int) *document {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("Recipe Platform", true)
+	pdf.AddUTF8FontFromBytes(fontFamily, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", gobold.TTF)
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+	pdf.SetFooterFunc(func() {
+		if pdf.PageNo() < firstNumbered {
+			return
+		}
+		pdf.SetY(-15)
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.CellFormat(0, 10, strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	return &document{pdf: pdf}
+}
+
+// recipe writes the recipe from the current position: title, image, details, ingredients,
+// numbered instructions and nutrition.
+func (d *document) recipe(page Page) {
+	pdf := d.pdf
+	recipe := page.Recipe
+	pdf.SetFont(fontFamily, "B", 20)
+	pdf.MultiCell(0, 9, recipe.Name, "", "L", false)
+	pdf.Ln(2)
+	if page.Image != nil {
+		d.image(page.Image)
+	}
+	pdf.SetFont(fontFamily, "", 10)
+	for _, line := range details(recipe) {
+		pdf.MultiCell(0, 5, line, "", "L", false)
+	}
+
+	d.heading("Ingredients")
+	pdf.SetFont(fontFamily, "", 11)
+	for _, ingredient := range recipe.Ingredients {
+		pdf.CellFormat(6, 6, "•", "", 0, "L", false, 0, "")
+		pdf.MultiCell(0, 6, ingredient, "", "L", false)
+	}
+
+	d.heading("Instructions")
+	pdf.SetFont(fontFamily, "", 11)
+	for i, step := range recipe.Instructions {
+		pdf.CellFormat(8, 6, strconv.Itoa(i+1)+".", "", 0, "L", false, 0, "")
+		pdf.MultiCell(0, 6, step, "", "L", false)
+		pdf.Ln(1)
+	}
+
+	if recipe.Nutrition != nil {
+		d.heading("Nutrition per serving")
+		pdf.SetFont(fontFamily, "", 10)
+		for _, row := range nutritionRows(recipe.Nutrition.PerServing) {
+			pdf.CellFormat(40, 6, row[0], "B", 0, "L", false, 0, "")
+			pdf.CellFormat(30, 6, row[1], "B", 1, "R", false, 0, "")
+		}
+	}
+}
+
+// image places the picture at full content width, or smaller to stay within maxImageHeight.
+func (d *document) image(img *Image) {
+	pdf := d.pdf
+	d.images++
+	name := "image-" + strconv.Itoa(d.images)
+	options := fpdf.ImageOptions{ImageType: "JPG"}
+	pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(img.jpeg))
+	width := contentWidth
+	height := width * float64(img.height) / float64(img.width)
+	if height > maxImageHeight {
+		height = maxImageHeight
+		width = height * float64(img.width) / float64(img.height)
+	}
+	//Flowing, the image goes on the next page when it does not fit
+	pdf.ImageOptions(name, margin, -1, width, height, true, options, 0, "")
+	pdf.Ln(4)
+}
+
+func (d *document) heading(text string) {
+	d.pdf.Ln(4)
+	d.pdf.SetFont(fontFamily, "B", 14)
+	d.pdf.CellFormat(0, 8, text, "", 1, "L", false, 0, "")
+}
+
+// fit shortens text with an ellipsis to fit width in the current font.
+func (d *document) fit(text string, width float64) string {
+	if d.pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && d.pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// pageAlias is the placeholder of the page number of the i-th recipe in the table of contents.
+func pageAlias(i int) string {
+	return "{toc:" + strconv.Itoa(i) + ":page}"
+}
+
+func recipeCount(n int) string {
+	if n == 1 {
+		return "1 recipe"
+	}
+	return strconv.Itoa(n) + " recipes"
+}
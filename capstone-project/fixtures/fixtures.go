@@ -0,0 +1,173 @@
+// Package fixtures holds the versioned seed data of the recipe database: users, recipes and reviews with
+// deterministic IDs, loaded by the seed command and reusable from tests.
+package fixtures
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"framework-api/models"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Latest is the version of the newest fixture files, in v<version>/. Released versions are not edited,
+// tests pinned to them keep passing; changes go into a new version.
+const Latest = 1
+
+//go:embed v*/*.json
+var files embed.FS
+
+// User is a user of the mini recipes API, stored in users.
+type User struct {
+	ID       bson.ObjectID `json:"-" bson:"_id"`
+	Username string        `json:"username" bson:"username"`
+	// Password is the fixture password in clear, only PasswordHash is stored, the SHA-256 the mini recipes API checks.
+	Password     string `json:"password" bson:"-"`
+	PasswordHash string `json:"-" bson:"password"`
+	Email        string `json:"email" bson:"email"`
+	Name         string `json:"name" bson:"name"`
+	Age          int    `json:"age" bson:"age"`
+	Gender       string `json:"gender" bson:"gender"`
+}
+
+// Review is a user's rating of a recipe from 1 to 5, stored in reviews.
+type Review struct {
+	ID  bson.ObjectID `json:"-" bson:"_id"`
+	Key string        `json:"key" bson:"-"`
+	// Recipe is the key of the recipe fixture, RecipeID its ID.
+	Recipe    string        `json:"recipe" bson:"-"`
+	RecipeID  bson.ObjectID `json:"-" bson:"recipeId"`
+	Username  string        `json:"username" bson:"username"`
+	Rating    int           `json:"rating" bson:"rating"`
+	Comment   string        `json:"comment" bson:"comment"`
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+}
+
+// Set is the data of one fixture version, in file order.
+type Set struct {
+	Version int
+	Users   []User
+	Recipes []models.Recipe
+	Reviews []Review
+}
+
+// recipeFixture is a recipe with the key its ID is derived from.
+type recipeFixture struct {
+	Key string `json:"key"`
+	models.Recipe
+}
+
+// RecipeID is the ID of the recipe fixture with key, the same in every version.
+func RecipeID(key string) bson.ObjectID {
+	return id("recipe", key)
+}
+
+// UserID is the ID of the user fixture with username.
+func UserID(username string) bson.ObjectID {
+	return id("user", username)
+}
+
+// ReviewID is the ID of the review fixture with key.
+func ReviewID(key string) bson.ObjectID {
+	return id("review", key)
+}
+
+// id derives an ObjectID from the kind and key of a fixture, so seeding twice updates the same documents.
+func id(kind, key string) bson.ObjectID {
+	sum := sha256.Sum256([]byte(kind + ":" + key))
+	var oid bson.ObjectID
+	copy(oid[:], sum[:])
+	return oid
+}
+
+// Load reads the fixtures of version, assigns their IDs and password hashes and checks that keys are unique
+// and reviews reference existing recipes and users.
+func Load(version int) (*Set, error) {
+	set := &Set{Version: version}
+	var recipes []recipeFixture
+	for _, file := range []struct {
+		name string
+		v    interface{}
+	}{{"users", &set.Users}, {"recipes", &recipes}, {"reviews", &set.Reviews}} {
+		data, err := files.ReadFile(fmt.Sprintf("v%d/%s.json", version, file.name))
+		if err != nil {
+			return nil, fmt.Errorf("fixtures v%d: %w", version, err)
+		}
+		if err := json.Unmarshal(data, file.v); err != nil {
+			return nil, fmt.Errorf("fixtures v%d/%s.json: %w", version, file.name, err)
+		}
+	}
+
+	users := make(map[string]bool)
+	for i, user := range set.Users {
+		if user.Username == "" || users[user.Username] {
+			return nil, fmt.Errorf("fixtures v%d: user %q is empty or duplicated", version, user.Username)
+		}
+		users[user.Username] = true
+		sum := sha256.Sum256([]byte(user.Password))
+		set.Users[i].ID = UserID(user.Username)
+		set.Users[i].PasswordHash = hex.EncodeToString(sum[:])
+	}
+	keys := make(map[string]bool)
+	for _, recipe := range recipes {
+		if recipe.Key == "" || keys[recipe.Key] {
+			return nil, fmt.Errorf("fixtures v%d: recipe %q is empty or duplicated", version, recipe.Key)
+		}
+		keys[recipe.Key] = true
+		recipe.Recipe.ID = RecipeID(recipe.Key)
+		set.Recipes = append(set.Recipes, recipe.Recipe)
+	}
+	reviews := make(map[string]bool)
+	for i, review := range set.Reviews {
+		switch {
+		case review.Key == "" || reviews[review.Key]:
+			return nil, fmt.Errorf("fixtures v%d: review %q is empty or duplicated", version, review.Key)
+		case !keys[review.Recipe]:
+			return nil, fmt.Errorf("fixtures v%d: review %s has an unknown recipe %q", version, review.Key, review.Recipe)
+		case !users[review.Username]:
+			return nil, fmt.Errorf("fixtures v%d: review %s has an unknown user %q", version, review.Key, review.Username)
+		case review.Rating < 1 || review.Rating > 5:
+			return nil, fmt.Errorf("fixtures v%d: review %s has a rating outside 1 to 5", version, review.Key)
+		}
+		reviews[review.Key] = true
+		set.Reviews[i].ID = ReviewID(review.Key)
+		set.Reviews[i].RecipeID = RecipeID(review.Recipe)
+	}
+	return set, nil
+}
+
+// Recipe returns the recipe with key, false when there is none.
+func (s *Set) Recipe(key string) (models.Recipe, bool) {
+	for _, recipe := range s.Recipes {
+		if recipe.ID == RecipeID(key) {
+			return recipe, true
+		}
+	}
+	return models.Recipe{}, false
+}
+
+// CacheRecipes stores recipes in the Redis cache keys the recipe handler reads first, "recipes" and
+// "recipe:<id>", so tests can serve them without MongoDB.
+func CacheRecipes(ctx context.Context, redisClient *redis.Client, recipes []models.Recipe) error {
+	list, err := json.Marshal(recipes)
+	if err != nil {
+		return err
+	}
+	pipe := redisClient.Pipeline()
+	pipe.Set(ctx, "recipes", string(list), 0)
+	for _, recipe := range recipes {
+		data, err := json.Marshal(recipe)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, "recipe:"+recipe.ID.Hex(), string(data), 0)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,71 @@
+package fixtures
+
+import (
+	"context"
+	"framework-api/handlers"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLoad(t *testing.T) {
+	set, err := Load(Latest)
+	if err != nil {
+		t.Fatalf("Unexpected error loading fixtures: %s", err)
+	}
+	if len(set.Users) == 0 || len(set.Recipes) == 0 || len(set.Reviews) == 0 {
+		t.Fatalf("Expected users, recipes and reviews, got %d, %d and %d", len(set.Users), len(set.Recipes), len(set.Reviews))
+	}
+
+	//IDs are derived from the keys, tests and seeded databases can rely on them
+	if id := RecipeID("palak-paneer").Hex(); id != "d2de9e904b307d02c2c16b80" {
+		t.Errorf("Expected a stable recipe ID, got %s", id)
+	}
+	again, _ := Load(Latest)
+	if set.Recipes[0].ID != again.Recipes[0].ID || set.Users[0].ID != again.Users[0].ID || set.Reviews[0].ID != again.Reviews[0].ID {
+		t.Errorf("Expected the same IDs on every load")
+	}
+	recipe, ok := set.Recipe("palak-paneer")
+	if !ok || recipe.ID != RecipeID("palak-paneer") || recipe.Name != "Palak Paneer" || recipe.PublishedAt.IsZero() {
+		t.Errorf("Expected Palak Paneer with its ID and publish time, got %+v", recipe)
+	}
+	if _, ok := set.Recipe("nope"); ok {
+		t.Errorf("Expected no recipe for an unknown key")
+	}
+
+	//The sha256 of "admin-local-only", as the mini recipes API hashes passwords
+	if set.Users[0].PasswordHash != "a10791941ce90745da5a152809527273f069abe30df31eb9e4bc974158f4e2bc" {
+		t.Errorf("Expected a SHA-256 password hash, got %s", set.Users[0].PasswordHash)
+	}
+	if set.Reviews[0].RecipeID != RecipeID(set.Reviews[0].Recipe) {
+		t.Errorf("Expected the review to reference its recipe ID")
+	}
+
+	if _, err := Load(Latest + 1); err == nil {
+		t.Errorf("Expected an error for a version without fixtures")
+	}
+}
+
+func TestCacheRecipes(t *testing.T) {
+	ctx := context.Background()
+	set, err := Load(Latest)
+	if err != nil {
+		t.Fatalf("Unexpected error loading fixtures: %s", err)
+	}
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	if err := CacheRecipes(ctx, redisClient, set.Recipes); err != nil {
+		t.Fatalf("Unexpected error caching recipes: %s", err)
+	}
+
+	recipeHandler := handlers.NewRecipesHandler(ctx, nil, redisClient, nil)
+	recipes, err := recipeHandler.ListRecipes(ctx)
+	if err != nil || len(recipes) != len(set.Recipes) {
+		t.Errorf("Expected %d recipes from the cache, got %d: %v", len(set.Recipes), len(recipes), err)
+	}
+	recipe, err := recipeHandler.FindRecipe(ctx, RecipeID("tomato-soup").Hex())
+	if err != nil || recipe.Translations["fr"].Name != "Soupe de tomates" {
+		t.Errorf("Expected Tomato Soup with its translations, got %+v: %v", recipe, err)
+	}
+}
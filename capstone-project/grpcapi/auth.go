@@ -0,0 +1,148 @@
+package grpcapi
+
+import (
+	"context"
+	"framework-api/handlers"
+	recipesv1 "framework-api/proto/recipes/v1"
+	"net"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// permission is what a method requires, like RequireScopes/RequireTenantAdmin on the REST routes.
+type permission struct {
+	scopes []string
+	admin  bool
+}
+
+// methodPermissions mirrors the REST routes: reads and watching are public, writes need a token.
+var methodPermissions = map[string]permission{
+	recipesv1.RecipeService_CreateRecipe_FullMethodName: {scopes: []string{handlers.ScopeRecipesWrite}},
+	recipesv1.RecipeService_UpdateRecipe_FullMethodName: {scopes: []string{handlers.ScopeRecipesWrite}},
+	recipesv1.RecipeService_DeleteRecipe_FullMethodName: {admin: true},
+}
+
+// AuthInterceptor verifies the same JWTs and API keys as AuthMiddleware, read from the
+// "authorization" and "x-api-key" metadata, and resolves the tenant like TenantMiddleware from the "x-tenant-id"
+// metadata. The principal and the tenant are put in the context for the handlers.
+type AuthInterceptor struct {
+	auth *handlers.AuthHandler
+}
+
+func NewAuthInterceptor(auth *handlers.AuthHandler) *AuthInterceptor {
+	return &AuthInterceptor{auth: auth}
+}
+
+func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (i *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize authenticates the caller when credentials are sent. Invalid credentials are always rejected,
+// missing ones only for methods with a permission. The "x-request-id" metadata and the peer address are put in
+// the context for the audit log, like RequestIDMiddleware does.
+func (i *AuthInterceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = handlers.ContextWithRequestInfo(ctx, handlers.RequestInfo{ID: handlers.RequestID(firstValue(md, "x-request-id")), IP: peerIP(ctx)})
+	principal, err := i.auth.AuthenticateCredentials(firstValue(md, "x-api-key"), firstValue(md, "authorization"))
+	perm, protected := methodPermissions[method]
+	if handlers.IsMissingCredentials(err) && !protected {
+		tenant, err := handlers.ResolveTenant(nil, firstValue(md, "x-tenant-id"))
+		if err != nil {
+			return ctx, tenantError(err)
+		}
+		return handlers.ContextWithTenant(ctx, tenant), nil
+	}
+	if err != nil {
+		zap.L().Warn("gRPC authentication failed", zap.String("method", method), zap.Error(err))
+		i.auth.RecordAuthFailure(ctx, err)
+		return ctx, status.Error(codes.Unauthenticated, "Invalid or missing credentials")
+	}
+	tenant, err := handlers.ResolveTenant(principal, firstValue(md, "x-tenant-id"))
+	if err != nil {
+		return ctx, tenantError(err)
+	}
+	if protected && !perm.allows(principal, tenant) {
+		return ctx, status.Error(codes.PermissionDenied, "Insufficient permissions")
+	}
+	ctx = handlers.ContextWithTenant(handlers.ContextWithPrincipal(ctx, principal), tenant)
+	i.auth.RecordAuthSuccess(ctx, principal)
+	return ctx, nil
+}
+
+// allows applies the REST rules: admins of the tenant pass every check, the others have to be members.
+func (perm permission) allows(p *handlers.Principal, tenant string) bool {
+	if p.IsTenantAdmin(tenant) {
+		return true
+	}
+	if perm.admin || !p.MemberOf(tenant) {
+		return false
+	}
+	for _, scope := range perm.scopes {
+		if !p.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func tenantError(err error) error {
+	switch err {
+	case handlers.ErrTenantUnauthorized:
+		return status.Error(codes.Unauthenticated, "Authentication required for this tenant")
+	case handlers.ErrTenantForbidden:
+		return status.Error(codes.PermissionDenied, "Not a member of this tenant")
+	default:
+		return status.Error(codes.InvalidArgument, "Invalid x-tenant-id")
+	}
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// peerIP is the IP address of the client, empty when unknown.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// authenticatedStream replaces the stream context so streaming handlers see the principal.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
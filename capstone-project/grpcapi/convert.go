@@ -0,0 +1,66 @@
+package grpcapi
+
+import (
+	"framework-api/handlers"
+	"framework-api/models"
+	recipesv1 "framework-api/proto/recipes/v1"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProto(recipe models.Recipe) *recipesv1.Recipe {
+	res := &recipesv1.Recipe{
+		Id:           recipe.ID.Hex(),
+		Name:         recipe.Name,
+		Tags:         recipe.Tags,
+		Ingredients:  recipe.Ingredients,
+		Instructions: recipe.Instructions,
+		ImageUrl:     recipe.ImageURL,
+		Servings:     int32(recipe.Servings),
+		Allergens:    recipe.Allergens,
+		Diets:        recipe.Diets,
+	}
+	if !recipe.PublishedAt.IsZero() {
+		res.PublishedAt = timestamppb.New(recipe.PublishedAt)
+	}
+	if recipe.Nutrition != nil {
+		res.Nutrition = &recipesv1.Nutrition{
+			Total:      factsToProto(recipe.Nutrition.Total),
+			PerServing: factsToProto(recipe.Nutrition.PerServing),
+			Unmatched:  recipe.Nutrition.Unmatched,
+		}
+	}
+	for _, conflict := range recipe.TagConflicts {
+		res.TagConflicts = append(res.TagConflicts, &recipesv1.TagConflict{Tag: conflict.Tag, Contains: conflict.Contains, Ingredients: conflict.Ingredients})
+	}
+	return res
+}
+
+func factsToProto(facts models.NutritionFacts) *recipesv1.NutritionFacts {
+	return &recipesv1.NutritionFacts{
+		Calories:      facts.Calories,
+		Protein:       facts.Protein,
+		Carbohydrates: facts.Carbohydrates,
+		Fat:           facts.Fat,
+		Fiber:         facts.Fiber,
+	}
+}
+
+var eventTypes = map[string]recipesv1.RecipeEvent_Type{
+	handlers.EventRecipeCreated: recipesv1.RecipeEvent_TYPE_CREATED,
+	handlers.EventRecipeUpdated: recipesv1.RecipeEvent_TYPE_UPDATED,
+	handlers.EventRecipeDeleted: recipesv1.RecipeEvent_TYPE_DELETED,
+}
+
+func eventToProto(event handlers.RecipeEvent) *recipesv1.RecipeEvent {
+	res := &recipesv1.RecipeEvent{
+		Id:         event.ID,
+		Type:       eventTypes[event.Type],
+		RecipeId:   event.RecipeID,
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+	if event.Recipe != nil {
+		res.Recipe = toProto(*event.Recipe)
+	}
+	return res
+}
@@ -0,0 +1,301 @@
+// Package grpcapi serves recipes.v1.RecipeService next to the Gin REST API.
+// It calls the same RecipeHandler data methods, so storage, cache, search and events are shared.
+package grpcapi
+
+//go:generate protoc -I ../proto --go_out=../proto --go_opt=paths=source_relative --go-grpc_out=../proto --go-grpc_opt=paths=source_relative recipes/v1/recipes.proto
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"framework-api/handlers"
+	"framework-api/models"
+	recipesv1 "framework-api/proto/recipes/v1"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// RecipeServer implements recipesv1.RecipeServiceServer on top of RecipeHandler and RecipeEvents.
+type RecipeServer struct {
+	recipesv1.UnimplementedRecipeServiceServer
+	recipes *handlers.RecipeHandler
+	events  *handlers.RecipeEvents
+}
+
+func NewRecipeServer(recipes *handlers.RecipeHandler, events *handlers.RecipeEvents) *RecipeServer {
+	return &RecipeServer{recipes: recipes, events: events}
+}
+
+// NewServer returns a gRPC server with the recipe service, JWT auth interceptors and reflection for grpcurl.
+func NewServer(auth *handlers.AuthHandler, recipes *handlers.RecipeHandler, events *handlers.RecipeEvents) *grpc.Server {
+	interceptor := NewAuthInterceptor(auth)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(interceptor.Unary()),
+		grpc.ChainStreamInterceptor(interceptor.Stream()),
+	)
+	recipesv1.RegisterRecipeServiceServer(server, NewRecipeServer(recipes, events))
+	reflection.Register(server)
+	return server
+}
+
+func (s *RecipeServer) GetRecipe(ctx context.Context, req *recipesv1.GetRecipeRequest) (*recipesv1.Recipe, error) {
+	recipe, err := s.recipes.FindRecipe(ctx, req.GetId())
+	if err != nil {
+		return nil, recipeError(err, "Failed to get recipe")
+	}
+	return toProto(recipe), nil
+}
+
+func (s *RecipeServer) ListRecipes(ctx context.Context, req *recipesv1.ListRecipesRequest) (*recipesv1.ListRecipesResponse, error) {
+	limit := handlers.DefaultPageLimit
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+	if req.GetPageSize() > 0 {
+		limit = min(int(req.GetPageSize()), handlers.MaxPageLimit)
+	}
+	offset, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid page_token")
+	}
+	recipes, err := s.recipes.ListRecipes(ctx)
+	if err != nil {
+		return nil, recipeError(err, "Failed to list recipes")
+	}
+	start, end, page := handlers.Paginate(len(recipes), limit, offset)
+	res := &recipesv1.ListRecipesResponse{
+		Recipes:   make([]*recipesv1.Recipe, 0, end-start),
+		TotalSize: int32(page.Total),
+	}
+	for _, recipe := range recipes[start:end] {
+		res.Recipes = append(res.Recipes, toProto(recipe))
+	}
+	if page.Next != nil {
+		res.NextPageToken = encodePageToken(*page.Next)
+	}
+	return res, nil
+}
+
+func (s *RecipeServer) CreateRecipe(ctx context.Context, req *recipesv1.CreateRecipeRequest) (*recipesv1.Recipe, error) {
+	in := req.GetRecipe()
+	if strings.TrimSpace(in.GetName()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "recipe.name must not be empty")
+	}
+	if in.GetServings() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "recipe.servings must not be negative")
+	}
+	recipe, err := s.recipes.CreateRecipe(ctx, models.Recipe{
+		Name:         in.GetName(),
+		Tags:         listOrEmpty(in.GetTags()),
+		Ingredients:  listOrEmpty(in.GetIngredients()),
+		Instructions: listOrEmpty(in.GetInstructions()),
+		ImageURL:     in.GetImageUrl(),
+		Servings:     int(in.GetServings()),
+	})
+	if err != nil {
+		return nil, recipeError(err, "Failed to insert recipe")
+	}
+	return toProto(recipe), nil
+}
+
+func (s *RecipeServer) UpdateRecipe(ctx context.Context, req *recipesv1.UpdateRecipeRequest) (*recipesv1.Recipe, error) {
+	fields, err := updateFields(req.GetRecipe(), req.GetUpdateMask().GetPaths())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	recipe, err := s.recipes.UpdateRecipe(ctx, req.GetId(), fields)
+	if err != nil {
+		return nil, recipeError(err, "Failed to update recipe")
+	}
+	return toProto(recipe), nil
+}
+
+func (s *RecipeServer) DeleteRecipe(ctx context.Context, req *recipesv1.DeleteRecipeRequest) (*emptypb.Empty, error) {
+	if err := s.recipes.DeleteRecipe(ctx, req.GetId()); err != nil {
+		return nil, recipeError(err, "Failed to delete recipe")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *RecipeServer) SearchRecipes(ctx context.Context, req *recipesv1.SearchRecipesRequest) (*recipesv1.SearchRecipesResponse, error) {
+	if req.GetMaxCalories() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max_calories must not be negative")
+	}
+	excluded, err := s.recipes.ExcludedAllergens(req.GetExcludeAllergens())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if strings.TrimSpace(req.GetQuery()) == "" && len(req.GetTags()) == 0 && req.GetMaxCalories() == 0 && len(excluded) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "query, tags, max_calories or exclude_allergens is required")
+	}
+	hits, facets, err := s.recipes.SearchRecipes(ctx, handlers.RecipeQuery{
+		Q:                strings.TrimSpace(req.GetQuery()),
+		Tags:             req.GetTags(),
+		MaxCalories:      req.GetMaxCalories(),
+		ExcludeAllergens: excluded,
+		Facets:           int(req.GetFacets()),
+	})
+	if err != nil {
+		return nil, recipeError(err, "Failed to search recipes")
+	}
+	res := &recipesv1.SearchRecipesResponse{
+		Hits:   make([]*recipesv1.SearchHit, 0, len(hits)),
+		Facets: make([]*recipesv1.TagFacet, 0, len(facets)),
+	}
+	for _, hit := range hits {
+		res.Hits = append(res.Hits, &recipesv1.SearchHit{Id: hit.ID, Name: hit.Name, Tags: hit.Tags, ImageUrl: hit.ImageURL, CaloriesPerServing: hit.CaloriesPerServing})
+	}
+	for _, facet := range facets {
+		res.Facets = append(res.Facets, &recipesv1.TagFacet{Tag: facet.Tag, Count: int64(facet.Count)})
+	}
+	return res, nil
+}
+
+// WatchRecipes streams the same feed as GET /recipes/events until the client cancels.
+func (s *RecipeServer) WatchRecipes(req *recipesv1.WatchRecipesRequest, stream grpc.ServerStreamingServer[recipesv1.RecipeEvent]) error {
+	ctx := stream.Context()
+	lastID := req.GetLastEventId()
+	if lastID != "" && !handlers.ValidStreamID(lastID) {
+		return status.Error(codes.InvalidArgument, "Invalid last_event_id")
+	}
+	sub, err := s.events.Subscribe(ctx, lastID)
+	if err != nil {
+		zap.L().Error("Failed to subscribe to recipe events", zap.Error(err))
+		return status.Error(codes.Unavailable, "Failed to subscribe to recipe events")
+	}
+	defer sub.Close()
+
+	if sub.Gap {
+		//The stream was trimmed past the client's position, it has to reload the catalog
+		if err := stream.Send(&recipesv1.RecipeEvent{Type: recipesv1.RecipeEvent_TYPE_RESET}); err != nil {
+			return err
+		}
+	}
+	for _, event := range sub.Replayed {
+		if err := stream.Send(eventToProto(event)); err != nil {
+			return err
+		}
+	}
+	messages := sub.Messages()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return status.Error(codes.Unavailable, "Recipe event feed closed")
+			}
+			event, ok := sub.Accept(msg)
+			if !ok {
+				continue
+			}
+			if err := stream.Send(eventToProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// updateFields turns an update mask into the fields set by RecipeHandler.UpdateRecipe.
+// Without a mask every non-empty field of recipe is set.
+func updateFields(recipe *recipesv1.Recipe, paths []string) (bson.M, error) {
+	fields := bson.M{}
+	if len(paths) == 0 {
+		if recipe.GetName() != "" {
+			fields["name"] = recipe.GetName()
+		}
+		if len(recipe.GetTags()) > 0 {
+			fields["tags"] = recipe.GetTags()
+		}
+		if len(recipe.GetIngredients()) > 0 {
+			fields["ingredients"] = recipe.GetIngredients()
+		}
+		if len(recipe.GetInstructions()) > 0 {
+			fields["instructions"] = recipe.GetInstructions()
+		}
+		if recipe.GetImageUrl() != "" {
+			fields["imageUrl"] = recipe.GetImageUrl()
+		}
+		if recipe.GetServings() > 0 {
+			fields["servings"] = int(recipe.GetServings())
+		}
+	}
+	for _, path := range paths {
+		switch path {
+		case "name":
+			if strings.TrimSpace(recipe.GetName()) == "" {
+				return nil, errors.New("recipe.name must not be empty")
+			}
+			fields["name"] = recipe.GetName()
+		case "tags":
+			fields["tags"] = listOrEmpty(recipe.GetTags())
+		case "ingredients":
+			fields["ingredients"] = listOrEmpty(recipe.GetIngredients())
+		case "instructions":
+			fields["instructions"] = listOrEmpty(recipe.GetInstructions())
+		case "image_url":
+			fields["imageUrl"] = recipe.GetImageUrl()
+		case "servings":
+			if recipe.GetServings() < 0 {
+				return nil, errors.New("recipe.servings must not be negative")
+			}
+			fields["servings"] = int(recipe.GetServings())
+		default:
+			return nil, fmt.Errorf("update_mask path %q is not updatable", path)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("update must set at least one field")
+	}
+	return fields, nil
+}
+
+// recipeError maps the RecipeHandler errors to status codes and hides storage failures.
+func recipeError(err error, message string) error {
+	switch {
+	case errors.Is(err, handlers.ErrInvalidRecipeID):
+		return status.Error(codes.InvalidArgument, "Invalid ID")
+	case errors.Is(err, handlers.ErrRecipeNotFound):
+		return status.Error(codes.NotFound, "Recipe not found")
+	case errors.Is(err, handlers.ErrUnsupportedLocale):
+		return status.Error(codes.InvalidArgument, "Unsupported locale")
+	default:
+		zap.L().Error(message, zap.Error(err))
+		return status.Error(codes.Internal, message)
+	}
+}
+
+// Page tokens are opaque to clients, they carry the offset of the next page.
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "offset:%d", offset))
+}
+
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	var offset int
+	if _, err := fmt.Sscanf(string(raw), "offset:%d", &offset); err != nil || offset < 0 {
+		return 0, errors.New("invalid page token")
+	}
+	return offset, nil
+}
+
+func listOrEmpty(list []string) []string {
+	if list == nil {
+		return make([]string, 0)
+	}
+	return list
+}
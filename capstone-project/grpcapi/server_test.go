@@ -0,0 +1,229 @@
+package grpcapi
+
+import (
+	"context"
+	"framework-api/handlers"
+	"framework-api/handlers/cachetest"
+	"framework-api/models"
+	recipesv1 "framework-api/proto/recipes/v1"
+	"net"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+type testServer struct {
+	client recipesv1.RecipeServiceClient
+	events *handlers.RecipeEvents
+	issuer *handlers.LocalIssuer
+}
+
+// newTestServer serves the recipe service over an in-memory listener with recipes cached in miniredis.
+func newTestServer(t *testing.T, recipes []models.Recipe) testServer {
+	t.Helper()
+	_, redisClient := cachetest.NewRedis(t, recipes)
+	recipeHandler := handlers.NewRecipesHandler(context.Background(), nil, redisClient, nil)
+	events := handlers.NewRecipeEvents(redisClient, 0)
+	issuer, err := handlers.NewHS256Issuer("recipes-internal", "", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("Unexpected error creating issuer: %s", err)
+	}
+	authenticator, _ := issuer.Authenticator(time.Minute)
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(handlers.NewAuthHandler(authenticator), recipeHandler, events)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error dialing: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return testServer{client: recipesv1.NewRecipeServiceClient(conn), events: events, issuer: issuer}
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func testRecipes() []models.Recipe {
+	return []models.Recipe{
+		{ID: bson.NewObjectID(), Name: "Tomato Soup", Tags: []string{"soup"}, PublishedAt: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: bson.NewObjectID(), Name: "Greek Salad", Tags: []string{"salad"}},
+		{ID: bson.NewObjectID(), Name: "Lentil Soup", Tags: []string{"soup", "vegan"}},
+	}
+}
+
+func TestGetAndListRecipes(t *testing.T) {
+	recipes := testRecipes()
+	s := newTestServer(t, recipes)
+	ctx := context.Background()
+
+	recipe, err := s.client.GetRecipe(ctx, &recipesv1.GetRecipeRequest{Id: recipes[0].ID.Hex()})
+	if err != nil {
+		t.Fatalf("Unexpected error getting recipe: %s", err)
+	}
+	if recipe.GetName() != "Tomato Soup" || !recipe.GetPublishedAt().AsTime().Equal(recipes[0].PublishedAt) {
+		t.Errorf("Unexpected recipe %v", recipe)
+	}
+
+	names := make([]string, 0)
+	token := ""
+	for {
+		page, err := s.client.ListRecipes(ctx, &recipesv1.ListRecipesRequest{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("Unexpected error listing recipes: %s", err)
+		}
+		if page.GetTotalSize() != 3 {
+			t.Errorf("Expected total_size 3, got %d", page.GetTotalSize())
+		}
+		for _, r := range page.GetRecipes() {
+			names = append(names, r.GetName())
+		}
+		if token = page.GetNextPageToken(); token == "" {
+			break
+		}
+	}
+	if len(names) != 3 || names[2] != "Lentil Soup" {
+		t.Errorf("Expected all 3 recipes over two pages, got %v", names)
+	}
+}
+
+func TestRecipeServiceErrors(t *testing.T) {
+	recipes := testRecipes()
+	s := newTestServer(t, recipes)
+	reader, _ := s.issuer.Issue("reader", nil, nil, time.Minute)
+	writer, _ := s.issuer.Issue("writer", []string{handlers.ScopeRecipesWrite}, nil, time.Minute)
+	id := recipes[0].ID.Hex()
+	ts := []struct {
+		text string
+		call func(ctx context.Context) error
+		code codes.Code
+	}{
+		{text: "invalid id", code: codes.InvalidArgument, call: func(ctx context.Context) error {
+			_, err := s.client.GetRecipe(ctx, &recipesv1.GetRecipeRequest{Id: "not-an-id"})
+			return err
+		}},
+		{text: "invalid page token", code: codes.InvalidArgument, call: func(ctx context.Context) error {
+			_, err := s.client.ListRecipes(ctx, &recipesv1.ListRecipesRequest{PageToken: "???"})
+			return err
+		}},
+		{text: "invalid token on a public method", code: codes.Unauthenticated, call: func(ctx context.Context) error {
+			_, err := s.client.GetRecipe(withToken(ctx, "not-a-jwt"), &recipesv1.GetRecipeRequest{Id: id})
+			return err
+		}},
+		{text: "anonymous create", code: codes.Unauthenticated, call: func(ctx context.Context) error {
+			_, err := s.client.CreateRecipe(ctx, &recipesv1.CreateRecipeRequest{Recipe: &recipesv1.Recipe{Name: "Pho"}})
+			return err
+		}},
+		{text: "create without scope", code: codes.PermissionDenied, call: func(ctx context.Context) error {
+			_, err := s.client.CreateRecipe(withToken(ctx, reader), &recipesv1.CreateRecipeRequest{Recipe: &recipesv1.Recipe{Name: "Pho"}})
+			return err
+		}},
+		{text: "create without name", code: codes.InvalidArgument, call: func(ctx context.Context) error {
+			_, err := s.client.CreateRecipe(withToken(ctx, writer), &recipesv1.CreateRecipeRequest{Recipe: &recipesv1.Recipe{}})
+			return err
+		}},
+		{text: "update of an unknown field", code: codes.InvalidArgument, call: func(ctx context.Context) error {
+			_, err := s.client.UpdateRecipe(withToken(ctx, writer), &recipesv1.UpdateRecipeRequest{
+				Id: id, Recipe: &recipesv1.Recipe{Id: "x"}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"id"}},
+			})
+			return err
+		}},
+		{text: "delete with write scope only", code: codes.PermissionDenied, call: func(ctx context.Context) error {
+			_, err := s.client.DeleteRecipe(withToken(ctx, writer), &recipesv1.DeleteRecipeRequest{Id: id})
+			return err
+		}},
+		{text: "search without query", code: codes.InvalidArgument, call: func(ctx context.Context) error {
+			_, err := s.client.SearchRecipes(ctx, &recipesv1.SearchRecipesRequest{})
+			return err
+		}},
+		{text: "search excluding an unknown allergen", code: codes.InvalidArgument, call: func(ctx context.Context) error {
+			_, err := s.client.SearchRecipes(ctx, &recipesv1.SearchRecipesRequest{Query: "soup", ExcludeAllergens: []string{"kryptonite"}})
+			return err
+		}},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		err := tc.call(context.Background())
+		if status.Code(err) != tc.code {
+			t.Errorf("%s: expected code %s, got %v", tc.text, tc.code, err)
+		}
+	}
+}
+
+func TestWatchRecipes(t *testing.T) {
+	recipes := testRecipes()
+	s := newTestServer(t, recipes)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := s.client.WatchRecipes(ctx, &recipesv1.WatchRecipesRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error watching recipes: %s", err)
+	}
+	//The server subscribes asynchronously, publish until the first event arrives
+	first := make(chan error, 1)
+	var created *recipesv1.RecipeEvent
+	go func() {
+		event, err := stream.Recv()
+		created = event
+		first <- err
+	}()
+	for received := false; !received; {
+		s.events.Publish(ctx, handlers.RecipeEvent{Type: handlers.EventRecipeCreated, RecipeID: recipes[0].ID.Hex(), Recipe: &recipes[0], OccurredAt: time.Now()})
+		select {
+		case err := <-first:
+			if err != nil {
+				t.Fatalf("Unexpected error receiving the first event: %s", err)
+			}
+			received = true
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	if created.GetType() != recipesv1.RecipeEvent_TYPE_CREATED || created.GetRecipe().GetName() != "Tomato Soup" {
+		t.Errorf("Unexpected event %v", created)
+	}
+
+	//Resuming after the first event replays the rest from the stream
+	s.events.Publish(ctx, handlers.RecipeEvent{Type: handlers.EventRecipeDeleted, RecipeID: recipes[1].ID.Hex(), OccurredAt: time.Now()})
+	resumed, err := s.client.WatchRecipes(ctx, &recipesv1.WatchRecipesRequest{LastEventId: created.GetId()})
+	if err != nil {
+		t.Fatalf("Unexpected error resuming: %s", err)
+	}
+	for {
+		event, err := resumed.Recv()
+		if err != nil {
+			t.Fatalf("Unexpected error receiving replayed events: %s", err)
+		}
+		if event.GetType() == recipesv1.RecipeEvent_TYPE_DELETED {
+			if event.GetRecipeId() != recipes[1].ID.Hex() || event.GetRecipe() != nil {
+				t.Errorf("Unexpected delete event %v", event)
+			}
+			break
+		}
+	}
+
+	//Streaming errors arrive with the first Recv
+	invalid, err := s.client.WatchRecipes(ctx, &recipesv1.WatchRecipesRequest{LastEventId: "nope"})
+	if err == nil {
+		_, err = invalid.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an invalid last_event_id, got %v", err)
+	}
+}
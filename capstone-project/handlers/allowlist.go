@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxRedirects is the most redirects followed when fetching from an allowed host.
+const maxRedirects = 5
+
+// hostAllowlist holds the hosts the server fetches from on behalf of users, matched exactly and case
+// insensitively, so imports and exports can not be used to reach arbitrary or internal addresses.
+type hostAllowlist []string
+
+func newHostAllowlist(hosts []string) hostAllowlist {
+	allowed := make(hostAllowlist, 0, len(hosts))
+	for _, host := range hosts {
+		allowed = append(allowed, strings.ToLower(strings.TrimSpace(host)))
+	}
+	return allowed
+}
+
+func (a hostAllowlist) allows(u *url.URL) bool {
+	return (u.Scheme == "http" || u.Scheme == "https") && slices.Contains(a, strings.ToLower(u.Hostname()))
+}
+
+// client only follows redirects to allowed hosts, others fail the request with denied.
+func (a hostAllowlist) client(timeout time.Duration, denied error) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects || !a.allows(req.URL) {
+				return denied
+			}
+			return nil
+		},
+	}
+}
+
+// ErrPrivateAddress is returned when a destination given by a user is a loopback, private or link-local address.
+var ErrPrivateAddress = errors.New("destination is a private, loopback or link-local address")
+
+// nonPublicPrefixes are the ranges netip has no predicate for: "this network" and carrier-grade NAT.
+var nonPublicPrefixes = []netip.Prefix{netip.MustParsePrefix("0.0.0.0/8"), netip.MustParsePrefix("100.64.0.0/10")}
+
+// publicAddr reports whether addr may be reached on behalf of users. Loopback, private, link-local (169.254.0.0/16
+// holds the cloud metadata endpoints), multicast and unspecified addresses can not.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	return !slices.ContainsFunc(nonPublicPrefixes, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// privateHost reports whether a URL host is a non public destination without resolving it: localhost or an IP
+// literal that is not public. Names are checked once resolved, by publicDialer.
+func privateHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && !publicAddr(addr)
+}
+
+// publicDialer only connects to public addresses. The check runs on the resolved address of every connection, so
+// neither DNS answers nor redirects can point the server at internal services.
+func publicDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !publicAddr(addrPort.Addr()) {
+				return ErrPrivateAddress
+			}
+			return nil
+		},
+	}
+}
+
+// readLimited reads all of r, failing with tooLarge when it has more than limit bytes.
+func readLimited(r io.Reader, limit int64, tooLarge error) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, tooLarge
+	}
+	return data, nil
+}
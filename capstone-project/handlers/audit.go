@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"framework-api/models"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// Page size of GET /admin/audit, exports take up to MaxAuditLimit entries in one go.
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 10000
+)
+
+// AuditLog records the write, auth and admin actions in the AuditStore and serves them to the tenant admins.
+// A nil *AuditLog records nothing, so handlers work without one in tests and tools.
+type AuditLog struct {
+	store AuditStore
+}
+
+func NewAuditLog(store AuditStore) *AuditLog {
+	return &AuditLog{store: store}
+}
+
+// Enabled reports whether entries are recorded, callers skip computing the hashes otherwise.
+func (a *AuditLog) Enabled() bool {
+	return a != nil
+}
+
+// Record appends entry in the tenant of ctx. ID and time are assigned, the actor is the principal of ctx unless
+// set, the IP and request ID come from RequestIDMiddleware and the outcome defaults to success.
+// The action already happened, so a failing store is logged and does not fail the request. The entry is written
+// even when the client has gone away in between.
+func (a *AuditLog) Record(ctx context.Context, entry models.AuditEntry) {
+	if a == nil {
+		return
+	}
+	entry.ID = bson.NewObjectID()
+	entry.At = time.Now().UTC()
+	if entry.Outcome == "" {
+		entry.Outcome = models.AuditSuccess
+	}
+	if p, ok := PrincipalFromContext(ctx); ok && entry.Actor == "" {
+		entry.Actor = p.Subject
+		entry.ActorName = p.Username
+	}
+	info := RequestInfoFromContext(ctx)
+	entry.IP = info.IP
+	entry.RequestID = info.ID
+	if err := a.store.AppendAudit(context.WithoutCancel(ctx), entry); err != nil {
+		zap.L().Error("Failed to record audit entry", zap.String("action", entry.Action), zap.String("request_id", entry.RequestID), zap.Error(err))
+	}
+}
+
+// ListAudit returns the audit entries of the tenant, newest first, as JSON or as a CSV download with
+// ?format=csv or Accept: text/csv. Query filters: action, actor, resource, resourceId, outcome, from and to
+// (RFC 3339, from inclusive, to exclusive) and limit.
+func (a *AuditLog) ListAudit(c *gin.Context) {
+	filter, limit, err := ParseAuditQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entries, err := a.store.FindAudit(c.Request.Context(), filter, int64(limit))
+	if err != nil {
+		zap.L().Error("Failed to list audit entries", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list audit entries"})
+		return
+	}
+	if c.Query("format") == "csv" || (c.Query("format") == "" && strings.Contains(c.GetHeader("Accept"), "text/csv")) {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.csv"`, time.Now().UTC().Format("20060102T150405Z")))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := WriteAuditCSV(c.Writer, entries); err != nil {
+			zap.L().Error("Failed to write audit CSV", zap.Error(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// ParseAuditQuery reads the filters and page size of GET /admin/audit.
+func ParseAuditQuery(c *gin.Context) (AuditFilter, int, error) {
+	filter := AuditFilter{
+		Action:     c.Query("action"),
+		Actor:      c.Query("actor"),
+		Resource:   c.Query("resource"),
+		ResourceID: c.Query("resourceId"),
+		Outcome:    c.Query("outcome"),
+	}
+	if format := c.Query("format"); format != "" && format != "json" && format != "csv" {
+		return filter, 0, errors.New("format must be json or csv")
+	}
+	for _, bound := range []struct {
+		name string
+		to   *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		val := c.Query(bound.name)
+		if val == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return filter, 0, errors.New(bound.name + " must be an RFC 3339 time like 2026-01-02T15:04:05Z")
+		}
+		*bound.to = t
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, 0, errors.New("from must be before to")
+	}
+	limit := DefaultAuditLimit
+	if val := c.Query("limit"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return filter, 0, errors.New("limit must be a positive integer")
+		}
+		limit = min(n, MaxAuditLimit)
+	}
+	return filter, limit, nil
+}
+
+// auditColumns is the header row of the CSV export.
+var auditColumns = []string{"at", "action", "outcome", "actor", "actorName", "ip", "requestId", "resource", "resourceId", "beforeHash", "afterHash", "detail"}
+
+// WriteAuditCSV writes the entries as CSV with a header row. Cells that a spreadsheet would evaluate as a
+// formula are prefixed with a quote: actor names and details come from users.
+func WriteAuditCSV(w io.Writer, entries []models.AuditEntry) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(auditColumns); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		row := []string{e.At.UTC().Format(time.RFC3339Nano), e.Action, e.Outcome, e.Actor, e.ActorName, e.IP, e.RequestID, e.Resource, e.ResourceID, e.BeforeHash, e.AfterHash, e.Detail}
+		for i, cell := range row {
+			row[i] = csvSafe(cell)
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
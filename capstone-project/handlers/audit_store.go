@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"framework-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// AuditFilter selects audit entries, empty fields match everything. From is inclusive, To exclusive.
+type AuditFilter struct {
+	Action     string
+	Actor      string
+	Resource   string
+	ResourceID string
+	Outcome    string
+	From       time.Time
+	To         time.Time
+}
+
+// AuditStore persists the audit trail. It is append-only: there is no way to change or remove an entry.
+// Entries belong to the tenant of the context they are appended in and are only found in it.
+type AuditStore interface {
+	AppendAudit(ctx context.Context, entry models.AuditEntry) error
+	// FindAudit returns the latest entries matching filter, newest first.
+	FindAudit(ctx context.Context, filter AuditFilter, limit int64) ([]models.AuditEntry, error)
+}
+
+// MongoAuditStore keeps the audit trail in the auditLog collection.
+type MongoAuditStore struct {
+	entries *mongo.Collection
+}
+
+func NewMongoAuditStore(db *mongo.Database) *MongoAuditStore {
+	return &MongoAuditStore{entries: db.Collection("auditLog")}
+}
+
+func (s *MongoAuditStore) AppendAudit(ctx context.Context, entry models.AuditEntry) error {
+	entry.Tenant = tenantField(ctx)
+	_, err := s.entries.InsertOne(ctx, entry)
+	return err
+}
+
+func (s *MongoAuditStore) FindAudit(ctx context.Context, filter AuditFilter, limit int64) ([]models.AuditEntry, error) {
+	query := tenantFilter(ctx)
+	for field, val := range map[string]string{
+		"action":     filter.Action,
+		"actor":      filter.Actor,
+		"resource":   filter.Resource,
+		"resourceId": filter.ResourceID,
+		"outcome":    filter.Outcome,
+	} {
+		if val != "" {
+			query[field] = val
+		}
+	}
+	at := bson.M{}
+	if !filter.From.IsZero() {
+		at["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		at["$lt"] = filter.To
+	}
+	if len(at) > 0 {
+		query["at"] = at
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(limit)
+	return findAll[models.AuditEntry](ctx, s.entries, query, opts)
+}
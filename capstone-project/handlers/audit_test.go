@@ -0,0 +1,246 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"framework-api/handlers"
+	"framework-api/handlers/storetest"
+	"framework-api/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// failingAuditStore is an AuditStore whose database is down.
+type failingAuditStore struct{}
+
+func (failingAuditStore) AppendAudit(ctx context.Context, entry models.AuditEntry) error {
+	return errors.New("down")
+}
+
+func (failingAuditStore) FindAudit(ctx context.Context, filter handlers.AuditFilter, limit int64) ([]models.AuditEntry, error) {
+	return nil, errors.New("down")
+}
+
+func TestAuditLogRecord(t *testing.T) {
+	store := storetest.NewAuditStore()
+	audit := handlers.NewAuditLog(store)
+	ctx := handlers.ContextWithPrincipal(context.Background(), &handlers.Principal{Subject: "user-1", Username: "ada"})
+	ctx = handlers.ContextWithTenant(ctx, "bistro")
+	ctx = handlers.ContextWithRequestInfo(ctx, handlers.RequestInfo{ID: "req-1", IP: "192.0.2.7"})
+	//The entry is written even when the request was cancelled after the write
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	audit.Record(ctx, models.AuditEntry{Action: models.AuditRecipeDelete, Resource: "recipe", ResourceID: "r1"})
+	audit.Record(ctx, models.AuditEntry{Action: models.AuditAuthRejected, Outcome: models.AuditFailure, Actor: "someone-else"})
+	var disabled *handlers.AuditLog
+	disabled.Record(ctx, models.AuditEntry{Action: models.AuditRecipeCreate})
+	handlers.NewAuditLog(failingAuditStore{}).Record(ctx, models.AuditEntry{Action: models.AuditRecipeCreate})
+
+	entries := store.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.ID.IsZero() || entry.At.IsZero() || entry.Outcome != models.AuditSuccess {
+		t.Errorf("Expected an ID, a time and success, got %+v", entry)
+	}
+	if entry.Actor != "user-1" || entry.ActorName != "ada" || entry.IP != "192.0.2.7" || entry.RequestID != "req-1" || entry.Tenant != "bistro" {
+		t.Errorf("Expected the caller, request and tenant of the context, got %+v", entry)
+	}
+	if entry := entries[1]; entry.Actor != "someone-else" || entry.ActorName != "" || entry.Outcome != models.AuditFailure {
+		t.Errorf("Expected the actor and outcome to be kept, got %+v", entry)
+	}
+}
+
+func TestParseAuditQuery(t *testing.T) {
+	ts := []struct {
+		text  string
+		query string
+		limit int
+		exp   handlers.AuditFilter
+		err   bool
+	}{
+		{text: "defaults", limit: handlers.DefaultAuditLimit},
+		{text: "filters", query: "action=recipe.update&actor=u1&resource=recipe&resourceId=r1&outcome=success&limit=5", limit: 5,
+			exp: handlers.AuditFilter{Action: "recipe.update", Actor: "u1", Resource: "recipe", ResourceID: "r1", Outcome: "success"}},
+		{text: "range", query: "from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00%2B01:00", limit: handlers.DefaultAuditLimit,
+			exp: handlers.AuditFilter{From: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 1, 31, 23, 0, 0, 0, time.UTC)}},
+		{text: "limit capped", query: "limit=1000000", limit: handlers.MaxAuditLimit},
+		{text: "csv", query: "format=csv", limit: handlers.DefaultAuditLimit},
+		{text: "bad from", query: "from=yesterday", err: true},
+		{text: "empty range", query: "from=2026-01-02T00:00:00Z&to=2026-01-01T00:00:00Z", err: true},
+		{text: "zero limit", query: "limit=0", err: true},
+		{text: "bad format", query: "format=xml", err: true},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/admin/audit?"+tc.query, nil)
+		filter, limit, err := handlers.ParseAuditQuery(c)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Expected an error")
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			continue
+		}
+		if limit != tc.limit || filter.Action != tc.exp.Action || filter.Actor != tc.exp.Actor || filter.Resource != tc.exp.Resource ||
+			filter.ResourceID != tc.exp.ResourceID || filter.Outcome != tc.exp.Outcome || !filter.From.Equal(tc.exp.From) || !filter.To.Equal(tc.exp.To) {
+			t.Errorf("Expected %+v limit %d, got %+v limit %d", tc.exp, tc.limit, filter, limit)
+		}
+	}
+}
+
+func TestWriteAuditCSV(t *testing.T) {
+	at := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	var buf bytes.Buffer
+	err := handlers.WriteAuditCSV(&buf, []models.AuditEntry{
+		{At: at, Action: models.AuditRecipeUpdate, Outcome: models.AuditSuccess, Actor: "u1", ActorName: "=1+2", Detail: "@SUM(A1)"},
+		{At: at, Action: models.AuditAuthRejected, Outcome: models.AuditFailure, ActorName: "-x", Detail: "a, \"quoted\"\nline"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error writing CSV: %s", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV: %s", err)
+	}
+	ts := []struct {
+		text string
+		row  int
+		col  int
+		exp  string
+	}{
+		{text: "header", row: 0, col: 0, exp: "at"},
+		{text: "time", row: 1, col: 0, exp: "2026-03-04T05:06:07Z"},
+		{text: "formula", row: 1, col: 4, exp: "'=1+2"},
+		{text: "function", row: 1, col: 11, exp: "'@SUM(A1)"},
+		{text: "minus", row: 2, col: 4, exp: "'-x"},
+		{text: "quotes and newline", row: 2, col: 11, exp: "a, \"quoted\"\nline"},
+		{text: "empty", row: 2, col: 3, exp: ""},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if len(rows) != 3 || len(rows[tc.row]) != len(handlers.AuditColumns) {
+			t.Fatalf("Expected 3 rows of %d columns, got %v", len(handlers.AuditColumns), rows)
+		}
+		if got := rows[tc.row][tc.col]; got != tc.exp {
+			t.Errorf("Expected %q, got %q", tc.exp, got)
+		}
+	}
+}
+
+func TestWebhookChangesAreAudited(t *testing.T) {
+	store := storetest.NewWebhookStore()
+	audits := storetest.NewAuditStore()
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewWebhookHandler(store, handlers.NewWebhookDispatcher(store, handlers.WebhookConfig{MaxAttempts: 3}))
+	h.SetAuditLog(handlers.NewAuditLog(audits))
+	engine := gin.New()
+	engine.Use(handlers.RequestIDMiddleware())
+	engine.POST("/admin/webhooks", h.CreateWebhook)
+	engine.DELETE("/admin/webhooks/:id", h.DeleteWebhook)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/webhooks", strings.NewReader(`{"url":"https://partner.example/hook","events":["recipe.created"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var created handlers.WebhookCreated
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Unexpected error decoding webhook: %s", err)
+	}
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/webhooks/"+created.ID.Hex(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	entries := audits.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	create, deleted := entries[0], entries[1]
+	if create.Action != models.AuditWebhookCreate || create.ResourceID != created.ID.Hex() || create.AfterHash == "" || create.RequestID == "" {
+		t.Errorf("Expected the creation with its hash and request, got %+v", create)
+	}
+	if deleted.Action != models.AuditWebhookDelete || deleted.BeforeHash != create.AfterHash || deleted.RequestID == create.RequestID {
+		t.Errorf("Expected the deletion of the created webhook, got %+v", deleted)
+	}
+}
+
+func TestAuthenticationsAreAudited(t *testing.T) {
+	issuer, err := handlers.NewHS256Issuer("recipes-internal", "", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("Unexpected error creating issuer: %s", err)
+	}
+	tokens, _ := issuer.Authenticator(time.Minute)
+	apiKeys, err := handlers.NewAPIKeyAuthenticator([]handlers.APIKey{{Name: "importer", KeySHA256: handlers.HashAPIKey("importer-key"), Scopes: []string{handlers.ScopeRecipesWrite}}})
+	if err != nil {
+		t.Fatalf("Unexpected error creating API key authenticator: %s", err)
+	}
+	audits := storetest.NewAuditStore()
+	gin.SetMode(gin.TestMode)
+	authHandler := handlers.NewAuthHandler(tokens, apiKeys)
+	authHandler.SetAuditLog(handlers.NewAuditLog(audits))
+	engine := gin.New()
+	engine.Use(handlers.RequestIDMiddleware())
+	engine.GET("/private", authHandler.AuthMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	alice, _ := issuer.Issue("alice", nil, nil, time.Minute)
+	bob, _ := issuer.Issue("bob", nil, nil, time.Minute)
+
+	ts := []struct {
+		text   string
+		header string
+		value  string
+		exp    int
+	}{
+		{text: "token", header: "Authorization", value: "Bearer " + alice, exp: http.StatusOK},
+		{text: "same caller again", header: "Authorization", value: "Bearer " + alice, exp: http.StatusOK},
+		{text: "another caller", header: "Authorization", value: "Bearer " + bob, exp: http.StatusOK},
+		{text: "API key", header: handlers.APIKeyHeader, value: "importer-key", exp: http.StatusOK},
+		{text: "forged token", header: "Authorization", value: "Bearer not-a-jwt", exp: http.StatusUnauthorized},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		req := httptest.NewRequest(http.MethodGet, "/private", nil)
+		req.Header.Set(tc.header, tc.value)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tc.exp {
+			t.Errorf("Expected status %d, got %d", tc.exp, w.Code)
+		}
+	}
+
+	//The repeated requests of a caller are recorded once
+	entries := audits.Entries()
+	exp := []struct{ action, actor, detail string }{
+		{models.AuditAuthAccepted, "alice", "recipes-internal"},
+		{models.AuditAuthAccepted, "bob", "recipes-internal"},
+		{models.AuditAuthAccepted, "apikey:importer", handlers.APIKeyIssuer},
+		{models.AuditAuthRejected, "", "Invalid token"},
+	}
+	if len(entries) != len(exp) {
+		t.Fatalf("Expected %d entries, got %+v", len(exp), entries)
+	}
+	for i, e := range exp {
+		if got := entries[i]; got.Action != e.action || got.Actor != e.actor || got.Detail != e.detail || got.RequestID == "" {
+			t.Errorf("Entry %d: expected %s by %q with %q, got %+v", i, e.action, e.actor, e.detail, got)
+		}
+	}
+}
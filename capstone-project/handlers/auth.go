@@ -1,75 +1,188 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"framework-api/models"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/MicahParks/keyfunc/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 )
 
-type AuthHandler struct{}
+// APIKeyHeader carries a static service account key instead of a bearer token.
+const APIKeyHeader = "X-API-Key"
 
-func NewAuthHandler() *AuthHandler {
-	return &AuthHandler{}
+// AuthAuditInterval is how often each instance records the same caller's accepted credentials, an entry per
+// request would bury the other actions.
+const AuthAuditInterval = time.Hour
+
+var errMissingCredentials = errors.New("missing Authorization header")
+
+type AuthHandler struct {
+	// authenticators by issuer, the token's iss claim selects the verifier
+	authenticators map[string]Authenticator
+	audit          *AuditLog
+	// accepted holds when a caller, by issuer, subject and tenant, was last recorded in the audit log
+	mu       sync.Mutex
+	accepted map[string]time.Time
 }
 
-func InitCognitoJWKS(region, userPoolID string) (*keyfunc.JWKS, error) {
-	jwksURL := "https://cognito-idp." + region + ".amazonaws.com/" + userPoolID + "/.well-known/jwks.json"
-	//Fetch public key from AWS
-	jwks, err := keyfunc.Get(jwksURL, keyfunc.Options{})
-	if err != nil {
-		zap.L().Error("Error fetching public key from AWS", zap.Error(err))
-		return nil, err
+func NewAuthHandler(authenticators ...Authenticator) *AuthHandler {
+	h := &AuthHandler{
+		authenticators: make(map[string]Authenticator, len(authenticators)),
+		accepted:       make(map[string]time.Time),
+	}
+	for _, a := range authenticators {
+		h.authenticators[a.Issuer()] = a
 	}
-	zap.L().Info("Public key fetched from AWS")
-	return jwks, nil
+	return h
 }
 
-func (h *AuthHandler) AuthMiddleware(jwks *keyfunc.JWKS, expectedIssuer, expectedClientID string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing Authorization header"})
-			return
+// SetAuditLog records the accepted and rejected credentials in audit.
+func (h *AuthHandler) SetAuditLog(audit *AuditLog) {
+	h.audit = audit
+}
+
+// RecordAuthFailure records credentials rejected with err in the audit log.
+func (h *AuthHandler) RecordAuthFailure(ctx context.Context, err error) {
+	h.audit.Record(ctx, models.AuditEntry{Action: models.AuditAuthRejected, Outcome: models.AuditFailure, Detail: authErrorMessage(err)})
+}
+
+// RecordAuthSuccess records that the token or API key of p was accepted in the tenant of ctx. Sign-ins happen at
+// the token issuers, this is where the API first sees a caller, so each caller is recorded once per
+// AuthAuditInterval. The detail is the issuer, APIKeyIssuer for API keys.
+func (h *AuthHandler) RecordAuthSuccess(ctx context.Context, p *Principal) {
+	if !h.audit.Enabled() {
+		return
+	}
+	key := p.Issuer + "\n" + p.Subject + "\n" + TenantFromContext(ctx)
+	now := time.Now()
+	h.mu.Lock()
+	if last, ok := h.accepted[key]; ok && now.Sub(last) < AuthAuditInterval {
+		h.mu.Unlock()
+		return
+	}
+	for k, last := range h.accepted {
+		if now.Sub(last) >= AuthAuditInterval {
+			delete(h.accepted, k)
 		}
+	}
+	h.accepted[key] = now
+	h.mu.Unlock()
+	h.audit.Record(ctx, models.AuditEntry{Action: models.AuditAuthAccepted, Actor: p.Subject, ActorName: p.Username, Detail: p.Issuer})
+}
+
+// Issuers lists the configured issuers, used for logging at startup.
+func (h *AuthHandler) Issuers() []string {
+	issuers := make([]string, 0, len(h.authenticators))
+	for issuer := range h.authenticators {
+		issuers = append(issuers, issuer)
+	}
+	return issuers
+}
+
+// Authenticate picks the authenticator for the request credentials and returns the caller.
+// An X-API-Key header is checked against the static API keys, otherwise the bearer token's iss selects the verifier.
+func (h *AuthHandler) Authenticate(r *http.Request) (*Principal, error) {
+	return h.AuthenticateCredentials(r.Header.Get(APIKeyHeader), r.Header.Get("Authorization"))
+}
+
+// AuthenticateCredentials is Authenticate for callers without an HTTP request, e.g. gRPC metadata.
+func (h *AuthHandler) AuthenticateCredentials(apiKey, authorization string) (*Principal, error) {
+	if apiKey != "" {
+		return h.authenticate(APIKeyIssuer, apiKey)
+	}
+	if authorization == "" {
+		return nil, errMissingCredentials
+	}
+	tokenString := strings.TrimPrefix(authorization, "Bearer ")
+	//The signature is not checked here, only the issuer is read to pick the verifier
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	issuer, _ := claims["iss"].(string)
+	return h.authenticate(issuer, tokenString)
+}
+
+// IsMissingCredentials reports whether Authenticate failed because no credentials were sent at all.
+func IsMissingCredentials(err error) bool {
+	return errors.Is(err, errMissingCredentials)
+}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		//Now parse the token using keys fetched from AWS Cognito
-		token, err := jwt.Parse(tokenString, jwks.Keyfunc)
-		if err != nil || !token.Valid {
-			zap.L().Warn("Error parsing token", zap.Error(err))
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+func (h *AuthHandler) authenticate(issuer, credential string) (*Principal, error) {
+	authenticator, ok := h.authenticators[issuer]
+	if !ok {
+		return nil, ErrUnknownIssuer
+	}
+	return authenticator.Authenticate(credential)
+}
+
+func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//TenantMiddleware authenticated the request already
+		if _, ok := GetPrincipal(c); ok {
+			c.Next()
 			return
 		}
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			zap.L().Warn("Invalid token claims")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		principal, err := h.Authenticate(c.Request)
+		if err != nil {
+			zap.L().Warn("Authentication failed", zap.Error(err))
+			h.RecordAuthFailure(c.Request.Context(), err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": authErrorMessage(err)})
 			return
 		}
-		if claims["iss"] != expectedIssuer {
-			zap.L().Warn("Invalid issuer")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid issuer"})
+		zap.L().Info("User authenticated", zap.String("user_id", principal.Subject), zap.String("issuer", principal.Issuer), zap.Strings("groups", principal.Groups))
+		//Claims are parsed once, handlers and RequireScopes/RequireTenantAdmin read the principal from the context
+		SetPrincipal(c, principal)
+		h.RecordAuthSuccess(c.Request.Context(), principal)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware authenticates requests that carry credentials and lets anonymous requests through.
+// Invalid credentials are still rejected with 401, handlers check the principal themselves.
+func (h *AuthHandler) OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetPrincipal(c); ok {
+			c.Next()
 			return
 		}
-		if claims["client_id"] != expectedClientID && claims["aud"] != expectedClientID {
-			zap.L().Warn("Invalid client_id")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid client_id"})
+		principal, err := h.Authenticate(c.Request)
+		if errors.Is(err, errMissingCredentials) {
+			c.Next()
 			return
 		}
-
-		//Ensure access token
-		if claims["token_use"] != "access" {
-			zap.L().Warn("Invalid token use")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token use"})
+		if err != nil {
+			zap.L().Warn("Authentication failed", zap.Error(err))
+			h.RecordAuthFailure(c.Request.Context(), err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": authErrorMessage(err)})
 			return
 		}
-		userID := claims["sub"].(string)
-		zap.L().Info("User authenticated", zap.String("user_id", userID))
-		c.Set("userID", userID)
+		SetPrincipal(c, principal)
+		h.RecordAuthSuccess(c.Request.Context(), principal)
 		c.Next()
 	}
 }
+
+func authErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, errMissingCredentials):
+		return "Missing Authorization header"
+	case errors.Is(err, ErrUnknownIssuer):
+		return "Invalid issuer"
+	case errors.Is(err, ErrInvalidAudience):
+		return "Invalid client_id"
+	case errors.Is(err, ErrInvalidTokenUse):
+		return "Invalid token use"
+	case errors.Is(err, ErrInvalidAPIKey):
+		return "Invalid API key"
+	default:
+		return "Invalid token"
+	}
+}
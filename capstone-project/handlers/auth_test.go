@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"framework-api/handlers/authtest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com/pool"
+	testClientID = "test-client"
+)
+
+func newAuthEngine(t *testing.T, authenticators ...Authenticator) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	authHandler := NewAuthHandler(authenticators...)
+	engine.GET("/private", authHandler.AuthMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"userID": c.GetString("userID")})
+	})
+	return engine
+}
+
+func newCognitoEngine(t *testing.T, keys KeyProvider) *gin.Engine {
+	t.Helper()
+	cognito, err := NewCognitoAuthenticator(testIssuer, testClientID, keys, 30*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error creating authenticator: %s", err)
+	}
+	return newAuthEngine(t, cognito)
+}
+
+func doAuthRequest(engine *gin.Engine, token string) int {
+	req := httptest.NewRequest(http.MethodGet, "/private", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAuthMiddlewareClaims(t *testing.T) {
+	signer, err := authtest.NewSigner(testIssuer, testClientID)
+	if err != nil {
+		t.Fatalf("Unexpected error creating signer: %s", err)
+	}
+	keys, err := NewLocalKeyProvider(signer.PublicKeys())
+	if err != nil {
+		t.Fatalf("Unexpected error creating key provider: %s", err)
+	}
+	engine := newCognitoEngine(t, keys)
+	now := time.Now()
+
+	ts := []struct {
+		text   string
+		mutate func(jwt.MapClaims)
+		exp    int
+	}{
+		{text: "valid token", mutate: func(jwt.MapClaims) {}, exp: http.StatusOK},
+		{text: "expired within clock skew", mutate: func(c jwt.MapClaims) { c["exp"] = now.Add(-10 * time.Second).Unix() }, exp: http.StatusOK},
+		{text: "expired beyond clock skew", mutate: func(c jwt.MapClaims) { c["exp"] = now.Add(-2 * time.Minute).Unix() }, exp: http.StatusUnauthorized},
+		{text: "missing exp", mutate: func(c jwt.MapClaims) { delete(c, "exp") }, exp: http.StatusUnauthorized},
+		{text: "nbf within clock skew", mutate: func(c jwt.MapClaims) { c["nbf"] = now.Add(10 * time.Second).Unix() }, exp: http.StatusOK},
+		{text: "nbf beyond clock skew", mutate: func(c jwt.MapClaims) { c["nbf"] = now.Add(5 * time.Minute).Unix() }, exp: http.StatusUnauthorized},
+		{text: "wrong issuer", mutate: func(c jwt.MapClaims) { c["iss"] = "https://other.example.com" }, exp: http.StatusUnauthorized},
+		{text: "wrong client", mutate: func(c jwt.MapClaims) { c["client_id"] = "other" }, exp: http.StatusUnauthorized},
+		{text: "id token", mutate: func(c jwt.MapClaims) { c["token_use"] = "id" }, exp: http.StatusUnauthorized},
+		{text: "numeric sub", mutate: func(c jwt.MapClaims) { c["sub"] = 12345 }, exp: http.StatusUnauthorized},
+		{text: "missing sub", mutate: func(c jwt.MapClaims) { delete(c, "sub") }, exp: http.StatusUnauthorized},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		claims := signer.Claims("user-1", time.Minute)
+		tc.mutate(claims)
+		token, err := signer.Sign(claims)
+		if err != nil {
+			t.Fatalf("Unexpected error signing token: %s", err)
+		}
+		if got := doAuthRequest(engine, token); got != tc.exp {
+			t.Errorf("%s: expected status %d, got %d", tc.text, tc.exp, got)
+		}
+	}
+
+	if got := doAuthRequest(engine, ""); got != http.StatusUnauthorized {
+		t.Errorf("missing header: expected status %d, got %d", http.StatusUnauthorized, got)
+	}
+}
+
+func TestJWKSKeyProviderRefreshesUnknownKID(t *testing.T) {
+	signer, err := authtest.NewSigner(testIssuer, testClientID)
+	if err != nil {
+		t.Fatalf("Unexpected error creating signer: %s", err)
+	}
+	server := signer.NewServer()
+	defer server.Close()
+
+	keys, err := NewJWKSKeyProvider(JWKSConfig{
+		URL:              server.URL + authtest.JWKSPath,
+		RefreshRateLimit: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error fetching JWKS: %s", err)
+	}
+	defer keys.EndBackground()
+	engine := newCognitoEngine(t, keys)
+
+	token, _ := signer.AccessToken("user-1", time.Minute)
+	if got := doAuthRequest(engine, token); got != http.StatusOK {
+		t.Fatalf("Expected status %d for initial key, got %d", http.StatusOK, got)
+	}
+
+	//A rotated key is unknown to the provider, so it refetches the JWKS once
+	if err := signer.Rotate(); err != nil {
+		t.Fatalf("Unexpected error rotating key: %s", err)
+	}
+	token, _ = signer.AccessToken("user-1", time.Minute)
+	if got := doAuthRequest(engine, token); got != http.StatusOK {
+		t.Fatalf("Expected status %d after rotation, got %d", http.StatusOK, got)
+	}
+	fetches := signer.Fetches()
+	if fetches != 2 {
+		t.Errorf("Expected 2 JWKS fetches after rotation, got %d", fetches)
+	}
+
+	//Within the rate limit another unknown kid must not hit the JWKS endpoint again
+	if err := signer.Rotate(); err != nil {
+		t.Fatalf("Unexpected error rotating key: %s", err)
+	}
+	token, _ = signer.AccessToken("user-1", time.Minute)
+	if got := doAuthRequest(engine, token); got != http.StatusUnauthorized {
+		t.Errorf("Expected status %d while rate limited, got %d", http.StatusUnauthorized, got)
+	}
+	if got := signer.Fetches(); got != fetches {
+		t.Errorf("Expected no JWKS fetch while rate limited, got %d extra", got-fetches)
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// AuthConfig lists the token issuers and API keys accepted by the API, loaded from AUTH_CONFIG_FILE.
+type AuthConfig struct {
+	ClockSkew string         `json:"clockSkew"`
+	Issuers   []IssuerConfig `json:"issuers"`
+	APIKeys   []APIKey       `json:"apiKeys"`
+}
+
+// IssuerConfig configures one issuer. Type is one of cognito, oidc, hs256 or eddsa.
+// Secrets are never stored in the file, SecretEnv names the env var holding them.
+type IssuerConfig struct {
+	Type       string `json:"type"`
+	Issuer     string `json:"issuer"`
+	Audience   string `json:"audience"`
+	Region     string `json:"region"`
+	UserPoolID string `json:"userPoolId"`
+	JWKSURL    string `json:"jwksUrl"`
+	// SecretEnv holds the HS256 secret, or the base64 ed25519 public key for eddsa.
+	SecretEnv string `json:"secretEnv"`
+}
+
+const (
+	IssuerTypeCognito = "cognito"
+	IssuerTypeOIDC    = "oidc"
+	IssuerTypeHS256   = "hs256"
+	IssuerTypeEdDSA   = "eddsa"
+)
+
+// LoadAuthConfig reads the auth config JSON file.
+func LoadAuthConfig(path string) (*AuthConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth config: %w", err)
+	}
+	var cfg AuthConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse auth config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// Authenticators builds one authenticator per configured issuer, plus the API key authenticator.
+// jwksCfg carries the refresh settings shared by every remote JWKS.
+func (cfg *AuthConfig) Authenticators(jwksCfg JWKSConfig) ([]Authenticator, error) {
+	clockSkew := time.Minute
+	if cfg.ClockSkew != "" {
+		d, err := time.ParseDuration(cfg.ClockSkew)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clockSkew: %w", err)
+		}
+		clockSkew = d
+	}
+	authenticators := make([]Authenticator, 0, len(cfg.Issuers)+1)
+	for _, issuerCfg := range cfg.Issuers {
+		authenticator, err := issuerCfg.authenticator(jwksCfg, clockSkew)
+		if err != nil {
+			return nil, fmt.Errorf("issuer %s (%s): %w", issuerCfg.Issuer, issuerCfg.Type, err)
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+	if len(cfg.APIKeys) > 0 {
+		apiKeys, err := NewAPIKeyAuthenticator(cfg.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, apiKeys)
+	}
+	return authenticators, nil
+}
+
+func (ic IssuerConfig) authenticator(jwksCfg JWKSConfig, clockSkew time.Duration) (Authenticator, error) {
+	switch ic.Type {
+	case IssuerTypeCognito:
+		jwksCfg.URL = ic.JWKSURL
+		if jwksCfg.URL == "" {
+			jwksCfg.URL = CognitoJWKSURL(ic.Region, ic.UserPoolID)
+		}
+		issuer := ic.Issuer
+		if issuer == "" {
+			issuer = "https://cognito-idp." + ic.Region + ".amazonaws.com/" + ic.UserPoolID
+		}
+		keys, err := NewJWKSKeyProvider(jwksCfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewCognitoAuthenticator(issuer, ic.Audience, keys, clockSkew)
+	case IssuerTypeOIDC:
+		return NewOIDCAuthenticator(ic.Issuer, ic.Audience, jwksCfg, clockSkew)
+	case IssuerTypeHS256:
+		secret := os.Getenv(ic.SecretEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("%s is not set", ic.SecretEnv)
+		}
+		issuer, err := NewHS256Issuer(ic.Issuer, ic.Audience, []byte(secret))
+		if err != nil {
+			return nil, err
+		}
+		return issuer.Authenticator(clockSkew)
+	case IssuerTypeEdDSA:
+		publicKey, err := base64.StdEncoding.DecodeString(os.Getenv(ic.SecretEnv))
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%s must hold a base64 ed25519 public key", ic.SecretEnv)
+		}
+		return NewEdDSAVerifier(ic.Issuer, ic.Audience, ed25519.PublicKey(publicKey)).Authenticator(clockSkew)
+	default:
+		return nil, fmt.Errorf("unknown issuer type %q", ic.Type)
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APIKeyIssuer is the registry key of the static API key authenticator.
+const APIKeyIssuer = "api-key"
+
+var (
+	ErrUnknownIssuer   = errors.New("unknown token issuer")
+	ErrInvalidToken    = errors.New("invalid token")
+	ErrInvalidAudience = errors.New("invalid audience")
+	ErrInvalidTokenUse = errors.New("invalid token use")
+	ErrInvalidAPIKey   = errors.New("invalid api key")
+)
+
+// Authenticator verifies a credential issued by one issuer and returns the caller.
+type Authenticator interface {
+	// Issuer is matched against the token's iss claim by AuthMiddleware.
+	Issuer() string
+	Authenticate(credential string) (*Principal, error)
+}
+
+// JWTConfig describes how tokens of one issuer are verified.
+type JWTConfig struct {
+	Issuer string
+	// Audience is matched against aud, or client_id for Cognito access tokens. Empty skips the check.
+	Audience string
+	// TokenUse, when set, must equal the token_use claim (Cognito sets "access" or "id").
+	TokenUse string
+	// Algorithms allowed for this issuer, a token signed with any other alg is rejected.
+	Algorithms []string
+	Keys       KeyProvider
+	ClockSkew  time.Duration
+}
+
+// JWTAuthenticator verifies signed JWTs of a single issuer.
+type JWTAuthenticator struct {
+	cfg    JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(cfg JWTConfig) (*JWTAuthenticator, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("issuer is required")
+	}
+	if cfg.Keys == nil {
+		return nil, fmt.Errorf("key provider is required for issuer %s", cfg.Issuer)
+	}
+	if len(cfg.Algorithms) == 0 {
+		return nil, fmt.Errorf("algorithms are required for issuer %s", cfg.Issuer)
+	}
+	return &JWTAuthenticator{
+		cfg: cfg,
+		//exp is required and exp/nbf are checked with clock skew
+		parser: jwt.NewParser(
+			jwt.WithLeeway(cfg.ClockSkew),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithValidMethods(cfg.Algorithms),
+		),
+	}, nil
+}
+
+// NewCognitoAuthenticator verifies Cognito access tokens for the given app client.
+func NewCognitoAuthenticator(issuer, clientID string, keys KeyProvider, clockSkew time.Duration) (*JWTAuthenticator, error) {
+	return NewJWTAuthenticator(JWTConfig{
+		Issuer:     issuer,
+		Audience:   clientID,
+		TokenUse:   "access",
+		Algorithms: []string{jwt.SigningMethodRS256.Alg()},
+		Keys:       keys,
+		ClockSkew:  clockSkew,
+	})
+}
+
+func (a *JWTAuthenticator) Issuer() string {
+	return a.cfg.Issuer
+}
+
+func (a *JWTAuthenticator) Authenticate(tokenString string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	token, err := a.parser.ParseWithClaims(tokenString, claims, a.cfg.Keys.Keyfunc)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if a.cfg.Audience != "" && !audienceMatches(claims, a.cfg.Audience) {
+		return nil, ErrInvalidAudience
+	}
+	if a.cfg.TokenUse != "" && claims["token_use"] != a.cfg.TokenUse {
+		return nil, ErrInvalidTokenUse
+	}
+	principal, ok := NewPrincipal(claims)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing or invalid sub", ErrInvalidToken)
+	}
+	principal.Issuer = a.cfg.Issuer
+	return principal, nil
+}
+
+func audienceMatches(claims jwt.MapClaims, expected string) bool {
+	if clientID, ok := claims["client_id"].(string); ok && clientID == expected {
+		return true
+	}
+	aud, err := claims.GetAudience()
+	if err != nil {
+		return false
+	}
+	return slices.Contains(aud, expected)
+}
+
+// APIKey is a static credential for a service account. Only the SHA-256 of the key is kept.
+type APIKey struct {
+	Name      string   `json:"name"`
+	KeySHA256 string   `json:"keySha256"`
+	Scopes    []string `json:"scopes"`
+	Groups    []string `json:"groups"`
+}
+
+// APIKeyAuthenticator authenticates service accounts sending the X-API-Key header.
+type APIKeyAuthenticator struct {
+	keys []APIKey
+}
+
+func NewAPIKeyAuthenticator(keys []APIKey) (*APIKeyAuthenticator, error) {
+	for _, key := range keys {
+		if key.Name == "" {
+			return nil, errors.New("api key name is required")
+		}
+		if hash, err := hex.DecodeString(key.KeySHA256); err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("api key %s must have a hex encoded sha256", key.Name)
+		}
+	}
+	return &APIKeyAuthenticator{keys: keys}, nil
+}
+
+// HashAPIKey returns the hex SHA-256 stored in the auth config for a raw key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *APIKeyAuthenticator) Issuer() string {
+	return APIKeyIssuer
+}
+
+func (a *APIKeyAuthenticator) Authenticate(credential string) (*Principal, error) {
+	given := HashAPIKey(credential)
+	for _, key := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(given), []byte(key.KeySHA256)) == 1 {
+			return &Principal{
+				Subject: "apikey:" + key.Name,
+				Issuer:  APIKeyIssuer,
+				Scopes:  slices.Clone(key.Scopes),
+				Groups:  slices.Clone(key.Groups),
+			}, nil
+		}
+	}
+	return nil, ErrInvalidAPIKey
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"framework-api/handlers/authtest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAuthMiddlewareSelectsIssuer(t *testing.T) {
+	cognitoSigner, err := authtest.NewSigner(testIssuer, testClientID)
+	if err != nil {
+		t.Fatalf("Unexpected error creating signer: %s", err)
+	}
+	cognitoKeys, _ := NewLocalKeyProvider(cognitoSigner.PublicKeys())
+	cognito, err := NewCognitoAuthenticator(testIssuer, testClientID, cognitoKeys, time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error creating cognito authenticator: %s", err)
+	}
+
+	//OIDC provider found through discovery, the server URL is the issuer
+	oidcSigner, _ := authtest.NewSigner("", "oidc-audience")
+	server := oidcSigner.NewServer()
+	defer server.Close()
+	oidcSigner.Issuer = server.URL
+	oidc, err := NewOIDCAuthenticator(server.URL, "oidc-audience", JWKSConfig{}, time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error creating oidc authenticator: %s", err)
+	}
+
+	hsIssuer, err := NewHS256Issuer("recipes-internal", "recipes-api", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("Unexpected error creating hs256 issuer: %s", err)
+	}
+	hs, _ := hsIssuer.Authenticator(time.Minute)
+
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	edIssuer := NewEdDSAIssuer("recipes-tools", "", edPrivate)
+	ed, _ := edIssuer.Authenticator(time.Minute)
+
+	apiKeys, err := NewAPIKeyAuthenticator([]APIKey{{Name: "importer", KeySHA256: HashAPIKey("s3cret-key"), Scopes: []string{ScopeRecipesWrite}}})
+	if err != nil {
+		t.Fatalf("Unexpected error creating api key authenticator: %s", err)
+	}
+
+	engine := newAuthEngine(t, cognito, oidc, hs, ed, apiKeys)
+
+	cognitoToken, _ := cognitoSigner.AccessToken("cognito-user", time.Minute)
+	oidcToken, _ := oidcSigner.AccessToken("oidc-user", time.Minute)
+	hsToken, _ := hsIssuer.Issue("tool", []string{ScopeRecipesWrite}, nil, time.Minute)
+	edToken, _ := edIssuer.Issue("tool", nil, []string{GroupAdmin}, time.Minute)
+	//A token claiming the HS256 issuer but signed with another secret must not verify
+	forgedIssuer, _ := NewHS256Issuer("recipes-internal", "recipes-api", []byte("ffffffffffffffffffffffffffffffff"))
+	forgedToken, _ := forgedIssuer.Issue("tool", nil, nil, time.Minute)
+	unknownIssuer, _ := NewHS256Issuer("someone-else", "", []byte("0123456789abcdef0123456789abcdef"))
+	unknownToken, _ := unknownIssuer.Issue("tool", nil, nil, time.Minute)
+	//alg confusion: an HS256 token for the cognito issuer must be rejected
+	confused := cognitoSigner.Claims("cognito-user", time.Minute)
+	confusedToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, confused).SignedString([]byte("0123456789abcdef0123456789abcdef"))
+
+	ts := []struct {
+		text   string
+		header string
+		value  string
+		exp    int
+	}{
+		{text: "cognito token", header: "Authorization", value: "Bearer " + cognitoToken, exp: http.StatusOK},
+		{text: "oidc token", header: "Authorization", value: "Bearer " + oidcToken, exp: http.StatusOK},
+		{text: "hs256 token", header: "Authorization", value: "Bearer " + hsToken, exp: http.StatusOK},
+		{text: "eddsa token", header: "Authorization", value: "Bearer " + edToken, exp: http.StatusOK},
+		{text: "api key", header: APIKeyHeader, value: "s3cret-key", exp: http.StatusOK},
+		{text: "wrong api key", header: APIKeyHeader, value: "guess", exp: http.StatusUnauthorized},
+		{text: "forged hs256 token", header: "Authorization", value: "Bearer " + forgedToken, exp: http.StatusUnauthorized},
+		{text: "unknown issuer", header: "Authorization", value: "Bearer " + unknownToken, exp: http.StatusUnauthorized},
+		{text: "alg confusion", header: "Authorization", value: "Bearer " + confusedToken, exp: http.StatusUnauthorized},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		req := httptest.NewRequest(http.MethodGet, "/private", nil)
+		req.Header.Set(tc.header, tc.value)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tc.exp {
+			t.Errorf("%s: expected status %d, got %d", tc.text, tc.exp, w.Code)
+		}
+	}
+}
+
+func TestDiscoverOIDCIssuerMismatch(t *testing.T) {
+	signer, _ := authtest.NewSigner("https://not-this-server.example.com", "aud")
+	server := signer.NewServer()
+	defer server.Close()
+	if _, err := DiscoverOIDC(server.URL, time.Second); err == nil {
+		t.Errorf("Expected error when discovery issuer does not match")
+	}
+}
+
+func TestNewHS256IssuerRejectsShortSecret(t *testing.T) {
+	if _, err := NewHS256Issuer("iss", "", []byte("short")); err == nil {
+		t.Errorf("Expected error for short secret")
+	}
+}
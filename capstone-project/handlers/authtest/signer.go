@@ -0,0 +1,163 @@
+// Package authtest provides an in-process token signer and JWKS server,
+// so the auth middleware can be tested without AWS Cognito.
+package authtest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	JWKSPath      = "/.well-known/jwks.json"
+	DiscoveryPath = "/.well-known/openid-configuration"
+)
+
+// Signer signs Cognito style access tokens with RSA keys it generates itself.
+type Signer struct {
+	Issuer   string
+	ClientID string
+
+	mu      sync.RWMutex
+	kid     string
+	keys    map[string]*rsa.PrivateKey
+	fetches atomic.Int64
+}
+
+// NewSigner creates a signer with one active key.
+func NewSigner(issuer, clientID string) (*Signer, error) {
+	s := &Signer{
+		Issuer:   issuer,
+		ClientID: clientID,
+		keys:     make(map[string]*rsa.PrivateKey),
+	}
+	if err := s.Rotate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Rotate generates a new signing key. Older keys stay published in the JWKS.
+func (s *Signer) Rotate() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return fmt.Errorf("failed to generate rsa key: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kid = fmt.Sprintf("test-key-%d", len(s.keys)+1)
+	s.keys[s.kid] = key
+	return nil
+}
+
+// KID returns the kid of the key currently used for signing.
+func (s *Signer) KID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.kid
+}
+
+// PublicKeys returns every published public key by kid, for use with a local key provider.
+func (s *Signer) PublicKeys() map[string]crypto.PublicKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make(map[string]crypto.PublicKey, len(s.keys))
+	for kid, key := range s.keys {
+		keys[kid] = &key.PublicKey
+	}
+	return keys
+}
+
+// Sign signs the given claims with the current key.
+func (s *Signer) Sign(claims jwt.MapClaims) (string, error) {
+	s.mu.RLock()
+	kid, key := s.kid, s.keys[s.kid]
+	s.mu.RUnlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
+
+// Claims returns valid access token claims for sub that expire after ttl.
+func (s *Signer) Claims(sub string, ttl time.Duration) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":       s.Issuer,
+		"client_id": s.ClientID,
+		"token_use": "access",
+		"sub":       sub,
+		"iat":       now.Unix(),
+		"exp":       now.Add(ttl).Unix(),
+	}
+}
+
+// AccessToken signs a valid access token for sub.
+func (s *Signer) AccessToken(sub string, ttl time.Duration) (string, error) {
+	return s.Sign(s.Claims(sub, ttl))
+}
+
+// JWKS returns the public keys as a JSON Web Key Set.
+func (s *Signer) JWKS() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	type jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	}
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{Keys: make([]jwk, 0, len(s.keys))}
+	for kid, key := range s.keys {
+		set.Keys = append(set.Keys, jwk{
+			Kty: "RSA",
+			Kid: kid,
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		})
+	}
+	data, _ := json.Marshal(set)
+	return data
+}
+
+// Fetches returns how many times the JWKS has been served.
+func (s *Signer) Fetches() int64 {
+	return s.fetches.Load()
+}
+
+// NewServer serves the JWKS at JWKSPath and an OpenID discovery document at DiscoveryPath.
+// The discovery document advertises s.Issuer, set it to the server URL to test discovery. The caller must Close it.
+func (s *Signer) NewServer() *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc(JWKSPath, func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(s.JWKS())
+	})
+	mux.HandleFunc(DiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                s.Issuer,
+			"jwks_uri":                              server.URL + JWKSPath,
+			"id_token_signing_alg_values_supported": []string{"RS256", "HS256"},
+		})
+	})
+	return server
+}
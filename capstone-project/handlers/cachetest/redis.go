@@ -0,0 +1,26 @@
+// Package cachetest starts miniredis with recipes in the cache keys of the recipe handler,
+// so handlers reading recipes can be tested without MongoDB.
+package cachetest
+
+import (
+	"context"
+	"framework-api/fixtures"
+	"framework-api/models"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedis runs miniredis for the test with recipes cached by fixtures.CacheRecipes and returns a client connected
+// to it. The server is stopped when the test ends.
+func NewRedis(t testing.TB, recipes []models.Recipe) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	if err := fixtures.CacheRecipes(context.Background(), redisClient, recipes); err != nil {
+		t.Fatalf("Unexpected error caching recipes: %s", err)
+	}
+	return mr, redisClient
+}
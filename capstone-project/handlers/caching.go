@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"framework-api/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// modifiedKey is the Redis hash holding when the recipes of a tenant last changed (see TenantKey), in Unix
+// milliseconds: the field catalogField for the catalog as a whole, one field per recipe ID. Reads answer conditional
+// requests from it without touching MongoDB.
+const (
+	modifiedKey  = "recipes:modified"
+	catalogField = "*"
+)
+
+// touch records that the recipes with the given IDs, and so the catalog, changed at.
+func (h *RecipeHandler) touch(ctx context.Context, at time.Time, ids ...string) {
+	ms := at.UnixMilli()
+	values := []interface{}{catalogField, ms}
+	for _, id := range ids {
+		values = append(values, id, ms)
+	}
+	if err := h.redisClient.HSet(ctx, TenantKey(ctx, modifiedKey), values...).Err(); err != nil {
+		zap.L().Warn("Failed to record recipe modification time", zap.Error(err))
+	}
+}
+
+// forget records that a recipe was deleted, which changes the catalog.
+func (h *RecipeHandler) forget(ctx context.Context, id string) {
+	key := TenantKey(ctx, modifiedKey)
+	if err := h.redisClient.HDel(ctx, key, id).Err(); err != nil {
+		zap.L().Warn("Failed to forget recipe modification time", zap.Error(err))
+	}
+	h.touch(ctx, time.Now())
+}
+
+// notModified answers 304 when the client's copy is current by the modification time recorded for field (a recipe
+// ID or catalogField). It reads Redis only, reads that know of no modification time yet go on and record one with
+// validate.
+func (h *RecipeHandler) notModified(c *gin.Context, field, locale string) bool {
+	ctx := c.Request.Context()
+	ms, err := h.redisClient.HGet(ctx, TenantKey(ctx, modifiedKey), field).Int64()
+	if err != nil {
+		return false
+	}
+	at := time.UnixMilli(ms)
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		if !etagMatches(inm, recipeETag(c, locale, at)) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+		if err != nil || at.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+	setValidators(c, locale, at)
+	c.Status(http.StatusNotModified)
+	c.Writer.WriteHeaderNow()
+	return true
+}
+
+// validate records at as the modification time of field unless one is recorded already, and sets the validators
+// of the response from the recorded one.
+func (h *RecipeHandler) validate(c *gin.Context, field, locale string, at time.Time) {
+	ctx := c.Request.Context()
+	key := TenantKey(ctx, modifiedKey)
+	if err := h.redisClient.HSetNX(ctx, key, field, at.UnixMilli()).Err(); err != nil {
+		zap.L().Warn("Failed to record recipe modification time", zap.Error(err))
+		return
+	}
+	ms, err := h.redisClient.HGet(ctx, key, field).Int64()
+	if err != nil {
+		return
+	}
+	setValidators(c, locale, time.UnixMilli(ms))
+}
+
+// catalogModified is the latest modification time of recipes, the time of the first read of an empty catalog.
+func catalogModified(recipes []models.Recipe) time.Time {
+	var at time.Time
+	for _, recipe := range recipes {
+		if modified := recipe.LastModified(); modified.After(at) {
+			at = modified
+		}
+	}
+	if at.IsZero() {
+		return time.Now()
+	}
+	return at
+}
+
+// setValidators sets Cache-Control, ETag and Last-Modified for a response last modified at. Recipes of tenants
+// other than the default one are only cached by the client.
+//
+// Search, by q or by tag, and similar recipes are not validated. Their results come from Elasticsearch, which is
+// updated after MongoDB and keeps old documents when indexing fails, while the times in modifiedKey are recorded
+// from MongoDB writes. A search answered while Elasticsearch lags would carry the ETag of the new catalog and be
+// answered 304 with the old results until the next change.
+func setValidators(c *gin.Context, locale string, at time.Time) {
+	if GetTenant(c) == DefaultTenant {
+		c.Header("Cache-Control", "public, max-age=0, must-revalidate")
+	} else {
+		c.Header("Cache-Control", "private, max-age=0, must-revalidate")
+	}
+	c.Header("ETag", recipeETag(c, locale, at))
+	c.Header("Last-Modified", at.UTC().Format(http.TimeFormat))
+	c.Writer.Header().Add("Vary", TenantHeader)
+}
+
+// recipeETag is the ETag of a response last modified at. It is weak, compressed and uncompressed responses carry
+// the same one, and covers what else changes the response: the tenant, the path and query, and the locale.
+func recipeETag(c *gin.Context, locale string, at time.Time) string {
+	parts := []string{GetTenant(c), c.Request.URL.Path, c.Request.URL.RawQuery, locale, strconv.FormatInt(at.UnixMilli(), 10)}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// etagMatches reports whether the If-None-Match header matches etag, with the weak comparison of RFC 9110.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
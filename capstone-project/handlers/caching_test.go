@@ -0,0 +1,24 @@
+package handlers
+
+import "testing"
+
+func TestETagMatches(t *testing.T) {
+	ts := []struct {
+		text   string
+		header string
+		exp    bool
+	}{
+		{text: "same", header: `W/"abc"`, exp: true},
+		{text: "strong form", header: `"abc"`, exp: true},
+		{text: "in a list", header: `"x", W/"abc"`, exp: true},
+		{text: "wildcard", header: "*", exp: true},
+		{text: "other", header: `W/"abd"`, exp: false},
+		{text: "unquoted", header: "abc", exp: false},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if got := etagMatches(tc.header, `W/"abc"`); got != tc.exp {
+			t.Errorf("Expected %t, got %t", tc.exp, got)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// MinCompressSize is the smallest body worth compressing, smaller ones are sent as is.
+const MinCompressSize = 1024
+
+// CompressionMiddleware compresses responses with brotli or gzip, the one the client prefers in Accept-Encoding;
+// brotli wins a tie. Bodies under MinCompressSize, responses that are already encoded and content that does not
+// shrink (images, PDFs) are sent as is. Event streams are never compressed, their events have to go out when flushed.
+func CompressionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := NegotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding}
+		c.Writer = w
+		defer w.Close()
+		c.Next()
+	}
+}
+
+// NegotiateEncoding picks "br", "gzip" or "" (identity) for an Accept-Encoding header. Encodings with q=0 are
+// refused, "*" stands for both.
+func NegotiateEncoding(acceptEncoding string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight := 1.0
+		if val, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			w, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				continue
+			}
+			weight = w
+		}
+		q[name] = weight
+	}
+	best, bestQ := "", 0.0
+	for _, encoding := range []string{"br", "gzip"} {
+		weight, ok := q[encoding]
+		if !ok {
+			weight, ok = q["*"]
+		}
+		if ok && weight > bestQ {
+			best, bestQ = encoding, weight
+		}
+	}
+	return best
+}
+
+// compressible reports whether a response of contentType shrinks when compressed.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "json"), strings.HasSuffix(mediaType, "xml"):
+		return true
+	default:
+		return mediaType == "application/javascript" || mediaType == "text/calendar"
+	}
+}
+
+// compressWriter holds the first MinCompressSize bytes back to decide whether to compress, then writes through the
+// encoder or, when the response does not qualify, as is.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	buf      []byte
+	decided  bool
+	encoder  io.WriteCloser
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= MinCompressSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// decide starts compressing when the response qualifies and writes the held back bytes.
+func (w *compressWriter) decide() error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	status := w.ResponseWriter.Status()
+	if len(w.buf) >= MinCompressSize && header.Get("Content-Encoding") == "" && compressible(header.Get("Content-Type")) &&
+		status != http.StatusNoContent && status != http.StatusNotModified {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		if w.encoding == "br" {
+			w.encoder = brotli.NewWriterLevel(w.ResponseWriter, 5)
+		} else {
+			w.encoder, _ = gzip.NewWriterLevel(w.ResponseWriter, gzip.DefaultCompression)
+		}
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// Flush sends what was written so far. A response flushed before MinCompressSize bytes is a stream, it is sent as is.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if f, ok := w.encoder.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Close writes what is held back and ends the compressed stream, after the handlers are done.
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if err := w.decide(); err != nil {
+			return err
+		}
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	ts := []struct {
+		text   string
+		header string
+		exp    string
+	}{
+		{text: "none", header: "", exp: ""},
+		{text: "identity", header: "identity", exp: ""},
+		{text: "gzip", header: "gzip, deflate", exp: "gzip"},
+		{text: "brotli wins a tie", header: "gzip, deflate, br", exp: "br"},
+		{text: "preferred gzip", header: "br;q=0.5, gzip", exp: "gzip"},
+		{text: "refused brotli", header: "br;q=0, gzip;q=0.1", exp: "gzip"},
+		{text: "wildcard", header: "*", exp: "br"},
+		{text: "wildcard without brotli", header: "*, br;q=0", exp: "gzip"},
+		{text: "everything refused", header: "gzip;q=0, *;q=0", exp: ""},
+		{text: "case and spaces", header: " GZIP ; q=0.8 ", exp: "gzip"},
+		{text: "bad weight", header: "br;q=x, gzip", exp: "gzip"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if got := NegotiateEncoding(tc.header); got != tc.exp {
+			t.Errorf("Expected %q, got %q", tc.exp, got)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	large := strings.Repeat(`{"name": "Tomato Soup"},`, 100)
+	engine := gin.New()
+	engine.Use(CompressionMiddleware())
+	engine.GET("/json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(large))
+	})
+	engine.GET("/small", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(`{"ok": true}`))
+	})
+	engine.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte(large))
+	})
+	engine.GET("/events", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+		c.Writer.WriteString("data: 1\n\n")
+		c.Writer.Flush()
+		c.Writer.WriteString(large)
+	})
+	ts := []struct {
+		text     string
+		path     string
+		accept   string
+		encoding string
+	}{
+		{text: "gzip", path: "/json", accept: "gzip", encoding: "gzip"},
+		{text: "brotli", path: "/json", accept: "gzip, br", encoding: "br"},
+		{text: "identity", path: "/json", accept: "", encoding: ""},
+		{text: "small body", path: "/small", accept: "gzip", encoding: ""},
+		{text: "image", path: "/image", accept: "gzip", encoding: ""},
+		{text: "event stream", path: "/events", accept: "gzip", encoding: ""},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("Accept-Encoding", tc.accept)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if got := w.Header().Get("Content-Encoding"); got != tc.encoding {
+			t.Errorf("Expected Content-Encoding %q, got %q", tc.encoding, got)
+			continue
+		}
+		if !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+			t.Errorf("Expected Vary to name Accept-Encoding, got %v", w.Header().Values("Vary"))
+		}
+		var body io.Reader = w.Body
+		switch tc.encoding {
+		case "gzip":
+			r, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error reading gzip: %s", err)
+			}
+			body = r
+		case "br":
+			body = brotli.NewReader(w.Body)
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("Unexpected error decoding body: %s", err)
+		}
+		if tc.encoding != "" && (len(data) != len(large) || w.Body.Len() >= len(large)) {
+			t.Errorf("Expected the body compressed, got %d bytes decoding to %d", w.Body.Len(), len(data))
+		}
+		if tc.path == "/events" && !strings.HasPrefix(string(data), "data: 1\n\n") {
+			t.Errorf("Expected the event first, got %q", data[:min(len(data), 20)])
+		}
+	}
+}
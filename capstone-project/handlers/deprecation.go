@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecationPolicy describes when a route version was deprecated and when it stops being served.
+type DeprecationPolicy struct {
+	DeprecatedAt time.Time
+	Sunset       time.Time
+	// Successor maps the request path to the path of the replacing route, an empty result adds no Link.
+	Successor func(path string) string
+}
+
+// Deprecation adds the Deprecation (RFC 9745), Sunset (RFC 8594) and successor-version Link headers
+// to every response of the routes it guards. Zero times are left out.
+func Deprecation(policy DeprecationPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !policy.DeprecatedAt.IsZero() {
+			c.Header("Deprecation", fmt.Sprintf("@%d", policy.DeprecatedAt.Unix()))
+		}
+		if !policy.Sunset.IsZero() {
+			c.Header("Sunset", policy.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if policy.Successor != nil {
+			if successor := policy.Successor(c.Request.URL.Path); successor != "" {
+				c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+			}
+		}
+		c.Next()
+	}
+}
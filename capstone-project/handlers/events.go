@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"framework-api/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Recipe change event types.
+const (
+	EventRecipeCreated = "created"
+	EventRecipeUpdated = "updated"
+	EventRecipeDeleted = "deleted"
+)
+
+// Redis keys of the event feed: a capped stream for Last-Event-ID replay and a pub/sub channel for live fan out.
+// Each tenant has its own stream and channel (see TenantKey), so stream IDs, replay and trimming are per tenant.
+const (
+	RecipeEventsStream  = "recipes:events"
+	RecipeEventsChannel = "recipes:events"
+)
+
+// DefaultEventsMaxLen is the approximate number of events kept in the replay stream.
+const DefaultEventsMaxLen = 1000
+
+// seenEventsLen is the number of delivered event IDs a subscription remembers to drop duplicates.
+const seenEventsLen = 2 * DefaultEventsMaxLen
+
+// RecipeEvent describes one change to the catalog. ID is the Redis stream entry ID and orders the events.
+type RecipeEvent struct {
+	ID       string         `json:"id"`
+	Type     string         `json:"type"`
+	RecipeID string         `json:"recipeId"`
+	Recipe   *models.Recipe `json:"recipe,omitempty"`
+	// Tenant is the tenant of the recipe, empty for the default tenant.
+	Tenant     string    `json:"tenant,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// RecipeEventPublisher is notified by RecipeHandler after a recipe was created, updated or deleted.
+type RecipeEventPublisher interface {
+	Publish(ctx context.Context, event RecipeEvent) error
+}
+
+// RecipeEvents is the Redis backed event feed. Every server instance publishes its own changes
+// and streams the changes of all instances to its SSE clients.
+type RecipeEvents struct {
+	redisClient *redis.Client
+	maxLen      int64
+	heartbeat   time.Duration
+}
+
+func NewRecipeEvents(redisClient *redis.Client, maxLen int64) *RecipeEvents {
+	if maxLen <= 0 {
+		maxLen = DefaultEventsMaxLen
+	}
+	return &RecipeEvents{redisClient: redisClient, maxLen: maxLen, heartbeat: 15 * time.Second}
+}
+
+// Publish appends the event to the capped stream of the tenant of ctx, which assigns its ID, then broadcasts it on
+// the channel of the tenant.
+func (e *RecipeEvents) Publish(ctx context.Context, event RecipeEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	id, err := e.redisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: TenantKey(ctx, RecipeEventsStream),
+		MaxLen: e.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": string(data)},
+	}).Result()
+	if err != nil {
+		return err
+	}
+	event.ID = id
+	data, err = json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return e.redisClient.Publish(ctx, TenantKey(ctx, RecipeEventsChannel), data).Err()
+}
+
+// Replay returns the events after lastID still held by the stream of the tenant of ctx. gap is true when lastID is
+// older than the oldest kept event of the tenant, so the client missed events and has to reload.
+func (e *RecipeEvents) Replay(ctx context.Context, lastID string) ([]RecipeEvent, bool, error) {
+	events := make([]RecipeEvent, 0)
+	stream := TenantKey(ctx, RecipeEventsStream)
+	oldest, err := e.redisClient.XRangeN(ctx, stream, "-", "+", 1).Result()
+	if err != nil {
+		return events, false, err
+	}
+	gap := len(oldest) > 0 && StreamIDLess(lastID, oldest[0].ID)
+	msgs, err := e.redisClient.XRange(ctx, stream, "("+lastID, "+").Result()
+	if err != nil {
+		return events, gap, err
+	}
+	for _, msg := range msgs {
+		event, ok := decodeStreamEvent(msg)
+		if !ok {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, gap, nil
+}
+
+// RecipeSubscription follows the live feed of all instances for one tenant, resumed after a client's last event ID.
+// Replayed holds the missed events still in the stream, Gap is true when some were trimmed already.
+//
+// Instances publish on the channel after XADD, so live events of different instances can arrive out of stream ID
+// order. Duplicates of the replay are dropped by the IDs already delivered, not by comparing with the last one.
+type RecipeSubscription struct {
+	pubsub   *redis.PubSub
+	seen     map[string]struct{}
+	order    []string
+	Replayed []RecipeEvent
+	Gap      bool
+}
+
+// Subscribe starts following the feed of the tenant of ctx. With a lastID the missed events are replayed, see
+// RecipeSubscription.
+func (e *RecipeEvents) Subscribe(ctx context.Context, lastID string) (*RecipeSubscription, error) {
+	//Subscribe before replaying so no event falls between the two, duplicates are dropped by ID in Accept
+	pubsub := e.redisClient.Subscribe(ctx, TenantKey(ctx, RecipeEventsChannel))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+	s := &RecipeSubscription{pubsub: pubsub, seen: make(map[string]struct{}), Replayed: make([]RecipeEvent, 0)}
+	if lastID == "" {
+		return s, nil
+	}
+	events, gap, err := e.Replay(ctx, lastID)
+	if err != nil {
+		zap.L().Error("Failed to replay recipe events", zap.String("last_event_id", lastID), zap.Error(err))
+	}
+	s.Gap = gap
+	for _, event := range events {
+		s.see(event.ID)
+		s.Replayed = append(s.Replayed, event)
+	}
+	return s, nil
+}
+
+// Messages is the channel of live messages, decode them with Accept.
+func (s *RecipeSubscription) Messages() <-chan *redis.Message {
+	return s.pubsub.Channel()
+}
+
+// Accept decodes a live message. It returns false for malformed events and events already delivered.
+func (s *RecipeSubscription) Accept(msg *redis.Message) (RecipeEvent, bool) {
+	var event RecipeEvent
+	if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+		zap.L().Warn("Dropping malformed recipe event", zap.Error(err))
+		return event, false
+	}
+	return event, s.see(event.ID)
+}
+
+// see records a delivered event ID and reports whether it is new. Only the latest seenEventsLen IDs are kept, older
+// ones are long past the replay a live event could duplicate.
+func (s *RecipeSubscription) see(id string) bool {
+	if _, ok := s.seen[id]; ok {
+		return false
+	}
+	s.seen[id] = struct{}{}
+	s.order = append(s.order, id)
+	if len(s.order) > seenEventsLen {
+		delete(s.seen, s.order[0])
+		s.order = s.order[1:]
+	}
+	return true
+}
+
+func (s *RecipeSubscription) Close() error {
+	return s.pubsub.Close()
+}
+
+// StreamRecipeEvents serves the feed as Server-Sent Events. Clients resume with the Last-Event-ID header
+// (sent by EventSource on reconnect) or the lastEventId query parameter.
+func (e *RecipeEvents) StreamRecipeEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("lastEventId")
+	}
+	if lastID != "" && !ValidStreamID(lastID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+		return
+	}
+
+	sub, err := e.Subscribe(ctx, lastID)
+	if err != nil {
+		zap.L().Error("Failed to subscribe to recipe events", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to subscribe to recipe events"})
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	if sub.Gap {
+		//The stream was trimmed past the client's position, it has to reload the catalog
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range sub.Replayed {
+		writeEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	messages := sub.Messages()
+	heartbeat := time.NewTicker(e.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			event, ok := sub.Accept(msg)
+			if !ok {
+				continue
+			}
+			writeEvent(c, event)
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, event RecipeEvent) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
+
+func decodeStreamEvent(msg redis.XMessage) (RecipeEvent, bool) {
+	var event RecipeEvent
+	raw, ok := msg.Values["event"].(string)
+	if !ok {
+		return event, false
+	}
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		return event, false
+	}
+	event.ID = msg.ID
+	return event, true
+}
+
+// ValidStreamID reports whether id looks like a Redis stream ID, <milliseconds>-<sequence>.
+func ValidStreamID(id string) bool {
+	_, _, ok := parseStreamID(id)
+	return ok
+}
+
+// StreamIDLess compares two Redis stream IDs numerically.
+func StreamIDLess(a, b string) bool {
+	aMs, aSeq, _ := parseStreamID(a)
+	bMs, bSeq, _ := parseStreamID(b)
+	if aMs != bMs {
+		return aMs < bMs
+	}
+	return aSeq < bSeq
+}
+
+func parseStreamID(id string) (uint64, uint64, bool) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !found {
+		return ms, 0, true
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
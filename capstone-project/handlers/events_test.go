@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"framework-api/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func newEventsServer(t *testing.T) (*RecipeEvents, *httptest.Server) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	mr := miniredis.RunT(t)
+	events := NewRecipeEvents(redis.NewClient(&redis.Options{Addr: mr.Addr()}), 0)
+	engine := gin.New()
+	engine.GET("/recipes/events", events.StreamRecipeEvents)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return events, server
+}
+
+// sseEvent is one parsed event of the stream, comments and the retry hint are skipped.
+type sseEvent struct {
+	id   string
+	kind string
+	data string
+}
+
+func readEvents(t *testing.T, reader *bufio.Reader, n int) []sseEvent {
+	t.Helper()
+	events := make([]sseEvent, 0, n)
+	var current sseEvent
+	for len(events) < n {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Unexpected error reading stream after %d events: %s", len(events), err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if current.kind != "" {
+				events = append(events, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.kind = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	return events
+}
+
+func openStream(t *testing.T, url, lastID string) *bufio.Reader {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url+"/recipes/events", nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error opening stream: %s", err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if got := res.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Expected Content-Type text/event-stream, got %s", got)
+	}
+	return bufio.NewReader(res.Body)
+}
+
+func publish(t *testing.T, events *RecipeEvents, eventType, name string) {
+	t.Helper()
+	recipe := models.Recipe{ID: bson.NewObjectID(), Name: name}
+	err := events.Publish(context.Background(), RecipeEvent{Type: eventType, RecipeID: recipe.ID.Hex(), Recipe: &recipe, OccurredAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Unexpected error publishing event: %s", err)
+	}
+}
+
+func TestRecipeEventsStreamLive(t *testing.T) {
+	events, server := newEventsServer(t)
+	reader := openStream(t, server.URL, "")
+	publish(t, events, EventRecipeCreated, "Pancakes")
+	publish(t, events, EventRecipeDeleted, "Pancakes")
+
+	got := readEvents(t, reader, 2)
+	if got[0].kind != EventRecipeCreated || got[1].kind != EventRecipeDeleted {
+		t.Errorf("Expected created then deleted, got %s then %s", got[0].kind, got[1].kind)
+	}
+	if !strings.Contains(got[0].data, `"name":"Pancakes"`) {
+		t.Errorf("Expected the recipe in the event data, got %s", got[0].data)
+	}
+	if got[0].id == "" || !StreamIDLess(got[0].id, got[1].id) {
+		t.Errorf("Expected increasing stream IDs, got %q and %q", got[0].id, got[1].id)
+	}
+}
+
+func TestRecipeEventsResumeFromLastEventID(t *testing.T) {
+	events, server := newEventsServer(t)
+	first := openStream(t, server.URL, "")
+	publish(t, events, EventRecipeCreated, "Soup")
+	publish(t, events, EventRecipeUpdated, "Soup")
+	publish(t, events, EventRecipeCreated, "Salad")
+	seen := readEvents(t, first, 3)
+
+	//Reconnect after the first event: the other two are replayed, then live events follow without duplicates
+	resumed := openStream(t, server.URL, seen[0].id)
+	publish(t, events, EventRecipeDeleted, "Soup")
+	got := readEvents(t, resumed, 3)
+	exp := []string{seen[1].id, seen[2].id}
+	for i, id := range exp {
+		if got[i].id != id {
+			t.Errorf("Replayed event %d: expected id %s, got %s", i, id, got[i].id)
+		}
+	}
+	if got[2].kind != EventRecipeDeleted {
+		t.Errorf("Expected the live deleted event after the replay, got %s", got[2].kind)
+	}
+}
+
+func TestRecipeEventsStreamIsBounded(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	events := NewRecipeEvents(client, 5)
+	for i := 0; i < 20; i++ {
+		publish(t, events, EventRecipeCreated, "Bread")
+	}
+	length, err := client.XLen(context.Background(), RecipeEventsStream).Result()
+	if err != nil {
+		t.Fatalf("Unexpected error reading stream length: %s", err)
+	}
+	if length > 5 {
+		t.Errorf("Expected at most 5 events kept, got %d", length)
+	}
+	//A client behind the trimmed part is told to reload
+	_, gap, err := events.Replay(context.Background(), "1-0")
+	if err != nil {
+		t.Fatalf("Unexpected error replaying: %s", err)
+	}
+	if !gap {
+		t.Errorf("Expected a gap for an event ID older than the stream")
+	}
+}
+
+func TestRecipeEventsRejectsInvalidLastEventID(t *testing.T) {
+	_, server := newEventsServer(t)
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/recipes/events", nil)
+	req.Header.Set("Last-Event-ID", "not-an-id")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, res.StatusCode)
+	}
+}
+
+func TestRecipeSubscriptionAcceptsEventsOutOfOrder(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	events := NewRecipeEvents(redisClient, 0)
+	publish(t, events, EventRecipeCreated, "Soup")
+	publish(t, events, EventRecipeCreated, "Salad")
+	replayed, _, err := events.Replay(context.Background(), "0-0")
+	if err != nil || len(replayed) != 2 {
+		t.Fatalf("Unexpected replay %v: %v", replayed, err)
+	}
+
+	sub, err := events.Subscribe(context.Background(), replayed[0].ID)
+	if err != nil {
+		t.Fatalf("Unexpected error subscribing: %s", err)
+	}
+	defer sub.Close()
+	if len(sub.Replayed) != 1 || sub.Replayed[0].ID != replayed[1].ID {
+		t.Fatalf("Expected the second event replayed, got %v", sub.Replayed)
+	}
+
+	//Two instances publish after their XADD, the later ID reaches the channel first
+	ts := []struct {
+		text string
+		id   string
+		exp  bool
+	}{
+		{text: "later ID first", id: "9999999999999-1", exp: true},
+		{text: "earlier ID second", id: "9999999999999-0", exp: true},
+		{text: "duplicate of the replay", id: replayed[1].ID, exp: false},
+		{text: "duplicate of a live event", id: "9999999999999-1", exp: false},
+	}
+	for _, tc := range ts {
+		if err := redisClient.Publish(context.Background(), RecipeEventsChannel, `{"id":"`+tc.id+`","type":"updated"}`).Err(); err != nil {
+			t.Fatalf("Unexpected error publishing: %s", err)
+		}
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		select {
+		case msg := <-sub.Messages():
+			event, ok := sub.Accept(msg)
+			if event.ID != tc.id || ok != tc.exp {
+				t.Errorf("Expected %s accepted %t, got %s accepted %t", tc.id, tc.exp, event.ID, ok)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected a message for %s", tc.id)
+		}
+	}
+}
+
+func TestRecipeEventsArePerTenant(t *testing.T) {
+	mr := miniredis.RunT(t)
+	events := NewRecipeEvents(redis.NewClient(&redis.Options{Addr: mr.Addr()}), 5)
+	bistro := ContextWithTenant(context.Background(), "bistro")
+	publish(t, events, EventRecipeCreated, "Soup")
+	replayed, _, err := events.Replay(context.Background(), "0-0")
+	if err != nil || len(replayed) != 1 {
+		t.Fatalf("Unexpected replay %v: %v", replayed, err)
+	}
+	sub, err := events.Subscribe(context.Background(), replayed[0].ID)
+	if err != nil {
+		t.Fatalf("Unexpected error subscribing: %s", err)
+	}
+	defer sub.Close()
+
+	//A busy tenant trims its own stream, the default tenant's client is not told to reload
+	for i := 0; i < 20; i++ {
+		if err := events.Publish(bistro, RecipeEvent{Type: EventRecipeUpdated, RecipeID: bson.NewObjectID().Hex(), Tenant: "bistro", OccurredAt: time.Now()}); err != nil {
+			t.Fatalf("Unexpected error publishing: %s", err)
+		}
+	}
+	ts := []struct {
+		text   string
+		ctx    context.Context
+		events int
+		gap    bool
+	}{
+		{text: "default tenant", ctx: context.Background(), events: 0, gap: false},
+		{text: "busy tenant", ctx: bistro, events: 5, gap: true},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		replayed, gap, err := events.Replay(tc.ctx, replayed[0].ID)
+		if err != nil {
+			t.Fatalf("Unexpected error replaying: %s", err)
+		}
+		if len(replayed) > tc.events || gap != tc.gap {
+			t.Errorf("Expected at most %d events and gap %t, got %d and %t", tc.events, tc.gap, len(replayed), gap)
+		}
+	}
+	//The events of the other tenant never reach the subscription
+	select {
+	case msg := <-sub.Messages():
+		t.Errorf("Expected no live event, got %s", msg.Payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"framework-api/export"
+	"framework-api/models"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxCookbookRecipes is the most recipes one cookbook export takes.
+	MaxCookbookRecipes = 50
+	// MaxImageBytes is the largest recipe image embedded in a PDF, 5 MB.
+	MaxImageBytes = 5 << 20
+	// imageFetchers bounds the concurrent image loads of a cookbook.
+	imageFetchers = 4
+)
+
+// ErrImageNotAllowed is returned for images neither under /static nor on an allowed host.
+var ErrImageNotAllowed = errors.New("Image location is not allowed")
+
+// ImageSource loads the image of a recipe, by its stored imageUrl, for PDF exports.
+type ImageSource interface {
+	LoadImage(ctx context.Context, src string) ([]byte, error)
+}
+
+// ImageLoader reads images served under /static from disk and downloads remote ones from the allowed hosts only,
+// so exports can not be used to make the server fetch arbitrary URLs.
+type ImageLoader struct {
+	static fs.FS
+	hosts  hostAllowlist
+	client *http.Client
+}
+
+// NewImageLoader loads /static images from staticDir and remote images from hosts, matched exactly.
+func NewImageLoader(staticDir string, hosts []string) *ImageLoader {
+	allowed := newHostAllowlist(hosts)
+	return &ImageLoader{static: os.DirFS(staticDir), hosts: allowed, client: allowed.client(10*time.Second, ErrImageNotAllowed)}
+}
+
+// LoadImage returns the image bytes, at most MaxImageBytes.
+func (l *ImageLoader) LoadImage(ctx context.Context, src string) ([]byte, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" && u.Host == "" {
+		name, ok := strings.CutPrefix(path.Clean("/"+u.Path), "/static/")
+		if !ok {
+			return nil, ErrImageNotAllowed
+		}
+		f, err := l.static.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readLimited(f, MaxImageBytes, export.ErrImageTooLarge)
+	}
+	if !l.hosts.allows(u) {
+		return nil, ErrImageNotAllowed
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Image request returned %s", res.Status)
+	}
+	return readLimited(res.Body, MaxImageBytes, export.ErrImageTooLarge)
+}
+
+// ExportHandler serves recipes as files to print or share: PDF, Markdown and plain text.
+type ExportHandler struct {
+	recipes *RecipeHandler
+	images  ImageSource
+	baseURL string
+}
+
+// NewExportHandler exports the recipes of recipes, baseURL makes image links in Markdown absolute.
+func NewExportHandler(recipes *RecipeHandler, images ImageSource, baseURL string) *ExportHandler {
+	return &ExportHandler{recipes: recipes, images: images, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// CookbookRequest lists the recipes of a cookbook, in the order they are printed.
+type CookbookRequest struct {
+	Title string   `json:"title"`
+	IDs   []string `json:"ids" binding:"required"`
+}
+
+// ExportRecipe returns one recipe as a file, ?format=pdf (default), md or txt, in the Accept-Language locale.
+func (h *ExportHandler) ExportRecipe(c *gin.Context) {
+	format := c.DefaultQuery("format", export.FormatPDF)
+	contentType, ok := export.ContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of pdf, md, txt"})
+		return
+	}
+	recipeId := c.Param("id")
+	locale := h.recipes.requestLocale(c)
+	recipe, err := h.recipes.FindRecipe(c.Request.Context(), recipeId)
+	if err != nil {
+		status, msg := recipeErrorStatus(err, "Failed to find recipe")
+		zap.L().Warn("Failed to find recipe to export", zap.String("recipe_id", recipeId), zap.Error(err))
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	recipe = localize(recipe, locale)
+
+	var buf bytes.Buffer
+	switch format {
+	case export.FormatMarkdown:
+		imageURL := ""
+		if recipe.ImageURL != "" {
+			imageURL = absoluteURL(h.baseURL, recipe.ImageURL)
+		}
+		err = export.Markdown(&buf, recipe, imageURL)
+	case export.FormatText:
+		err = export.Text(&buf, recipe)
+	default:
+		err = export.RecipePDF(&buf, h.pages(c.Request.Context(), []models.Recipe{recipe})[0])
+	}
+	if err != nil {
+		zap.L().Error("Failed to export recipe", zap.String("recipe_id", recipeId), zap.String("format", format), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export recipe"})
+		return
+	}
+	c.Header("Content-Language", recipe.Locale)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, Slug(recipe.Name), format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// ExportCookbook returns the recipes of the request as one PDF with a table of contents, in the Accept-Language locale.
+func (h *ExportHandler) ExportCookbook(c *gin.Context) {
+	var req CookbookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.IDs) == 0 || len(req.IDs) > MaxCookbookRecipes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ids must list 1 to %d recipes", MaxCookbookRecipes)})
+		return
+	}
+	req.Title = strings.Join(strings.Fields(req.Title), " ")
+	if len([]rune(req.Title)) > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title must be at most 200 characters"})
+		return
+	}
+	locale := h.recipes.requestLocale(c)
+	recipes, errs := h.recipes.FindRecipes(c.Request.Context(), req.IDs)
+	for i, err := range errs {
+		if err != nil {
+			status, msg := recipeErrorStatus(err, "Failed to find recipe")
+			zap.L().Warn("Failed to find recipe to export", zap.String("recipe_id", req.IDs[i]), zap.Error(err))
+			c.JSON(status, gin.H{"error": msg, "id": req.IDs[i]})
+			return
+		}
+	}
+	for i := range recipes {
+		recipes[i] = localize(recipes[i], locale)
+	}
+
+	var buf bytes.Buffer
+	if err := export.CookbookPDF(&buf, req.Title, h.pages(c.Request.Context(), recipes)); err != nil {
+		zap.L().Error("Failed to export cookbook", zap.Int("recipes", len(recipes)), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export cookbook"})
+		return
+	}
+	name := "cookbook"
+	if req.Title != "" {
+		name = Slug(req.Title)
+	}
+	c.Header("Content-Language", locale)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, name))
+	c.Data(http.StatusOK, export.ContentTypes[export.FormatPDF], buf.Bytes())
+}
+
+// pages loads the images of the recipes concurrently. A recipe whose image can not be loaded is printed without it.
+func (h *ExportHandler) pages(ctx context.Context, recipes []models.Recipe) []export.Page {
+	pages := make([]export.Page, len(recipes))
+	slots := make(chan struct{}, imageFetchers)
+	var wg sync.WaitGroup
+	for i, recipe := range recipes {
+		pages[i].Recipe = recipe
+		if recipe.ImageURL == "" || h.images == nil {
+			continue
+		}
+		wg.Go(func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			image, err := h.loadImage(ctx, recipe.ImageURL)
+			if err != nil {
+				zap.L().Warn("Exporting recipe without its image", zap.String("recipe_id", recipe.ID.Hex()), zap.String("image", recipe.ImageURL), zap.Error(err))
+				return
+			}
+			pages[i].Image = image
+		})
+	}
+	wg.Wait()
+	return pages
+}
+
+func (h *ExportHandler) loadImage(ctx context.Context, src string) (*export.Image, error) {
+	data, err := h.images.LoadImage(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return export.NewImage(data)
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"framework-api/handlers/cachetest"
+	"framework-api/models"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeImageSource map[string][]byte
+
+func (f fakeImageSource) LoadImage(ctx context.Context, src string) ([]byte, error) {
+	if data, ok := f[src]; ok {
+		return data, nil
+	}
+	return nil, ErrImageNotAllowed
+}
+
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 4))); err != nil {
+		t.Fatalf("Unexpected error encoding PNG: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func newExportEngine(t *testing.T, recipes []models.Recipe, images ImageSource) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	_, redisClient := cachetest.NewRedis(t, recipes)
+	recipeHandler := NewRecipesHandler(context.Background(), nil, redisClient, nil)
+	exports := NewExportHandler(recipeHandler, images, "https://recipes.example.com/")
+	engine := gin.New()
+	engine.GET("/recipe/:id/export", exports.ExportRecipe)
+	engine.POST("/recipes/export", exports.ExportCookbook)
+	return engine
+}
+
+func TestExportRecipe(t *testing.T) {
+	recipe := translatedRecipe()
+	recipe.ImageURL = "static/images/soup.png"
+	engine := newExportEngine(t, []models.Recipe{recipe}, fakeImageSource{"static/images/soup.png": pngBytes(t)})
+	path := "/recipe/" + recipe.ID.Hex() + "/export"
+	ts := []struct {
+		text        string
+		query       string
+		lang        string
+		exp         int
+		contentType string
+		filename    string
+		body        string
+	}{
+		{text: "pdf by default", exp: http.StatusOK, contentType: "application/pdf", filename: "tomato-soup.pdf", body: "%PDF-"},
+		{text: "markdown with absolute image", query: "?format=md", exp: http.StatusOK, contentType: "text/markdown; charset=utf-8", filename: "tomato-soup.md", body: "# Tomato Soup\n\n![Tomato Soup](<https://recipes.example.com/static/images/soup.png>)"},
+		{text: "text", query: "?format=txt", exp: http.StatusOK, contentType: "text/plain; charset=utf-8", filename: "tomato-soup.txt", body: "Tomato Soup\n==========="},
+		{text: "translated", query: "?format=txt", lang: "fr", exp: http.StatusOK, contentType: "text/plain; charset=utf-8", filename: "soupe-de-tomates.txt", body: "Soupe de tomates"},
+		{text: "unknown format", query: "?format=docx", exp: http.StatusBadRequest},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		req := httptest.NewRequest(http.MethodGet, path+tc.query, nil)
+		if tc.lang != "" {
+			req.Header.Set("Accept-Language", tc.lang)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tc.exp {
+			t.Errorf("Expected status %d, got %d: %s", tc.exp, w.Code, w.Body.String())
+			continue
+		}
+		if tc.exp != http.StatusOK {
+			continue
+		}
+		if got := w.Header().Get("Content-Type"); got != tc.contentType {
+			t.Errorf("Expected Content-Type %s, got %s", tc.contentType, got)
+		}
+		if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="`+tc.filename+`"` {
+			t.Errorf("Expected file name %s, got %s", tc.filename, got)
+		}
+		if !strings.HasPrefix(w.Body.String(), tc.body) {
+			t.Errorf("Expected body to start with %q, got %q", tc.body, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recipe/nope/export", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid ID, got %d", w.Code)
+	}
+}
+
+func TestExportCookbook(t *testing.T) {
+	recipes := testRecipes()
+	recipes[0].ImageURL = "https://cdn.example.com/broken.jpg"
+	engine := newExportEngine(t, recipes, fakeImageSource{})
+	ids := []string{recipes[2].ID.Hex(), recipes[0].ID.Hex()}
+	tooMany := make([]string, MaxCookbookRecipes+1)
+	for i := range tooMany {
+		tooMany[i] = recipes[0].ID.Hex()
+	}
+	ts := []struct {
+		text     string
+		body     interface{}
+		exp      int
+		filename string
+		errorID  string
+	}{
+		{text: "with title", body: CookbookRequest{Title: "Sunday  dinners", IDs: ids}, exp: http.StatusOK, filename: "sunday-dinners.pdf"},
+		{text: "without title", body: CookbookRequest{IDs: ids}, exp: http.StatusOK, filename: "cookbook.pdf"},
+		{text: "no ids", body: CookbookRequest{IDs: []string{}}, exp: http.StatusBadRequest},
+		{text: "too many ids", body: CookbookRequest{IDs: tooMany}, exp: http.StatusBadRequest},
+		{text: "title too long", body: CookbookRequest{Title: strings.Repeat("a", 201), IDs: ids}, exp: http.StatusBadRequest},
+		{text: "invalid id", body: CookbookRequest{IDs: []string{ids[0], "nope"}}, exp: http.StatusBadRequest, errorID: "nope"},
+		{text: "not JSON", body: "ids", exp: http.StatusBadRequest},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		body, _ := json.Marshal(tc.body)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/recipes/export", bytes.NewReader(body)))
+		if w.Code != tc.exp {
+			t.Errorf("Expected status %d, got %d: %s", tc.exp, w.Code, w.Body.String())
+			continue
+		}
+		if tc.exp == http.StatusOK {
+			if !strings.HasPrefix(w.Body.String(), "%PDF-") || w.Header().Get("Content-Disposition") != `attachment; filename="`+tc.filename+`"` {
+				t.Errorf("Expected %s, got %s", tc.filename, w.Header().Get("Content-Disposition"))
+			}
+			continue
+		}
+		if tc.errorID != "" {
+			var res map[string]string
+			json.Unmarshal(w.Body.Bytes(), &res)
+			if res["id"] != tc.errorID {
+				t.Errorf("Expected the failing id %s, got %v", tc.errorID, res)
+			}
+		}
+	}
+}
+
+func TestImageLoader(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "images"), 0o755)
+	os.WriteFile(filepath.Join(dir, "images", "soup.png"), pngBytes(t), 0o644)
+	os.WriteFile(filepath.Join(dir, "big.png"), make([]byte, MaxImageBytes+1), 0o644)
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://localhost/soup.png", http.StatusFound)
+			return
+		}
+		w.Write(pngBytes(t))
+	}))
+	defer remote.Close()
+	loader := NewImageLoader(dir, []string{"127.0.0.1"})
+
+	ts := []struct {
+		text string
+		src  string
+		err  error
+	}{
+		{text: "static path", src: "static/images/soup.png"},
+		{text: "absolute static path", src: "/static/images/soup.png"},
+		{text: "outside static", src: "/static/../main.go", err: ErrImageNotAllowed},
+		{text: "not under static", src: "images/soup.png", err: ErrImageNotAllowed},
+		{text: "allowed host", src: remote.URL + "/soup.png"},
+		{text: "other host", src: "https://example.com/soup.png", err: ErrImageNotAllowed},
+		{text: "redirect to other host", src: remote.URL + "/redirect", err: ErrImageNotAllowed},
+		{text: "file scheme", src: "file:///etc/passwd", err: ErrImageNotAllowed},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		data, err := loader.LoadImage(context.Background(), tc.src)
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Expected %s, got %v", tc.err, err)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(data, pngBytes(t)) {
+			t.Errorf("Unexpected error loading image: %v", err)
+		}
+	}
+	if _, err := loader.LoadImage(context.Background(), "static/big.png"); err == nil {
+		t.Errorf("Expected an error for an image above MaxImageBytes")
+	}
+}
@@ -0,0 +1,803 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"framework-api/i18n"
+	"framework-api/models"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/dataloader/v7"
+	"github.com/graph-gophers/graphql-go"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// GraphQLSchema is served on POST /graphql. Queries are public, mutations need the same
+// permissions as the REST write routes.
+const GraphQLSchema = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+scalar Time
+
+type Query {
+	recipe(id: ID!): Recipe
+	recipes(first: Int = 20, after: String, filter: RecipeFilter): RecipeConnection!
+	search(q: String, tags: [String!], maxCalories: Float, excludeAllergens: [String!], first: Int = 20, facets: Int = 10): SearchResult!
+}
+
+type Mutation {
+	createRecipe(input: RecipeInput!): Recipe!
+	updateRecipe(id: ID!, input: RecipePatch!): Recipe!
+	deleteRecipe(id: ID!): ID!
+}
+
+type Recipe {
+	id: ID!
+	name: String!
+	tags: [String!]!
+	ingredients: [String!]!
+	instructions: [String!]!
+	publishedAt: Time!
+	imageUrl: String!
+	servings: Int!
+	nutrition: Nutrition
+	allergens: [String!]!
+	diets: [String!]!
+	tagConflicts: [TagConflict!]!
+	locale: String!
+	reviews: [Review!]!
+}
+
+type Review {
+	id: ID!
+	username: String!
+	rating: Int!
+	comment: String!
+	createdAt: Time!
+}
+
+type TagConflict {
+	tag: String!
+	contains: [String!]!
+	ingredients: [String!]!
+}
+
+type Nutrition {
+	total: NutritionFacts!
+	perServing: NutritionFacts!
+	unmatched: [String!]!
+}
+
+type NutritionFacts {
+	calories: Float!
+	protein: Float!
+	carbohydrates: Float!
+	fat: Float!
+	fiber: Float!
+}
+
+input RecipeFilter {
+	tags: [String!]
+	nameContains: String
+	ingredient: String
+	maxCalories: Float
+	excludeAllergens: [String!]
+}
+
+input RecipeInput {
+	name: String!
+	tags: [String!]
+	ingredients: [String!]
+	instructions: [String!]
+	imageUrl: String
+	servings: Int
+}
+
+input RecipePatch {
+	name: String
+	tags: [String!]
+	ingredients: [String!]
+	instructions: [String!]
+	imageUrl: String
+	servings: Int
+}
+
+type RecipeConnection {
+	edges: [RecipeEdge!]!
+	pageInfo: PageInfo!
+	totalCount: Int!
+}
+
+type RecipeEdge {
+	cursor: String!
+	node: Recipe!
+}
+
+type PageInfo {
+	hasNextPage: Boolean!
+	endCursor: String
+}
+
+type SearchResult {
+	hits: [Recipe!]!
+	facets: [TagFacet!]!
+}
+
+type TagFacet {
+	tag: String!
+	count: Int!
+}
+`
+
+// GraphQLHandler serves the GraphQL endpoint on top of the RecipeHandler data access and Redis cache, and the
+// reviews of the recipes from reviews. Without a review store recipes have no reviews.
+type GraphQLHandler struct {
+	recipes *RecipeHandler
+	reviews ReviewStore
+	schema  *graphql.Schema
+}
+
+func NewGraphQLHandler(recipes *RecipeHandler, reviews ReviewStore) (*GraphQLHandler, error) {
+	schema, err := graphql.ParseSchema(GraphQLSchema, &graphqlResolver{recipes: recipes},
+		graphql.MaxDepth(8),
+		graphql.MaxParallelism(16),
+		graphql.MaxQueryLength(16<<10),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &GraphQLHandler{recipes: recipes, reviews: reviews, schema: schema}, nil
+}
+
+// GraphQLRequest is the standard GraphQL-over-HTTP request body.
+type GraphQLRequest struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Serve executes one GraphQL request. Errors are reported in the errors list with status 200, as GraphQL clients expect.
+func (h *GraphQLHandler) Serve(c *gin.Context) {
+	var req GraphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	//One loader per request, so batching and caching never leak between callers
+	ctx := context.WithValue(c.Request.Context(), recipeLoaderKey{}, newRecipeLoader(h.recipes))
+	ctx = context.WithValue(ctx, reviewLoaderKey{}, newReviewLoader(h.reviews))
+	ctx = i18n.WithLocale(ctx, h.recipes.requestLocale(c))
+	c.JSON(http.StatusOK, h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+type recipeLoaderKey struct{}
+
+// newRecipeLoader batches every recipe lookup of one request, made within 2ms, into a single FindRecipes call.
+func newRecipeLoader(recipes *RecipeHandler) *dataloader.Loader[string, models.Recipe] {
+	batch := func(ctx context.Context, ids []string) []*dataloader.Result[models.Recipe] {
+		found, errs := recipes.FindRecipes(ctx, ids)
+		results := make([]*dataloader.Result[models.Recipe], len(ids))
+		for i := range ids {
+			results[i] = &dataloader.Result[models.Recipe]{Data: found[i], Error: errs[i]}
+		}
+		return results
+	}
+	return dataloader.NewBatchedLoader(batch, dataloader.WithWait[string, models.Recipe](2*time.Millisecond))
+}
+
+func recipeLoader(ctx context.Context) *dataloader.Loader[string, models.Recipe] {
+	return ctx.Value(recipeLoaderKey{}).(*dataloader.Loader[string, models.Recipe])
+}
+
+type reviewLoaderKey struct{}
+
+// newReviewLoader batches the reviews of every recipe resolved in one request, within 2ms, into a single ReviewsOf
+// call.
+func newReviewLoader(reviews ReviewStore) *dataloader.Loader[bson.ObjectID, []models.Review] {
+	batch := func(ctx context.Context, ids []bson.ObjectID) []*dataloader.Result[[]models.Review] {
+		results := make([]*dataloader.Result[[]models.Review], len(ids))
+		var byRecipe map[bson.ObjectID][]models.Review
+		var err error
+		if reviews != nil {
+			byRecipe, err = reviews.ReviewsOf(ctx, ids)
+		}
+		for i, id := range ids {
+			results[i] = &dataloader.Result[[]models.Review]{Data: byRecipe[id], Error: err}
+		}
+		return results
+	}
+	return dataloader.NewBatchedLoader(batch, dataloader.WithWait[bson.ObjectID, []models.Review](2*time.Millisecond))
+}
+
+func reviewLoader(ctx context.Context) *dataloader.Loader[bson.ObjectID, []models.Review] {
+	return ctx.Value(reviewLoaderKey{}).(*dataloader.Loader[bson.ObjectID, []models.Review])
+}
+
+// graphqlError carries a machine readable code in the error extensions.
+type graphqlError struct {
+	message string
+	code    string
+}
+
+func (e graphqlError) Error() string {
+	return e.message
+}
+
+func (e graphqlError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+var (
+	errGraphQLUnauthenticated = graphqlError{message: "Not authenticated", code: "UNAUTHENTICATED"}
+	errGraphQLForbidden       = graphqlError{message: "Insufficient permissions", code: "FORBIDDEN"}
+)
+
+func badUserInput(message string) error {
+	return graphqlError{message: message, code: "BAD_USER_INPUT"}
+}
+
+// requireScopes mirrors RequireScopes for mutations, admins of the tenant pass every scope check.
+func requireScopes(ctx context.Context, scopes ...string) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return errGraphQLUnauthenticated
+	}
+	tenant := TenantFromContext(ctx)
+	if p.IsTenantAdmin(tenant) {
+		return nil
+	}
+	if !p.MemberOf(tenant) {
+		return errGraphQLForbidden
+	}
+	for _, scope := range scopes {
+		if !p.HasScope(scope) {
+			return errGraphQLForbidden
+		}
+	}
+	return nil
+}
+
+// requireTenantAdmin mirrors RequireTenantAdmin for mutations.
+func requireTenantAdmin(ctx context.Context) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return errGraphQLUnauthenticated
+	}
+	if !p.IsTenantAdmin(TenantFromContext(ctx)) {
+		return errGraphQLForbidden
+	}
+	return nil
+}
+
+// recipeError hides storage failures, not found and invalid ids are reported as they are.
+func recipeError(err error) error {
+	switch err {
+	case ErrInvalidRecipeID:
+		return badUserInput("Invalid ID")
+	case ErrRecipeNotFound:
+		return graphqlError{message: "Recipe not found", code: "NOT_FOUND"}
+	default:
+		return errors.New("Failed to access recipes")
+	}
+}
+
+type graphqlResolver struct {
+	recipes *RecipeHandler
+}
+
+func (r *graphqlResolver) Recipe(ctx context.Context, args struct{ ID graphql.ID }) (*recipeResolver, error) {
+	recipe, err := recipeLoader(ctx).Load(ctx, string(args.ID))()
+	if err == ErrRecipeNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, recipeError(err)
+	}
+	return &recipeResolver{recipe}, nil
+}
+
+type recipeFilterInput struct {
+	Tags             *[]string
+	NameContains     *string
+	Ingredient       *string
+	MaxCalories      *float64
+	ExcludeAllergens *[]string
+}
+
+func (f *recipeFilterInput) matches(h *RecipeHandler, recipe models.Recipe) bool {
+	if f == nil {
+		return true
+	}
+	if f.Tags != nil {
+		for _, tag := range *f.Tags {
+			if !slices.Contains(recipe.Tags, tag) {
+				return false
+			}
+		}
+	}
+	if f.NameContains != nil && !strings.Contains(strings.ToLower(recipe.Name), strings.ToLower(*f.NameContains)) {
+		return false
+	}
+	if f.Ingredient != nil {
+		found := slices.ContainsFunc(recipe.Ingredients, func(ingredient string) bool {
+			return strings.Contains(strings.ToLower(ingredient), strings.ToLower(*f.Ingredient))
+		})
+		if !found {
+			return false
+		}
+	}
+	if f.MaxCalories != nil && (recipe.Nutrition == nil || recipe.Nutrition.PerServing.Calories > *f.MaxCalories) {
+		return false
+	}
+	if f.ExcludeAllergens != nil {
+		allergens := h.allergensOf(recipe)
+		if slices.ContainsFunc(*f.ExcludeAllergens, func(allergen string) bool { return slices.Contains(allergens, allergen) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// Recipes pages through the cached recipe list. Cursors are opaque, after is the cursor of the last edge seen.
+func (r *graphqlResolver) Recipes(ctx context.Context, args struct {
+	First  int32
+	After  *string
+	Filter *recipeFilterInput
+}) (*recipeConnectionResolver, error) {
+	if args.First < 0 || args.First > MaxPageLimit {
+		return nil, badUserInput("first must be between 0 and 100")
+	}
+	if args.Filter != nil && args.Filter.ExcludeAllergens != nil {
+		allergens, err := r.recipes.ExcludedAllergens(*args.Filter.ExcludeAllergens)
+		if err != nil {
+			return nil, badUserInput(err.Error())
+		}
+		args.Filter.ExcludeAllergens = &allergens
+	}
+	all, err := r.recipes.ListRecipes(ctx)
+	if err != nil {
+		return nil, recipeError(err)
+	}
+	recipes := make([]models.Recipe, 0, len(all))
+	for _, recipe := range all {
+		if args.Filter.matches(r.recipes, recipe) {
+			recipes = append(recipes, recipe)
+		}
+	}
+	start := 0
+	if args.After != nil {
+		afterID, ok := decodeCursor(*args.After)
+		if !ok {
+			return nil, badUserInput("Invalid cursor")
+		}
+		after := slices.IndexFunc(recipes, func(recipe models.Recipe) bool { return recipe.ID.Hex() == afterID })
+		if after < 0 {
+			//Starting over would serve the first page again as if it were the next one
+			return nil, badUserInput("Unknown cursor, the recipe was deleted or does not match the filter")
+		}
+		start = after + 1
+	}
+	end := min(start+int(args.First), len(recipes))
+	loader := recipeLoader(ctx)
+	edges := make([]*recipeEdgeResolver, 0, end-start)
+	for _, recipe := range recipes[start:end] {
+		//Later recipe(id) lookups in the same request are served from the loader cache
+		loader.Prime(ctx, recipe.ID.Hex(), recipe)
+		edges = append(edges, &recipeEdgeResolver{recipe})
+	}
+	return &recipeConnectionResolver{edges: edges, total: len(recipes), hasNext: end < len(recipes)}, nil
+}
+
+// Search runs the Elasticsearch query and loads the full recipes of the hits in one batch.
+func (r *graphqlResolver) Search(ctx context.Context, args struct {
+	Q                *string
+	Tags             *[]string
+	MaxCalories      *float64
+	ExcludeAllergens *[]string
+	First            int32
+	Facets           int32
+}) (*searchResultResolver, error) {
+	q := ""
+	if args.Q != nil {
+		q = strings.TrimSpace(*args.Q)
+	}
+	tags := make([]string, 0)
+	if args.Tags != nil {
+		tags = *args.Tags
+	}
+	maxCalories := 0.0
+	if args.MaxCalories != nil {
+		if *args.MaxCalories <= 0 {
+			return nil, badUserInput("maxCalories must be positive")
+		}
+		maxCalories = *args.MaxCalories
+	}
+	excluded, err := r.recipes.ExcludedAllergens(listOrEmpty(args.ExcludeAllergens))
+	if err != nil {
+		return nil, badUserInput(err.Error())
+	}
+	if q == "" && len(tags) == 0 && maxCalories == 0 && len(excluded) == 0 {
+		return nil, badUserInput("q, tags, maxCalories or excludeAllergens is required")
+	}
+	if args.First < 0 || args.First > MaxPageLimit || args.Facets < 0 || args.Facets > MaxPageLimit {
+		return nil, badUserInput("first and facets must be between 0 and 100")
+	}
+	results, facets, err := r.recipes.SearchRecipes(ctx, RecipeQuery{Q: q, Tags: tags, MaxCalories: maxCalories, ExcludeAllergens: excluded, Facets: int(args.Facets), Locale: i18n.FromContext(ctx)})
+	if err != nil {
+		return nil, recipeError(err)
+	}
+	ids := make([]string, 0, len(results))
+	for _, result := range results[:min(len(results), int(args.First))] {
+		ids = append(ids, result.ID)
+	}
+	recipes, errs := recipeLoader(ctx).LoadMany(ctx, ids)()
+	hits := make([]*recipeResolver, 0, len(recipes))
+	for i, recipe := range recipes {
+		//The search index can lag behind MongoDB, hits deleted since are skipped
+		if len(errs) > i && errs[i] != nil {
+			continue
+		}
+		hits = append(hits, &recipeResolver{recipe})
+	}
+	return &searchResultResolver{hits: hits, facets: facets}, nil
+}
+
+type recipeInput struct {
+	Name         string
+	Tags         *[]string
+	Ingredients  *[]string
+	Instructions *[]string
+	ImageURL     *string
+	Servings     *int32
+}
+
+func (r *graphqlResolver) CreateRecipe(ctx context.Context, args struct{ Input recipeInput }) (*recipeResolver, error) {
+	if err := requireScopes(ctx, ScopeRecipesWrite); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Input.Name) == "" {
+		return nil, badUserInput("name must not be empty")
+	}
+	recipe := models.Recipe{
+		Name:         args.Input.Name,
+		Tags:         listOrEmpty(args.Input.Tags),
+		Ingredients:  listOrEmpty(args.Input.Ingredients),
+		Instructions: listOrEmpty(args.Input.Instructions),
+	}
+	if args.Input.ImageURL != nil {
+		recipe.ImageURL = *args.Input.ImageURL
+	}
+	if args.Input.Servings != nil {
+		if *args.Input.Servings < 0 {
+			return nil, badUserInput("servings must not be negative")
+		}
+		recipe.Servings = int(*args.Input.Servings)
+	}
+	recipe, err := r.recipes.CreateRecipe(ctx, recipe)
+	if err != nil {
+		return nil, recipeError(err)
+	}
+	return &recipeResolver{recipe}, nil
+}
+
+type recipePatch struct {
+	Name         *string
+	Tags         *[]string
+	Ingredients  *[]string
+	Instructions *[]string
+	ImageURL     *string
+	Servings     *int32
+}
+
+func (r *graphqlResolver) UpdateRecipe(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input recipePatch
+}) (*recipeResolver, error) {
+	if err := requireScopes(ctx, ScopeRecipesWrite); err != nil {
+		return nil, err
+	}
+	fields := bson.M{}
+	if args.Input.Name != nil {
+		fields["name"] = *args.Input.Name
+	}
+	if args.Input.Tags != nil {
+		fields["tags"] = *args.Input.Tags
+	}
+	if args.Input.Ingredients != nil {
+		fields["ingredients"] = *args.Input.Ingredients
+	}
+	if args.Input.Instructions != nil {
+		fields["instructions"] = *args.Input.Instructions
+	}
+	if args.Input.ImageURL != nil {
+		fields["imageUrl"] = *args.Input.ImageURL
+	}
+	if args.Input.Servings != nil {
+		if *args.Input.Servings < 0 {
+			return nil, badUserInput("servings must not be negative")
+		}
+		fields["servings"] = int(*args.Input.Servings)
+	}
+	if len(fields) == 0 {
+		return nil, badUserInput("input must set at least one field")
+	}
+	recipe, err := r.recipes.UpdateRecipe(ctx, string(args.ID), fields)
+	if err != nil {
+		return nil, recipeError(err)
+	}
+	recipeLoader(ctx).Clear(ctx, string(args.ID))
+	return &recipeResolver{recipe}, nil
+}
+
+func (r *graphqlResolver) DeleteRecipe(ctx context.Context, args struct{ ID graphql.ID }) (graphql.ID, error) {
+	if err := requireTenantAdmin(ctx); err != nil {
+		return "", err
+	}
+	if err := r.recipes.DeleteRecipe(ctx, string(args.ID)); err != nil {
+		return "", recipeError(err)
+	}
+	recipeLoader(ctx).Clear(ctx, string(args.ID))
+	return args.ID, nil
+}
+
+type recipeResolver struct {
+	recipe models.Recipe
+}
+
+func (r *recipeResolver) ID() graphql.ID {
+	return graphql.ID(r.recipe.ID.Hex())
+}
+
+func (r *recipeResolver) Name(ctx context.Context) string {
+	return localize(r.recipe, i18n.FromContext(ctx)).Name
+}
+
+func (r *recipeResolver) Tags() []string {
+	return listOrEmpty(&r.recipe.Tags)
+}
+
+func (r *recipeResolver) Ingredients(ctx context.Context) []string {
+	localized := localize(r.recipe, i18n.FromContext(ctx))
+	return listOrEmpty(&localized.Ingredients)
+}
+
+func (r *recipeResolver) Instructions(ctx context.Context) []string {
+	localized := localize(r.recipe, i18n.FromContext(ctx))
+	return listOrEmpty(&localized.Instructions)
+}
+
+func (r *recipeResolver) Locale(ctx context.Context) string {
+	return localize(r.recipe, i18n.FromContext(ctx)).Locale
+}
+
+func (r *recipeResolver) PublishedAt() graphql.Time {
+	return graphql.Time{Time: r.recipe.PublishedAt}
+}
+
+func (r *recipeResolver) ImageURL() string {
+	return r.recipe.ImageURL
+}
+
+func (r *recipeResolver) Servings() int32 {
+	return int32(r.recipe.Servings)
+}
+
+func (r *recipeResolver) Nutrition() *nutritionResolver {
+	if r.recipe.Nutrition == nil {
+		return nil
+	}
+	return &nutritionResolver{*r.recipe.Nutrition}
+}
+
+func (r *recipeResolver) Allergens() []string {
+	return listOrEmpty(&r.recipe.Allergens)
+}
+
+func (r *recipeResolver) Diets() []string {
+	return listOrEmpty(&r.recipe.Diets)
+}
+
+func (r *recipeResolver) TagConflicts() []*tagConflictResolver {
+	res := make([]*tagConflictResolver, 0, len(r.recipe.TagConflicts))
+	for _, conflict := range r.recipe.TagConflicts {
+		res = append(res, &tagConflictResolver{conflict})
+	}
+	return res
+}
+
+// Reviews are loaded in one batch for all the recipes of the request.
+func (r *recipeResolver) Reviews(ctx context.Context) ([]*reviewResolver, error) {
+	reviews, err := reviewLoader(ctx).Load(ctx, r.recipe.ID)()
+	if err != nil {
+		return nil, errors.New("Failed to access reviews")
+	}
+	resolvers := make([]*reviewResolver, 0, len(reviews))
+	for _, review := range reviews {
+		resolvers = append(resolvers, &reviewResolver{review})
+	}
+	return resolvers, nil
+}
+
+type reviewResolver struct {
+	review models.Review
+}
+
+func (r *reviewResolver) ID() graphql.ID {
+	return graphql.ID(r.review.ID.Hex())
+}
+
+func (r *reviewResolver) Username() string {
+	return r.review.Username
+}
+
+func (r *reviewResolver) Rating() int32 {
+	return int32(r.review.Rating)
+}
+
+func (r *reviewResolver) Comment() string {
+	return r.review.Comment
+}
+
+func (r *reviewResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.review.CreatedAt}
+}
+
+type tagConflictResolver struct {
+	conflict models.TagConflict
+}
+
+func (r *tagConflictResolver) Tag() string {
+	return r.conflict.Tag
+}
+
+func (r *tagConflictResolver) Contains() []string {
+	return listOrEmpty(&r.conflict.Contains)
+}
+
+func (r *tagConflictResolver) Ingredients() []string {
+	return listOrEmpty(&r.conflict.Ingredients)
+}
+
+type nutritionResolver struct {
+	nutrition models.Nutrition
+}
+
+func (r *nutritionResolver) Total() *nutritionFactsResolver {
+	return &nutritionFactsResolver{r.nutrition.Total}
+}
+
+func (r *nutritionResolver) PerServing() *nutritionFactsResolver {
+	return &nutritionFactsResolver{r.nutrition.PerServing}
+}
+
+func (r *nutritionResolver) Unmatched() []string {
+	return listOrEmpty(&r.nutrition.Unmatched)
+}
+
+type nutritionFactsResolver struct {
+	facts models.NutritionFacts
+}
+
+func (r *nutritionFactsResolver) Calories() float64 {
+	return r.facts.Calories
+}
+
+func (r *nutritionFactsResolver) Protein() float64 {
+	return r.facts.Protein
+}
+
+func (r *nutritionFactsResolver) Carbohydrates() float64 {
+	return r.facts.Carbohydrates
+}
+
+func (r *nutritionFactsResolver) Fat() float64 {
+	return r.facts.Fat
+}
+
+func (r *nutritionFactsResolver) Fiber() float64 {
+	return r.facts.Fiber
+}
+
+type recipeConnectionResolver struct {
+	edges   []*recipeEdgeResolver
+	total   int
+	hasNext bool
+}
+
+func (r *recipeConnectionResolver) Edges() []*recipeEdgeResolver {
+	return r.edges
+}
+
+func (r *recipeConnectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNext: r.hasNext}
+	if len(r.edges) > 0 {
+		cursor := r.edges[len(r.edges)-1].Cursor()
+		info.endCursor = &cursor
+	}
+	return info
+}
+
+func (r *recipeConnectionResolver) TotalCount() int32 {
+	return int32(r.total)
+}
+
+type recipeEdgeResolver struct {
+	recipe models.Recipe
+}
+
+func (r *recipeEdgeResolver) Cursor() string {
+	return encodeCursor(r.recipe.ID.Hex())
+}
+
+func (r *recipeEdgeResolver) Node() *recipeResolver {
+	return &recipeResolver{r.recipe}
+}
+
+type pageInfoResolver struct {
+	hasNext   bool
+	endCursor *string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNext
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
+
+type searchResultResolver struct {
+	hits   []*recipeResolver
+	facets []TagFacet
+}
+
+func (r *searchResultResolver) Hits() []*recipeResolver {
+	return r.hits
+}
+
+func (r *searchResultResolver) Facets() []*tagFacetResolver {
+	facets := make([]*tagFacetResolver, 0, len(r.facets))
+	for _, facet := range r.facets {
+		facets = append(facets, &tagFacetResolver{facet})
+	}
+	return facets
+}
+
+type tagFacetResolver struct {
+	facet TagFacet
+}
+
+func (r *tagFacetResolver) Tag() string {
+	return r.facet.Tag
+}
+
+func (r *tagFacetResolver) Count() int32 {
+	return int32(r.facet.Count)
+}
+
+func encodeCursor(recipeID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("recipe:" + recipeID))
+}
+
+func decodeCursor(cursor string) (string, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", false
+	}
+	return strings.CutPrefix(string(data), "recipe:")
+}
+
+func listOrEmpty(list *[]string) []string {
+	if list == nil || *list == nil {
+		return make([]string, 0)
+	}
+	return *list
+}
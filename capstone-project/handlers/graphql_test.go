@@ -0,0 +1,255 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"framework-api/handlers"
+	"framework-api/handlers/cachetest"
+	"framework-api/handlers/storetest"
+	"framework-api/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// newGraphQLEngine serves /graphql with recipes cached in miniredis, so no MongoDB is needed, and the reviews of
+// reviews, none when nil.
+func newGraphQLEngine(t *testing.T, recipes []models.Recipe, reviews handlers.ReviewStore) (*gin.Engine, *miniredis.Miniredis, *handlers.LocalIssuer) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	//Caching the recipes opens the connection, handshake commands don't count against the batching checks
+	mr, redisClient := cachetest.NewRedis(t, recipes)
+	recipeHandler := handlers.NewRecipesHandler(context.Background(), nil, redisClient, nil)
+	graphqlHandler, err := handlers.NewGraphQLHandler(recipeHandler, reviews)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing schema: %s", err)
+	}
+	issuer, err := handlers.NewHS256Issuer("recipes-internal", "", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("Unexpected error creating issuer: %s", err)
+	}
+	authenticator, _ := issuer.Authenticator(time.Minute)
+	engine := gin.New()
+	engine.POST("/graphql", handlers.NewAuthHandler(authenticator).OptionalAuthMiddleware(), graphqlHandler.Serve)
+	return engine, mr, issuer
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func doGraphQL(t *testing.T, engine *gin.Engine, token, query string, variables map[string]interface{}) (int, graphqlResponse) {
+	t.Helper()
+	body, _ := json.Marshal(handlers.GraphQLRequest{Query: query, Variables: variables})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	var res graphqlResponse
+	json.Unmarshal(w.Body.Bytes(), &res)
+	return w.Code, res
+}
+
+func TestGraphQLBatchesRecipeLookups(t *testing.T) {
+	recipes := handlers.SampleRecipes()
+	engine, mr, _ := newGraphQLEngine(t, recipes, nil)
+	before := mr.CommandCount()
+	query := `query($a: ID!, $b: ID!, $c: ID!) {
+		a: recipe(id: $a) { name }
+		b: recipe(id: $b) { name }
+		c: recipe(id: $c) { name tags }
+	}`
+	code, res := doGraphQL(t, engine, "", query, map[string]interface{}{
+		"a": recipes[0].ID.Hex(), "b": recipes[2].ID.Hex(), "c": recipes[0].ID.Hex(),
+	})
+	if code != http.StatusOK || len(res.Errors) > 0 {
+		t.Fatalf("Expected no errors, got status %d and %+v", code, res.Errors)
+	}
+	var data struct {
+		A, B, C struct {
+			Name string
+			Tags []string
+		}
+	}
+	json.Unmarshal(res.Data, &data)
+	if data.A.Name != "Tomato Soup" || data.B.Name != "Greek Salad" || data.C.Name != "Tomato Soup" {
+		t.Errorf("Unexpected data %s", res.Data)
+	}
+	//Three lookups of two distinct ids, one MGET
+	if n := mr.CommandCount() - before; n != 1 {
+		t.Errorf("Expected 1 redis command for the batched lookups, got %d", n)
+	}
+}
+
+func TestGraphQLBatchesReviews(t *testing.T) {
+	recipes := handlers.SampleRecipes()
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	reviews := storetest.NewReviewStore(
+		models.Review{ID: bson.NewObjectID(), RecipeID: recipes[0].ID, Username: "alice", Rating: 5, Comment: "Lovely", CreatedAt: at},
+		models.Review{ID: bson.NewObjectID(), RecipeID: recipes[0].ID, Username: "bob", Rating: 3, Comment: "Bland", CreatedAt: at},
+		models.Review{ID: bson.NewObjectID(), RecipeID: recipes[3].ID, Username: "carol", Rating: 4, Comment: "Hearty", CreatedAt: at},
+	)
+	engine, _, _ := newGraphQLEngine(t, recipes, reviews)
+	_, res := doGraphQL(t, engine, "", `{ recipes(first: 10) { edges { node { name reviews { username rating comment createdAt } } } } }`, nil)
+	if len(res.Errors) > 0 {
+		t.Fatalf("Unexpected errors %+v", res.Errors)
+	}
+	var data struct {
+		Recipes struct {
+			Edges []struct {
+				Node struct {
+					Name    string
+					Reviews []struct {
+						Username  string
+						Rating    int
+						Comment   string
+						CreatedAt time.Time
+					}
+				}
+			}
+		}
+	}
+	json.Unmarshal(res.Data, &data)
+	counts := make([]int, 0)
+	for _, edge := range data.Recipes.Edges {
+		counts = append(counts, len(edge.Node.Reviews))
+	}
+	if !slices.Equal(counts, []int{2, 0, 0, 1}) {
+		t.Fatalf("Expected 2, 0, 0 and 1 reviews, got %v in %s", counts, res.Data)
+	}
+	if got := data.Recipes.Edges[0].Node.Reviews[0]; got.Username != "alice" || got.Rating != 5 || got.Comment != "Lovely" || !got.CreatedAt.Equal(at) {
+		t.Errorf("Unexpected review %+v", got)
+	}
+	//Four recipes, one store call
+	if n := reviews.Calls(); n != 1 {
+		t.Errorf("Expected 1 review store call, got %d", n)
+	}
+}
+
+func TestGraphQLRejectsInvalidRecipeID(t *testing.T) {
+	engine, _, _ := newGraphQLEngine(t, handlers.SampleRecipes(), nil)
+	_, res := doGraphQL(t, engine, "", `{ recipe(id: "not-an-id") { name } }`, nil)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "BAD_USER_INPUT" {
+		t.Errorf("Expected BAD_USER_INPUT for an invalid id, got %+v", res.Errors)
+	}
+}
+
+func TestGraphQLRecipesPagination(t *testing.T) {
+	engine, _, _ := newGraphQLEngine(t, handlers.SampleRecipes(), nil)
+	query := `query($after: String) {
+		recipes(first: 2, after: $after, filter: {tags: ["soup"]}) {
+			totalCount
+			edges { node { name } }
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+	type page struct {
+		Recipes struct {
+			TotalCount int
+			Edges      []struct{ Node struct{ Name string } }
+			PageInfo   struct {
+				HasNextPage bool
+				EndCursor   *string
+			}
+		}
+	}
+	names := make([]string, 0)
+	var after interface{}
+	for i := 0; i < 3; i++ {
+		_, res := doGraphQL(t, engine, "", query, map[string]interface{}{"after": after})
+		if len(res.Errors) > 0 {
+			t.Fatalf("Unexpected errors %+v", res.Errors)
+		}
+		var data page
+		json.Unmarshal(res.Data, &data)
+		if data.Recipes.TotalCount != 3 {
+			t.Errorf("Expected totalCount 3, got %d", data.Recipes.TotalCount)
+		}
+		for _, edge := range data.Recipes.Edges {
+			names = append(names, edge.Node.Name)
+		}
+		if !data.Recipes.PageInfo.HasNextPage {
+			break
+		}
+		after = *data.Recipes.PageInfo.EndCursor
+	}
+	if exp := "Tomato Soup,Chicken Soup,Lentil Soup"; strings.Join(names, ",") != exp {
+		t.Errorf("Expected %s, got %s", exp, strings.Join(names, ","))
+	}
+}
+
+func TestGraphQLRecipesRejectsUnknownCursors(t *testing.T) {
+	recipes := handlers.SampleRecipes()
+	engine, _, _ := newGraphQLEngine(t, recipes, nil)
+	query := `query($after: String) { recipes(first: 2, after: $after, filter: {tags: ["soup"]}) { edges { node { name } } } }`
+	ts := []struct {
+		text  string
+		after string
+	}{
+		{text: "malformed cursor", after: "not a cursor"},
+		{text: "recipe that does not exist", after: handlers.EncodeCursor(bson.NewObjectID().Hex())},
+		{text: "recipe outside the filter", after: handlers.EncodeCursor(recipes[2].ID.Hex())},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		_, res := doGraphQL(t, engine, "", query, map[string]interface{}{"after": tc.after})
+		if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "BAD_USER_INPUT" {
+			t.Errorf("Expected BAD_USER_INPUT, got %+v", res.Errors)
+		}
+	}
+}
+
+func TestGraphQLMutationsRequirePermissions(t *testing.T) {
+	recipes := handlers.SampleRecipes()
+	engine, _, issuer := newGraphQLEngine(t, recipes, nil)
+	reader, _ := issuer.Issue("reader", nil, nil, time.Minute)
+	writer, _ := issuer.Issue("writer", []string{handlers.ScopeRecipesWrite}, nil, time.Minute)
+	create := `mutation { createRecipe(input: {name: "Pho"}) { id } }`
+	remove := `mutation($id: ID!) { deleteRecipe(id: $id) }`
+	ts := []struct {
+		text  string
+		token string
+		query string
+		code  string
+	}{
+		{text: "anonymous create", query: create, code: "UNAUTHENTICATED"},
+		{text: "create without scope", token: reader, query: create, code: "FORBIDDEN"},
+		{text: "delete with write scope only", token: writer, query: remove, code: "FORBIDDEN"},
+		{text: "empty patch", token: writer, query: `mutation($id: ID!) { updateRecipe(id: $id, input: {}) { id } }`, code: "BAD_USER_INPUT"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		_, res := doGraphQL(t, engine, tc.token, tc.query, map[string]interface{}{"id": recipes[0].ID.Hex()})
+		if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != tc.code {
+			t.Errorf("%s: expected error code %s, got %+v", tc.text, tc.code, res.Errors)
+		}
+	}
+
+	//Invalid credentials are rejected before the query runs
+	code, _ := doGraphQL(t, engine, "not-a-jwt", create, nil)
+	if code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for an invalid token, got %d", http.StatusUnauthorized, code)
+	}
+}
+
+func TestGraphQLSearchRequiresQuery(t *testing.T) {
+	engine, _, _ := newGraphQLEngine(t, handlers.SampleRecipes(), nil)
+	_, res := doGraphQL(t, engine, "", `{ search { hits { name } } }`, nil)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "BAD_USER_INPUT" {
+		t.Errorf("Expected BAD_USER_INPUT, got %+v", res.Errors)
+	}
+}
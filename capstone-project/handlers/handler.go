@@ -1,203 +1,129 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"framework-api/dietary"
+	"framework-api/i18n"
 	"framework-api/models"
+	"framework-api/nutrition"
 	"net/http"
+	"strconv"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/elastic/go-elasticsearch/v9"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
-	"go.uber.org/zap"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
 )
 
 type RecipeHandler struct {
-	collection    *mongo.Collection
+	store         RecipeStore
 	ctx           context.Context
 	redisClient   *redis.Client
 	elasticClient *elasticsearch.Client
+	publishers    []RecipeEventPublisher
+	nutrients     *nutrition.Table
+	classifier    *dietary.Classifier
+	locales       *i18n.Matcher
+	audit         *AuditLog
+	// indexes holds the Elasticsearch indexes set up by EnsureElasticIndex, see ensureTenantIndex
+	indexes sync.Map
 }
 
 //Constructor
 
-func NewRecipesHandler(ctx context.Context, collection *mongo.Collection, redisClient *redis.Client, elasticClient *elasticsearch.Client) *RecipeHandler {
+func NewRecipesHandler(ctx context.Context, store RecipeStore, redisClient *redis.Client, elasticClient *elasticsearch.Client) *RecipeHandler {
 	return &RecipeHandler{
-		collection:    collection,
+		store:         store,
 		ctx:           ctx,
 		redisClient:   redisClient,
 		elasticClient: elasticClient,
+		nutrients:     nutrition.Default(),
+		classifier:    dietary.Default(),
+		locales:       i18n.Default(),
 	}
 }
 
-func (h *RecipeHandler) HomePageHandler(c *gin.Context) {
-	c.HTML(http.StatusOK, "home.html", nil)
+// AddEventPublisher registers p to be notified of every recipe change.
+func (h *RecipeHandler) AddEventPublisher(p RecipeEventPublisher) {
+	h.publishers = append(h.publishers, p)
 }
 
-// Swagger Documentation
-// getRecipes godoc
-// @Summary Get All Recipes
-// @Description Gets all the recipes from the in-memory store
-// @Tags recipes
-// @Accept json
-// @Produce json
-// @Success 200 {array} main.Recipe
-// @Failure 400 {object} map[string]string "error"
-// @Router /recipes [get]
-func (h *RecipeHandler) GetRecipes(c *gin.Context) {
-	zap.L().Info("Fetching all recipes")
-	//Check Redis first
-	val, err := h.redisClient.Get(h.ctx, "recipes").Result()
-	//val is string returned by redis so this would need to be unmarshalled.
-
-	if err == redis.Nil {
-		zap.L().Info("Request sent to MongoDB")
-		cur, err := h.collection.Find(h.ctx, bson.M{})
-		if err != nil {
-			zap.L().Error("Failed to fetch recipes from redis", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes"})
-			return
-		}
-		defer cur.Close(h.ctx)
+// SetAuditLog records every recipe write in audit.
+func (h *RecipeHandler) SetAuditLog(audit *AuditLog) {
+	h.audit = audit
+}
 
-		//Hold Results
-		dbRecipes := make([]models.Recipe, 0)
-		for cur.Next(h.ctx) {
-			var recipe models.Recipe
-			if err := cur.Decode(&recipe); err != nil {
-				zap.L().Error("Failed to decode recipe", zap.Error(err))
-				continue
-			}
-			dbRecipes = append(dbRecipes, recipe)
-		}
-		if len(dbRecipes) == 0 {
-			zap.L().Warn("No recipes found")
-			c.JSON(http.StatusNotFound, gin.H{"error": "No recipes found"})
-			return
-		}
-		//update redis cache
-		data, _ := json.Marshal(dbRecipes)
-		zap.L().Info("Storing recipes in redis")
-		h.redisClient.Set(h.ctx, "recipes", string(data), 0)
-		c.JSON(http.StatusOK, dbRecipes)
-	} else if err != nil {
+// GetRecipes returns all recipes from MongoDB, cached in Redis under "recipes", in the Accept-Language locale.
+// Conditional requests for an unchanged catalog are answered with 304 from Redis (see notModified).
+func (h *RecipeHandler) GetRecipes(c *gin.Context) {
+	locale := h.requestLocale(c)
+	if h.notModified(c, catalogField, locale) {
+		return
+	}
+	zap.L().Info("Fetching all recipes", zap.String("locale", locale))
+	recipes, err := h.ListRecipes(c.Request.Context())
+	if err != nil {
 		zap.L().Error("Failed to fetch recipes", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes"})
 		return
-	} else {
-		zap.L().Info("Found recipes in redis")
-		recipes := make([]models.Recipe, 0)
-		json.Unmarshal([]byte(val), &recipes)
-		c.JSON(http.StatusOK, recipes)
 	}
+	if len(recipes) == 0 {
+		zap.L().Warn("No recipes found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "No recipes found"})
+		return
+	}
+	h.validate(c, catalogField, locale, catalogModified(recipes))
+	c.JSON(http.StatusOK, localizeAll(recipes, locale))
 }
 
-// Swagger Documentation
-// getRecipesById godoc
-// @Summary Get Recipe by ID
-// @Description Gets the recipe by ID from the in-memory store
-// @Tags recipes
-// @Accept json
-// @Produce json
-// @Param id path string true "Recipe ID"
-// @Success 200 {object} Recipe
-// @Failure 400 {object} map[string]string "error"
-// @Router /recipe/{id} [get]
+// GetRecipeById returns one recipe, cached in Redis under "recipe:<id>", in the Accept-Language locale.
+// Conditional requests for an unchanged recipe are answered with 304 from Redis.
 func (h *RecipeHandler) GetRecipeById(c *gin.Context) {
 	recipeId := c.Param("id")
-	zap.L().Info("Fetching recipe by id", zap.String("recipe_id", recipeId))
-
-	val, err := h.redisClient.Get(h.ctx, "recipe:"+recipeId).Result()
-	if err == nil {
-		zap.L().Info("Found recipe in redis", zap.String("recipe_id", recipeId))
-		var recipe models.Recipe
-		json.Unmarshal([]byte(val), &recipe)
-		c.JSON(http.StatusOK, recipe)
-		return
-	}
-	zap.L().Info("Recipe not found in redis, fetching from DB", zap.String("recipe_id", recipeId))
-	var recipe models.Recipe
-	objectId, err := bson.ObjectIDFromHex(recipeId)
-	if err != nil {
-		zap.L().Error("Failed to convert ID to ObjectID", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+	locale := h.requestLocale(c)
+	if h.notModified(c, recipeId, locale) {
 		return
 	}
-	err = h.collection.FindOne(h.ctx, bson.M{"_id": objectId}).Decode(&recipe)
+	zap.L().Info("Fetching recipe by id", zap.String("recipe_id", recipeId))
+	recipe, err := h.FindRecipe(c.Request.Context(), recipeId)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			zap.L().Warn("Recipe not found", zap.String("recipe_id", recipeId))
-			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
-		} else {
-			zap.L().Error("Failed to find recipe, database error", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find recipe"})
-		}
+		status, msg := recipeErrorStatus(err, "Failed to find recipe")
+		zap.L().Warn("Failed to find recipe", zap.String("recipe_id", recipeId), zap.Error(err))
+		c.JSON(status, gin.H{"error": msg})
 		return
 	}
-	//update redis cache
-	data, _ := json.Marshal(recipe)
-	zap.L().Info("Storing recipe in redis", zap.String("recipe_id", recipeId))
-	h.redisClient.Set(h.ctx, "recipe:"+recipeId, string(data), 0)
+	h.validate(c, recipeId, locale, recipe.LastModified())
+	recipe = localize(recipe, locale)
+	c.Header("Content-Language", recipe.Locale)
 	c.JSON(http.StatusOK, recipe)
 }
 
-// Swagger Documentation
-// insertRecipe godoc
-// @Summary Create a new recipe
-// @Description Adds a new recipe to the in-memory store
-// @Tags recipes
-// @Accept json
-// @Produce json
-// @Param recipe body Recipe true "Recipe Data"
-// @Success 201 {object} Recipe
-// @Failure 400 {object} map[string]string "error"
-// @Router /recipe [post]
+// InsertRecipe stores a new recipe and indexes it in Elasticsearch.
 func (h *RecipeHandler) InsertRecipe(c *gin.Context) {
-	var Recipe models.Recipe
-	if err := c.ShouldBindJSON(&Recipe); err != nil {
+	var recipe models.Recipe
+	if err := c.ShouldBindJSON(&recipe); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	Recipe.ID = bson.NewObjectID()
-	Recipe.PublishedAt = time.Now()
-	_, err := h.collection.InsertOne(h.ctx, Recipe)
+	recipe, err := h.CreateRecipe(c.Request.Context(), recipe)
 	if err != nil {
 		zap.L().Error("Failed to insert recipe", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert recipe"})
+		status, msg := recipeErrorStatus(err, "Failed to insert recipe")
+		c.JSON(status, gin.H{"error": msg})
 		return
 	}
-	//Invalidate cache
-	h.redisClient.Del(h.ctx, "recipes")
-	//Add recipe to elastic store
-	err = h.insertRecipeInElasticstore(Recipe)
-	if err != nil {
-		zap.L().Error("Failed to insert recipe in elastic store", zap.Error(err))
-	}
-	c.JSON(http.StatusCreated, Recipe)
+	c.JSON(http.StatusCreated, recipe)
 }
 
-// Swagger Documentation
-// updateRecipeById godoc
-// @Summary UPDATE Recipe by ID
-// @Description Update the recipe by ID from the in-memory store
-// @Tags recipes
-// @Accept json
-// @Produce json
-// @Param id path string true "Recipe ID"
-// @Success 200 {object} Recipe
-// @Failure 400 {object} map[string]string "error"
-// @Router /recipe/{id} [put]
+// UpdateRecipeById sets the given fields on a recipe (PATCH semantics).
 func (h *RecipeHandler) UpdateRecipeById(c *gin.Context) {
 	recipeId := c.Param("id")
-	objectId, err := bson.ObjectIDFromHex(recipeId)
-	if err != nil {
+	if _, err := bson.ObjectIDFromHex(recipeId); err != nil {
 		zap.L().Error("Failed to convert id", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
@@ -209,228 +135,108 @@ func (h *RecipeHandler) UpdateRecipeById(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	//recipe.PublishedAt = time.Now()
-	delete(updateData, "_id")
-	delete(updateData, "publishedAt")
-
-	updatedRecipe := bson.M{
-		"$set": updateData,
-	}
-
-	//Execute update
-	_, err = h.collection.UpdateOne(h.ctx, bson.M{"_id": objectId}, updatedRecipe)
-	if err != nil {
+	if _, err := h.UpdateRecipe(c.Request.Context(), recipeId, updateData); err != nil {
 		zap.L().Error("Failed to update recipe", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update the recipe"})
+		status, msg := recipeErrorStatus(err, "Failed to update the recipe")
+		c.JSON(status, gin.H{"error": msg})
 		return
 	}
 	msg := fmt.Sprintf("Recipe Successfully Updated %v", recipeId)
-	//After update invalidate cache
-	h.redisClient.Del(h.ctx, "recipe:"+recipeId)
-	h.redisClient.Del(h.ctx, "recipes") //Invalidate all recipes cache
-
-	//Update in Elastic store
-	var recipe models.Recipe
-	err = h.collection.FindOne(h.ctx, bson.M{"_id": objectId}).Decode(&recipe)
-	if err != nil {
-		zap.L().Error("Failed to find recipe in DB", zap.Error(err))
-	}
-	//Update recipe in elastic store
-	err = h.insertRecipeInElasticstore(recipe)
-	if err != nil {
-		zap.L().Error("Failed to insert recipe in elastic store", zap.Error(err))
-	}
 	c.JSON(http.StatusOK, gin.H{"message": msg})
 }
 
-// Swagger Documentation
-// deleteRecipeById godoc
-// @Summary Delete Recipe by ID from Recipes
-// @Description Delete the recipe by ID from the in-memory store
-// @Tags recipes
-// @Accept json
-// @Produce json
-// @Param id path string true "Recipe ID"
-// @Success 200 {object} Recipe
-// @Failure 400 {object} map[string]string "error"
-// @Router /recipe/{id} [delete]
+// DeleteRecipeById removes a recipe from MongoDB, Redis and Elasticsearch.
 func (h *RecipeHandler) DeleteRecipeById(c *gin.Context) {
 	recipeId := c.Param("id")
 	zap.L().Info("Deleting recipe", zap.String("recipe_id", recipeId))
-	objectId, err := bson.ObjectIDFromHex(recipeId)
-	if err != nil {
-		zap.L().Error("Failed to parse recipe id", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe not found"})
-		return
-	}
-	res, err := h.collection.DeleteOne(h.ctx, bson.M{"_id": objectId})
-	if err != nil {
-		zap.L().Error("Failed to delete recipe", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Recipe not found"})
-		return
-	}
-	if res.DeletedCount == 0 {
-		zap.L().Warn("Recipe not found to delete", zap.String("recipe_id", recipeId))
-		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+	if err := h.DeleteRecipe(c.Request.Context(), recipeId); err != nil {
+		switch err {
+		case ErrInvalidRecipeID:
+			zap.L().Error("Failed to parse recipe id", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe not found"})
+		case ErrRecipeNotFound:
+			zap.L().Warn("Recipe not found to delete", zap.String("recipe_id", recipeId))
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		default:
+			zap.L().Error("Failed to delete recipe", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Recipe not found"})
+		}
 		return
 	}
-	//After delete - invalidate cache
-	h.redisClient.Del(h.ctx, "recipe:"+recipeId)
-	h.redisClient.Del(h.ctx, "recipes")
-	//Delete recipe from elastic store
-	err = h.deleteRecipeInElasticStore(recipeId)
-	if err != nil {
-		zap.L().Error("Failed to delete recipe from elastic store", zap.Error(err))
-	}
 	c.JSON(http.StatusOK, gin.H{"message": "Recipe deleted successfully"})
 }
 
-// Search recipe in elasticsearch
-func (h *RecipeHandler) insertRecipeInElasticstore(recipe models.Recipe) error {
-	zap.L().Info("Inserting recipe in elastic store", zap.String("recipe_id", recipe.ID.Hex()))
-	data, err := json.Marshal(recipe)
-	if err != nil {
-		zap.L().Error("Failed to marshal recipe", zap.Error(err))
-		return err
-	}
-
-	res, err := h.elasticClient.Index(
-		"recipe",
-		bytes.NewReader(data),
-		h.elasticClient.Index.WithDocumentID(recipe.ID.Hex()),
-		h.elasticClient.Index.WithRefresh("true"),
-	)
-
-	if err != nil {
-		zap.L().Error("Failed to insert recipe in elastic", zap.Error(err))
-		return err
-	}
-	defer res.Body.Close()
-	if res.IsError() {
-		zap.L().Error("Failed to insert recipe in elastic", zap.String("response", res.String()))
-		return errors.New("Failed to insert recipe in elastic")
-	}
-	zap.L().Info("Recipe inserted in elastic", zap.String("recipe_id", recipe.ID.Hex()))
-	return nil
-
-}
-
-func (h *RecipeHandler) deleteRecipeInElasticStore(recpieId string) error {
-	zap.L().Info("Deleting recipe from elastic store", zap.String("recipe_id", recpieId))
-	res, err := h.elasticClient.Delete(
-		"recipe",
-		recpieId,
-		h.elasticClient.Delete.WithRefresh("true"),
-	)
-	if err != nil {
-		zap.L().Error("Failed to delete recipe from elastic store", zap.Error(err))
-		return errors.New("Failed to delete recipe from elastic store")
-	}
-	defer res.Body.Close()
-	if res.IsError() {
-		zap.L().Error("Failed to delete recipe in elastic", zap.String("response", res.String()))
-		return errors.New("Failed to delete recipe from elastic store")
-	}
-	zap.L().Info("Recipe deleted from elastic", zap.String("recipe_id", recpieId))
-	return nil
-
-}
-
+// SearchRecipeInElasticStore searches recipes by name/tags (q), exact tag (tag) and calories per serving (maxCalories).
 func (h *RecipeHandler) SearchRecipeInElasticStore(c *gin.Context) {
-	q := strings.TrimSpace(c.Query("q"))
-	tag := strings.TrimSpace(c.Query("tag"))
-	zap.L().Info("Searching recipes in elastic store", zap.String("q", q), zap.String("tag", tag))
-	if q == "" && tag == "" {
-		zap.L().Warn("Search query is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
-		return
-	}
-
-	should := make([]interface{}, 0)
-	filter := make([]interface{}, 0)
-	if q != "" {
-		should = append(should, map[string]interface{}{
-			"match": map[string]interface{}{
-				"name": map[string]interface{}{
-					"query":     q,
-					"fuzziness": "AUTO",
-				},
-			},
-		},
-			map[string]interface{}{
-				"match": map[string]interface{}{
-					"tags": q,
-				},
-			},
-		)
-	}
-
-	if tag != "" {
-		filter = append(filter, map[string]interface{}{
-			"term": map[string]interface{}{
-				"tags.keyword": tag,
-			},
-		})
-	}
-
-	boolQuery := map[string]interface{}{}
-	if len(should) > 0 {
-		boolQuery["should"] = should
-		boolQuery["minimum_should_match"] = 1
-	}
-	if len(filter) > 0 {
-		boolQuery["filter"] = filter
-	}
-	zap.S().Infof("Search recipe query in elastic store: %v", boolQuery)
-
-	searchBody := map[string]interface{}{
-		"_source": []string{"id", "name", "tags", "imageUrl"},
-		"query": map[string]interface{}{
-			"bool": boolQuery,
-		},
-	}
-
-	bodyBytes, err := json.Marshal(searchBody)
+	query, err := h.searchQuery(c)
+	zap.L().Info("Searching recipes in elastic store", zap.String("q", query.Q), zap.Strings("tags", query.Tags), zap.Float64("max_calories", query.MaxCalories))
 	if err != nil {
-		zap.L().Error("Failed to marshal search body", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search recipes"})
+		zap.L().Warn("Invalid search query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	res, err := h.elasticClient.Search(
-		h.elasticClient.Search.WithContext(h.ctx),
-		h.elasticClient.Search.WithIndex("recipe"),
-		h.elasticClient.Search.WithBody(bytes.NewReader(bodyBytes)),
-	)
+	results, _, err := h.SearchRecipes(c.Request.Context(), query)
 	if err != nil {
 		zap.L().Error("Failed to search recipes", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search recipes"})
 		return
 	}
-	defer res.Body.Close()
+	zap.L().Info("Found recipes", zap.Int("count", len(results)))
+	c.JSON(http.StatusOK, results)
+}
 
-	if res.IsError() {
-		zap.L().Error("Failed to search recipes in elastic", zap.String("response", res.String()))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search recipes"})
+// searchQuery reads q, tag and maxCalories of the search endpoints, at least one of them is required.
+func (h *RecipeHandler) searchQuery(c *gin.Context) (RecipeQuery, error) {
+	query := RecipeQuery{Q: strings.TrimSpace(c.Query("q")), Tags: make([]string, 0, 1), Locale: h.requestLocale(c)}
+	if tag := strings.TrimSpace(c.Query("tag")); tag != "" {
+		query.Tags = append(query.Tags, tag)
 	}
-
-	var searchResp struct {
-		Hits struct {
-			Hits []struct {
-				Source models.RecipeSearchResult `json:"_source"`
-			} `json:"hits"`
-		} `json:"hits"`
+	if val := c.Query("maxCalories"); val != "" {
+		maxCalories, err := strconv.ParseFloat(val, 64)
+		if err != nil || maxCalories <= 0 {
+			return query, errors.New("maxCalories must be a positive number")
+		}
+		query.MaxCalories = maxCalories
 	}
-	if err := json.NewDecoder(res.Body).Decode(&searchResp); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse search response"})
-		return
+	if val := c.Query("excludeAllergens"); val != "" {
+		allergens, err := h.ExcludedAllergens(strings.Split(val, ","))
+		if err != nil {
+			return query, err
+		}
+		query.ExcludeAllergens = allergens
+	}
+	if query.Q == "" && len(query.Tags) == 0 && query.MaxCalories == 0 && len(query.ExcludeAllergens) == 0 {
+		return query, errors.New("Search query is required")
 	}
+	return query, nil
+}
 
-	results := make([]models.RecipeSearchResult, 0, len(searchResp.Hits.Hits))
-	for _, hit := range searchResp.Hits.Hits {
-		results = append(results, hit.Source)
+// ExcludedAllergens trims and checks the allergens a search excludes against the rule table.
+func (h *RecipeHandler) ExcludedAllergens(allergens []string) ([]string, error) {
+	res := make([]string, 0, len(allergens))
+	for _, allergen := range allergens {
+		allergen = strings.ToLower(strings.TrimSpace(allergen))
+		if allergen == "" {
+			continue
+		}
+		if !h.classifier.IsAllergen(allergen) {
+			return nil, fmt.Errorf("Unknown allergen %q, expected one of %s", allergen, strings.Join(h.classifier.Allergens(), ", "))
+		}
+		res = append(res, allergen)
 	}
-	zap.L().Info("Found recipes", zap.Int("count", len(results)))
-	c.JSON(http.StatusOK, results)
+	return res, nil
+}
 
+// recipeErrorStatus maps the data access errors to a status code and message, fallback is used for storage failures.
+func recipeErrorStatus(err error, fallback string) (int, string) {
+	switch err {
+	case ErrInvalidRecipeID:
+		return http.StatusBadRequest, "Invalid ID"
+	case ErrRecipeNotFound:
+		return http.StatusNotFound, "Recipe not found"
+	case ErrUnsupportedLocale:
+		return http.StatusBadRequest, "Unsupported locale"
+	default:
+		return http.StatusInternalServerError, fallback
+	}
 }
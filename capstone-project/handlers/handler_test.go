@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"framework-api/models"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestSearchQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ts := []struct {
+		text        string
+		query       string
+		valid       bool
+		maxCalories float64
+		tags        int
+		allergens   int
+	}{
+		{text: "free text", query: "q=soup", valid: true},
+		{text: "tag only", query: "tag=vegan", valid: true, tags: 1},
+		{text: "calories only", query: "maxCalories=450.5", valid: true, maxCalories: 450.5},
+		{text: "all criteria", query: "q=soup&tag=vegan&maxCalories=300", valid: true, maxCalories: 300, tags: 1},
+		{text: "nothing", query: "q=%20", valid: false},
+		{text: "not a number", query: "q=soup&maxCalories=lots", valid: false},
+		{text: "zero calories", query: "q=soup&maxCalories=0", valid: false},
+		{text: "allergens only", query: "excludeAllergens=gluten,%20Dairy", valid: true, allergens: 2},
+		{text: "unknown allergen", query: "q=soup&excludeAllergens=gluten,kryptonite", valid: false},
+	}
+	h := NewRecipesHandler(context.Background(), nil, nil, nil)
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/recipes/search?"+tc.query, nil)
+		query, err := h.searchQuery(c)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: expected valid %v, got error %v", tc.text, tc.valid, err)
+			continue
+		}
+		if tc.valid && (query.MaxCalories != tc.maxCalories || len(query.Tags) != tc.tags || len(query.ExcludeAllergens) != tc.allergens) {
+			t.Errorf("%s: unexpected query %+v", tc.text, query)
+		}
+	}
+}
+
+func testRecipes() []models.Recipe {
+	return []models.Recipe{
+		{ID: bson.NewObjectID(), Name: "Tomato Soup", Tags: []string{"soup", "vegan"}, Ingredients: []string{"tomatoes", "onion"}},
+		{ID: bson.NewObjectID(), Name: "Chicken Soup", Tags: []string{"soup"}, Ingredients: []string{"chicken", "carrots"}},
+		{ID: bson.NewObjectID(), Name: "Greek Salad", Tags: []string{"salad", "vegetarian"}, Ingredients: []string{"feta", "tomatoes"}},
+		{ID: bson.NewObjectID(), Name: "Lentil Soup", Tags: []string{"soup", "vegan"}, Ingredients: []string{"lentils"}},
+	}
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"framework-api/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// APIVersionV2 is reported in the meta of every v2 response.
+const APIVersionV2 = "v2"
+
+// Pagination defaults of the v2 list endpoint.
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Envelope is the v2 response format: the payload in data, paging and version info in meta, related URLs in links.
+type Envelope[T any] struct {
+	Data  T     `json:"data"`
+	Meta  Meta  `json:"meta"`
+	Links Links `json:"links"`
+}
+
+type Meta struct {
+	APIVersion string `json:"apiVersion"`
+	Count      *int   `json:"count,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+	Limit      *int   `json:"limit,omitempty"`
+	Offset     *int   `json:"offset,omitempty"`
+}
+
+type Links struct {
+	Self       string `json:"self"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+	Collection string `json:"collection,omitempty"`
+}
+
+// Page is one window of a list, Next and Prev are nil at the ends.
+type Page struct {
+	Limit  int
+	Offset int
+	Total  int
+	Next   *int
+	Prev   *int
+}
+
+// Paginate returns the start and end index of the page of total items described by limit and offset.
+func Paginate(total, limit, offset int) (int, int, Page) {
+	page := Page{Limit: limit, Offset: offset, Total: total}
+	start := min(offset, total)
+	end := min(offset+limit, total)
+	if end < total {
+		next := end
+		page.Next = &next
+	}
+	if offset > 0 {
+		prev := max(offset-limit, 0)
+		page.Prev = &prev
+	}
+	return start, end, page
+}
+
+// ParsePageQuery reads limit and offset from the query string, limit defaults to DefaultPageLimit and is capped at MaxPageLimit.
+func ParsePageQuery(c *gin.Context) (int, int, bool) {
+	limit := DefaultPageLimit
+	offset := 0
+	if val := c.Query("limit"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		limit = min(n, MaxPageLimit)
+	}
+	if val := c.Query("offset"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		offset = n
+	}
+	return limit, offset, true
+}
+
+// GetRecipesV2 returns one page of recipes. Unlike v1 an empty catalog is an empty page, not a 404. Like v1,
+// conditional requests for an unchanged catalog are answered with 304.
+func (h *RecipeHandler) GetRecipesV2(c *gin.Context) {
+	limit, offset, ok := ParsePageQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer and offset a non negative integer"})
+		return
+	}
+	locale := h.requestLocale(c)
+	if h.notModified(c, catalogField, locale) {
+		return
+	}
+	recipes, err := h.ListRecipes(c.Request.Context())
+	if err != nil {
+		zap.L().Error("Failed to fetch recipes", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recipes"})
+		return
+	}
+	h.validate(c, catalogField, locale, catalogModified(recipes))
+	start, end, page := Paginate(len(recipes), limit, offset)
+	data := localizeAll(recipes[start:end], locale)
+	count := len(data)
+	links := Links{Self: pageURL(c, limit, offset)}
+	if page.Next != nil {
+		links.Next = pageURL(c, limit, *page.Next)
+	}
+	if page.Prev != nil {
+		links.Prev = pageURL(c, limit, *page.Prev)
+	}
+	c.JSON(http.StatusOK, Envelope[[]models.Recipe]{
+		Data:  data,
+		Meta:  Meta{APIVersion: APIVersionV2, Count: &count, Total: &page.Total, Limit: &page.Limit, Offset: &page.Offset},
+		Links: links,
+	})
+}
+
+// GetRecipeByIdV2 returns one recipe in the Accept-Language locale with a link back to the collection.
+func (h *RecipeHandler) GetRecipeByIdV2(c *gin.Context) {
+	locale := h.requestLocale(c)
+	if h.notModified(c, c.Param("id"), locale) {
+		return
+	}
+	recipe, err := h.FindRecipe(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status, msg := recipeErrorStatus(err, "Failed to find recipe")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	h.validate(c, c.Param("id"), locale, recipe.LastModified())
+	recipe = localize(recipe, locale)
+	c.Header("Content-Language", recipe.Locale)
+	c.JSON(http.StatusOK, h.recipeEnvelope(c, recipe))
+}
+
+// InsertRecipeV2 creates a recipe and points the Location header at it.
+func (h *RecipeHandler) InsertRecipeV2(c *gin.Context) {
+	var recipe models.Recipe
+	if err := c.ShouldBindJSON(&recipe); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	recipe, err := h.CreateRecipe(c.Request.Context(), recipe)
+	if err != nil {
+		zap.L().Error("Failed to insert recipe", zap.Error(err))
+		status, msg := recipeErrorStatus(err, "Failed to insert recipe")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	envelope := h.recipeEnvelope(c, recipe)
+	c.Header("Location", envelope.Links.Self)
+	c.JSON(http.StatusCreated, envelope)
+}
+
+// UpdateRecipeByIdV2 sets the given fields on a recipe and returns the updated recipe instead of a message.
+func (h *RecipeHandler) UpdateRecipeByIdV2(c *gin.Context) {
+	var updateData bson.M
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	recipe, err := h.UpdateRecipe(c.Request.Context(), c.Param("id"), updateData)
+	if err != nil {
+		zap.L().Error("Failed to update recipe", zap.Error(err))
+		status, msg := recipeErrorStatus(err, "Failed to update the recipe")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	c.JSON(http.StatusOK, h.recipeEnvelope(c, recipe))
+}
+
+// DeleteRecipeByIdV2 removes a recipe and answers 204 without a body.
+func (h *RecipeHandler) DeleteRecipeByIdV2(c *gin.Context) {
+	if err := h.DeleteRecipe(c.Request.Context(), c.Param("id")); err != nil {
+		zap.L().Error("Failed to delete recipe", zap.Error(err))
+		status, msg := recipeErrorStatus(err, "Failed to delete recipe")
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SearchRecipesV2 searches recipes by name/tags (q), exact tag (tag) and calories per serving (maxCalories).
+func (h *RecipeHandler) SearchRecipesV2(c *gin.Context) {
+	query, err := h.searchQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	results, _, err := h.SearchRecipes(c.Request.Context(), query)
+	if err != nil {
+		zap.L().Error("Failed to search recipes", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search recipes"})
+		return
+	}
+	count := len(results)
+	c.JSON(http.StatusOK, Envelope[[]models.RecipeSearchResult]{
+		Data:  results,
+		Meta:  Meta{APIVersion: APIVersionV2, Count: &count},
+		Links: Links{Self: c.Request.URL.RequestURI(), Collection: collectionPath(c)},
+	})
+}
+
+func (h *RecipeHandler) recipeEnvelope(c *gin.Context, recipe models.Recipe) Envelope[models.Recipe] {
+	return Envelope[models.Recipe]{
+		Data: recipe,
+		Meta: Meta{APIVersion: APIVersionV2},
+		Links: Links{
+			Self:       versionPrefix(c) + "/recipe/" + recipe.ID.Hex(),
+			Collection: collectionPath(c),
+		},
+	}
+}
+
+// versionPrefix is the part of the route path before /recipe, e.g. /api/v2.
+func versionPrefix(c *gin.Context) string {
+	path := c.FullPath()
+	if i := strings.Index(path, "/recipe"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func collectionPath(c *gin.Context) string {
+	return versionPrefix(c) + "/recipes"
+}
+
+func pageURL(c *gin.Context, limit, offset int) string {
+	query := url.Values{}
+	for key, vals := range c.Request.URL.Query() {
+		query[key] = vals
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	return c.Request.URL.Path + "?" + query.Encode()
+}
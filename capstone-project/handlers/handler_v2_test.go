@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	ts := []struct {
+		text   string
+		total  int
+		limit  int
+		offset int
+		start  int
+		end    int
+		next   int
+		prev   int
+	}{
+		{text: "first page", total: 45, limit: 20, offset: 0, start: 0, end: 20, next: 20, prev: -1},
+		{text: "middle page", total: 45, limit: 20, offset: 20, start: 20, end: 40, next: 40, prev: 0},
+		{text: "last page", total: 45, limit: 20, offset: 40, start: 40, end: 45, next: -1, prev: 20},
+		{text: "offset past the end", total: 5, limit: 20, offset: 30, start: 5, end: 5, next: -1, prev: 10},
+		{text: "unaligned offset", total: 45, limit: 20, offset: 5, start: 5, end: 25, next: 25, prev: 0},
+		{text: "empty catalog", total: 0, limit: 20, offset: 0, start: 0, end: 0, next: -1, prev: -1},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		start, end, page := Paginate(tc.total, tc.limit, tc.offset)
+		if start != tc.start || end != tc.end {
+			t.Errorf("%s: expected window [%d:%d], got [%d:%d]", tc.text, tc.start, tc.end, start, end)
+		}
+		if got := pageIndex(page.Next); got != tc.next {
+			t.Errorf("%s: expected next %d, got %d", tc.text, tc.next, got)
+		}
+		if got := pageIndex(page.Prev); got != tc.prev {
+			t.Errorf("%s: expected prev %d, got %d", tc.text, tc.prev, got)
+		}
+	}
+}
+
+func pageIndex(offset *int) int {
+	if offset == nil {
+		return -1
+	}
+	return *offset
+}
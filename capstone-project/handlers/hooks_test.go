@@ -0,0 +1,21 @@
+package handlers
+
+import "time"
+
+// Test helpers of the package, exported for the handlers_test tests, which use the stores of storetest.
+var (
+	AuditColumns  = auditColumns
+	EncodeCursor  = encodeCursor
+	PublicAddr    = publicAddr
+	SampleRecipes = testRecipes
+)
+
+// SetClock makes the dispatcher read the time from now, so tests decide when retries are due.
+func (d *WebhookDispatcher) SetClock(now func() time.Time) {
+	d.now = now
+}
+
+// SetClock makes the handler read the time from now, so tests decide which week is the current one.
+func (h *MealPlanHandler) SetClock(now func() time.Time) {
+	h.now = now
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"framework-api/i18n"
+	"framework-api/models"
+	"framework-api/schemaorg"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MaxImportPageBytes is the largest HTML page imported, uploaded or fetched, 2 MB.
+const MaxImportPageBytes = 2 << 20
+
+var (
+	// ErrHostNotAllowed is returned for import URLs whose host is not allowed.
+	ErrHostNotAllowed = errors.New("Host is not allowed")
+	// ErrPageTooLarge is returned for pages above MaxImportPageBytes.
+	ErrPageTooLarge = errors.New("Page is too large")
+	// ErrNotHTML is returned when the fetched page is not HTML.
+	ErrNotHTML = errors.New("Page is not HTML")
+	// ErrPageUnavailable is returned when the page host answers with another status than 200.
+	ErrPageUnavailable = errors.New("Page is not available")
+)
+
+// PageFetcher downloads the HTML page of a recipe import. It returns the page and its URL after redirects.
+type PageFetcher interface {
+	FetchPage(ctx context.Context, pageURL string) ([]byte, *url.URL, error)
+}
+
+// HTTPPageFetcher fetches pages from the allowed hosts only, with a timeout.
+type HTTPPageFetcher struct {
+	hosts  hostAllowlist
+	client *http.Client
+}
+
+// NewPageFetcher fetches from hosts, matched exactly, giving up after timeout.
+func NewPageFetcher(hosts []string, timeout time.Duration) *HTTPPageFetcher {
+	allowed := newHostAllowlist(hosts)
+	return &HTTPPageFetcher{hosts: allowed, client: allowed.client(timeout, ErrHostNotAllowed)}
+}
+
+// FetchPage returns the page, at most MaxImportPageBytes of text/html or application/xhtml+xml.
+func (f *HTTPPageFetcher) FetchPage(ctx context.Context, pageURL string) ([]byte, *url.URL, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !f.hosts.allows(u) {
+		return nil, nil, ErrHostNotAllowed
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%w, the site returned %s", ErrPageUnavailable, res.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, nil, ErrNotHTML
+	}
+	page, err := readLimited(res.Body, MaxImportPageBytes, ErrPageTooLarge)
+	return page, res.Request.URL, err
+}
+
+// ImportHandler previews recipes from the schema.org JSON-LD of web pages, they are saved with POST /recipe.
+type ImportHandler struct {
+	recipes *RecipeHandler
+	fetcher PageFetcher
+}
+
+// NewImportHandler previews recipes for recipes, fetching page URLs with fetcher.
+func NewImportHandler(recipes *RecipeHandler, fetcher PageFetcher) *ImportHandler {
+	return &ImportHandler{recipes: recipes, fetcher: fetcher}
+}
+
+// ImportRequest is the page to import, either its URL or its HTML.
+type ImportRequest struct {
+	URL  string `json:"url"`
+	HTML string `json:"html"`
+}
+
+// ImportPreview holds the recipes found in a page, as they would be stored, nothing is saved.
+type ImportPreview struct {
+	// Source is the page URL after redirects, empty for uploaded HTML.
+	Source  string          `json:"source,omitempty"`
+	Recipes []models.Recipe `json:"recipes"`
+	// Warnings point out what to check or complete before saving, such as missing ingredients.
+	Warnings []string `json:"warnings"`
+}
+
+// ImportRecipes previews the recipes of a page: a JSON {"url": ...} or {"html": ...}, a multipart "file" upload,
+// or a text/html body.
+func (h *ImportHandler) ImportRecipes(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportPageBytes+1<<20)
+	page, source, status, msg := h.page(c)
+	if status != 0 {
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	recipes, err := schemaorg.ExtractRecipes(bytes.NewReader(page), source)
+	if err != nil {
+		if errors.Is(err, schemaorg.ErrNoRecipe) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		zap.L().Warn("Failed to read imported page", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the page"})
+		return
+	}
+	preview := ImportPreview{Recipes: make([]models.Recipe, 0, len(recipes)), Warnings: make([]string, 0)}
+	if source != nil {
+		preview.Source = source.String()
+	}
+	for i, recipe := range recipes {
+		recipe, warnings := h.preview(recipe)
+		for _, warning := range warnings {
+			if len(recipes) > 1 {
+				warning = fmt.Sprintf("Recipe %d: %s", i+1, warning)
+			}
+			preview.Warnings = append(preview.Warnings, warning)
+		}
+		preview.Recipes = append(preview.Recipes, recipe)
+	}
+	zap.L().Info("Previewed recipe import", zap.String("source", preview.Source), zap.Int("recipes", len(preview.Recipes)))
+	c.JSON(http.StatusOK, preview)
+}
+
+// page reads the HTML of the request, fetching it for a URL. A non zero status reports a failure.
+func (h *ImportHandler) page(c *gin.Context) ([]byte, *url.URL, int, string) {
+	switch c.ContentType() {
+	case "application/json":
+		var req ImportRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, nil, http.StatusBadRequest, err.Error()
+		}
+		if (req.URL == "") == (req.HTML == "") {
+			return nil, nil, http.StatusBadRequest, "Either url or html is required"
+		}
+		if req.HTML != "" {
+			if len(req.HTML) > MaxImportPageBytes {
+				return nil, nil, http.StatusRequestEntityTooLarge, ErrPageTooLarge.Error()
+			}
+			return []byte(req.HTML), nil, 0, ""
+		}
+		page, source, err := h.fetcher.FetchPage(c.Request.Context(), req.URL)
+		if err != nil {
+			status, msg := fetchErrorStatus(err)
+			zap.L().Warn("Failed to fetch page to import", zap.String("url", req.URL), zap.Error(err))
+			return nil, nil, status, msg
+		}
+		return page, source, 0, ""
+	case "multipart/form-data":
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, "A file field with the HTML page is required"
+		}
+		f, err := header.Open()
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, err.Error()
+		}
+		defer f.Close()
+		page, err := readLimited(f, MaxImportPageBytes, ErrPageTooLarge)
+		if err != nil {
+			return nil, nil, http.StatusRequestEntityTooLarge, ErrPageTooLarge.Error()
+		}
+		return page, nil, 0, ""
+	case "text/html", "application/xhtml+xml":
+		page, err := readLimited(c.Request.Body, MaxImportPageBytes, ErrPageTooLarge)
+		if err != nil {
+			return nil, nil, http.StatusRequestEntityTooLarge, ErrPageTooLarge.Error()
+		}
+		return page, nil, 0, ""
+	default:
+		return nil, nil, http.StatusUnsupportedMediaType, "Send JSON with url or html, a multipart file or a text/html body"
+	}
+}
+
+// fetchErrorStatus maps the errors of fetching a page to a status code and message.
+func fetchErrorStatus(err error) (int, string) {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrHostNotAllowed):
+		return http.StatusBadRequest, "The host of the URL is not allowed for imports"
+	case errors.Is(err, ErrPageTooLarge):
+		return http.StatusRequestEntityTooLarge, ErrPageTooLarge.Error()
+	case errors.Is(err, ErrNotHTML):
+		return http.StatusUnprocessableEntity, ErrNotHTML.Error()
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusGatewayTimeout, "Timed out fetching the page"
+	case errors.Is(err, ErrPageUnavailable):
+		return http.StatusBadGateway, err.Error()
+	default:
+		return http.StatusBadGateway, "Failed to fetch the page"
+	}
+}
+
+// preview prepares an imported recipe as CreateRecipe would, resolving its locale to a supported one,
+// and returns what to check before saving.
+func (h *ImportHandler) preview(recipe models.Recipe) (models.Recipe, []string) {
+	var warnings []string
+	if recipe.Locale != "" {
+		language := recipe.Locale
+		recipe.Locale = ""
+		for _, locale := range i18n.Fallbacks(language) {
+			if slices.Contains(h.recipes.locales.Supported(), locale) {
+				recipe.Locale = locale
+				break
+			}
+		}
+		if recipe.Locale == "" {
+			warnings = append(warnings, fmt.Sprintf("Language %s is not supported, the recipe is imported as %s", language, i18n.DefaultLocale))
+		}
+	}
+	recipe, err := h.recipes.normalizeLocales(recipe)
+	if err != nil {
+		zap.L().Warn("Failed to normalize imported recipe locale", zap.Error(err))
+	}
+	recipe = h.recipes.derive(recipe)
+	if recipe.Name == "" {
+		warnings = append(warnings, "The recipe has no name")
+	}
+	if len(recipe.Ingredients) == 0 {
+		warnings = append(warnings, "The recipe has no ingredients")
+	}
+	if len(recipe.Instructions) == 0 {
+		warnings = append(warnings, "The recipe has no instructions")
+	}
+	if recipe.Nutrition != nil && len(recipe.Nutrition.Unmatched) > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d ingredients are not in the nutrient table, nutrition is a lower bound", len(recipe.Nutrition.Unmatched)))
+	}
+	for _, conflict := range recipe.TagConflicts {
+		warnings = append(warnings, fmt.Sprintf("Tag %s conflicts with the ingredients", conflict.Tag))
+	}
+	return recipe, warnings
+}
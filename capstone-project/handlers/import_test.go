@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	page, err := os.ReadFile("../schemaorg/testdata/" + name)
+	if err != nil {
+		t.Fatalf("Unexpected error reading fixture: %s", err)
+	}
+	return page
+}
+
+// newRecipeSite serves the schema.org fixtures like a recipe blog would.
+func newRecipeSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/palak-paneer/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(readFixture(t, "graph.html"))
+		case "/old/palak-paneer":
+			http.Redirect(w, r, "/palak-paneer/", http.StatusMovedPermanently)
+		case "/elsewhere":
+			http.Redirect(w, r, "http://localhost/palak-paneer/", http.StatusFound)
+		case "/api/recipe.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name": "Palak Paneer"}`))
+		case "/huge":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write(bytes.Repeat([]byte("<p>paneer</p>"), MaxImportPageBytes/10))
+		case "/slow":
+			select {
+			case <-time.After(5 * time.Second):
+			case <-r.Context().Done():
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(site.Close)
+	return site
+}
+
+func newImportEngine(t *testing.T) (*gin.Engine, *httptest.Server) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	site := newRecipeSite(t)
+	recipeHandler := NewRecipesHandler(context.Background(), nil, nil, nil)
+	imports := NewImportHandler(recipeHandler, NewPageFetcher([]string{"127.0.0.1"}, 500*time.Millisecond))
+	engine := gin.New()
+	engine.POST("/recipes/import-url", imports.ImportRecipes)
+	return engine, site
+}
+
+func postImport(engine *gin.Engine, contentType string, body []byte) (*httptest.ResponseRecorder, ImportPreview) {
+	req := httptest.NewRequest(http.MethodPost, "/recipes/import-url", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	var preview ImportPreview
+	json.Unmarshal(w.Body.Bytes(), &preview)
+	return w, preview
+}
+
+func TestImportRecipesFromURL(t *testing.T) {
+	engine, site := newImportEngine(t)
+	ts := []struct {
+		text   string
+		url    string
+		exp    int
+		source string
+	}{
+		{text: "recipe page", url: site.URL + "/palak-paneer/", exp: http.StatusOK, source: site.URL + "/palak-paneer/"},
+		{text: "redirect on the same host", url: site.URL + "/old/palak-paneer", exp: http.StatusOK, source: site.URL + "/palak-paneer/"},
+		{text: "host not allowed", url: "http://localhost/palak-paneer/", exp: http.StatusBadRequest},
+		{text: "redirect to a host not allowed", url: site.URL + "/elsewhere", exp: http.StatusBadRequest},
+		{text: "not http", url: "file:///etc/passwd", exp: http.StatusBadRequest},
+		{text: "not found", url: site.URL + "/missing", exp: http.StatusBadGateway},
+		{text: "not HTML", url: site.URL + "/api/recipe.json", exp: http.StatusUnprocessableEntity},
+		{text: "too large", url: site.URL + "/huge", exp: http.StatusRequestEntityTooLarge},
+		{text: "timeout", url: site.URL + "/slow", exp: http.StatusGatewayTimeout},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		body, _ := json.Marshal(ImportRequest{URL: tc.url})
+		w, preview := postImport(engine, "application/json", body)
+		if w.Code != tc.exp {
+			t.Errorf("Expected status %d, got %d: %s", tc.exp, w.Code, w.Body.String())
+			continue
+		}
+		if tc.exp != http.StatusOK {
+			continue
+		}
+		if preview.Source != tc.source || len(preview.Recipes) != 1 {
+			t.Errorf("Expected one recipe from %s, got %+v", tc.source, preview)
+			continue
+		}
+		recipe := preview.Recipes[0]
+		if recipe.Name != "Palak Paneer & Rice" || len(recipe.Ingredients) != 3 || len(recipe.Instructions) != 4 || recipe.Servings != 4 {
+			t.Errorf("Expected the mapped recipe, got %+v", recipe)
+		}
+		//Relative to the page, inLanguage en-US falls back to the supported en
+		if recipe.ImageURL != site.URL+"/images/palak-paneer.jpg" || recipe.Locale != "en" {
+			t.Errorf("Expected the resolved image and locale en, got %s and %s", recipe.ImageURL, recipe.Locale)
+		}
+		if recipe.Nutrition == nil || recipe.Allergens == nil {
+			t.Errorf("Expected nutrition and allergens derived like on save, got %+v", recipe)
+		}
+	}
+}
+
+func TestImportRecipesFromHTML(t *testing.T) {
+	engine, _ := newImportEngine(t)
+
+	var upload bytes.Buffer
+	form := multipart.NewWriter(&upload)
+	part, _ := form.CreateFormFile("file", "multiple.html")
+	part.Write(readFixture(t, "multiple.html"))
+	form.Close()
+	w, preview := postImport(engine, form.FormDataContentType(), upload.Bytes())
+	if w.Code != http.StatusOK || len(preview.Recipes) != 2 || preview.Source != "" {
+		t.Fatalf("Expected 2 recipes from the upload, got %d: %s", w.Code, w.Body.String())
+	}
+	if preview.Recipes[0].Locale != "fr" || preview.Recipes[1].Name != "Garlic Bread" {
+		t.Errorf("Expected Tomato Soup in fr and Garlic Bread, got %+v", preview.Recipes)
+	}
+	if !strings.Contains(strings.Join(preview.Warnings, "\n"), "Recipe 2: The recipe has no ingredients") {
+		t.Errorf("Expected a warning for the missing ingredients, got %v", preview.Warnings)
+	}
+
+	japanese := `<script type="application/ld+json">{"@type": "Recipe", "name": "Miso Soup", "inLanguage": "ja", "recipeIngredient": ["miso"], "recipeInstructions": "Stir"}</script>`
+	body, _ := json.Marshal(ImportRequest{HTML: japanese})
+	w, preview = postImport(engine, "application/json", body)
+	if w.Code != http.StatusOK || preview.Recipes[0].Locale != "en" || len(preview.Warnings) == 0 || !strings.HasPrefix(preview.Warnings[0], "Language ja is not supported") {
+		t.Errorf("Expected the recipe in en with a warning, got %d: %s", w.Code, w.Body.String())
+	}
+
+	ts := []struct {
+		text        string
+		contentType string
+		body        string
+		exp         int
+	}{
+		{text: "html body", contentType: "text/html; charset=utf-8", body: string(readFixture(t, "graph.html")), exp: http.StatusOK},
+		{text: "page without recipe", contentType: "text/html", body: string(readFixture(t, "none.html")), exp: http.StatusUnprocessableEntity},
+		{text: "both url and html", contentType: "application/json", body: `{"url": "http://127.0.0.1/", "html": "<p></p>"}`, exp: http.StatusBadRequest},
+		{text: "neither url nor html", contentType: "application/json", body: `{}`, exp: http.StatusBadRequest},
+		{text: "multipart without file", contentType: "multipart/form-data; boundary=x", body: "--x--\r\n", exp: http.StatusBadRequest},
+		{text: "unsupported content type", contentType: "text/plain", body: "Palak Paneer", exp: http.StatusUnsupportedMediaType},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if w, _ := postImport(engine, tc.contentType, []byte(tc.body)); w.Code != tc.exp {
+			t.Errorf("Expected status %d, got %d: %s", tc.exp, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"framework-api/i18n"
+	"framework-api/models"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// ErrUnsupportedLocale is returned for a recipe locale or translation the server does not serve.
+var ErrUnsupportedLocale = errors.New("unsupported locale")
+
+// SetLocales replaces the locales recipes can be stored and served in, i18n.DefaultSupported by default.
+func (h *RecipeHandler) SetLocales(m *i18n.Matcher) {
+	h.locales = m
+}
+
+// Locales returns the supported locales, i18n.DefaultLocale first.
+func (h *RecipeHandler) Locales() []string {
+	return h.locales.Supported()
+}
+
+// requestLocale negotiates the locale of a response from Accept-Language.
+func (h *RecipeHandler) requestLocale(c *gin.Context) string {
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	return h.locales.Match(c.GetHeader("Accept-Language"))
+}
+
+// localize returns the recipe with Name, Ingredients and Instructions in the closest translation to locale,
+// "pt-BR" falls back to "pt" and then to the recipe's own content. Locale is set to the locale served.
+func localize(recipe models.Recipe, locale string) models.Recipe {
+	recipe.Locale = ownLocale(recipe)
+	for _, candidate := range i18n.Fallbacks(locale) {
+		if candidate == recipe.Locale {
+			return recipe
+		}
+		translation, ok := recipe.Translations[candidate]
+		if !ok {
+			continue
+		}
+		if translation.Name != "" {
+			recipe.Name = translation.Name
+		}
+		if len(translation.Ingredients) > 0 {
+			recipe.Ingredients = translation.Ingredients
+		}
+		if len(translation.Instructions) > 0 {
+			recipe.Instructions = translation.Instructions
+		}
+		recipe.Locale = candidate
+		return recipe
+	}
+	return recipe
+}
+
+// ownLocale is the locale of the recipe's own content.
+func ownLocale(recipe models.Recipe) string {
+	if recipe.Locale == "" {
+		return i18n.DefaultLocale
+	}
+	return recipe.Locale
+}
+
+func localizeAll(recipes []models.Recipe, locale string) []models.Recipe {
+	res := make([]models.Recipe, len(recipes))
+	for i, recipe := range recipes {
+		res[i] = localize(recipe, locale)
+	}
+	return res
+}
+
+// supportedLocale normalizes locale and checks that it is served.
+func (h *RecipeHandler) supportedLocale(locale string) (string, error) {
+	normalized, err := i18n.Normalize(locale)
+	if err != nil {
+		return "", ErrUnsupportedLocale
+	}
+	if !slices.Contains(h.locales.Supported(), normalized) {
+		return "", ErrUnsupportedLocale
+	}
+	return normalized, nil
+}
+
+// normalizeLocales normalizes the locale and translation keys of a new recipe, a missing locale is i18n.DefaultLocale.
+func (h *RecipeHandler) normalizeLocales(recipe models.Recipe) (models.Recipe, error) {
+	if recipe.Locale == "" {
+		recipe.Locale = i18n.DefaultLocale
+	}
+	locale, err := h.supportedLocale(recipe.Locale)
+	if err != nil {
+		return recipe, err
+	}
+	recipe.Locale = locale
+	if len(recipe.Translations) == 0 {
+		recipe.Translations = nil
+		return recipe, nil
+	}
+	translations := make(map[string]models.RecipeTranslation, len(recipe.Translations))
+	for key, translation := range recipe.Translations {
+		locale, err := h.supportedLocale(key)
+		if err != nil {
+			return recipe, err
+		}
+		translations[locale] = translation
+	}
+	recipe.Translations = translations
+	return recipe, nil
+}
+
+// normalizeLocaleFields normalizes the locales of a PATCH, "translations" replaces all translations
+// and "translations.<locale>" sets one.
+func (h *RecipeHandler) normalizeLocaleFields(fields bson.M) error {
+	for key, val := range fields {
+		switch {
+		case key == "locale":
+			locale, ok := val.(string)
+			if !ok {
+				return ErrUnsupportedLocale
+			}
+			normalized, err := h.supportedLocale(locale)
+			if err != nil {
+				return err
+			}
+			fields[key] = normalized
+		case key == "translations":
+			translations, ok := val.(map[string]interface{})
+			if !ok {
+				return ErrUnsupportedLocale
+			}
+			normalized := bson.M{}
+			for locale, translation := range translations {
+				locale, err := h.supportedLocale(locale)
+				if err != nil {
+					return err
+				}
+				normalized[locale] = translation
+			}
+			fields[key] = normalized
+		case strings.HasPrefix(key, "translations."):
+			locale, err := h.supportedLocale(strings.TrimPrefix(key, "translations."))
+			if err != nil {
+				return err
+			}
+			delete(fields, key)
+			fields["translations."+locale] = val
+		}
+	}
+	return nil
+}
+
+// EnsureElasticIndex creates the recipe index of the tenant of ctx with a language analyzer for every supported locale.
+// The localized fields of a document are indexed under i18n.<locale>; an existing index gets the
+// mapping templates added, documents indexed before are analyzed on their next update.
+func (h *RecipeHandler) EnsureElasticIndex(ctx context.Context) error {
+	templates := make([]interface{}, 0, len(h.locales.Supported()))
+	for _, locale := range h.locales.Supported() {
+		templates = append(templates, map[string]interface{}{
+			"i18n_" + locale: map[string]interface{}{
+				"path_match":         "i18n." + locale + ".*",
+				"match_mapping_type": "string",
+				"mapping":            map[string]interface{}{"type": "text", "analyzer": i18n.Analyzer(locale)},
+			},
+		})
+	}
+	mappings := map[string]interface{}{"dynamic_templates": templates}
+
+	index := RecipeIndex(TenantFromContext(ctx))
+	res, err := h.elasticClient.Indices.Exists([]string{index}, h.elasticClient.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode == 404 {
+		body, _ := json.Marshal(map[string]interface{}{"mappings": mappings})
+		res, err = h.elasticClient.Indices.Create(index,
+			h.elasticClient.Indices.Create.WithContext(ctx),
+			h.elasticClient.Indices.Create.WithBody(bytes.NewReader(body)),
+		)
+	} else {
+		body, _ := json.Marshal(mappings)
+		res, err = h.elasticClient.Indices.PutMapping([]string{index}, bytes.NewReader(body),
+			h.elasticClient.Indices.PutMapping.WithContext(ctx),
+		)
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.New("Failed to set up recipe index: " + res.String())
+	}
+	h.indexes.Store(index, true)
+	zap.L().Info("Recipe index mapped", zap.String("index", index), zap.Strings("locales", h.locales.Supported()))
+	return nil
+}
+
+// elasticTranslation is the content of one locale in the indexed document.
+type elasticTranslation struct {
+	Name         string   `json:"name,omitempty"`
+	Ingredients  []string `json:"ingredients,omitempty"`
+	Instructions []string `json:"instructions,omitempty"`
+}
+
+// elasticTranslations indexes the recipe's own content and every translation by locale.
+func elasticTranslations(recipe models.Recipe) map[string]elasticTranslation {
+	res := make(map[string]elasticTranslation, len(recipe.Translations)+1)
+	for locale := range recipe.Translations {
+		localized := localize(recipe, locale)
+		res[locale] = elasticTranslation{Name: localized.Name, Ingredients: localized.Ingredients, Instructions: localized.Instructions}
+	}
+	res[ownLocale(recipe)] = elasticTranslation{Name: recipe.Name, Ingredients: recipe.Ingredients, Instructions: recipe.Instructions}
+	return res
+}
+
+// localizedFields are the per-locale fields a search or more_like_this query on locale should look at.
+func localizedFields(locale string, fields ...string) []string {
+	res := make([]string, 0)
+	for _, candidate := range i18n.Fallbacks(locale) {
+		for _, field := range fields {
+			res = append(res, "i18n."+candidate+"."+field)
+		}
+	}
+	return res
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"framework-api/handlers/cachetest"
+	"framework-api/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func translatedRecipe() models.Recipe {
+	return models.Recipe{
+		ID:           bson.NewObjectID(),
+		Name:         "Tomato Soup",
+		Ingredients:  []string{"4 tomatoes", "1 onion"},
+		Instructions: []string{"Simmer for 20 minutes"},
+		Tags:         []string{"soup"},
+		Translations: map[string]models.RecipeTranslation{
+			"fr": {Name: "Soupe de tomates", Ingredients: []string{"4 tomates", "1 oignon"}},
+			"pt": {Name: "Sopa de tomate", Ingredients: []string{"4 tomates", "1 cebola"}, Instructions: []string{"Cozinhe por 20 minutos"}},
+		},
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	ts := []struct {
+		text         string
+		locale       string
+		name         string
+		instructions string
+		served       string
+	}{
+		{text: "own locale", locale: "en", name: "Tomato Soup", instructions: "Simmer for 20 minutes", served: "en"},
+		{text: "partial translation keeps own instructions", locale: "fr", name: "Soupe de tomates", instructions: "Simmer for 20 minutes", served: "fr"},
+		{text: "region falls back to language", locale: "pt-BR", name: "Sopa de tomate", instructions: "Cozinhe por 20 minutos", served: "pt"},
+		{text: "no translation", locale: "de", name: "Tomato Soup", instructions: "Simmer for 20 minutes", served: "en"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		recipe := localize(translatedRecipe(), tc.locale)
+		if recipe.Name != tc.name || recipe.Instructions[0] != tc.instructions || recipe.Locale != tc.served {
+			t.Errorf("Expected %s / %s in %s, got %s / %s in %s", tc.name, tc.instructions, tc.served, recipe.Name, recipe.Instructions[0], recipe.Locale)
+		}
+	}
+}
+
+func TestNormalizeLocales(t *testing.T) {
+	h := NewRecipesHandler(context.Background(), nil, nil, nil)
+	recipe := translatedRecipe()
+	recipe.Translations["PT_br"] = models.RecipeTranslation{Name: "Sopa de tomate"}
+	recipe, err := h.normalizeLocales(recipe)
+	if err != nil {
+		t.Fatalf("Unexpected error normalizing locales: %s", err)
+	}
+	if _, ok := recipe.Translations["pt-BR"]; !ok || recipe.Locale != "en" {
+		t.Errorf("Expected a pt-BR translation of an en recipe, got %s %v", recipe.Locale, recipe.Translations)
+	}
+
+	ts := []struct {
+		text   string
+		fields bson.M
+		valid  bool
+		key    string
+	}{
+		{text: "locale", fields: bson.M{"locale": "FR"}, valid: true, key: "locale"},
+		{text: "one translation", fields: bson.M{"translations.pt_BR": map[string]interface{}{"name": "Sopa"}}, valid: true, key: "translations.pt-BR"},
+		{text: "all translations", fields: bson.M{"translations": map[string]interface{}{"de": map[string]interface{}{"name": "Tomatensuppe"}}}, valid: true, key: "translations"},
+		{text: "unsupported locale", fields: bson.M{"locale": "ja"}, valid: false},
+		{text: "invalid translation key", fields: bson.M{"translations.not a locale": bson.M{}}, valid: false},
+		{text: "locale not a string", fields: bson.M{"locale": 3}, valid: false},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		err := h.normalizeLocaleFields(tc.fields)
+		if (err == nil) != tc.valid {
+			t.Errorf("Expected valid %v, got error %v", tc.valid, err)
+			continue
+		}
+		if _, ok := tc.fields[tc.key]; tc.valid && !ok {
+			t.Errorf("Expected field %s, got %v", tc.key, tc.fields)
+		}
+	}
+}
+
+func TestGetRecipeNegotiatesLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recipe := translatedRecipe()
+	_, redisClient := cachetest.NewRedis(t, []models.Recipe{recipe})
+	h := NewRecipesHandler(context.Background(), nil, redisClient, nil)
+	engine := gin.New()
+	engine.GET("/recipe/:id", h.GetRecipeById)
+	engine.GET("/recipes", h.GetRecipes)
+
+	ts := []struct {
+		text           string
+		path           string
+		acceptLanguage string
+		name           string
+		locale         string
+	}{
+		{text: "no header", path: "/recipe/" + recipe.ID.Hex(), name: "Tomato Soup", locale: "en"},
+		{text: "regional preference", path: "/recipe/" + recipe.ID.Hex(), acceptLanguage: "fr-CA,fr;q=0.9,en;q=0.5", name: "Soupe de tomates", locale: "fr"},
+		{text: "supported without translation", path: "/recipe/" + recipe.ID.Hex(), acceptLanguage: "de", name: "Tomato Soup", locale: "en"},
+		{text: "list", path: "/recipes", acceptLanguage: "pt-BR", name: "Sopa de tomate"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.acceptLanguage != "" {
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if !slices.Contains(w.Header().Values("Vary"), "Accept-Language") {
+			t.Errorf("Expected Vary: Accept-Language, got %v", w.Header().Values("Vary"))
+		}
+		var got models.Recipe
+		if tc.path == "/recipes" {
+			var recipes []models.Recipe
+			json.Unmarshal(w.Body.Bytes(), &recipes)
+			got = recipes[0]
+		} else {
+			json.Unmarshal(w.Body.Bytes(), &got)
+			if lang := w.Header().Get("Content-Language"); lang != tc.locale {
+				t.Errorf("Expected Content-Language %s, got %s", tc.locale, lang)
+			}
+		}
+		if got.Name != tc.name {
+			t.Errorf("Expected %s, got %s", tc.name, got.Name)
+		}
+	}
+}
+
+func TestSimilarRecipesCachedPerLocale(t *testing.T) {
+	recipes := testRecipes()
+	recipes[2].Translations = map[string]models.RecipeTranslation{"fr": {Name: "Salade grecque"}}
+	engine, mr := newSimilarEngine(t, recipes, "http://127.0.0.1:1")
+
+	names := map[string]string{}
+	for _, locale := range []string{"en", "fr"} {
+		req := httptest.NewRequest(http.MethodGet, "/recipe/"+recipes[0].ID.Hex()+"/similar", nil)
+		req.Header.Set("Accept-Language", locale)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		var results []models.RecipeSearchResult
+		json.Unmarshal(w.Body.Bytes(), &results)
+		if len(results) == 0 {
+			t.Fatalf("Expected similar recipes in %s, got %s", locale, w.Body.String())
+		}
+		names[locale] = results[0].Name
+	}
+	if names["en"] != "Greek Salad" || names["fr"] != "Salade grecque" {
+		t.Errorf("Expected the translated name in fr only, got %v", names)
+	}
+	if fields, _ := mr.HKeys(similarLocalCacheKey); len(fields) != 2 {
+		t.Errorf("Expected one cache entry per locale, got %v", fields)
+	}
+}
@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"framework-api/ical"
+	"framework-api/models"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// WeekFormat is the format of MealPlan.WeekStart.
+const WeekFormat = "2006-01-02"
+
+// MaxMealSlots limits the slots of one plan, a few dishes for every meal of the week.
+const MaxMealSlots = 100
+
+// MealTimes are the wall clock start times of the meals in the iCalendar export, every meal lasts an hour.
+var MealTimes = map[string]time.Duration{
+	"breakfast": 8 * time.Hour,
+	"lunch":     12*time.Hour + 30*time.Minute,
+	"snack":     16 * time.Hour,
+	"dinner":    19 * time.Hour,
+}
+
+// MealPlanRequest creates or replaces a meal plan. The name defaults to "Week of <weekStart>".
+type MealPlanRequest struct {
+	Name      string            `json:"name"`
+	WeekStart string            `json:"weekStart" binding:"required"`
+	Slots     []MealSlotRequest `json:"slots"`
+}
+
+// MealSlotRequest plans a recipe for a meal, servings default to the servings of the recipe.
+type MealSlotRequest struct {
+	Day      string `json:"day" binding:"required"`
+	Meal     string `json:"meal" binding:"required"`
+	RecipeID string `json:"recipeId" binding:"required"`
+	Servings int    `json:"servings"`
+}
+
+// CopyMealPlanRequest copies the plan of the week before WeekStart, the current week when empty.
+type CopyMealPlanRequest struct {
+	WeekStart string `json:"weekStart"`
+}
+
+// MealPlanHandler serves the meal plans of the authenticated user. It is also a RecipeEventPublisher,
+// deleted recipes are flagged in the plans referencing them.
+type MealPlanHandler struct {
+	store   MealPlanStore
+	recipes *RecipeHandler
+	now     func() time.Time
+}
+
+func NewMealPlanHandler(store MealPlanStore, recipes *RecipeHandler) *MealPlanHandler {
+	return &MealPlanHandler{store: store, recipes: recipes, now: time.Now}
+}
+
+// CreateMealPlan stores a plan for a week without one.
+func (h *MealPlanHandler) CreateMealPlan(c *gin.Context) {
+	owner, ok := mealPlanOwner(c)
+	if !ok {
+		return
+	}
+	var req MealPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plan, status, err := h.planFromRequest(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	plan.ID = bson.NewObjectID()
+	plan.Owner = owner
+	plan.CreatedAt = h.now()
+	plan.UpdatedAt = plan.CreatedAt
+	if err := h.store.CreateMealPlan(c.Request.Context(), plan); err != nil {
+		mealPlanError(c, err)
+		return
+	}
+	zap.L().Info("Meal plan created", zap.String("meal_plan_id", plan.ID.Hex()), zap.String("user_id", owner), zap.String("week", plan.WeekStart))
+	c.JSON(http.StatusCreated, plan)
+}
+
+// ListMealPlans returns the plans of the user, latest week first. ?week= returns only the plan of that week.
+func (h *MealPlanHandler) ListMealPlans(c *gin.Context) {
+	owner, ok := mealPlanOwner(c)
+	if !ok {
+		return
+	}
+	if week := c.Query("week"); week != "" {
+		if _, err := ParseWeekStart(week); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		plan, err := h.store.GetMealPlanByWeek(c.Request.Context(), owner, week)
+		if errors.Is(err, ErrMealPlanNotFound) {
+			c.JSON(http.StatusOK, []models.MealPlan{})
+			return
+		}
+		if err != nil {
+			mealPlanError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, []models.MealPlan{plan})
+		return
+	}
+	plans, err := h.store.ListMealPlans(c.Request.Context(), owner)
+	if err != nil {
+		mealPlanError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+// GetMealPlan returns one plan of the user.
+func (h *MealPlanHandler) GetMealPlan(c *gin.Context) {
+	plan, ok := h.ownedPlan(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// UpdateMealPlan replaces the name, week and slots of a plan.
+func (h *MealPlanHandler) UpdateMealPlan(c *gin.Context) {
+	existing, ok := h.ownedPlan(c)
+	if !ok {
+		return
+	}
+	var req MealPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plan, status, err := h.planFromRequest(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	plan.ID = existing.ID
+	plan.Owner = existing.Owner
+	plan.CreatedAt = existing.CreatedAt
+	plan.UpdatedAt = h.now()
+	if err := h.store.ReplaceMealPlan(c.Request.Context(), plan); err != nil {
+		mealPlanError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// DeleteMealPlan removes a plan of the user.
+func (h *MealPlanHandler) DeleteMealPlan(c *gin.Context) {
+	owner, ok := mealPlanOwner(c)
+	if !ok {
+		return
+	}
+	id, ok := objectIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteMealPlan(c.Request.Context(), owner, id); err != nil {
+		mealPlanError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CopyLastWeek creates the plan of a week from the plan of the week before.
+// Slots of deleted recipes are left out of the copy.
+func (h *MealPlanHandler) CopyLastWeek(c *gin.Context) {
+	owner, ok := mealPlanOwner(c)
+	if !ok {
+		return
+	}
+	var req CopyMealPlanRequest
+	//The body is optional
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	week := StartOfWeek(h.now())
+	if req.WeekStart != "" {
+		var err error
+		if week, err = ParseWeekStart(req.WeekStart); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	lastWeek := week.AddDate(0, 0, -7).Format(WeekFormat)
+	source, err := h.store.GetMealPlanByWeek(c.Request.Context(), owner, lastWeek)
+	if errors.Is(err, ErrMealPlanNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No meal plan for the week of " + lastWeek})
+		return
+	}
+	if err != nil {
+		mealPlanError(c, err)
+		return
+	}
+	plan := models.MealPlan{
+		ID:        bson.NewObjectID(),
+		Owner:     owner,
+		Name:      source.Name,
+		WeekStart: week.Format(WeekFormat),
+		Slots:     make([]models.MealSlot, 0, len(source.Slots)),
+		CreatedAt: h.now(),
+	}
+	plan.UpdatedAt = plan.CreatedAt
+	if plan.Name == "Week of "+source.WeekStart {
+		plan.Name = "Week of " + plan.WeekStart
+	}
+	for _, slot := range source.Slots {
+		if !slot.RecipeDeleted {
+			plan.Slots = append(plan.Slots, slot)
+		}
+	}
+	if err := h.store.CreateMealPlan(c.Request.Context(), plan); err != nil {
+		mealPlanError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, plan)
+}
+
+// ExportMealPlanICS returns the plan as an iCalendar file with one event per slot.
+func (h *MealPlanHandler) ExportMealPlanICS(c *gin.Context) {
+	plan, ok := h.ownedPlan(c)
+	if !ok {
+		return
+	}
+	week, err := ParseWeekStart(plan.WeekStart)
+	if err != nil {
+		mealPlanError(c, err)
+		return
+	}
+	ids := make([]string, 0, len(plan.Slots))
+	for _, slot := range plan.Slots {
+		ids = append(ids, slot.RecipeID.Hex())
+	}
+	//Current names and ingredients, a recipe that can not be loaded keeps the name stored in the plan
+	recipes, errs := h.recipes.FindRecipes(c.Request.Context(), ids)
+	cal := ical.Calendar{ProdID: "-//framework-api//Meal planner//EN", Name: plan.Name, Events: make([]ical.Event, 0, len(plan.Slots))}
+	for i, slot := range plan.Slots {
+		day := week.AddDate(0, 0, slices.Index(models.Weekdays, slot.Day))
+		start := day.Add(MealTimes[slot.Meal])
+		event := ical.Event{
+			UID:     fmt.Sprintf("%s-%d@framework-api", plan.ID.Hex(), i),
+			Start:   start,
+			End:     start.Add(time.Hour),
+			Stamp:   plan.UpdatedAt,
+			Summary: mealTitle(slot.Meal) + ": " + slot.RecipeName,
+		}
+		switch {
+		case slot.RecipeDeleted:
+			event.Summary += " (recipe deleted)"
+		case errs[i] == nil:
+			event.Summary = mealTitle(slot.Meal) + ": " + recipes[i].Name
+			event.Description = slotDescription(slot, recipes[i])
+		default:
+			if !errors.Is(errs[i], ErrRecipeNotFound) {
+				zap.L().Warn("Failed to load recipe for meal plan export", zap.String("recipe_id", ids[i]), zap.Error(errs[i]))
+			}
+			event.Description = fmt.Sprintf("%d servings", slot.Servings)
+		}
+		cal.Events = append(cal.Events, event)
+	}
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="mealplan-%s.ics"`, plan.WeekStart))
+	c.Status(http.StatusOK)
+	if err := cal.Write(c.Writer); err != nil {
+		zap.L().Error("Failed to write meal plan calendar", zap.Error(err))
+	}
+}
+
+// Publish flags the slots of deleted recipes, the plans keep them so users see what to replace.
+func (h *MealPlanHandler) Publish(ctx context.Context, event RecipeEvent) error {
+	if event.Type != EventRecipeDeleted {
+		return nil
+	}
+	recipeID, err := bson.ObjectIDFromHex(event.RecipeID)
+	if err != nil {
+		return err
+	}
+	flagged, err := h.store.FlagDeletedRecipe(ctx, recipeID)
+	if err != nil {
+		return err
+	}
+	if flagged > 0 {
+		zap.L().Info("Flagged deleted recipe in meal plans", zap.String("recipe_id", event.RecipeID), zap.Int64("meal_plans", flagged))
+	}
+	return nil
+}
+
+// planFromRequest validates a request, the recipes must exist. The status is the one to answer with on error.
+func (h *MealPlanHandler) planFromRequest(ctx context.Context, req MealPlanRequest) (models.MealPlan, int, error) {
+	plan := models.MealPlan{Name: strings.TrimSpace(req.Name), WeekStart: req.WeekStart, Slots: make([]models.MealSlot, 0, len(req.Slots))}
+	if _, err := ParseWeekStart(req.WeekStart); err != nil {
+		return plan, http.StatusBadRequest, err
+	}
+	if plan.Name == "" {
+		plan.Name = "Week of " + plan.WeekStart
+	}
+	if len(req.Slots) > MaxMealSlots {
+		return plan, http.StatusBadRequest, fmt.Errorf("A meal plan has at most %d slots", MaxMealSlots)
+	}
+	ids := make([]string, 0, len(req.Slots))
+	for _, slot := range req.Slots {
+		if !slices.Contains(models.Weekdays, slot.Day) {
+			return plan, http.StatusBadRequest, fmt.Errorf("Unknown day %q, expected one of %s", slot.Day, strings.Join(models.Weekdays, ", "))
+		}
+		if !slices.Contains(models.Meals, slot.Meal) {
+			return plan, http.StatusBadRequest, fmt.Errorf("Unknown meal %q, expected one of %s", slot.Meal, strings.Join(models.Meals, ", "))
+		}
+		if slot.Servings < 0 {
+			return plan, http.StatusBadRequest, errors.New("servings must not be negative")
+		}
+		ids = append(ids, slot.RecipeID)
+	}
+	recipes, errs := h.recipes.FindRecipes(ctx, ids)
+	for i, slot := range req.Slots {
+		switch {
+		case errors.Is(errs[i], ErrInvalidRecipeID), errors.Is(errs[i], ErrRecipeNotFound):
+			return plan, http.StatusBadRequest, fmt.Errorf("Unknown recipe %q", slot.RecipeID)
+		case errs[i] != nil:
+			zap.L().Error("Failed to load recipe for meal plan", zap.String("recipe_id", slot.RecipeID), zap.Error(errs[i]))
+			return plan, http.StatusInternalServerError, errors.New("Failed to load recipes")
+		}
+		servings := slot.Servings
+		if servings == 0 {
+			servings = max(recipes[i].Servings, 1)
+		}
+		plan.Slots = append(plan.Slots, models.MealSlot{
+			Day:        slot.Day,
+			Meal:       slot.Meal,
+			RecipeID:   recipes[i].ID,
+			RecipeName: recipes[i].Name,
+			Servings:   servings,
+		})
+	}
+	//Calendar order, slots of the same meal keep the order they were given in
+	slices.SortStableFunc(plan.Slots, func(a, b models.MealSlot) int {
+		if d := slices.Index(models.Weekdays, a.Day) - slices.Index(models.Weekdays, b.Day); d != 0 {
+			return d
+		}
+		return slices.Index(models.Meals, a.Meal) - slices.Index(models.Meals, b.Meal)
+	})
+	return plan, 0, nil
+}
+
+func (h *MealPlanHandler) ownedPlan(c *gin.Context) (models.MealPlan, bool) {
+	owner, ok := mealPlanOwner(c)
+	if !ok {
+		return models.MealPlan{}, false
+	}
+	id, ok := objectIDParam(c, "id")
+	if !ok {
+		return models.MealPlan{}, false
+	}
+	plan, err := h.store.GetMealPlan(c.Request.Context(), owner, id)
+	if err != nil {
+		mealPlanError(c, err)
+		return plan, false
+	}
+	return plan, true
+}
+
+// ParseWeekStart parses a week start, it has to be a Monday.
+func ParseWeekStart(week string) (time.Time, error) {
+	day, err := time.Parse(WeekFormat, week)
+	if err != nil || day.Weekday() != time.Monday {
+		return day, fmt.Errorf("weekStart must be a Monday formatted as %s", WeekFormat)
+	}
+	return day, nil
+}
+
+// StartOfWeek returns the Monday of the week of t, at midnight UTC.
+func StartOfWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	//Weekday counts from Sunday
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+func mealPlanOwner(c *gin.Context) (string, bool) {
+	p, ok := GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return "", false
+	}
+	return p.Subject, true
+}
+
+func mealPlanError(c *gin.Context, err error) {
+	switch err {
+	case ErrMealPlanNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal plan not found"})
+	case ErrMealPlanExists:
+		c.JSON(http.StatusConflict, gin.H{"error": "A meal plan for this week exists already"})
+	default:
+		zap.L().Error("Meal plan store failure", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to access meal plans"})
+	}
+}
+
+func mealTitle(meal string) string {
+	return strings.ToUpper(meal[:1]) + meal[1:]
+}
+
+func slotDescription(slot models.MealSlot, recipe models.Recipe) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d servings", slot.Servings)
+	if recipe.Servings > 0 && recipe.Servings != slot.Servings {
+		fmt.Fprintf(&b, " (recipe makes %d)", recipe.Servings)
+	}
+	if len(recipe.Ingredients) > 0 {
+		b.WriteString("\n\nIngredients:")
+		for _, ingredient := range recipe.Ingredients {
+			b.WriteString("\n- " + ingredient)
+		}
+	}
+	return b.String()
+}
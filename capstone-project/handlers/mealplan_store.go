@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"framework-api/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrMealPlanNotFound = errors.New("meal plan not found")
+	ErrMealPlanExists   = errors.New("meal plan for this week exists")
+)
+
+// MealPlanStore persists meal plans. Plans belong to the tenant of the context they are created in, every lookup is
+// scoped to the owner and that tenant: plans of other users or made in another tenant are not found.
+type MealPlanStore interface {
+	// CreateMealPlan returns ErrMealPlanExists when the owner already has a plan for the week in the tenant.
+	CreateMealPlan(ctx context.Context, plan models.MealPlan) error
+	// ListMealPlans returns the plans of owner, latest week first.
+	ListMealPlans(ctx context.Context, owner string) ([]models.MealPlan, error)
+	GetMealPlan(ctx context.Context, owner string, id bson.ObjectID) (models.MealPlan, error)
+	GetMealPlanByWeek(ctx context.Context, owner, weekStart string) (models.MealPlan, error)
+	// ReplaceMealPlan stores plan over the plan with the same ID and owner.
+	ReplaceMealPlan(ctx context.Context, plan models.MealPlan) error
+	DeleteMealPlan(ctx context.Context, owner string, id bson.ObjectID) error
+	// FlagDeletedRecipe marks the slots of every plan of the tenant referencing the recipe and returns the number of
+	// plans changed.
+	FlagDeletedRecipe(ctx context.Context, recipeID bson.ObjectID) (int64, error)
+}
+
+// MongoMealPlanStore keeps meal plans in the mealPlans collection.
+type MongoMealPlanStore struct {
+	plans *mongo.Collection
+}
+
+func NewMongoMealPlanStore(db *mongo.Database) *MongoMealPlanStore {
+	return &MongoMealPlanStore{plans: db.Collection("mealPlans")}
+}
+
+func (s *MongoMealPlanStore) CreateMealPlan(ctx context.Context, plan models.MealPlan) error {
+	plan.Tenant = tenantField(ctx)
+	_, err := s.plans.InsertOne(ctx, plan)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrMealPlanExists
+	}
+	return err
+}
+
+func (s *MongoMealPlanStore) ListMealPlans(ctx context.Context, owner string) ([]models.MealPlan, error) {
+	return findAll[models.MealPlan](ctx, s.plans, ownedBy(ctx, owner), options.Find().SetSort(bson.D{{Key: "weekStart", Value: -1}}))
+}
+
+func (s *MongoMealPlanStore) GetMealPlan(ctx context.Context, owner string, id bson.ObjectID) (models.MealPlan, error) {
+	filter := ownedBy(ctx, owner)
+	filter["_id"] = id
+	return s.findOne(ctx, filter)
+}
+
+func (s *MongoMealPlanStore) GetMealPlanByWeek(ctx context.Context, owner, weekStart string) (models.MealPlan, error) {
+	filter := ownedBy(ctx, owner)
+	filter["weekStart"] = weekStart
+	return s.findOne(ctx, filter)
+}
+
+func (s *MongoMealPlanStore) findOne(ctx context.Context, filter bson.M) (models.MealPlan, error) {
+	var plan models.MealPlan
+	err := s.plans.FindOne(ctx, filter).Decode(&plan)
+	if err == mongo.ErrNoDocuments {
+		return plan, ErrMealPlanNotFound
+	}
+	return plan, err
+}
+
+func (s *MongoMealPlanStore) ReplaceMealPlan(ctx context.Context, plan models.MealPlan) error {
+	plan.Tenant = tenantField(ctx)
+	filter := ownedBy(ctx, plan.Owner)
+	filter["_id"] = plan.ID
+	res, err := s.plans.ReplaceOne(ctx, filter, plan)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrMealPlanExists
+	}
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrMealPlanNotFound
+	}
+	return nil
+}
+
+func (s *MongoMealPlanStore) DeleteMealPlan(ctx context.Context, owner string, id bson.ObjectID) error {
+	filter := ownedBy(ctx, owner)
+	filter["_id"] = id
+	res, err := s.plans.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrMealPlanNotFound
+	}
+	return nil
+}
+
+func (s *MongoMealPlanStore) FlagDeletedRecipe(ctx context.Context, recipeID bson.ObjectID) (int64, error) {
+	update := bson.M{"$set": bson.M{"slots.$[slot].recipeDeleted": true}}
+	opts := options.UpdateMany().SetArrayFilters([]interface{}{bson.M{"slot.recipeId": recipeID}})
+	filter := tenantFilter(ctx)
+	filter["slots.recipeId"] = recipeID
+	res, err := s.plans.UpdateMany(ctx, filter, update, opts)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// ownedBy filters the plans of owner in the tenant of ctx.
+func ownedBy(ctx context.Context, owner string) bson.M {
+	filter := tenantFilter(ctx)
+	filter["owner"] = owner
+	return filter
+}
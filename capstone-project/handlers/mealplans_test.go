@@ -0,0 +1,258 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"framework-api/handlers"
+	"framework-api/handlers/cachetest"
+	"framework-api/handlers/storetest"
+	"framework-api/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newMealPlanEngine serves /mealplans with recipes cached in miniredis, in the default tenant and in "bistro". The
+// X-Test-User header stands in for the auth middleware, requests without it are anonymous, and X-Tenant-ID for the
+// tenant middleware.
+func newMealPlanEngine(t *testing.T, store handlers.MealPlanStore, recipes []models.Recipe, now time.Time) (*gin.Engine, *handlers.MealPlanHandler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	mr, redisClient := cachetest.NewRedis(t, recipes)
+	//The same recipes are cached for bistro under its tenant keys
+	bistro := handlers.ContextWithTenant(context.Background(), "bistro")
+	for _, recipe := range recipes {
+		key := "recipe:" + recipe.ID.Hex()
+		data, _ := mr.Get(key)
+		mr.Set(handlers.TenantKey(bistro, key), data)
+	}
+	h := handlers.NewMealPlanHandler(store, handlers.NewRecipesHandler(context.Background(), nil, redisClient, nil))
+	h.SetClock(func() time.Time { return now })
+	engine := gin.New()
+	plans := engine.Group("/mealplans", func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			handlers.SetPrincipal(c, &handlers.Principal{Subject: user})
+		}
+		if tenant := c.GetHeader(handlers.TenantHeader); tenant != "" {
+			handlers.SetTenant(c, tenant)
+		}
+	})
+	plans.POST("", h.CreateMealPlan)
+	plans.GET("", h.ListMealPlans)
+	plans.POST("/copy-last-week", h.CopyLastWeek)
+	plans.GET("/:id", h.GetMealPlan)
+	plans.PUT("/:id", h.UpdateMealPlan)
+	plans.DELETE("/:id", h.DeleteMealPlan)
+	plans.GET("/:id/ics", h.ExportMealPlanICS)
+	return engine, h
+}
+
+func doMealPlanRequest(engine *gin.Engine, user, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func decodeMealPlan(t *testing.T, w *httptest.ResponseRecorder) models.MealPlan {
+	t.Helper()
+	var plan models.MealPlan
+	if err := json.Unmarshal(w.Body.Bytes(), &plan); err != nil {
+		t.Fatalf("Unexpected error decoding meal plan %s: %s", w.Body.String(), err)
+	}
+	return plan
+}
+
+func TestMealPlanLifecycle(t *testing.T) {
+	recipes := handlers.SampleRecipes()
+	recipes[0].Servings = 4
+	engine, _ := newMealPlanEngine(t, storetest.NewMealPlanStore(), recipes, time.Date(2026, 10, 21, 9, 0, 0, 0, time.UTC))
+	body := `{"weekStart": "2026-10-12", "slots": [
+		{"day": "tuesday", "meal": "dinner", "recipeId": "` + recipes[1].ID.Hex() + `", "servings": 2},
+		{"day": "monday", "meal": "dinner", "recipeId": "` + recipes[0].ID.Hex() + `"},
+		{"day": "monday", "meal": "breakfast", "recipeId": "` + recipes[2].ID.Hex() + `"}
+	]}`
+
+	w := doMealPlanRequest(engine, "alice", http.MethodPost, "/mealplans", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	plan := decodeMealPlan(t, w)
+	if plan.Name != "Week of 2026-10-12" || len(plan.Slots) != 3 {
+		t.Fatalf("Unexpected plan %+v", plan)
+	}
+	if plan.Slots[0].Meal != "breakfast" || plan.Slots[1].RecipeName != "Tomato Soup" || plan.Slots[2].Day != "tuesday" {
+		t.Errorf("Expected slots in calendar order, got %+v", plan.Slots)
+	}
+	if plan.Slots[1].Servings != 4 || plan.Slots[0].Servings != 1 || plan.Slots[2].Servings != 2 {
+		t.Errorf("Expected servings to default to the recipe's, got %+v", plan.Slots)
+	}
+
+	if w := doMealPlanRequest(engine, "alice", http.MethodPost, "/mealplans", body); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a second plan of the week, got %d", http.StatusConflict, w.Code)
+	}
+	if w := doMealPlanRequest(engine, "bob", http.MethodGet, "/mealplans/"+plan.ID.Hex(), ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for the plan of another user, got %d", http.StatusNotFound, w.Code)
+	}
+
+	//Without a week copy-last-week fills the current week, 2026-10-19
+	w = doMealPlanRequest(engine, "alice", http.MethodPost, "/mealplans/copy-last-week", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d copying last week, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	copied := decodeMealPlan(t, w)
+	if copied.WeekStart != "2026-10-19" || copied.Name != "Week of 2026-10-19" || len(copied.Slots) != 3 || copied.ID == plan.ID {
+		t.Errorf("Unexpected copy %+v", copied)
+	}
+	if w := doMealPlanRequest(engine, "alice", http.MethodPost, "/mealplans/copy-last-week", `{"weekStart": "2026-10-12"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d without a plan the week before, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = doMealPlanRequest(engine, "alice", http.MethodGet, "/mealplans/"+copied.ID.Hex()+"/ics", "")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("Expected an iCalendar file, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	ics := w.Body.String()
+	for _, exp := range []string{"BEGIN:VCALENDAR\r\n", "DTSTART:20261019T080000\r\n", "SUMMARY:Dinner: Tomato Soup\r\n", "DTSTART:20261020T190000\r\n", "SUMMARY:Dinner: Chicken Soup\r\n"} {
+		if !strings.Contains(ics, exp) {
+			t.Errorf("Expected %q in %q", exp, ics)
+		}
+	}
+	if strings.Count(ics, "BEGIN:VEVENT") != 3 {
+		t.Errorf("Expected 3 events, got %q", ics)
+	}
+
+	if w := doMealPlanRequest(engine, "alice", http.MethodDelete, "/mealplans/"+plan.ID.Hex(), ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d deleting, got %d", http.StatusNoContent, w.Code)
+	}
+	w = doMealPlanRequest(engine, "alice", http.MethodGet, "/mealplans", "")
+	var plans []models.MealPlan
+	json.Unmarshal(w.Body.Bytes(), &plans)
+	if len(plans) != 1 || plans[0].ID != copied.ID {
+		t.Errorf("Expected only the copied plan, got %+v", plans)
+	}
+}
+
+func TestMealPlanValidation(t *testing.T) {
+	recipes := handlers.SampleRecipes()
+	engine, _ := newMealPlanEngine(t, storetest.NewMealPlanStore(), recipes, time.Now())
+	id := recipes[0].ID.Hex()
+	ts := []struct {
+		text   string
+		user   string
+		body   string
+		status int
+	}{
+		{text: "anonymous", body: `{"weekStart": "2026-10-19"}`, status: http.StatusUnauthorized},
+		{text: "missing week", user: "alice", body: `{"slots": []}`, status: http.StatusBadRequest},
+		{text: "week not starting on a Monday", user: "alice", body: `{"weekStart": "2026-10-20"}`, status: http.StatusBadRequest},
+		{text: "unknown day", user: "alice", body: `{"weekStart": "2026-10-19", "slots": [{"day": "funday", "meal": "lunch", "recipeId": "` + id + `"}]}`, status: http.StatusBadRequest},
+		{text: "unknown meal", user: "alice", body: `{"weekStart": "2026-10-19", "slots": [{"day": "monday", "meal": "brunch", "recipeId": "` + id + `"}]}`, status: http.StatusBadRequest},
+		{text: "invalid recipe id", user: "alice", body: `{"weekStart": "2026-10-19", "slots": [{"day": "monday", "meal": "lunch", "recipeId": "nope"}]}`, status: http.StatusBadRequest},
+		{text: "negative servings", user: "alice", body: `{"weekStart": "2026-10-19", "slots": [{"day": "monday", "meal": "lunch", "recipeId": "` + id + `", "servings": -1}]}`, status: http.StatusBadRequest},
+		{text: "empty plan", user: "alice", body: `{"weekStart": "2026-10-19", "name": "Busy week"}`, status: http.StatusCreated},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		w := doMealPlanRequest(engine, tc.user, http.MethodPost, "/mealplans", tc.body)
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tc.text, tc.status, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestDeletedRecipesAreFlaggedInMealPlans(t *testing.T) {
+	recipes := handlers.SampleRecipes()
+	store := storetest.NewMealPlanStore()
+	engine, h := newMealPlanEngine(t, store, recipes, time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC))
+	body := `{"weekStart": "2026-10-12", "slots": [
+		{"day": "monday", "meal": "lunch", "recipeId": "` + recipes[0].ID.Hex() + `"},
+		{"day": "monday", "meal": "dinner", "recipeId": "` + recipes[1].ID.Hex() + `"}
+	]}`
+	plan := decodeMealPlan(t, doMealPlanRequest(engine, "alice", http.MethodPost, "/mealplans", body))
+
+	if err := h.Publish(context.Background(), handlers.RecipeEvent{Type: handlers.EventRecipeDeleted, RecipeID: recipes[1].ID.Hex()}); err != nil {
+		t.Fatalf("Unexpected error publishing: %s", err)
+	}
+	flagged := decodeMealPlan(t, doMealPlanRequest(engine, "alice", http.MethodGet, "/mealplans/"+plan.ID.Hex(), ""))
+	if flagged.Slots[0].RecipeDeleted || !flagged.Slots[1].RecipeDeleted || flagged.Slots[1].RecipeName != "Chicken Soup" {
+		t.Errorf("Expected only the dinner slot to be flagged, got %+v", flagged.Slots)
+	}
+
+	copied := decodeMealPlan(t, doMealPlanRequest(engine, "alice", http.MethodPost, "/mealplans/copy-last-week", ""))
+	if len(copied.Slots) != 1 || copied.Slots[0].RecipeID != recipes[0].ID {
+		t.Errorf("Expected the copy to leave out the deleted recipe, got %+v", copied.Slots)
+	}
+}
+
+func TestMealPlansArePerTenant(t *testing.T) {
+	recipes := handlers.SampleRecipes()
+	store := storetest.NewMealPlanStore()
+	engine, h := newMealPlanEngine(t, store, recipes, time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC))
+	body := `{"weekStart": "2026-10-12", "slots": [{"day": "monday", "meal": "lunch", "recipeId": "` + recipes[0].ID.Hex() + `"}]}`
+	inBistro := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", "alice")
+		req.Header.Set(handlers.TenantHeader, "bistro")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := doMealPlanRequest(engine, "alice", http.MethodPost, "/mealplans", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	plan := decodeMealPlan(t, w)
+	//The same user plans the same week in another tenant
+	w = inBistro(http.MethodPost, "/mealplans", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	bistroPlan := decodeMealPlan(t, w)
+
+	ts := []struct {
+		text   string
+		method string
+		path   string
+		exp    int
+	}{
+		{text: "get a plan of the default tenant", method: http.MethodGet, path: "/mealplans/" + plan.ID.Hex(), exp: http.StatusNotFound},
+		{text: "update a plan of the default tenant", method: http.MethodPut, path: "/mealplans/" + plan.ID.Hex(), exp: http.StatusNotFound},
+		{text: "delete a plan of the default tenant", method: http.MethodDelete, path: "/mealplans/" + plan.ID.Hex(), exp: http.StatusNotFound},
+		{text: "get the plan of the tenant", method: http.MethodGet, path: "/mealplans/" + bistroPlan.ID.Hex(), exp: http.StatusOK},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if w := inBistro(tc.method, tc.path, body); w.Code != tc.exp {
+			t.Errorf("Expected status %d, got %d: %s", tc.exp, w.Code, w.Body.String())
+		}
+	}
+
+	var list []models.MealPlan
+	if err := json.Unmarshal(inBistro(http.MethodGet, "/mealplans", "").Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].ID != bistroPlan.ID {
+		t.Errorf("Expected only the plan of the tenant, got %+v (%v)", list, err)
+	}
+
+	//Deleting a recipe in the tenant leaves the plans of the default tenant alone
+	if err := h.Publish(handlers.ContextWithTenant(context.Background(), "bistro"), handlers.RecipeEvent{Type: handlers.EventRecipeDeleted, RecipeID: recipes[0].ID.Hex()}); err != nil {
+		t.Fatalf("Unexpected error publishing: %s", err)
+	}
+	if got := decodeMealPlan(t, doMealPlanRequest(engine, "alice", http.MethodGet, "/mealplans/"+plan.ID.Hex(), "")); got.Slots[0].RecipeDeleted {
+		t.Errorf("Expected the plan of the default tenant not to be flagged, got %+v", got.Slots)
+	}
+	if got := decodeMealPlan(t, inBistro(http.MethodGet, "/mealplans/"+bistroPlan.ID.Hex(), "")); !got.Slots[0].RecipeDeleted {
+		t.Errorf("Expected the plan of the tenant to be flagged, got %+v", got.Slots)
+	}
+}
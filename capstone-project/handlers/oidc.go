@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// OIDCDiscoveryPath is appended to the issuer URL to find the provider metadata.
+const OIDCDiscoveryPath = "/.well-known/openid-configuration"
+
+// OIDCProviderMetadata holds the discovery fields used for token verification.
+type OIDCProviderMetadata struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// DiscoverOIDC fetches the provider metadata of issuerURL and checks that it describes the same issuer.
+func DiscoverOIDC(issuerURL string, timeout time.Duration) (*OIDCProviderMetadata, error) {
+	if timeout == 0 {
+		timeout = defaultJWKSRefreshTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	res, err := client.Get(strings.TrimRight(issuerURL, "/") + OIDCDiscoveryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch oidc discovery document: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery returned status %d", res.StatusCode)
+	}
+	var metadata OIDCProviderMetadata
+	if err := json.NewDecoder(res.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode oidc discovery document: %w", err)
+	}
+	if metadata.Issuer != issuerURL {
+		return nil, fmt.Errorf("oidc discovery issuer %q does not match %q", metadata.Issuer, issuerURL)
+	}
+	if metadata.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document has no jwks_uri")
+	}
+	return &metadata, nil
+}
+
+// NewOIDCAuthenticator verifies tokens of any OpenID Connect provider found via discovery.
+// jwksCfg.URL is ignored, the jwks_uri of the discovery document is used instead.
+func NewOIDCAuthenticator(issuerURL, audience string, jwksCfg JWKSConfig, clockSkew time.Duration) (*JWTAuthenticator, error) {
+	metadata, err := DiscoverOIDC(issuerURL, jwksCfg.RefreshTimeout)
+	if err != nil {
+		zap.L().Error("OIDC discovery failed", zap.String("issuer", issuerURL), zap.Error(err))
+		return nil, err
+	}
+	jwksCfg.URL = metadata.JWKSURI
+	keys, err := NewJWKSKeyProvider(jwksCfg)
+	if err != nil {
+		return nil, err
+	}
+	algorithms := metadata.IDTokenSigningAlgValuesSupported
+	if len(algorithms) == 0 {
+		algorithms = []string{jwt.SigningMethodRS256.Alg()}
+	}
+	//Never accept symmetric algorithms from a remote provider, the "key" would be public
+	algorithms = filterAsymmetricAlgorithms(algorithms)
+	return NewJWTAuthenticator(JWTConfig{
+		Issuer:     metadata.Issuer,
+		Audience:   audience,
+		Algorithms: algorithms,
+		Keys:       keys,
+		ClockSkew:  clockSkew,
+	})
+}
+
+func filterAsymmetricAlgorithms(algorithms []string) []string {
+	filtered := make([]string, 0, len(algorithms))
+	for _, alg := range algorithms {
+		if alg == "none" || strings.HasPrefix(alg, "HS") {
+			continue
+		}
+		filtered = append(filtered, alg)
+	}
+	return filtered
+}
@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"framework-api/models"
+	"framework-api/schemaorg"
+	"html/template"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Server rendered pages, readable by crawlers without the React app.
+const (
+	// PageSize is the number of recipes on one page of the recipe list.
+	PageSize = 24
+	// SitemapMaxURLs is the limit of one sitemap file, newer recipes are listed first.
+	SitemapMaxURLs = 50000
+	// DefaultRecipeImage is shown for recipes without an image.
+	DefaultRecipeImage = "static/images/recipe.jpg"
+	siteName           = "Recipe Platform"
+)
+
+// SitemapSource lists the recipes published in sitemap.xml.
+type SitemapSource interface {
+	SitemapRecipes(ctx context.Context) ([]models.Recipe, error)
+}
+
+// PageHandler renders the recipe list, recipe and search pages, sitemap.xml and robots.txt.
+// baseURL is the public origin used in canonical URLs, OpenGraph tags and the sitemap.
+type PageHandler struct {
+	recipes *RecipeHandler
+	sitemap SitemapSource
+	baseURL string
+}
+
+func NewPageHandler(recipes *RecipeHandler, baseURL string) *PageHandler {
+	return &PageHandler{recipes: recipes, sitemap: recipes, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// SitemapRecipes reads the fields the sitemap needs straight from MongoDB, so it never depends on the cache.
+func (h *RecipeHandler) SitemapRecipes(ctx context.Context) ([]models.Recipe, error) {
+	return h.store.LatestRecipes(ctx, SitemapMaxURLs)
+}
+
+// pageMeta is the <head> of a page, see templates/layout.html.
+type pageMeta struct {
+	Lang        string
+	Title       string
+	Description string
+	Canonical   string
+	Image       string
+	OGType      string
+	Robots      string
+	Prev        string
+	Next        string
+	Alternates  []pageAlternate
+	JSONLD      template.JS
+}
+
+// pageAlternate is the same page in another locale, Locale "x-default" is the page without ?lang.
+type pageAlternate struct {
+	Locale string
+	URL    string
+}
+
+type recipeCard struct {
+	Name     string
+	URL      string
+	Image    string
+	Tags     []string
+	Calories string
+}
+
+// Home renders one page of the recipe list, ?page= starts at 1.
+func (h *PageHandler) Home(c *gin.Context) {
+	locale := h.pageLocale(c)
+	page := 1
+	if val := c.Query("page"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			h.notFound(c, locale)
+			return
+		}
+		page = n
+	}
+	recipes, err := h.recipes.ListRecipes(c.Request.Context())
+	if err != nil {
+		h.serverError(c, locale, "Recipes are unavailable right now", err)
+		return
+	}
+	start, end, p := Paginate(len(recipes), PageSize, (page-1)*PageSize)
+	if page > 1 && start == end {
+		h.notFound(c, locale)
+		return
+	}
+	list := schemaorg.NewItemList()
+	cards := make([]recipeCard, 0, end-start)
+	for _, recipe := range localizeAll(recipes[start:end], locale) {
+		card := h.card(recipe.ID.Hex(), recipe.Name, recipe.ImageURL, recipe.Tags, recipe.Nutrition)
+		cards = append(cards, card)
+		list.Add(card.URL, card.Name)
+	}
+	meta := pageMeta{
+		Lang:        locale,
+		Title:       siteName,
+		Description: fmt.Sprintf("%d recipes with ingredients, instructions and nutrition facts.", p.Total),
+		Canonical:   h.pageURL("/", page),
+		Image:       h.imageURL(""),
+		OGType:      "website",
+		Robots:      "index, follow",
+		JSONLD:      h.jsonLD(list),
+	}
+	if page > 1 {
+		meta.Title = fmt.Sprintf("%s - page %d", siteName, page)
+	}
+	if p.Prev != nil {
+		meta.Prev = h.pageURL("/", page-1)
+	}
+	if p.Next != nil {
+		meta.Next = h.pageURL("/", page+1)
+	}
+	c.HTML(http.StatusOK, "recipes.html", gin.H{"Meta": meta, "Recipes": cards, "Page": page, "Prev": meta.Prev, "Next": meta.Next})
+}
+
+// RecipePage renders a recipe at its canonical /r/:id/:slug URL, other slugs are redirected there.
+func (h *PageHandler) RecipePage(c *gin.Context) {
+	locale := h.pageLocale(c)
+	recipe, err := h.recipes.FindRecipe(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, ErrInvalidRecipeID) || errors.Is(err, ErrRecipeNotFound) {
+		h.notFound(c, locale)
+		return
+	}
+	if err != nil {
+		h.serverError(c, locale, "This recipe is unavailable right now", err)
+		return
+	}
+	path := RecipePagePath(recipe.ID.Hex(), recipe.Name)
+	if c.Request.URL.Path != path {
+		target := path
+		if c.Request.URL.RawQuery != "" {
+			target += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, target)
+		return
+	}
+	own := ownLocale(recipe)
+	localized := localize(recipe, locale)
+	image := h.imageURL(recipe.ImageURL)
+	canonical := h.localeURL(path, localized.Locale, own)
+	meta := pageMeta{
+		Lang:        localized.Locale,
+		Title:       localized.Name + " - " + siteName,
+		Description: recipeDescription(localized),
+		Canonical:   canonical,
+		Image:       image,
+		OGType:      "article",
+		Robots:      "index, follow",
+		JSONLD:      h.jsonLD(schemaorg.FromRecipe(localized, canonical, image)),
+		Alternates:  []pageAlternate{{Locale: own, URL: h.baseURL + path}},
+	}
+	for _, translation := range slices.Sorted(maps.Keys(recipe.Translations)) {
+		meta.Alternates = append(meta.Alternates, pageAlternate{Locale: translation, URL: h.localeURL(path, translation, own)})
+	}
+	if len(meta.Alternates) > 1 {
+		meta.Alternates = append(meta.Alternates, pageAlternate{Locale: "x-default", URL: h.baseURL + path})
+	} else {
+		meta.Alternates = nil
+	}
+	c.Header("Content-Language", localized.Locale)
+	c.HTML(http.StatusOK, "recipe.html", gin.H{"Meta": meta, "Recipe": localized, "Image": image, "Nutrition": localized.Nutrition})
+}
+
+// SearchPage renders the results of ?q=. Result pages are not indexed, the recipes they link to are.
+func (h *PageHandler) SearchPage(c *gin.Context) {
+	locale := h.pageLocale(c)
+	q := strings.TrimSpace(c.Query("q"))
+	meta := pageMeta{
+		Lang:        locale,
+		Title:       "Search - " + siteName,
+		Description: "Search recipes by name, ingredient or tag.",
+		Canonical:   h.baseURL + "/search",
+		Image:       h.imageURL(""),
+		OGType:      "website",
+		Robots:      "noindex, follow",
+	}
+	if q == "" {
+		c.HTML(http.StatusOK, "search.html", gin.H{"Meta": meta})
+		return
+	}
+	meta.Title = q + " - Search - " + siteName
+	meta.Canonical += "?q=" + url.QueryEscape(q)
+	results, _, err := h.recipes.SearchRecipes(c.Request.Context(), RecipeQuery{Q: q, Locale: locale})
+	if err != nil {
+		zap.L().Error("Failed to search recipes", zap.String("q", q), zap.Error(err))
+		c.HTML(http.StatusServiceUnavailable, "search.html", gin.H{"Meta": meta, "Query": q, "Error": "Search is unavailable right now, please try again later."})
+		return
+	}
+	list := schemaorg.NewItemList()
+	cards := make([]recipeCard, 0, len(results))
+	for _, result := range results {
+		var nutrition *models.Nutrition
+		if result.CaloriesPerServing != nil {
+			nutrition = &models.Nutrition{PerServing: models.NutritionFacts{Calories: *result.CaloriesPerServing}}
+		}
+		card := h.card(result.ID, result.Name, result.ImageURL, result.Tags, nutrition)
+		cards = append(cards, card)
+		list.Add(card.URL, card.Name)
+	}
+	meta.JSONLD = h.jsonLD(list)
+	c.HTML(http.StatusOK, "search.html", gin.H{"Meta": meta, "Query": q, "Recipes": cards})
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	XHTML   string       `xml:"xmlns:xhtml,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string             `xml:"loc"`
+	LastMod    string             `xml:"lastmod,omitempty"`
+	Alternates []sitemapXHTMLLink `xml:"xhtml:link"`
+}
+
+type sitemapXHTMLLink struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// Sitemap lists the home page and every recipe page, translated recipes with their hreflang alternates.
+func (h *PageHandler) Sitemap(c *gin.Context) {
+	recipes, err := h.sitemap.SitemapRecipes(c.Request.Context())
+	if err != nil {
+		zap.L().Error("Failed to read recipes for the sitemap", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to generate sitemap")
+		return
+	}
+	set := sitemapURLSet{
+		XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		XHTML: "http://www.w3.org/1999/xhtml",
+		URLs:  []sitemapURL{{Loc: h.baseURL + "/"}},
+	}
+	for _, recipe := range recipes {
+		path := RecipePagePath(recipe.ID.Hex(), recipe.Name)
+		entry := sitemapURL{Loc: h.baseURL + path}
+		if !recipe.PublishedAt.IsZero() {
+			entry.LastMod = recipe.PublishedAt.UTC().Format("2006-01-02")
+		}
+		if len(recipe.Translations) > 0 {
+			own := ownLocale(recipe)
+			entry.Alternates = append(entry.Alternates, sitemapXHTMLLink{Rel: "alternate", Hreflang: own, Href: entry.Loc})
+			for _, translation := range slices.Sorted(maps.Keys(recipe.Translations)) {
+				entry.Alternates = append(entry.Alternates, sitemapXHTMLLink{Rel: "alternate", Hreflang: translation, Href: h.localeURL(path, translation, own)})
+			}
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+	data, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to generate sitemap")
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), data...))
+}
+
+// Robots points crawlers at the sitemap and keeps them out of the API.
+func (h *PageHandler) Robots(c *gin.Context) {
+	c.String(http.StatusOK, "User-agent: *\nDisallow: /api/\nDisallow: /admin/\nDisallow: /mealplans\nAllow: /\n\nSitemap: %s/sitemap.xml\n", h.baseURL)
+}
+
+// RecipePagePath is the canonical path of a recipe page.
+func RecipePagePath(id, name string) string {
+	return "/r/" + id + "/" + Slug(name)
+}
+
+// Slug lowercases name and joins its ASCII letters and digits with dashes, "Palak Paneer (Spinach)" becomes
+// "palak-paneer-spinach". Names without any, such as Hindi ones, get "recipe"; the ID identifies the page anyway.
+func Slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if b.Len() == 0 {
+		return "recipe"
+	}
+	return b.String()
+}
+
+// pageLocale is ?lang= when it is supported, so every translation has a crawlable URL, else Accept-Language.
+func (h *PageHandler) pageLocale(c *gin.Context) string {
+	if lang := c.Query("lang"); lang != "" {
+		if locale, err := h.recipes.supportedLocale(lang); err == nil {
+			return locale
+		}
+	}
+	return h.recipes.requestLocale(c)
+}
+
+// localeURL is the URL of path in locale, the recipe's own locale needs no ?lang.
+func (h *PageHandler) localeURL(path, locale, own string) string {
+	if locale == own {
+		return h.baseURL + path
+	}
+	return h.baseURL + path + "?lang=" + url.QueryEscape(locale)
+}
+
+func (h *PageHandler) pageURL(path string, page int) string {
+	if page <= 1 {
+		return h.baseURL + path
+	}
+	return h.baseURL + path + "?page=" + strconv.Itoa(page)
+}
+
+// imageURL makes the stored image path absolute, as OpenGraph and JSON-LD require.
+func (h *PageHandler) imageURL(image string) string {
+	if image == "" {
+		image = DefaultRecipeImage
+	}
+	return absoluteURL(h.baseURL, image)
+}
+
+// absoluteURL resolves a stored path such as "static/images/pasta.jpg" against baseURL, URLs are kept.
+func absoluteURL(baseURL, link string) string {
+	if strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://") {
+		return link
+	}
+	return baseURL + "/" + strings.TrimPrefix(link, "/")
+}
+
+func (h *PageHandler) card(id, name, image string, tags []string, nutrition *models.Nutrition) recipeCard {
+	card := recipeCard{Name: name, URL: h.baseURL + RecipePagePath(id, name), Image: h.imageURL(image), Tags: tags}
+	if nutrition != nil {
+		card.Calories = fmt.Sprintf("%.0f kcal per serving", nutrition.PerServing.Calories)
+	}
+	return card
+}
+
+func (h *PageHandler) jsonLD(doc interface{}) template.JS {
+	script, err := schemaorg.Script(doc)
+	if err != nil {
+		zap.L().Error("Failed to marshal JSON-LD", zap.Error(err))
+	}
+	return script
+}
+
+func (h *PageHandler) notFound(c *gin.Context, locale string) {
+	meta := pageMeta{Lang: locale, Title: "Not found - " + siteName, Robots: "noindex"}
+	c.HTML(http.StatusNotFound, "error.html", gin.H{"Meta": meta, "Message": "This page does not exist."})
+}
+
+func (h *PageHandler) serverError(c *gin.Context, locale, message string, err error) {
+	zap.L().Error(message, zap.String("path", c.Request.URL.Path), zap.Error(err))
+	meta := pageMeta{Lang: locale, Title: siteName, Robots: "noindex"}
+	c.HTML(http.StatusInternalServerError, "error.html", gin.H{"Meta": meta, "Message": message})
+}
+
+// recipeDescription is the meta description, the ingredients cut at about 155 characters.
+func recipeDescription(recipe models.Recipe) string {
+	text := strings.Join(recipe.Ingredients, ", ")
+	if text == "" {
+		text = strings.Join(recipe.Tags, ", ")
+	}
+	if text == "" {
+		return recipe.Name
+	}
+	runes := []rune(recipe.Name + ": " + text)
+	if len(runes) > 155 {
+		return strings.TrimSpace(string(runes[:154])) + "…"
+	}
+	return string(runes)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"framework-api/handlers/cachetest"
+	"framework-api/models"
+	"framework-api/schemaorg"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeSitemapSource []models.Recipe
+
+func (f fakeSitemapSource) SitemapRecipes(ctx context.Context) ([]models.Recipe, error) {
+	return f, nil
+}
+
+// newPageEngine serves the pages with recipes cached in miniredis and the templates of the repository.
+func newPageEngine(t *testing.T, recipes []models.Recipe) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	_, redisClient := cachetest.NewRedis(t, recipes)
+	recipeHandler := NewRecipesHandler(context.Background(), nil, redisClient, nil)
+	pages := NewPageHandler(recipeHandler, "https://recipes.example.com/")
+	pages.sitemap = fakeSitemapSource(recipes)
+	engine := gin.New()
+	engine.LoadHTMLGlob("../templates/*.html")
+	engine.GET("/", pages.Home)
+	engine.GET("/r/:id", pages.RecipePage)
+	engine.GET("/r/:id/:slug", pages.RecipePage)
+	engine.GET("/sitemap.xml", pages.Sitemap)
+	return engine
+}
+
+func getPage(engine *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+var jsonLDPattern = regexp.MustCompile(`(?s)<script type="application/ld\+json">(.*?)</script>`)
+
+func jsonLDOf(t *testing.T, body string, v interface{}) {
+	t.Helper()
+	m := jsonLDPattern.FindStringSubmatch(body)
+	if m == nil {
+		t.Fatalf("Expected a JSON-LD script, got %s", body)
+	}
+	if err := json.Unmarshal([]byte(m[1]), v); err != nil {
+		t.Fatalf("Unexpected error parsing JSON-LD %s: %s", m[1], err)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	ts := []struct {
+		name string
+		exp  string
+	}{
+		{name: "Palak Paneer (Spinach)", exp: "palak-paneer-spinach"},
+		{name: "  Mom's  best--pie! ", exp: "mom-s-best-pie"},
+		{name: "Crème brûlée", exp: "cr-me-br-l-e"},
+		{name: "पालक पनीर", exp: "recipe"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.name)
+		if got := Slug(tc.name); got != tc.exp {
+			t.Errorf("Expected %s, got %s", tc.exp, got)
+		}
+	}
+}
+
+func TestRecipePage(t *testing.T) {
+	recipe := translatedRecipe()
+	recipe.Servings = 4
+	recipe.PublishedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	recipe.Nutrition = &models.Nutrition{PerServing: models.NutritionFacts{Calories: 180, Protein: 4}}
+	recipe.Diets = []string{"vegan"}
+	engine := newPageEngine(t, []models.Recipe{recipe})
+	path := "/r/" + recipe.ID.Hex() + "/tomato-soup"
+
+	w := getPage(engine, path)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, exp := range []string{
+		`<html lang="en">`,
+		`<link rel="canonical" href="https://recipes.example.com` + path + `">`,
+		`<meta property="og:title" content="Tomato Soup - Recipe Platform">`,
+		`<meta property="og:image" content="https://recipes.example.com/static/images/recipe.jpg">`,
+		`<link rel="alternate" hreflang="fr" href="https://recipes.example.com` + path + `?lang=fr">`,
+		`<link rel="alternate" hreflang="x-default" href="https://recipes.example.com` + path + `">`,
+		`<li>Simmer for 20 minutes</li>`,
+	} {
+		if !strings.Contains(body, exp) {
+			t.Errorf("Expected %s in the page", exp)
+		}
+	}
+	var doc schemaorg.Recipe
+	jsonLDOf(t, body, &doc)
+	if doc.Type != "Recipe" || doc.Name != "Tomato Soup" || len(doc.RecipeIngredient) != 2 || len(doc.RecipeInstructions) != 1 {
+		t.Errorf("Expected the recipe as JSON-LD, got %+v", doc)
+	}
+	if doc.RecipeYield != "4 servings" || doc.Nutrition == nil || doc.Nutrition.Calories != "180 calories" || doc.DatePublished != "2026-03-01T12:00:00Z" {
+		t.Errorf("Expected yield, nutrition and publish date, got %+v", doc)
+	}
+	if len(doc.SuitableForDiet) != 1 || doc.SuitableForDiet[0] != "https://schema.org/VeganDiet" {
+		t.Errorf("Expected VeganDiet, got %v", doc.SuitableForDiet)
+	}
+
+	w = getPage(engine, path+"?lang=fr")
+	body = w.Body.String()
+	if !strings.Contains(body, `<html lang="fr">`) || !strings.Contains(body, "<h1>Soupe de tomates</h1>") || !strings.Contains(body, `<link rel="canonical" href="https://recipes.example.com`+path+`?lang=fr">`) {
+		t.Errorf("Expected the French page with its own canonical URL, got %s", body)
+	}
+}
+
+func TestRecipePageRedirectsAndNotFound(t *testing.T) {
+	recipe := translatedRecipe()
+	engine := newPageEngine(t, []models.Recipe{recipe})
+	ts := []struct {
+		text     string
+		path     string
+		exp      int
+		location string
+	}{
+		{text: "without slug", path: "/r/" + recipe.ID.Hex(), exp: http.StatusMovedPermanently, location: "/r/" + recipe.ID.Hex() + "/tomato-soup"},
+		{text: "outdated slug keeps query", path: "/r/" + recipe.ID.Hex() + "/tomato?lang=fr", exp: http.StatusMovedPermanently, location: "/r/" + recipe.ID.Hex() + "/tomato-soup?lang=fr"},
+		{text: "invalid id", path: "/r/nope/tomato-soup", exp: http.StatusNotFound},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		w := getPage(engine, tc.path)
+		if w.Code != tc.exp {
+			t.Errorf("Expected status %d, got %d", tc.exp, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != tc.location {
+			t.Errorf("Expected Location %s, got %s", tc.location, location)
+		}
+	}
+	if w := getPage(engine, "/r/nope"); !strings.Contains(w.Body.String(), `<meta name="robots" content="noindex">`) {
+		t.Errorf("Expected the not found page to be noindex")
+	}
+}
+
+func TestRecipePageEscapesContent(t *testing.T) {
+	recipe := translatedRecipe()
+	recipe.Name = `Soup </script><script>alert(1)</script>`
+	recipe.Ingredients = []string{`<img src=x onerror=alert(1)>`}
+	engine := newPageEngine(t, []models.Recipe{recipe})
+
+	w := getPage(engine, RecipePagePath(recipe.ID.Hex(), recipe.Name))
+	body := w.Body.String()
+	if strings.Contains(body, "<script>alert(1)") || strings.Contains(body, "<img src=x") {
+		t.Errorf("Expected recipe content to be escaped, got %s", body)
+	}
+	var doc schemaorg.Recipe
+	jsonLDOf(t, body, &doc)
+	if doc.Name != recipe.Name {
+		t.Errorf("Expected the name intact in JSON-LD, got %s", doc.Name)
+	}
+}
+
+func TestHomePage(t *testing.T) {
+	engine := newPageEngine(t, testRecipes())
+
+	w := getPage(engine, "/")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list schemaorg.ItemList
+	jsonLDOf(t, w.Body.String(), &list)
+	if list.Type != "ItemList" || len(list.ItemListElement) != 4 || list.ItemListElement[0].Position != 1 {
+		t.Errorf("Expected an ItemList of 4 recipes, got %+v", list)
+	}
+	if !strings.HasPrefix(list.ItemListElement[0].URL, "https://recipes.example.com/r/") {
+		t.Errorf("Expected absolute recipe URLs, got %s", list.ItemListElement[0].URL)
+	}
+	for _, path := range []string{"/?page=2", "/?page=0", "/?page=x"} {
+		if w := getPage(engine, path); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %s, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	recipes := testRecipes()
+	recipes[0].PublishedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	recipes[0].Translations = map[string]models.RecipeTranslation{"fr": {Name: "Soupe de tomates"}}
+	engine := newPageEngine(t, recipes)
+
+	w := getPage(engine, "/sitemap.xml")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/xml") {
+		t.Fatalf("Expected an XML sitemap, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var set struct {
+		URLs []struct {
+			Loc        string `xml:"loc"`
+			LastMod    string `xml:"lastmod"`
+			Alternates []struct {
+				Hreflang string `xml:"hreflang,attr"`
+				Href     string `xml:"href,attr"`
+			} `xml:"link"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &set); err != nil {
+		t.Fatalf("Unexpected error parsing sitemap: %s", err)
+	}
+	if len(set.URLs) != 5 || set.URLs[0].Loc != "https://recipes.example.com/" {
+		t.Fatalf("Expected the home page and 4 recipes, got %+v", set.URLs)
+	}
+	first := set.URLs[1]
+	if first.Loc != "https://recipes.example.com/r/"+recipes[0].ID.Hex()+"/tomato-soup" || first.LastMod != "2026-03-01" {
+		t.Errorf("Expected the canonical URL and lastmod, got %+v", first)
+	}
+	if len(first.Alternates) != 2 || first.Alternates[1].Hreflang != "fr" || !strings.HasSuffix(first.Alternates[1].Href, "?lang=fr") {
+		t.Errorf("Expected en and fr alternates, got %+v", first.Alternates)
+	}
+	if len(set.URLs[2].Alternates) != 0 {
+		t.Errorf("Expected no alternates without translations, got %+v", set.URLs[2].Alternates)
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// Permissions declared by the recipe routes.
+const (
+	ScopeRecipesWrite = "recipes:write"
+	GroupAdmin        = "admin"
+)
+
+// principalKey is the gin context key holding the authenticated *Principal.
+const principalKey = "principal"
+
+// Principal is the authenticated caller, parsed once from the token claims or API key by AuthMiddleware.
+type Principal struct {
+	Subject  string   `json:"sub"`
+	Issuer   string   `json:"iss,omitempty"`
+	Username string   `json:"username,omitempty"`
+	ClientID string   `json:"clientId,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	// Tenant the caller belongs to, empty for callers of the default tenant.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// NewPrincipal reads the Cognito claims into a Principal. It returns false when sub is missing or not a string.
+func NewPrincipal(claims jwt.MapClaims) (*Principal, bool) {
+	sub, ok := claims["sub"].(string)
+	if !ok || sub == "" {
+		return nil, false
+	}
+	p := &Principal{Subject: sub}
+	p.Username, _ = claims["username"].(string)
+	p.ClientID, _ = claims["client_id"].(string)
+	if p.ClientID == "" {
+		p.ClientID, _ = claims["aud"].(string)
+	}
+	p.Groups = stringListClaim(claims["cognito:groups"])
+	if len(p.Groups) == 0 {
+		p.Groups = stringListClaim(claims["groups"])
+	}
+	//Cognito custom attributes are prefixed with "custom:", other issuers use a plain claim
+	p.Tenant, _ = claims["custom:tenant"].(string)
+	if p.Tenant == "" {
+		p.Tenant, _ = claims["tenant"].(string)
+	}
+	//scope is a space separated string in OAuth2 access tokens
+	if scope, ok := claims["scope"].(string); ok {
+		p.Scopes = strings.Fields(scope)
+	} else {
+		p.Scopes = stringListClaim(claims["scope"])
+	}
+	return p, true
+}
+
+func stringListClaim(val interface{}) []string {
+	list := make([]string, 0)
+	switch v := val.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+	case []string:
+		list = append(list, v...)
+	case string:
+		if v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+func (p *Principal) InGroup(group string) bool {
+	return slices.Contains(p.Groups, group)
+}
+
+// SetPrincipal stores the principal in the gin context and in the request context,
+// for code that only sees a context.Context like the GraphQL resolvers.
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+	c.Set("userID", p.Subject)
+	c.Request = c.Request.WithContext(ContextWithPrincipal(c.Request.Context(), p))
+}
+
+type principalContextKey struct{}
+
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored by SetPrincipal, false for anonymous requests.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// GetPrincipal returns the principal stored by AuthMiddleware.
+func GetPrincipal(c *gin.Context) (*Principal, bool) {
+	val, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := val.(*Principal)
+	return p, ok
+}
+
+// RequireScopes allows the request only if the principal is a member of the request's tenant and has every one
+// of the scopes. Admins of the tenant pass every scope check.
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			return
+		}
+		tenant := GetTenant(c)
+		if p.IsTenantAdmin(tenant) {
+			c.Next()
+			return
+		}
+		if !p.MemberOf(tenant) {
+			zap.L().Warn("Not a member of the tenant", zap.String("user_id", p.Subject), zap.String("tenant", tenant))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Not a member of this tenant"})
+			return
+		}
+		for _, scope := range scopes {
+			if !p.HasScope(scope) {
+				zap.L().Warn("Missing required scope", zap.String("user_id", p.Subject), zap.String("scope", scope))
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing required scope " + scope})
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestNewPrincipal(t *testing.T) {
+	p, ok := NewPrincipal(jwt.MapClaims{
+		"sub":            "user-1",
+		"username":       "chef",
+		"client_id":      "client",
+		"cognito:groups": []interface{}{"admin", "cooks", 7},
+		"scope":          "openid recipes:write",
+	})
+	if !ok {
+		t.Fatalf("Expected principal to be parsed")
+	}
+	exp := &Principal{
+		Subject:  "user-1",
+		Username: "chef",
+		ClientID: "client",
+		Groups:   []string{"admin", "cooks"},
+		Scopes:   []string{"openid", "recipes:write"},
+	}
+	if !reflect.DeepEqual(p, exp) {
+		t.Errorf("Expected %+v, got %+v", exp, p)
+	}
+
+	if _, ok := NewPrincipal(jwt.MapClaims{"sub": 42}); ok {
+		t.Errorf("Expected non string sub to be rejected")
+	}
+}
+
+func TestRequireScopesAndTenantAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ts := []struct {
+		text      string
+		principal *Principal
+		guard     gin.HandlerFunc
+		exp       int
+	}{
+		{text: "no principal", guard: RequireScopes(ScopeRecipesWrite), exp: http.StatusUnauthorized},
+		{text: "scope present", principal: &Principal{Subject: "u", Scopes: []string{ScopeRecipesWrite}}, guard: RequireScopes(ScopeRecipesWrite), exp: http.StatusOK},
+		{text: "scope missing", principal: &Principal{Subject: "u", Scopes: []string{"openid"}}, guard: RequireScopes(ScopeRecipesWrite), exp: http.StatusForbidden},
+		{text: "admin passes scope check", principal: &Principal{Subject: "u", Groups: []string{GroupAdmin}}, guard: RequireScopes(ScopeRecipesWrite), exp: http.StatusOK},
+		{text: "admin group present", principal: &Principal{Subject: "u", Groups: []string{GroupAdmin}}, guard: RequireTenantAdmin(), exp: http.StatusOK},
+		{text: "admin group missing", principal: &Principal{Subject: "u", Scopes: []string{ScopeRecipesWrite}}, guard: RequireTenantAdmin(), exp: http.StatusForbidden},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		engine := gin.New()
+		principal := tc.principal
+		engine.GET("/guarded", func(c *gin.Context) {
+			if principal != nil {
+				SetPrincipal(c, principal)
+			}
+		}, tc.guard, func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/guarded", nil))
+		if w.Code != tc.exp {
+			t.Errorf("%s: expected status %d, got %d", tc.text, tc.exp, w.Code)
+		}
+	}
+}
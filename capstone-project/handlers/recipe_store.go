@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"framework-api/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+// RecipeStore persists recipes, the source of truth behind the Redis cache and the Elasticsearch index.
+// Every method works inside the tenant of ctx, see TenantFromContext: recipes of other tenants are not found.
+type RecipeStore interface {
+	// ListRecipes returns every recipe, recipes that fail to decode are skipped.
+	ListRecipes(ctx context.Context) ([]models.Recipe, error)
+	// LatestRecipes returns the limit latest published recipes, newest first, with only their name,
+	// publishedAt, locale and translations.
+	LatestRecipes(ctx context.Context, limit int64) ([]models.Recipe, error)
+	// GetRecipe returns ErrRecipeNotFound when no recipe has id.
+	GetRecipe(ctx context.Context, id bson.ObjectID) (models.Recipe, error)
+	// GetRecipes returns the recipes with the given ids that exist, in any order.
+	GetRecipes(ctx context.Context, ids []bson.ObjectID) ([]models.Recipe, error)
+	InsertRecipe(ctx context.Context, recipe models.Recipe) error
+	// UpdateRecipe sets fields, named by their bson keys, on the recipe with id, or returns ErrRecipeNotFound.
+	// The tenant field can not be set.
+	UpdateRecipe(ctx context.Context, id bson.ObjectID, fields bson.M) error
+	// DeleteRecipe returns ErrRecipeNotFound when no recipe has id.
+	DeleteRecipe(ctx context.Context, id bson.ObjectID) error
+	// ReplaceRecipe stores recipe over the recipe with the same ID, or inserts it. It fails when the ID
+	// belongs to another tenant.
+	ReplaceRecipe(ctx context.Context, recipe models.Recipe) error
+	// DeleteAllRecipes returns the number of recipes deleted.
+	DeleteAllRecipes(ctx context.Context) (int64, error)
+}
+
+// MongoRecipeStore keeps recipes in a MongoDB collection, recipeDB.recipes, with a tenant field on the recipes
+// of every tenant but the default one.
+type MongoRecipeStore struct {
+	recipes *mongo.Collection
+}
+
+func NewMongoRecipeStore(collection *mongo.Collection) *MongoRecipeStore {
+	return &MongoRecipeStore{recipes: collection}
+}
+
+func (s *MongoRecipeStore) ListRecipes(ctx context.Context) ([]models.Recipe, error) {
+	return s.find(ctx, tenantFilter(ctx))
+}
+
+func (s *MongoRecipeStore) LatestRecipes(ctx context.Context, limit int64) ([]models.Recipe, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"name": 1, "publishedAt": 1, "locale": 1, "translations": 1}).
+		SetSort(bson.D{{Key: "publishedAt", Value: -1}}).
+		SetLimit(limit)
+	return findAll[models.Recipe](ctx, s.recipes, tenantFilter(ctx), opts)
+}
+
+func (s *MongoRecipeStore) GetRecipe(ctx context.Context, id bson.ObjectID) (models.Recipe, error) {
+	var recipe models.Recipe
+	err := s.recipes.FindOne(ctx, byID(ctx, id)).Decode(&recipe)
+	if err == mongo.ErrNoDocuments {
+		return recipe, ErrRecipeNotFound
+	}
+	return recipe, err
+}
+
+func (s *MongoRecipeStore) GetRecipes(ctx context.Context, ids []bson.ObjectID) ([]models.Recipe, error) {
+	filter := tenantFilter(ctx)
+	filter["_id"] = bson.M{"$in": ids}
+	return s.find(ctx, filter)
+}
+
+func (s *MongoRecipeStore) InsertRecipe(ctx context.Context, recipe models.Recipe) error {
+	recipe.Tenant = tenantField(ctx)
+	_, err := s.recipes.InsertOne(ctx, recipe)
+	return err
+}
+
+func (s *MongoRecipeStore) UpdateRecipe(ctx context.Context, id bson.ObjectID, fields bson.M) error {
+	set := make(bson.M, len(fields))
+	for key, val := range fields {
+		//Moving a recipe to another tenant would make it readable there
+		if key != "tenant" {
+			set[key] = val
+		}
+	}
+	res, err := s.recipes.UpdateOne(ctx, byID(ctx, id), bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrRecipeNotFound
+	}
+	return nil
+}
+
+func (s *MongoRecipeStore) DeleteRecipe(ctx context.Context, id bson.ObjectID) error {
+	res, err := s.recipes.DeleteOne(ctx, byID(ctx, id))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrRecipeNotFound
+	}
+	return nil
+}
+
+func (s *MongoRecipeStore) ReplaceRecipe(ctx context.Context, recipe models.Recipe) error {
+	recipe.Tenant = tenantField(ctx)
+	//An ID of another tenant does not match, the upsert then fails on the duplicate _id
+	_, err := s.recipes.ReplaceOne(ctx, byID(ctx, recipe.ID), recipe, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *MongoRecipeStore) DeleteAllRecipes(ctx context.Context) (int64, error) {
+	res, err := s.recipes.DeleteMany(ctx, tenantFilter(ctx))
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// find decodes the recipes matching filter one by one, skipping those that fail to decode.
+func (s *MongoRecipeStore) find(ctx context.Context, filter bson.M) ([]models.Recipe, error) {
+	cur, err := s.recipes.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	recipes := make([]models.Recipe, 0)
+	for cur.Next(ctx) {
+		var recipe models.Recipe
+		if err := cur.Decode(&recipe); err != nil {
+			zap.L().Error("Failed to decode recipe", zap.Error(err))
+			continue
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, cur.Err()
+}
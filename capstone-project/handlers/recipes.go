@@ -0,0 +1,570 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"framework-api/i18n"
+	"framework-api/models"
+	"net/http"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// Errors returned by the recipe data access methods, the HTTP handlers map them to status codes.
+var (
+	ErrInvalidRecipeID = errors.New("invalid recipe id")
+	ErrRecipeNotFound  = errors.New("recipe not found")
+)
+
+// ListRecipes returns all recipes of the tenant of ctx from MongoDB, cached in Redis under "recipes" (see TenantKey).
+func (h *RecipeHandler) ListRecipes(ctx context.Context) ([]models.Recipe, error) {
+	//Check Redis first
+	val, err := h.redisClient.Get(ctx, TenantKey(ctx, "recipes")).Result()
+	if err == nil {
+		zap.L().Info("Found recipes in redis")
+		recipes := make([]models.Recipe, 0)
+		json.Unmarshal([]byte(val), &recipes)
+		return recipes, nil
+	}
+	if err != redis.Nil {
+		return nil, err
+	}
+
+	zap.L().Info("Request sent to MongoDB")
+	dbRecipes, err := h.store.ListRecipes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbRecipes) > 0 {
+		//update redis cache, an empty collection is not cached
+		data, _ := json.Marshal(dbRecipes)
+		zap.L().Info("Storing recipes in redis")
+		h.redisClient.Set(ctx, TenantKey(ctx, "recipes"), string(data), 0)
+	}
+	return dbRecipes, nil
+}
+
+// FindRecipe returns one recipe, cached in Redis under "recipe:<id>".
+func (h *RecipeHandler) FindRecipe(ctx context.Context, recipeId string) (models.Recipe, error) {
+	var recipe models.Recipe
+	val, err := h.redisClient.Get(ctx, TenantKey(ctx, "recipe:"+recipeId)).Result()
+	if err == nil {
+		zap.L().Info("Found recipe in redis", zap.String("recipe_id", recipeId))
+		json.Unmarshal([]byte(val), &recipe)
+		return recipe, nil
+	}
+	zap.L().Info("Recipe not found in redis, fetching from DB", zap.String("recipe_id", recipeId))
+	objectId, err := bson.ObjectIDFromHex(recipeId)
+	if err != nil {
+		return recipe, ErrInvalidRecipeID
+	}
+	recipe, err = h.store.GetRecipe(ctx, objectId)
+	if err != nil {
+		return recipe, err
+	}
+	//update redis cache
+	data, _ := json.Marshal(recipe)
+	zap.L().Info("Storing recipe in redis", zap.String("recipe_id", recipeId))
+	h.redisClient.Set(ctx, TenantKey(ctx, "recipe:"+recipeId), string(data), 0)
+	return recipe, nil
+}
+
+// FindRecipes returns the recipes with the given ids in the same order, reading all cached ones with one
+// Redis MGET and the rest with one MongoDB query. errs[i] is set when ids[i] is invalid or not found.
+func (h *RecipeHandler) FindRecipes(ctx context.Context, ids []string) ([]models.Recipe, []error) {
+	recipes := make([]models.Recipe, len(ids))
+	errs := make([]error, len(ids))
+	if len(ids) == 0 {
+		return recipes, errs
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = TenantKey(ctx, "recipe:"+id)
+	}
+	cached, err := h.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		zap.L().Warn("Failed to read recipes from redis", zap.Error(err))
+		cached = make([]interface{}, len(ids))
+	}
+
+	//Everything not in the cache is fetched from MongoDB in one query
+	missing := make(map[bson.ObjectID][]int)
+	objectIds := make([]bson.ObjectID, 0)
+	for i, id := range ids {
+		if val, ok := cached[i].(string); ok && json.Unmarshal([]byte(val), &recipes[i]) == nil {
+			continue
+		}
+		objectId, err := bson.ObjectIDFromHex(id)
+		if err != nil {
+			errs[i] = ErrInvalidRecipeID
+			continue
+		}
+		if _, ok := missing[objectId]; !ok {
+			objectIds = append(objectIds, objectId)
+		}
+		missing[objectId] = append(missing[objectId], i)
+	}
+	if len(objectIds) == 0 {
+		return recipes, errs
+	}
+	zap.L().Info("Fetching recipes from DB", zap.Int("count", len(objectIds)))
+	found, err := h.store.GetRecipes(ctx, objectIds)
+	if err != nil {
+		for _, positions := range missing {
+			for _, i := range positions {
+				errs[i] = err
+			}
+		}
+		return recipes, errs
+	}
+	for _, recipe := range found {
+		for _, i := range missing[recipe.ID] {
+			recipes[i] = recipe
+		}
+		delete(missing, recipe.ID)
+		//update redis cache
+		data, _ := json.Marshal(recipe)
+		h.redisClient.Set(ctx, TenantKey(ctx, "recipe:"+recipe.ID.Hex()), string(data), 0)
+	}
+	for _, positions := range missing {
+		for _, i := range positions {
+			errs[i] = ErrRecipeNotFound
+		}
+	}
+	return recipes, errs
+}
+
+// CreateRecipe assigns the ID and publish time, derives nutrition and dietary information, stores the recipe and indexes it in Elasticsearch.
+func (h *RecipeHandler) CreateRecipe(ctx context.Context, recipe models.Recipe) (models.Recipe, error) {
+	recipe.ID = bson.NewObjectID()
+	recipe.PublishedAt = time.Now()
+	recipe, err := h.normalizeLocales(recipe)
+	if err != nil {
+		return recipe, err
+	}
+	recipe = h.derive(recipe)
+	if err := h.store.InsertRecipe(ctx, recipe); err != nil {
+		return recipe, err
+	}
+	//Invalidate cache
+	h.invalidateLists(ctx)
+	h.touch(ctx, recipe.PublishedAt, recipe.ID.Hex())
+	//Add recipe to elastic store, search lagging behind is not fatal
+	if err := h.insertRecipeInElasticstore(ctx, recipe); err != nil {
+		zap.L().Error("Failed to insert recipe in elastic store", zap.Error(err))
+	}
+	h.emit(ctx, EventRecipeCreated, recipe.ID.Hex(), &recipe)
+	h.audit.Record(ctx, models.AuditEntry{Action: models.AuditRecipeCreate, Resource: "recipe", ResourceID: recipe.ID.Hex(), AfterHash: h.storedHash(ctx, recipe.ID)})
+	return recipe, nil
+}
+
+// UpdateRecipe sets the given fields on a recipe (PATCH semantics) and returns the updated recipe.
+// _id, publishedAt, updatedAt and the derived fields can not be changed, they are recomputed from the stored recipe.
+func (h *RecipeHandler) UpdateRecipe(ctx context.Context, recipeId string, fields bson.M) (models.Recipe, error) {
+	var recipe models.Recipe
+	objectId, err := bson.ObjectIDFromHex(recipeId)
+	if err != nil {
+		return recipe, ErrInvalidRecipeID
+	}
+	delete(fields, "_id")
+	delete(fields, "id")
+	delete(fields, "publishedAt")
+	delete(fields, "tenant")
+	updatedAt := time.Now()
+	fields["updatedAt"] = updatedAt
+	for _, field := range derivedFields {
+		delete(fields, field)
+	}
+	if err := h.normalizeLocaleFields(fields); err != nil {
+		return recipe, err
+	}
+	before := h.storedHash(ctx, objectId)
+
+	//Execute update
+	if err := h.store.UpdateRecipe(ctx, objectId, fields); err != nil {
+		return recipe, err
+	}
+	//After update invalidate cache
+	h.redisClient.Del(ctx, TenantKey(ctx, "recipe:"+recipeId))
+	h.invalidateLists(ctx)
+	h.touch(ctx, updatedAt, recipeId)
+
+	recipe, err = h.store.GetRecipe(ctx, objectId)
+	if err != nil {
+		return recipe, err
+	}
+	//Also fills in the derived fields of recipes stored before they were computed
+	derived := h.derive(recipe)
+	if changes := derivedChanges(recipe, derived); len(changes) > 0 {
+		if err := h.store.UpdateRecipe(ctx, objectId, changes); err != nil {
+			return recipe, err
+		}
+		recipe = derived
+	}
+	//Update recipe in elastic store
+	if err := h.insertRecipeInElasticstore(ctx, recipe); err != nil {
+		zap.L().Error("Failed to insert recipe in elastic store", zap.Error(err))
+	}
+	h.emit(ctx, EventRecipeUpdated, recipeId, &recipe)
+	h.audit.Record(ctx, models.AuditEntry{Action: models.AuditRecipeUpdate, Resource: "recipe", ResourceID: recipeId, BeforeHash: before, AfterHash: h.storedHash(ctx, objectId)})
+	return recipe, nil
+}
+
+// DeleteRecipe removes a recipe from MongoDB, Redis and Elasticsearch.
+func (h *RecipeHandler) DeleteRecipe(ctx context.Context, recipeId string) error {
+	objectId, err := bson.ObjectIDFromHex(recipeId)
+	if err != nil {
+		return ErrInvalidRecipeID
+	}
+	before := h.storedHash(ctx, objectId)
+	if err := h.store.DeleteRecipe(ctx, objectId); err != nil {
+		return err
+	}
+	//After delete - invalidate cache
+	h.redisClient.Del(ctx, TenantKey(ctx, "recipe:"+recipeId))
+	h.invalidateLists(ctx)
+	h.forget(ctx, recipeId)
+	//Delete recipe from elastic store
+	if err := h.deleteRecipeInElasticStore(ctx, recipeId); err != nil {
+		zap.L().Error("Failed to delete recipe from elastic store", zap.Error(err))
+	}
+	h.emit(ctx, EventRecipeDeleted, recipeId, nil)
+	h.audit.Record(ctx, models.AuditEntry{Action: models.AuditRecipeDelete, Resource: "recipe", ResourceID: recipeId, BeforeHash: before})
+	return nil
+}
+
+// storedHash is the audit hash of the recipe as stored, so the after hash of a write matches the before hash of
+// the next one. Empty when the audit log is disabled or the recipe can not be read.
+func (h *RecipeHandler) storedHash(ctx context.Context, id bson.ObjectID) string {
+	if !h.audit.Enabled() {
+		return ""
+	}
+	recipe, err := h.store.GetRecipe(ctx, id)
+	if err != nil {
+		return ""
+	}
+	return models.AuditHash(recipe)
+}
+
+// emit notifies the event publishers. The change is already stored, so a failing publisher is only logged.
+func (h *RecipeHandler) emit(ctx context.Context, eventType, recipeId string, recipe *models.Recipe) {
+	event := RecipeEvent{Type: eventType, RecipeID: recipeId, Recipe: recipe, Tenant: tenantField(ctx), OccurredAt: time.Now().UTC()}
+	for _, p := range h.publishers {
+		if err := p.Publish(ctx, event); err != nil {
+			zap.L().Error("Failed to publish recipe event", zap.String("type", eventType), zap.String("recipe_id", recipeId), zap.Error(err))
+		}
+	}
+}
+
+// derivedFields are computed from the ingredients, clients can not set them.
+var derivedFields = []string{"nutrition", "allergens", "diets", "tagConflicts"}
+
+// derive computes the nutrition, allergens, diets and tag conflicts of a recipe.
+// Conflicting tags are kept, the author may know better than the rule table, but they are logged.
+func (h *RecipeHandler) derive(recipe models.Recipe) models.Recipe {
+	computed := h.nutrients.Compute(recipe.Ingredients, recipe.Servings)
+	recipe.Nutrition = &computed
+	classified := h.classifier.Classify(recipe.Ingredients, recipe.Tags)
+	recipe.Allergens = classified.Allergens
+	recipe.Diets = classified.Diets
+	recipe.TagConflicts = classified.Conflicts
+	if len(recipe.TagConflicts) > 0 {
+		zap.L().Warn("Recipe tags conflict with its ingredients", zap.String("recipe_id", recipe.ID.Hex()), zap.Any("conflicts", recipe.TagConflicts))
+	}
+	return recipe
+}
+
+// allergensOf returns the stored allergens, recipes stored before they were derived are classified on the fly.
+func (h *RecipeHandler) allergensOf(recipe models.Recipe) []string {
+	if recipe.Allergens != nil {
+		return recipe.Allergens
+	}
+	return h.classifier.Classify(recipe.Ingredients, nil).Allergens
+}
+
+// derivedChanges returns the derived fields of the stored recipe that are outdated.
+func derivedChanges(stored, derived models.Recipe) bson.M {
+	changes := bson.M{}
+	if !reflect.DeepEqual(stored.Nutrition, derived.Nutrition) {
+		changes["nutrition"] = derived.Nutrition
+	}
+	if !slices.Equal(stored.Allergens, derived.Allergens) || stored.Allergens == nil {
+		changes["allergens"] = derived.Allergens
+	}
+	if !slices.Equal(stored.Diets, derived.Diets) || stored.Diets == nil {
+		changes["diets"] = derived.Diets
+	}
+	if !reflect.DeepEqual(stored.TagConflicts, derived.TagConflicts) {
+		changes["tagConflicts"] = derived.TagConflicts
+	}
+	return changes
+}
+
+// TagFacet is the number of search hits carrying a tag.
+type TagFacet struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// RecipeQuery is a recipe search, zero values leave a criterion out.
+type RecipeQuery struct {
+	// Q is matched against name (fuzzy) and tags.
+	Q string
+	// Tags must all be carried by a hit.
+	Tags []string
+	// MaxCalories per serving, recipes stored without nutrition never match.
+	MaxCalories float64
+	// ExcludeAllergens drops hits containing any of them.
+	ExcludeAllergens []string
+	// Facets is the number of most common tags among all hits to return.
+	Facets int
+	// Locale of the hit names, Q is also matched with its language analyzer. Empty is i18n.DefaultLocale.
+	Locale string
+}
+
+// SearchRecipes searches recipes in Elasticsearch and, when query.Facets > 0, returns the most common tags of the hits.
+func (h *RecipeHandler) SearchRecipes(ctx context.Context, query RecipeQuery) ([]models.RecipeSearchResult, []TagFacet, error) {
+	should := make([]interface{}, 0)
+	filter := make([]interface{}, 0)
+	locale := query.Locale
+	if locale == "" {
+		locale = i18n.DefaultLocale
+	}
+	if query.Q != "" {
+		should = append(should, map[string]interface{}{
+			"match": map[string]interface{}{
+				"name": map[string]interface{}{
+					"query":     query.Q,
+					"fuzziness": "AUTO",
+				},
+			},
+		},
+			map[string]interface{}{
+				"match": map[string]interface{}{
+					"tags": query.Q,
+				},
+			},
+		)
+		//Stemmed matches in the localized names, "tomates" finds "Tomate"
+		should = append(should, map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  query.Q,
+				"fields": localizedFields(locale, "name", "ingredients"),
+			},
+		})
+	}
+
+	for _, tag := range query.Tags {
+		filter = append(filter, map[string]interface{}{
+			"term": map[string]interface{}{
+				"tags.keyword": tag,
+			},
+		})
+	}
+
+	if query.MaxCalories > 0 {
+		filter = append(filter, map[string]interface{}{
+			"range": map[string]interface{}{
+				"caloriesPerServing": map[string]interface{}{"lte": query.MaxCalories},
+			},
+		})
+	}
+
+	boolQuery := map[string]interface{}{}
+	if len(query.ExcludeAllergens) > 0 {
+		boolQuery["must_not"] = []interface{}{
+			map[string]interface{}{
+				"terms": map[string]interface{}{"allergens.keyword": query.ExcludeAllergens},
+			},
+		}
+	}
+	if len(should) > 0 {
+		boolQuery["should"] = should
+		boolQuery["minimum_should_match"] = 1
+	}
+	if len(filter) > 0 {
+		boolQuery["filter"] = filter
+	}
+	zap.S().Infof("Search recipe query in elastic store: %v", boolQuery)
+
+	searchBody := map[string]interface{}{
+		"_source": searchSource,
+		"query": map[string]interface{}{
+			"bool": boolQuery,
+		},
+	}
+	if query.Facets > 0 {
+		searchBody["aggs"] = map[string]interface{}{
+			"tags": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "tags.keyword", "size": query.Facets},
+			},
+		}
+	}
+
+	bodyBytes, err := json.Marshal(searchBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := h.elasticClient.Search(
+		h.elasticClient.Search.WithContext(ctx),
+		h.elasticClient.Search.WithIndex(RecipeIndex(TenantFromContext(ctx))),
+		h.elasticClient.Search.WithBody(bytes.NewReader(bodyBytes)),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	//A tenant without recipes has no index yet
+	if res.StatusCode == http.StatusNotFound {
+		return make([]models.RecipeSearchResult, 0), make([]TagFacet, 0), nil
+	}
+	if res.IsError() {
+		zap.L().Error("Failed to search recipes in elastic", zap.String("response", res.String()))
+		return nil, nil, errors.New("Failed to search recipes in elastic")
+	}
+
+	var searchResp struct {
+		Hits struct {
+			Hits []struct {
+				Source searchHit `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+		Aggregations struct {
+			Tags struct {
+				Buckets []struct {
+					Key      string `json:"key"`
+					DocCount int    `json:"doc_count"`
+				} `json:"buckets"`
+			} `json:"tags"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&searchResp); err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]models.RecipeSearchResult, 0, len(searchResp.Hits.Hits))
+	for _, hit := range searchResp.Hits.Hits {
+		results = append(results, hit.Source.localize(locale))
+	}
+	tagFacets := make([]TagFacet, 0, len(searchResp.Aggregations.Tags.Buckets))
+	for _, bucket := range searchResp.Aggregations.Tags.Buckets {
+		tagFacets = append(tagFacets, TagFacet{Tag: bucket.Key, Count: bucket.DocCount})
+	}
+	return results, tagFacets, nil
+}
+
+// elasticRecipe is the indexed document, calories per serving are flattened for the maxCalories range filter.
+// The translations are indexed by locale under i18n instead, so each locale gets its language analyzer.
+type elasticRecipe struct {
+	models.Recipe
+	CaloriesPerServing *float64                      `json:"caloriesPerServing,omitempty"`
+	Translations       map[string]elasticTranslation `json:"translations,omitempty"`
+	I18n               map[string]elasticTranslation `json:"i18n"`
+}
+
+// searchSource are the document fields returned as search hits.
+var searchSource = []string{"id", "name", "tags", "imageUrl", "caloriesPerServing", "locale", "i18n.*.name"}
+
+// searchHit is a search hit with the names in every locale.
+type searchHit struct {
+	models.RecipeSearchResult
+	I18n map[string]elasticTranslation `json:"i18n"`
+}
+
+// localize returns the hit with the name in the closest locale to locale.
+func (hit searchHit) localize(locale string) models.RecipeSearchResult {
+	for _, candidate := range i18n.Fallbacks(locale) {
+		if translation, ok := hit.I18n[candidate]; ok && translation.Name != "" {
+			hit.RecipeSearchResult.Name = translation.Name
+			hit.RecipeSearchResult.Locale = candidate
+			break
+		}
+	}
+	return hit.RecipeSearchResult
+}
+
+func (h *RecipeHandler) insertRecipeInElasticstore(ctx context.Context, recipe models.Recipe) error {
+	zap.L().Info("Inserting recipe in elastic store", zap.String("recipe_id", recipe.ID.Hex()))
+	doc := elasticRecipe{Recipe: recipe, I18n: elasticTranslations(recipe)}
+	if recipe.Nutrition != nil {
+		doc.CaloriesPerServing = &recipe.Nutrition.PerServing.Calories
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		zap.L().Error("Failed to marshal recipe", zap.Error(err))
+		return err
+	}
+
+	if err := h.ensureTenantIndex(ctx); err != nil {
+		return err
+	}
+	res, err := h.elasticClient.Index(
+		RecipeIndex(TenantFromContext(ctx)),
+		bytes.NewReader(data),
+		h.elasticClient.Index.WithContext(ctx),
+		h.elasticClient.Index.WithDocumentID(recipe.ID.Hex()),
+		h.elasticClient.Index.WithRefresh("true"),
+	)
+
+	if err != nil {
+		zap.L().Error("Failed to insert recipe in elastic", zap.Error(err))
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		zap.L().Error("Failed to insert recipe in elastic", zap.String("response", res.String()))
+		return errors.New("Failed to insert recipe in elastic")
+	}
+	zap.L().Info("Recipe inserted in elastic", zap.String("recipe_id", recipe.ID.Hex()))
+	return nil
+
+}
+
+func (h *RecipeHandler) deleteRecipeInElasticStore(ctx context.Context, recpieId string) error {
+	zap.L().Info("Deleting recipe from elastic store", zap.String("recipe_id", recpieId))
+	res, err := h.elasticClient.Delete(
+		RecipeIndex(TenantFromContext(ctx)),
+		recpieId,
+		h.elasticClient.Delete.WithContext(ctx),
+		h.elasticClient.Delete.WithRefresh("true"),
+	)
+	if err != nil {
+		zap.L().Error("Failed to delete recipe from elastic store", zap.Error(err))
+		return errors.New("Failed to delete recipe from elastic store")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		zap.L().Error("Failed to delete recipe in elastic", zap.String("response", res.String()))
+		return errors.New("Failed to delete recipe from elastic store")
+	}
+	zap.L().Info("Recipe deleted from elastic", zap.String("recipe_id", recpieId))
+	return nil
+
+}
+
+// ensureTenantIndex sets up the Elasticsearch index of the tenant of ctx on its first recipe. The default index is
+// set up at startup.
+func (h *RecipeHandler) ensureTenantIndex(ctx context.Context) error {
+	index := RecipeIndex(TenantFromContext(ctx))
+	if index == RecipeIndex(DefaultTenant) {
+		return nil
+	}
+	if _, ok := h.indexes.Load(index); ok {
+		return nil
+	}
+	return h.EnsureElasticIndex(ctx)
+}
+
+// invalidateLists deletes the cached list and similar recipes of the tenant of ctx, after any recipe change.
+func (h *RecipeHandler) invalidateLists(ctx context.Context) {
+	h.redisClient.Del(ctx, TenantKey(ctx, "recipes"), TenantKey(ctx, similarCacheKey), TenantKey(ctx, similarLocalCacheKey))
+}
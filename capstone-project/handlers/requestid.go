@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID of a request, set by the client or a proxy in front of the API, generated otherwise.
+// It is echoed in the response and recorded in the audit log, to match an audit entry to the logs of its request.
+const RequestIDHeader = "X-Request-ID"
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestInfo is where a request came from, recorded with the audit entries of its actions.
+type RequestInfo struct {
+	ID string
+	IP string
+}
+
+// RequestIDMiddleware accepts the client's X-Request-ID when it is 1 to 64 letters, digits, dots, dashes and
+// underscores and replaces it with a random ID otherwise, so a client can not smuggle text into the audit log.
+// The ID and the client IP go in the request context, see RequestInfoFromContext.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := RequestID(c.GetHeader(RequestIDHeader))
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(ContextWithRequestInfo(c.Request.Context(), RequestInfo{ID: id, IP: c.ClientIP()}))
+		c.Next()
+	}
+}
+
+// RequestID returns the ID sent by a client when RequestIDMiddleware accepts it, a new random ID otherwise.
+func RequestID(sent string) string {
+	if requestIDPattern.MatchString(sent) {
+		return sent
+	}
+	return rand.Text()
+}
+
+type requestInfoContextKey struct{}
+
+func ContextWithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoContextKey{}, info)
+}
+
+// RequestInfoFromContext returns the request stored by RequestIDMiddleware, empty outside of a request.
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoContextKey{}).(RequestInfo)
+	return info
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"framework-api/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ReviewStore reads the reviews of recipes. Like recipes they are only found in the tenant of ctx.
+type ReviewStore interface {
+	// ReviewsOf returns the reviews of every recipe in recipeIDs, newest first, keyed by recipe ID. Recipes
+	// without reviews are left out.
+	ReviewsOf(ctx context.Context, recipeIDs []bson.ObjectID) (map[bson.ObjectID][]models.Review, error)
+}
+
+// MongoReviewStore reads the reviews collection.
+type MongoReviewStore struct {
+	reviews *mongo.Collection
+}
+
+func NewMongoReviewStore(db *mongo.Database) *MongoReviewStore {
+	return &MongoReviewStore{reviews: db.Collection("reviews")}
+}
+
+func (s *MongoReviewStore) ReviewsOf(ctx context.Context, recipeIDs []bson.ObjectID) (map[bson.ObjectID][]models.Review, error) {
+	filter := tenantFilter(ctx)
+	filter["recipeId"] = bson.M{"$in": recipeIDs}
+	reviews, err := findAll[models.Review](ctx, s.reviews, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	byRecipe := make(map[bson.ObjectID][]models.Review)
+	for _, review := range reviews {
+		byRecipe[review.RecipeID] = append(byRecipe[review.RecipeID], review)
+	}
+	return byRecipe, nil
+}
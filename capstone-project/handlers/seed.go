@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"framework-api/models"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SeedRecipes stores recipes in the tenant of ctx with their own IDs and publish times, replacing recipes with the
+// same ID, caches them in Redis and indexes them in Elasticsearch. Derived fields are computed like on create, no events are emitted.
+func (h *RecipeHandler) SeedRecipes(ctx context.Context, recipes []models.Recipe) error {
+	ids := make([]string, 0, len(recipes))
+	for _, recipe := range recipes {
+		recipe, err := h.normalizeLocales(recipe)
+		if err != nil {
+			return err
+		}
+		recipe = h.derive(recipe)
+		if err := h.store.ReplaceRecipe(ctx, recipe); err != nil {
+			return err
+		}
+		data, _ := json.Marshal(recipe)
+		h.redisClient.Set(ctx, TenantKey(ctx, "recipe:"+recipe.ID.Hex()), string(data), 0)
+		if err := h.insertRecipeInElasticstore(ctx, recipe); err != nil {
+			return err
+		}
+		ids = append(ids, recipe.ID.Hex())
+	}
+	h.invalidateLists(ctx)
+	//Replaced recipes keep their publish time, their cached copies are stale all the same
+	h.touch(ctx, time.Now(), ids...)
+	//Cache the list like the first read would
+	_, err := h.ListRecipes(ctx)
+	return err
+}
+
+// ResetRecipes deletes every recipe of the tenant of ctx from MongoDB, Redis and Elasticsearch, the index and its
+// mapping are kept.
+// Meal plans referencing the recipes are not flagged, no events are emitted.
+func (h *RecipeHandler) ResetRecipes(ctx context.Context) error {
+	deleted, err := h.store.DeleteAllRecipes(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := h.redisClient.Keys(ctx, TenantKey(ctx, "recipe:*")).Result()
+	if err != nil {
+		return err
+	}
+	keys = append(keys, TenantKey(ctx, "recipes"), TenantKey(ctx, similarCacheKey), TenantKey(ctx, similarLocalCacheKey), TenantKey(ctx, modifiedKey))
+	if err := h.redisClient.Del(ctx, keys...).Err(); err != nil {
+		return err
+	}
+	es, err := h.elasticClient.DeleteByQuery([]string{RecipeIndex(TenantFromContext(ctx))}, strings.NewReader(`{"query": {"match_all": {}}}`),
+		h.elasticClient.DeleteByQuery.WithContext(ctx),
+		h.elasticClient.DeleteByQuery.WithRefresh(true),
+	)
+	if err != nil {
+		return err
+	}
+	defer es.Body.Close()
+	//A missing index has nothing to delete
+	if es.IsError() && es.StatusCode != 404 {
+		return errors.New("Failed to delete recipes from elastic store: " + es.String())
+	}
+	zap.L().Info("Deleted all recipes", zap.Int64("count", deleted))
+	return nil
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"framework-api/models"
+	"framework-api/similarity"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Limits of the similar recipes endpoints.
+const (
+	DefaultSimilarLimit = 5
+	MaxSimilarLimit     = 20
+)
+
+// Sources of similar recipes, reported in the X-Similar-Source header.
+const (
+	SimilarSourceElasticsearch = "elasticsearch"
+	SimilarSourceLocal         = "local"
+	SimilarSourceHeader        = "X-Similar-Source"
+)
+
+// Similar recipes are cached in two Redis hashes per tenant with "<id>:<limit>:<locale>" fields, deleted on every
+// recipe change.
+// Locally ranked results also expire, so Elasticsearch results replace them once it is reachable again.
+const (
+	similarCacheKey      = "recipes:similar"
+	similarLocalCacheKey = "recipes:similar:local"
+	similarLocalCacheTTL = 10 * time.Minute
+)
+
+// SimilarRecipes returns up to limit recipes like the given one, named in locale, and the source that ranked them.
+// Elasticsearch more_like_this compares name, ingredients and tags; without Elasticsearch the recipes
+// are ranked locally by ingredient TF-IDF and tag overlap.
+func (h *RecipeHandler) SimilarRecipes(ctx context.Context, recipeId string, limit int, locale string) ([]models.RecipeSearchResult, string, error) {
+	recipe, err := h.FindRecipe(ctx, recipeId)
+	if err != nil {
+		return nil, "", err
+	}
+	field := fmt.Sprintf("%s:%d:%s", recipeId, limit, locale)
+	if results, ok := h.cachedSimilar(ctx, TenantKey(ctx, similarCacheKey), field); ok {
+		return results, SimilarSourceElasticsearch, nil
+	}
+	results, err := h.moreLikeThis(ctx, recipeId, limit, locale)
+	if err == nil {
+		h.cacheSimilar(ctx, TenantKey(ctx, similarCacheKey), field, results, 0)
+		return results, SimilarSourceElasticsearch, nil
+	}
+	zap.L().Warn("Failed to find similar recipes in elastic store, ranking locally", zap.String("recipe_id", recipeId), zap.Error(err))
+
+	if results, ok := h.cachedSimilar(ctx, TenantKey(ctx, similarLocalCacheKey), field); ok {
+		return results, SimilarSourceLocal, nil
+	}
+	recipes, err := h.ListRecipes(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	matches := similarity.Rank(recipe, recipes, limit)
+	results = make([]models.RecipeSearchResult, 0, len(matches))
+	for _, match := range matches {
+		results = append(results, searchResultOf(localize(match.Recipe, locale)))
+	}
+	h.cacheSimilar(ctx, TenantKey(ctx, similarLocalCacheKey), field, results, similarLocalCacheTTL)
+	return results, SimilarSourceLocal, nil
+}
+
+func (h *RecipeHandler) moreLikeThis(ctx context.Context, recipeId string, limit int, locale string) ([]models.RecipeSearchResult, error) {
+	index := RecipeIndex(TenantFromContext(ctx))
+	body, err := json.Marshal(map[string]interface{}{
+		"_source": searchSource,
+		"size":    limit,
+		"query": map[string]interface{}{
+			"more_like_this": map[string]interface{}{
+				"fields":          append([]string{"name", "ingredients", "tags"}, localizedFields(locale, "name", "ingredients")...),
+				"like":            []interface{}{map[string]interface{}{"_index": index, "_id": recipeId}},
+				"min_term_freq":   1,
+				"min_doc_freq":    1,
+				"max_query_terms": 25,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := h.elasticClient.Search(
+		h.elasticClient.Search.WithContext(ctx),
+		h.elasticClient.Search.WithIndex(index),
+		h.elasticClient.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, errors.New("more_like_this query failed: " + res.Status())
+	}
+	var searchResp struct {
+		Hits struct {
+			Hits []struct {
+				Source searchHit `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&searchResp); err != nil {
+		return nil, err
+	}
+	results := make([]models.RecipeSearchResult, 0, len(searchResp.Hits.Hits))
+	for _, hit := range searchResp.Hits.Hits {
+		results = append(results, hit.Source.localize(locale))
+	}
+	return results, nil
+}
+
+func (h *RecipeHandler) cachedSimilar(ctx context.Context, key, field string) ([]models.RecipeSearchResult, bool) {
+	val, err := h.redisClient.HGet(ctx, key, field).Result()
+	if err != nil {
+		return nil, false
+	}
+	var results []models.RecipeSearchResult
+	if err := json.Unmarshal([]byte(val), &results); err != nil {
+		return nil, false
+	}
+	return results, true
+}
+
+// cacheSimilar stores results, a ttl above 0 is set on the hash when it has none, so entries live at most that long.
+func (h *RecipeHandler) cacheSimilar(ctx context.Context, key, field string, results []models.RecipeSearchResult, ttl time.Duration) {
+	data, _ := json.Marshal(results)
+	if err := h.redisClient.HSet(ctx, key, field, string(data)).Err(); err != nil {
+		zap.L().Warn("Failed to cache similar recipes", zap.Error(err))
+		return
+	}
+	if ttl > 0 {
+		h.redisClient.ExpireNX(ctx, key, ttl)
+	}
+}
+
+func searchResultOf(recipe models.Recipe) models.RecipeSearchResult {
+	result := models.RecipeSearchResult{ID: recipe.ID.Hex(), Name: recipe.Name, Tags: recipe.Tags, ImageURL: recipe.ImageURL, Locale: recipe.Locale}
+	if recipe.Nutrition != nil {
+		result.CaloriesPerServing = &recipe.Nutrition.PerServing.Calories
+	}
+	return result
+}
+
+// similarLimit reads ?limit=, it defaults to DefaultSimilarLimit and is capped at MaxSimilarLimit.
+func similarLimit(c *gin.Context) (int, bool) {
+	val := c.Query("limit")
+	if val == "" {
+		return DefaultSimilarLimit, true
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return min(n, MaxSimilarLimit), true
+}
+
+// GetSimilarRecipes returns the recipes like the given one, "you might also like".
+func (h *RecipeHandler) GetSimilarRecipes(c *gin.Context) {
+	results, ok := h.similarRecipes(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// GetSimilarRecipesV2 wraps GetSimilarRecipes in the v2 envelope.
+func (h *RecipeHandler) GetSimilarRecipesV2(c *gin.Context) {
+	results, ok := h.similarRecipes(c)
+	if !ok {
+		return
+	}
+	count := len(results)
+	c.JSON(http.StatusOK, Envelope[[]models.RecipeSearchResult]{
+		Data:  results,
+		Meta:  Meta{APIVersion: APIVersionV2, Count: &count},
+		Links: Links{Self: c.Request.URL.RequestURI(), Collection: collectionPath(c)},
+	})
+}
+
+func (h *RecipeHandler) similarRecipes(c *gin.Context) ([]models.RecipeSearchResult, bool) {
+	limit, ok := similarLimit(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return nil, false
+	}
+	recipeId := c.Param("id")
+	results, source, err := h.SimilarRecipes(c.Request.Context(), recipeId, limit, h.requestLocale(c))
+	if err != nil {
+		status, msg := recipeErrorStatus(err, "Failed to find similar recipes")
+		zap.L().Warn("Failed to find similar recipes", zap.String("recipe_id", recipeId), zap.Error(err))
+		c.JSON(status, gin.H{"error": msg})
+		return nil, false
+	}
+	c.Header(SimilarSourceHeader, source)
+	return results, true
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"framework-api/handlers/cachetest"
+	"framework-api/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/elastic/go-elasticsearch/v9"
+	"github.com/gin-gonic/gin"
+)
+
+// newSimilarEngine serves the similar recipes routes with recipes cached in miniredis and Elasticsearch at esAddr.
+func newSimilarEngine(t *testing.T, recipes []models.Recipe, esAddr string) (*gin.Engine, *miniredis.Miniredis) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	mr, redisClient := cachetest.NewRedis(t, recipes)
+	elasticClient, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{esAddr}, MaxRetries: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error creating elastic client: %s", err)
+	}
+	recipeHandler := NewRecipesHandler(context.Background(), nil, redisClient, elasticClient)
+	engine := gin.New()
+	engine.GET("/recipe/:id/similar", recipeHandler.GetSimilarRecipes)
+	engine.GET("/api/v2/recipe/:id/similar", recipeHandler.GetSimilarRecipesV2)
+	return engine, mr
+}
+
+func getSimilar(engine *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestSimilarRecipesFallsBackToLocalRanking(t *testing.T) {
+	recipes := testRecipes()
+	engine, mr := newSimilarEngine(t, recipes, "http://127.0.0.1:1")
+
+	w := getSimilar(engine, "/recipe/"+recipes[0].ID.Hex()+"/similar")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if source := w.Header().Get(SimilarSourceHeader); source != SimilarSourceLocal {
+		t.Errorf("Expected source %s, got %s", SimilarSourceLocal, source)
+	}
+	var results []models.RecipeSearchResult
+	json.Unmarshal(w.Body.Bytes(), &results)
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.Name)
+	}
+	//Greek Salad shares the tomatoes, the other soups only tags
+	if len(names) != 3 || names[0] != "Greek Salad" {
+		t.Errorf("Expected Greek Salad first of 3, got %v", names)
+	}
+	if !mr.Exists(similarLocalCacheKey) || mr.TTL(similarLocalCacheKey) <= 0 {
+		t.Errorf("Expected the local result cached with a TTL")
+	}
+	if mr.Exists(similarCacheKey) {
+		t.Errorf("Expected no elasticsearch cache entry")
+	}
+}
+
+func TestSimilarRecipesUsesElasticsearch(t *testing.T) {
+	recipes := testRecipes()
+	requests := 0
+	var query string
+	es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		body, _ := json.Marshal(req["query"])
+		query = string(body)
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"hits":[{"_source":{"id":"` + recipes[3].ID.Hex() + `","name":"Lentil Soup","tags":["soup","vegan"]}}]}}`))
+	}))
+	defer es.Close()
+	engine, mr := newSimilarEngine(t, recipes, es.URL)
+
+	for i := 0; i < 2; i++ {
+		w := getSimilar(engine, "/api/v2/recipe/"+recipes[0].ID.Hex()+"/similar?limit=3")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if source := w.Header().Get(SimilarSourceHeader); source != SimilarSourceElasticsearch {
+			t.Errorf("Expected source %s, got %s", SimilarSourceElasticsearch, source)
+		}
+		var env Envelope[[]models.RecipeSearchResult]
+		json.Unmarshal(w.Body.Bytes(), &env)
+		if len(env.Data) != 1 || env.Data[0].Name != "Lentil Soup" || env.Meta.Count == nil || *env.Meta.Count != 1 {
+			t.Errorf("Expected Lentil Soup in the envelope, got %s", w.Body.String())
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected the second request served from cache, got %d elasticsearch requests", requests)
+	}
+	if !strings.Contains(query, `"more_like_this"`) || !strings.Contains(query, recipes[0].ID.Hex()) {
+		t.Errorf("Expected a more_like_this query on the recipe, got %s", query)
+	}
+	if !mr.Exists(similarCacheKey) {
+		t.Errorf("Expected the elasticsearch result cached")
+	}
+}
+
+func TestSimilarRecipesRejectsInvalidInput(t *testing.T) {
+	recipes := testRecipes()
+	engine, _ := newSimilarEngine(t, recipes, "http://127.0.0.1:1")
+	ts := []struct {
+		text string
+		path string
+		exp  int
+	}{
+		{text: "invalid id", path: "/recipe/nope/similar", exp: http.StatusBadRequest},
+		{text: "zero limit", path: "/recipe/" + recipes[0].ID.Hex() + "/similar?limit=0", exp: http.StatusBadRequest},
+		{text: "non numeric limit", path: "/recipe/" + recipes[0].ID.Hex() + "/similar?limit=all", exp: http.StatusBadRequest},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if w := getSimilar(engine, tc.path); w.Code != tc.exp {
+			t.Errorf("Expected status %d, got %d", tc.exp, w.Code)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package storetest
+
+import (
+	"context"
+	"framework-api/handlers"
+	"framework-api/models"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AuditStore keeps the audit trail in memory, appended in order. Like MongoAuditStore it stores the tenant of ctx
+// with every entry and only finds the entries of the tenant of ctx.
+type AuditStore struct {
+	mu      sync.Mutex
+	entries []models.AuditEntry
+}
+
+func NewAuditStore() *AuditStore {
+	return &AuditStore{}
+}
+
+func (s *AuditStore) AppendAudit(ctx context.Context, entry models.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.Tenant = tenant(ctx)
+	//Like MongoDB, times are stored with millisecond precision
+	entry.At = entry.At.Truncate(time.Millisecond)
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *AuditStore) FindAudit(ctx context.Context, filter handlers.AuditFilter, limit int64) ([]models.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := make([]models.AuditEntry, 0)
+	for _, entry := range slices.Backward(s.entries) {
+		if int64(len(found)) == limit {
+			break
+		}
+		if entry.Tenant == tenant(ctx) && matches(entry, filter) {
+			found = append(found, entry)
+		}
+	}
+	return found, nil
+}
+
+// Entries returns every entry of every tenant in the order they were appended.
+func (s *AuditStore) Entries() []models.AuditEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.entries)
+}
+
+func matches(entry models.AuditEntry, filter handlers.AuditFilter) bool {
+	for _, f := range []struct{ want, got string }{
+		{filter.Action, entry.Action},
+		{filter.Actor, entry.Actor},
+		{filter.Resource, entry.Resource},
+		{filter.ResourceID, entry.ResourceID},
+		{filter.Outcome, entry.Outcome},
+	} {
+		if f.want != "" && f.want != f.got {
+			return false
+		}
+	}
+	if !filter.From.IsZero() && entry.At.Before(filter.From) {
+		return false
+	}
+	return filter.To.IsZero() || entry.At.Before(filter.To)
+}
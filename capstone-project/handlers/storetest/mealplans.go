@@ -0,0 +1,129 @@
+package storetest
+
+import (
+	"context"
+	"framework-api/handlers"
+	"framework-api/models"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MealPlanStore keeps meal plans in memory. Like MongoMealPlanStore it stores the tenant of ctx with every plan,
+// only finds the plans of the owner in the tenant of ctx and keeps an owner to one plan a week in each tenant.
+type MealPlanStore struct {
+	mu    sync.Mutex
+	plans map[bson.ObjectID]models.MealPlan
+}
+
+func NewMealPlanStore() *MealPlanStore {
+	return &MealPlanStore{plans: make(map[bson.ObjectID]models.MealPlan)}
+}
+
+// owns reports whether plan belongs to owner in the tenant of ctx.
+func owns(ctx context.Context, plan models.MealPlan, owner string) bool {
+	return plan.Owner == owner && plan.Tenant == tenant(ctx)
+}
+
+func (s *MealPlanStore) weekTaken(plan models.MealPlan) bool {
+	for _, other := range s.plans {
+		if other.ID != plan.ID && other.Owner == plan.Owner && other.Tenant == plan.Tenant && other.WeekStart == plan.WeekStart {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MealPlanStore) CreateMealPlan(ctx context.Context, plan models.MealPlan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plan.Tenant = tenant(ctx)
+	if s.weekTaken(plan) {
+		return handlers.ErrMealPlanExists
+	}
+	s.plans[plan.ID] = plan
+	return nil
+}
+
+func (s *MealPlanStore) ListMealPlans(ctx context.Context, owner string) ([]models.MealPlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]models.MealPlan, 0)
+	for _, plan := range s.plans {
+		if owns(ctx, plan, owner) {
+			list = append(list, plan)
+		}
+	}
+	slices.SortFunc(list, func(a, b models.MealPlan) int { return strings.Compare(b.WeekStart, a.WeekStart) })
+	return list, nil
+}
+
+func (s *MealPlanStore) GetMealPlan(ctx context.Context, owner string, id bson.ObjectID) (models.MealPlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plan, ok := s.plans[id]
+	if !ok || !owns(ctx, plan, owner) {
+		return models.MealPlan{}, handlers.ErrMealPlanNotFound
+	}
+	return plan, nil
+}
+
+func (s *MealPlanStore) GetMealPlanByWeek(ctx context.Context, owner, weekStart string) (models.MealPlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, plan := range s.plans {
+		if owns(ctx, plan, owner) && plan.WeekStart == weekStart {
+			return plan, nil
+		}
+	}
+	return models.MealPlan{}, handlers.ErrMealPlanNotFound
+}
+
+func (s *MealPlanStore) ReplaceMealPlan(ctx context.Context, plan models.MealPlan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plan.Tenant = tenant(ctx)
+	if existing, ok := s.plans[plan.ID]; !ok || !owns(ctx, existing, plan.Owner) {
+		return handlers.ErrMealPlanNotFound
+	}
+	if s.weekTaken(plan) {
+		return handlers.ErrMealPlanExists
+	}
+	s.plans[plan.ID] = plan
+	return nil
+}
+
+func (s *MealPlanStore) DeleteMealPlan(ctx context.Context, owner string, id bson.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if plan, ok := s.plans[id]; !ok || !owns(ctx, plan, owner) {
+		return handlers.ErrMealPlanNotFound
+	}
+	delete(s.plans, id)
+	return nil
+}
+
+func (s *MealPlanStore) FlagDeletedRecipe(ctx context.Context, recipeID bson.ObjectID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flagged := int64(0)
+	for id, plan := range s.plans {
+		if plan.Tenant != tenant(ctx) {
+			continue
+		}
+		changed := false
+		for i := range plan.Slots {
+			if plan.Slots[i].RecipeID == recipeID && !plan.Slots[i].RecipeDeleted {
+				plan.Slots[i].RecipeDeleted = true
+				changed = true
+			}
+		}
+		if changed {
+			s.plans[id] = plan
+			flagged++
+		}
+	}
+	return flagged, nil
+}
@@ -0,0 +1,244 @@
+// Package storetest provides in-memory stores, so the handlers can be tested end to end without MongoDB.
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"framework-api/handlers"
+	"framework-api/models"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// RecipeStore keeps recipes as BSON documents in insertion order, like a MongoDB collection without indexes:
+// reads decode a copy, times are stored with millisecond precision and updates set fields by their bson keys,
+// "translations.fr" included. Like MongoRecipeStore every method only sees the recipes of the tenant of ctx.
+type RecipeStore struct {
+	mu      sync.Mutex
+	ids     []bson.ObjectID
+	docs    map[bson.ObjectID]bson.Raw
+	tenants map[bson.ObjectID]string
+	calls   int
+}
+
+// NewRecipeStore returns a store holding recipes in the default tenant.
+func NewRecipeStore(recipes ...models.Recipe) *RecipeStore {
+	s := &RecipeStore{docs: make(map[bson.ObjectID]bson.Raw), tenants: make(map[bson.ObjectID]string)}
+	for _, recipe := range recipes {
+		if err := s.ReplaceRecipe(context.Background(), recipe); err != nil {
+			panic(err)
+		}
+	}
+	s.calls = 0
+	return s
+}
+
+// Calls returns the number of store calls since the store was created, to check what the cache saved.
+func (s *RecipeStore) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *RecipeStore) ListRecipes(ctx context.Context) ([]models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return s.decode(s.visible(ctx))
+}
+
+func (s *RecipeStore) LatestRecipes(ctx context.Context, limit int64) ([]models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	recipes, err := s.decode(s.visible(ctx))
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(recipes, func(a, b models.Recipe) int { return b.PublishedAt.Compare(a.PublishedAt) })
+	if int64(len(recipes)) > limit {
+		recipes = recipes[:limit]
+	}
+	//Only the projected fields
+	for i, recipe := range recipes {
+		recipes[i] = models.Recipe{ID: recipe.ID, Name: recipe.Name, PublishedAt: recipe.PublishedAt, Locale: recipe.Locale, Translations: recipe.Translations}
+	}
+	return recipes, nil
+}
+
+func (s *RecipeStore) GetRecipe(ctx context.Context, id bson.ObjectID) (models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if !s.owns(ctx, id) {
+		return models.Recipe{}, handlers.ErrRecipeNotFound
+	}
+	recipes, err := s.decode([]bson.ObjectID{id})
+	if err != nil {
+		return models.Recipe{}, err
+	}
+	return recipes[0], nil
+}
+
+func (s *RecipeStore) GetRecipes(ctx context.Context, ids []bson.ObjectID) ([]models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	found := make([]bson.ObjectID, 0, len(ids))
+	for _, id := range s.visible(ctx) {
+		if slices.Contains(ids, id) {
+			found = append(found, id)
+		}
+	}
+	return s.decode(found)
+}
+
+func (s *RecipeStore) InsertRecipe(ctx context.Context, recipe models.Recipe) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if _, ok := s.docs[recipe.ID]; ok {
+		return fmt.Errorf("E11000 duplicate key error, _id %s", recipe.ID.Hex())
+	}
+	return s.put(ctx, recipe)
+}
+
+func (s *RecipeStore) UpdateRecipe(ctx context.Context, id bson.ObjectID, fields bson.M) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if !s.owns(ctx, id) {
+		return handlers.ErrRecipeNotFound
+	}
+	doc := bson.M{}
+	if err := bson.Unmarshal(s.docs[id], &doc); err != nil {
+		return err
+	}
+	for key, val := range fields {
+		if key != "tenant" {
+			set(doc, strings.Split(key, "."), val)
+		}
+	}
+	updated, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	//Fail like MongoDB would on the next read of a field with the wrong type
+	var recipe models.Recipe
+	if err := bson.Unmarshal(updated, &recipe); err != nil {
+		return err
+	}
+	s.docs[id] = updated
+	return nil
+}
+
+func (s *RecipeStore) DeleteRecipe(ctx context.Context, id bson.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if !s.owns(ctx, id) {
+		return handlers.ErrRecipeNotFound
+	}
+	s.remove(id)
+	return nil
+}
+
+func (s *RecipeStore) ReplaceRecipe(ctx context.Context, recipe models.Recipe) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if _, ok := s.docs[recipe.ID]; ok && !s.owns(ctx, recipe.ID) {
+		return fmt.Errorf("E11000 duplicate key error, _id %s", recipe.ID.Hex())
+	}
+	return s.put(ctx, recipe)
+}
+
+func (s *RecipeStore) DeleteAllRecipes(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	ids := s.visible(ctx)
+	for _, id := range ids {
+		s.remove(id)
+	}
+	return int64(len(ids)), nil
+}
+
+func (s *RecipeStore) put(ctx context.Context, recipe models.Recipe) error {
+	recipe.Tenant = tenant(ctx)
+	raw, err := bson.Marshal(recipe)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.docs[recipe.ID]; !ok {
+		s.ids = append(s.ids, recipe.ID)
+	}
+	s.docs[recipe.ID] = raw
+	s.tenants[recipe.ID] = recipe.Tenant
+	return nil
+}
+
+func (s *RecipeStore) remove(id bson.ObjectID) {
+	delete(s.docs, id)
+	delete(s.tenants, id)
+	s.ids = slices.DeleteFunc(s.ids, func(other bson.ObjectID) bool { return other == id })
+}
+
+// owns reports whether the recipe with id exists in the tenant of ctx.
+func (s *RecipeStore) owns(ctx context.Context, id bson.ObjectID) bool {
+	_, ok := s.docs[id]
+	return ok && s.tenants[id] == tenant(ctx)
+}
+
+// visible returns the IDs of the recipes of the tenant of ctx in insertion order.
+func (s *RecipeStore) visible(ctx context.Context) []bson.ObjectID {
+	ids := make([]bson.ObjectID, 0, len(s.ids))
+	for _, id := range s.ids {
+		if s.tenants[id] == tenant(ctx) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// tenant is the tenant field of the recipes stored in ctx, empty for the default tenant.
+func tenant(ctx context.Context) string {
+	if t := handlers.TenantFromContext(ctx); t != handlers.DefaultTenant {
+		return t
+	}
+	return ""
+}
+
+func (s *RecipeStore) decode(ids []bson.ObjectID) ([]models.Recipe, error) {
+	recipes := make([]models.Recipe, 0, len(ids))
+	for _, id := range ids {
+		var recipe models.Recipe
+		if err := bson.Unmarshal(s.docs[id], &recipe); err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, nil
+}
+
+// set sets the value at path in doc, creating the embedded documents on the way like $set.
+func set(doc bson.M, path []string, val interface{}) {
+	if len(path) == 1 {
+		doc[path[0]] = val
+		return
+	}
+	next, ok := doc[path[0]].(bson.M)
+	if !ok {
+		next = bson.M{}
+		if d, isD := doc[path[0]].(bson.D); isD {
+			for _, e := range d {
+				next[e.Key] = e.Value
+			}
+		}
+		doc[path[0]] = next
+	}
+	set(next, path[1:], val)
+}
@@ -0,0 +1,44 @@
+package storetest
+
+import (
+	"context"
+	"framework-api/models"
+	"slices"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ReviewStore keeps reviews in memory and counts the calls, to check the batching. Like MongoReviewStore it only
+// finds the reviews of the tenant of ctx, the Tenant of a review names its tenant, empty for the default one.
+type ReviewStore struct {
+	mu      sync.Mutex
+	reviews []models.Review
+	calls   int
+}
+
+func NewReviewStore(reviews ...models.Review) *ReviewStore {
+	return &ReviewStore{reviews: slices.Clone(reviews)}
+}
+
+// Calls returns the number of store calls since the store was created.
+func (s *ReviewStore) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *ReviewStore) ReviewsOf(ctx context.Context, recipeIDs []bson.ObjectID) (map[bson.ObjectID][]models.Review, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	reviews := slices.Clone(s.reviews)
+	slices.SortStableFunc(reviews, func(a, b models.Review) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	byRecipe := make(map[bson.ObjectID][]models.Review)
+	for _, review := range reviews {
+		if slices.Contains(recipeIDs, review.RecipeID) && review.Tenant == tenant(ctx) {
+			byRecipe[review.RecipeID] = append(byRecipe[review.RecipeID], review)
+		}
+	}
+	return byRecipe, nil
+}
@@ -0,0 +1,136 @@
+package storetest
+
+import (
+	"context"
+	"framework-api/handlers"
+	"framework-api/models"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// WebhookStore keeps webhooks and their deliveries in memory. Like MongoWebhookStore it stores the tenant of ctx
+// with every webhook and only finds the webhooks of the tenant of ctx, deliveries are found by their IDs.
+type WebhookStore struct {
+	mu         sync.Mutex
+	webhooks   []models.Webhook
+	deliveries map[bson.ObjectID]models.WebhookDelivery
+}
+
+func NewWebhookStore() *WebhookStore {
+	return &WebhookStore{deliveries: make(map[bson.ObjectID]models.WebhookDelivery)}
+}
+
+func (s *WebhookStore) CreateWebhook(ctx context.Context, webhook models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhook.Tenant = tenant(ctx)
+	s.webhooks = append(s.webhooks, webhook)
+	return nil
+}
+
+func (s *WebhookStore) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]models.Webhook, 0)
+	for _, webhook := range s.webhooks {
+		if webhook.Tenant == tenant(ctx) {
+			list = append(list, webhook)
+		}
+	}
+	return list, nil
+}
+
+func (s *WebhookStore) GetWebhook(ctx context.Context, id bson.ObjectID) (models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.index(ctx, id); i >= 0 {
+		return s.webhooks[i], nil
+	}
+	return models.Webhook{}, handlers.ErrWebhookNotFound
+}
+
+func (s *WebhookStore) DeleteWebhook(ctx context.Context, id bson.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(ctx, id)
+	if i < 0 {
+		return handlers.ErrWebhookNotFound
+	}
+	s.webhooks = slices.Delete(s.webhooks, i, i+1)
+	return nil
+}
+
+func (s *WebhookStore) WebhooksFor(ctx context.Context, event string) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]models.Webhook, 0)
+	for _, webhook := range s.webhooks {
+		if webhook.Tenant == tenant(ctx) && webhook.Active && slices.Contains(webhook.Events, event) {
+			list = append(list, webhook)
+		}
+	}
+	return list, nil
+}
+
+func (s *WebhookStore) SaveDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (s *WebhookStore) GetDelivery(ctx context.Context, id bson.ObjectID) (models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return delivery, handlers.ErrDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+func (s *WebhookStore) ListDeliveries(ctx context.Context, webhookID bson.ObjectID, limit int64) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]models.WebhookDelivery, 0)
+	for _, delivery := range s.deliveries {
+		if delivery.WebhookID == webhookID {
+			list = append(list, delivery)
+		}
+	}
+	slices.SortFunc(list, func(a, b models.WebhookDelivery) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return list[:min(int64(len(list)), limit)], nil
+}
+
+func (s *WebhookStore) ClaimDueDelivery(ctx context.Context, now time.Time, lease time.Duration) (models.WebhookDelivery, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, delivery := range s.deliveries {
+		if delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(now) && !delivery.LockedUntil.After(now) {
+			delivery.LockedUntil = now.Add(lease)
+			s.deliveries[id] = delivery
+			return delivery, true, nil
+		}
+	}
+	return models.WebhookDelivery{}, false, nil
+}
+
+// Deliveries returns the deliveries of every webhook of every tenant, in no particular order.
+func (s *WebhookStore) Deliveries() []models.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]models.WebhookDelivery, 0, len(s.deliveries))
+	for _, delivery := range s.deliveries {
+		list = append(list, delivery)
+	}
+	return list
+}
+
+func (s *WebhookStore) index(ctx context.Context, id bson.ObjectID) int {
+	return slices.IndexFunc(s.webhooks, func(webhook models.Webhook) bool {
+		return webhook.ID == id && webhook.Tenant == tenant(ctx)
+	})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// TenantHeader selects the kitchen (tenant) of a request. Members of a tenant get theirs from the token.
+const TenantHeader = "X-Tenant-ID"
+
+// DefaultTenant is the shared namespace of the recipes stored before tenants existed: its recipes have no tenant
+// field, its Redis keys no prefix and its Elasticsearch index is "recipe". It stays public, like the API was.
+const DefaultTenant = "default"
+
+var (
+	ErrInvalidTenant      = errors.New("invalid tenant id")
+	ErrTenantUnauthorized = errors.New("tenant requires authentication")
+	ErrTenantForbidden    = errors.New("not a member of the tenant")
+)
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,31}$`)
+
+// ValidTenant reports whether id can name a tenant: 2 to 32 lowercase letters, digits and dashes, not starting
+// with a dash. The IDs end up in Redis keys and Elasticsearch index names.
+func ValidTenant(id string) bool {
+	return tenantPattern.MatchString(id)
+}
+
+// TenantAdminGroup is the group of the admins of one tenant, like "admin:bistro". They have the rights of the
+// admin group, only in their tenant.
+func TenantAdminGroup(tenant string) string {
+	return GroupAdmin + ":" + tenant
+}
+
+// IsTenantAdmin reports whether p administers tenant. Members of the admin group administer every tenant unless
+// their token binds them to a tenant, then only the admin:<tenant> group counts.
+func (p *Principal) IsTenantAdmin(tenant string) bool {
+	return (p.Tenant == "" && p.InGroup(GroupAdmin)) || p.InGroup(TenantAdminGroup(tenant))
+}
+
+// MemberOf reports whether p may act in tenant. Principals without a tenant claim belong to the default tenant.
+func (p *Principal) MemberOf(tenant string) bool {
+	if p.IsTenantAdmin(tenant) {
+		return true
+	}
+	if p.Tenant == "" {
+		return tenant == DefaultTenant
+	}
+	return p.Tenant == tenant
+}
+
+// ResolveTenant picks the tenant of a request from the caller, nil when anonymous, and the requested tenant of the
+// X-Tenant-ID header. Without a header members get the tenant of their token and everyone else the default one.
+// Every tenant but the default one is private: it needs a principal that is a member or an admin of it.
+func ResolveTenant(p *Principal, requested string) (string, error) {
+	if requested != "" && !ValidTenant(requested) {
+		return "", ErrInvalidTenant
+	}
+	tenant := requested
+	if tenant == "" {
+		tenant = DefaultTenant
+		if p != nil && p.Tenant != "" {
+			tenant = p.Tenant
+		}
+	}
+	if tenant == DefaultTenant {
+		return tenant, nil
+	}
+	if p == nil {
+		return "", ErrTenantUnauthorized
+	}
+	if !p.MemberOf(tenant) {
+		return "", ErrTenantForbidden
+	}
+	return tenant, nil
+}
+
+// TenantMiddleware resolves the tenant of every request, see ResolveTenant, and stores it in the gin and request
+// contexts. Requests with valid credentials are authenticated here already, AuthMiddleware reuses the principal.
+// Invalid credentials are left to the routes: public ones ignore them, protected ones answer 401.
+func (h *AuthHandler) TenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := h.Authenticate(c.Request)
+		if err == nil {
+			SetPrincipal(c, principal)
+		} else {
+			principal = nil
+		}
+		tenant, err := ResolveTenant(principal, c.GetHeader(TenantHeader))
+		if err != nil {
+			zap.L().Warn("Tenant rejected", zap.String("tenant", c.GetHeader(TenantHeader)), zap.Error(err))
+			c.AbortWithStatusJSON(tenantErrorStatus(err), gin.H{"error": tenantErrorMessage(err)})
+			return
+		}
+		SetTenant(c, tenant)
+		if principal != nil {
+			h.RecordAuthSuccess(c.Request.Context(), principal)
+		}
+		c.Next()
+	}
+}
+
+// RequireTenantAdmin allows the request if the principal is an admin of the request's tenant.
+func RequireTenantAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			return
+		}
+		if tenant := GetTenant(c); !p.IsTenantAdmin(tenant) {
+			zap.L().Warn("Missing tenant admin group", zap.String("user_id", p.Subject), zap.String("tenant", tenant))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func tenantErrorStatus(err error) int {
+	switch err {
+	case ErrTenantUnauthorized:
+		return http.StatusUnauthorized
+	case ErrTenantForbidden:
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func tenantErrorMessage(err error) string {
+	switch err {
+	case ErrTenantUnauthorized:
+		return "Authentication required for this tenant"
+	case ErrTenantForbidden:
+		return "Not a member of this tenant"
+	default:
+		return "Invalid " + TenantHeader
+	}
+}
+
+// SetTenant stores the tenant in the request context, the data access reads it from there.
+func SetTenant(c *gin.Context, tenant string) {
+	c.Request = c.Request.WithContext(ContextWithTenant(c.Request.Context(), tenant))
+}
+
+// GetTenant returns the tenant stored by TenantMiddleware, the default tenant when there is none.
+func GetTenant(c *gin.Context) string {
+	return TenantFromContext(c.Request.Context())
+}
+
+type tenantContextKey struct{}
+
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored by ContextWithTenant. Contexts without one, like the startup
+// context, are in the default tenant.
+func TenantFromContext(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantContextKey{}).(string); ok && tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
+
+// TenantKey prefixes a Redis key with the tenant of ctx, "tenant:<id>:recipes". Keys of the default tenant
+// are unchanged.
+func TenantKey(ctx context.Context, key string) string {
+	if tenant := TenantFromContext(ctx); tenant != DefaultTenant {
+		return "tenant:" + tenant + ":" + key
+	}
+	return key
+}
+
+// RecipeIndex is the Elasticsearch index of the tenant's recipes, "recipe" for the default tenant and
+// "recipe-<id>" for the others.
+func RecipeIndex(tenant string) string {
+	if tenant == DefaultTenant {
+		return "recipe"
+	}
+	return "recipe-" + tenant
+}
+
+// tenantFilter is the part of every MongoDB filter that keeps a query inside the tenant of ctx. Documents of the
+// default tenant have no tenant field, so documents written by older code stay in it; null matches a missing field.
+func tenantFilter(ctx context.Context) bson.M {
+	if tenant := tenantField(ctx); tenant != "" {
+		return bson.M{"tenant": tenant}
+	}
+	return bson.M{"tenant": nil}
+}
+
+// byID filters one document of the tenant of ctx.
+func byID(ctx context.Context, id bson.ObjectID) bson.M {
+	filter := tenantFilter(ctx)
+	filter["_id"] = id
+	return filter
+}
+
+// tenantField is the tenant field of the documents written in ctx, empty (omitted) for the default tenant.
+func tenantField(ctx context.Context) string {
+	if tenant := TenantFromContext(ctx); tenant != DefaultTenant {
+		return tenant
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"framework-api/handlers/authtest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestResolveTenant(t *testing.T) {
+	member := &Principal{Subject: "u", Tenant: "bistro"}
+	ts := []struct {
+		text      string
+		principal *Principal
+		requested string
+		exp       string
+		err       error
+	}{
+		{text: "anonymous without header", exp: DefaultTenant},
+		{text: "anonymous in the default tenant", requested: DefaultTenant, exp: DefaultTenant},
+		{text: "anonymous in another tenant", requested: "bistro", err: ErrTenantUnauthorized},
+		{text: "member without header", principal: member, exp: "bistro"},
+		{text: "member in their tenant", principal: member, requested: "bistro", exp: "bistro"},
+		{text: "member in another tenant", principal: member, requested: "diner", err: ErrTenantForbidden},
+		{text: "member in the default tenant", principal: member, requested: DefaultTenant, exp: DefaultTenant},
+		{text: "user without tenant claim", principal: &Principal{Subject: "u"}, requested: "bistro", err: ErrTenantForbidden},
+		{text: "tenant admin", principal: &Principal{Subject: "u", Groups: []string{"admin:diner"}}, requested: "diner", exp: "diner"},
+		{text: "global admin", principal: &Principal{Subject: "u", Groups: []string{GroupAdmin}}, requested: "diner", exp: "diner"},
+		{text: "admin bound to another tenant", principal: &Principal{Subject: "u", Tenant: "bistro", Groups: []string{GroupAdmin}}, requested: "diner", err: ErrTenantForbidden},
+		{text: "uppercase", requested: "Bistro", err: ErrInvalidTenant},
+		{text: "leading dash", requested: "-bistro", err: ErrInvalidTenant},
+		{text: "key separator", requested: "bistro:recipes", err: ErrInvalidTenant},
+		{text: "too short", requested: "b", err: ErrInvalidTenant},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		tenant, err := ResolveTenant(tc.principal, tc.requested)
+		if err != tc.err {
+			t.Errorf("Expected error %v, got %v", tc.err, err)
+			continue
+		}
+		if tenant != tc.exp {
+			t.Errorf("Expected tenant %q, got %q", tc.exp, tenant)
+		}
+	}
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	signer, err := authtest.NewSigner(testIssuer, testClientID)
+	if err != nil {
+		t.Fatalf("Unexpected error creating signer: %s", err)
+	}
+	keys, err := NewLocalKeyProvider(signer.PublicKeys())
+	if err != nil {
+		t.Fatalf("Unexpected error creating key provider: %s", err)
+	}
+	cognito, err := NewCognitoAuthenticator(testIssuer, testClientID, keys, 30*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error creating authenticator: %s", err)
+	}
+	sign := func(tenant string, groups ...string) string {
+		claims := signer.Claims("user-1", time.Minute)
+		if tenant != "" {
+			claims["custom:tenant"] = tenant
+		}
+		if len(groups) > 0 {
+			claims["cognito:groups"] = groups
+		}
+		token, err := signer.Sign(claims)
+		if err != nil {
+			t.Fatalf("Unexpected error signing token: %s", err)
+		}
+		return token
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	authHandler := NewAuthHandler(cognito)
+	engine.Use(authHandler.TenantMiddleware())
+	engine.GET("/tenant", func(c *gin.Context) {
+		c.String(http.StatusOK, GetTenant(c))
+	})
+	engine.DELETE("/tenant", authHandler.AuthMiddleware(), RequireTenantAdmin(), func(c *gin.Context) {
+		c.String(http.StatusOK, GetTenant(c))
+	})
+
+	ts := []struct {
+		text   string
+		method string
+		token  string
+		header string
+		exp    int
+		tenant string
+	}{
+		{text: "anonymous", method: http.MethodGet, exp: http.StatusOK, tenant: DefaultTenant},
+		{text: "invalid token in the default tenant", method: http.MethodGet, token: "garbage", exp: http.StatusOK, tenant: DefaultTenant},
+		{text: "member from the token", method: http.MethodGet, token: sign("bistro"), exp: http.StatusOK, tenant: "bistro"},
+		{text: "member with the header", method: http.MethodGet, token: sign("bistro"), header: "bistro", exp: http.StatusOK, tenant: "bistro"},
+		{text: "member of another tenant", method: http.MethodGet, token: sign("diner"), header: "bistro", exp: http.StatusForbidden},
+		{text: "anonymous in a tenant", method: http.MethodGet, header: "bistro", exp: http.StatusUnauthorized},
+		{text: "invalid header", method: http.MethodGet, header: "Bistro!", exp: http.StatusBadRequest},
+		{text: "member deleting", method: http.MethodDelete, token: sign("bistro"), exp: http.StatusForbidden},
+		{text: "tenant admin deleting", method: http.MethodDelete, token: sign("bistro", "admin:bistro"), exp: http.StatusOK, tenant: "bistro"},
+		{text: "tenant admin deleting in the default tenant", method: http.MethodDelete, token: sign("bistro", "admin:bistro"), header: DefaultTenant, exp: http.StatusForbidden},
+		{text: "admin deleting in any tenant", method: http.MethodDelete, token: sign("", GroupAdmin), header: "diner", exp: http.StatusOK, tenant: "diner"},
+		{text: "admin of a tenant deleting in another tenant", method: http.MethodDelete, token: sign("bistro", GroupAdmin), header: "diner", exp: http.StatusForbidden},
+		{text: "admin of a tenant deleting in the default tenant", method: http.MethodDelete, token: sign("bistro", GroupAdmin), header: DefaultTenant, exp: http.StatusForbidden},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		req := httptest.NewRequest(tc.method, "/tenant", nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		if tc.header != "" {
+			req.Header.Set(TenantHeader, tc.header)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tc.exp {
+			t.Errorf("Expected status %d, got %d: %s", tc.exp, w.Code, w.Body.String())
+			continue
+		}
+		if tc.tenant != "" && w.Body.String() != tc.tenant {
+			t.Errorf("Expected tenant %q, got %q", tc.tenant, w.Body.String())
+		}
+	}
+}
+
+func TestTenantScoping(t *testing.T) {
+	id := bson.NewObjectID()
+	ts := []struct {
+		text   string
+		ctx    context.Context
+		key    string
+		index  string
+		filter bson.M
+	}{
+		{text: "context without tenant", ctx: context.Background(), key: "recipes", index: "recipe", filter: bson.M{"tenant": nil, "_id": id}},
+		{text: "default tenant", ctx: ContextWithTenant(context.Background(), DefaultTenant), key: "recipes", index: "recipe", filter: bson.M{"tenant": nil, "_id": id}},
+		{text: "other tenant", ctx: ContextWithTenant(context.Background(), "bistro"), key: "tenant:bistro:recipes", index: "recipe-bistro", filter: bson.M{"tenant": "bistro", "_id": id}},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if got := TenantKey(tc.ctx, "recipes"); got != tc.key {
+			t.Errorf("Expected key %s, got %s", tc.key, got)
+		}
+		if got := RecipeIndex(TenantFromContext(tc.ctx)); got != tc.index {
+			t.Errorf("Expected index %s, got %s", tc.index, got)
+		}
+		if got := byID(tc.ctx, id); len(got) != 2 || got["tenant"] != tc.filter["tenant"] || got["_id"] != id {
+			t.Errorf("Expected filter %v, got %v", tc.filter, got)
+		}
+	}
+}
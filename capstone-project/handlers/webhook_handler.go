@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"framework-api/models"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// WebhookRequest registers a webhook. A secret is generated when none is given.
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+	Secret string   `json:"secret"`
+}
+
+// WebhookCreated is the only response that includes the signing secret.
+type WebhookCreated struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookHandler serves the admin endpoints managing webhooks and their delivery log.
+type WebhookHandler struct {
+	store      WebhookStore
+	dispatcher *WebhookDispatcher
+	audit      *AuditLog
+}
+
+func NewWebhookHandler(store WebhookStore, dispatcher *WebhookDispatcher) *WebhookHandler {
+	return &WebhookHandler{store: store, dispatcher: dispatcher}
+}
+
+// SetAuditLog records the webhook changes and redeliveries in audit.
+func (h *WebhookHandler) SetAuditLog(audit *AuditLog) {
+	h.audit = audit
+}
+
+// CreateWebhook registers a webhook for the given event types.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url must be an absolute http or https URL"})
+		return
+	}
+	if !h.dispatcher.allowsURL(target) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url must not point to a private, loopback or link-local address"})
+		return
+	}
+	if len(req.Events) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "events must not be empty"})
+		return
+	}
+	for _, event := range req.Events {
+		if !slices.Contains(WebhookEvents, event) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event " + event})
+			return
+		}
+	}
+	if req.Secret == "" {
+		req.Secret = newWebhookSecret()
+	}
+	webhook := models.Webhook{
+		ID:        bson.NewObjectID(),
+		URL:       req.URL,
+		Events:    slices.Compact(slices.Sorted(slices.Values(req.Events))),
+		Secret:    req.Secret,
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+	if err := h.store.CreateWebhook(c.Request.Context(), webhook); err != nil {
+		zap.L().Error("Failed to create webhook", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+	zap.L().Info("Webhook created", zap.String("webhook_id", webhook.ID.Hex()), zap.String("url", webhook.URL))
+	h.audit.Record(c.Request.Context(), models.AuditEntry{Action: models.AuditWebhookCreate, Resource: "webhook", ResourceID: webhook.ID.Hex(), AfterHash: models.AuditHash(webhook), Detail: webhook.URL})
+	c.JSON(http.StatusCreated, WebhookCreated{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// ListWebhooks returns every webhook without its secret.
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.store.ListWebhooks(c.Request.Context())
+	if err != nil {
+		zap.L().Error("Failed to list webhooks", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhooks"})
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook returns one webhook without its secret.
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := objectIDParam(c, "id")
+	if !ok {
+		return
+	}
+	webhook, err := h.store.GetWebhook(c.Request.Context(), id)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook stops deliveries to a webhook, pending retries are dropped.
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := objectIDParam(c, "id")
+	if !ok {
+		return
+	}
+	webhook, err := h.store.GetWebhook(c.Request.Context(), id)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	if err := h.store.DeleteWebhook(c.Request.Context(), id); err != nil {
+		webhookError(c, err)
+		return
+	}
+	h.audit.Record(c.Request.Context(), models.AuditEntry{Action: models.AuditWebhookDelete, Resource: "webhook", ResourceID: id.Hex(), BeforeHash: models.AuditHash(webhook), Detail: webhook.URL})
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// ListDeliveries returns the delivery log of a webhook, newest first.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := objectIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, err := h.store.GetWebhook(c.Request.Context(), id); err != nil {
+		webhookError(c, err)
+		return
+	}
+	limit, _, ok := ParsePageQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	deliveries, err := h.store.ListDeliveries(c.Request.Context(), id, int64(limit))
+	if err != nil {
+		zap.L().Error("Failed to list webhook deliveries", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list deliveries"})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RedeliverDelivery queues the payload of a delivery again, e.g. after the receiver fixed an outage.
+func (h *WebhookHandler) RedeliverDelivery(c *gin.Context) {
+	webhookID, ok := objectIDParam(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := objectIDParam(c, "deliveryId")
+	if !ok {
+		return
+	}
+	if _, err := h.store.GetWebhook(c.Request.Context(), webhookID); err != nil {
+		webhookError(c, err)
+		return
+	}
+	original, err := h.store.GetDelivery(c.Request.Context(), deliveryID)
+	if err != nil || original.WebhookID != webhookID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	delivery, err := h.dispatcher.Redeliver(c.Request.Context(), deliveryID)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	h.audit.Record(c.Request.Context(), models.AuditEntry{Action: models.AuditWebhookRedeliver, Resource: "webhook", ResourceID: webhookID.Hex(), Detail: "delivery " + deliveryID.Hex() + " queued again as " + delivery.ID.Hex()})
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func objectIDParam(c *gin.Context, name string) (bson.ObjectID, bool) {
+	id, err := bson.ObjectIDFromHex(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return id, false
+	}
+	return id, true
+}
+
+func webhookError(c *gin.Context, err error) {
+	switch err {
+	case ErrWebhookNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	case ErrDeliveryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+	default:
+		zap.L().Error("Webhook store failure", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to access webhooks"})
+	}
+}
+
+func newWebhookSecret() string {
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	return "whsec_" + hex.EncodeToString(buf)
+}
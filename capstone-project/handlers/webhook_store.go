@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"framework-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookStore persists the subscriptions and the delivery log. Webhooks belong to the tenant of the context they
+// are created in and are only found in it; deliveries are found by ID and carry the tenant of their webhook.
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, webhook models.Webhook) error
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, id bson.ObjectID) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id bson.ObjectID) error
+	// WebhooksFor returns the active webhooks subscribed to event.
+	WebhooksFor(ctx context.Context, event string) ([]models.Webhook, error)
+
+	// SaveDelivery inserts or replaces a delivery.
+	SaveDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id bson.ObjectID) (models.WebhookDelivery, error)
+	// ListDeliveries returns the latest deliveries of a webhook, newest first.
+	ListDeliveries(ctx context.Context, webhookID bson.ObjectID, limit int64) ([]models.WebhookDelivery, error)
+	// ClaimDueDelivery locks one pending delivery whose next attempt is due until now+lease,
+	// so only one server instance sends it. ok is false when nothing is due.
+	ClaimDueDelivery(ctx context.Context, now time.Time, lease time.Duration) (models.WebhookDelivery, bool, error)
+}
+
+// MongoWebhookStore keeps webhooks and deliveries in two MongoDB collections.
+type MongoWebhookStore struct {
+	webhooks   *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+func NewMongoWebhookStore(db *mongo.Database) *MongoWebhookStore {
+	return &MongoWebhookStore{
+		webhooks:   db.Collection("webhooks"),
+		deliveries: db.Collection("webhookDeliveries"),
+	}
+}
+
+func (s *MongoWebhookStore) CreateWebhook(ctx context.Context, webhook models.Webhook) error {
+	webhook.Tenant = tenantField(ctx)
+	_, err := s.webhooks.InsertOne(ctx, webhook)
+	return err
+}
+
+func (s *MongoWebhookStore) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	return findAll[models.Webhook](ctx, s.webhooks, tenantFilter(ctx), options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+}
+
+func (s *MongoWebhookStore) GetWebhook(ctx context.Context, id bson.ObjectID) (models.Webhook, error) {
+	var webhook models.Webhook
+	err := s.webhooks.FindOne(ctx, byID(ctx, id)).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return webhook, ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+func (s *MongoWebhookStore) DeleteWebhook(ctx context.Context, id bson.ObjectID) error {
+	res, err := s.webhooks.DeleteOne(ctx, byID(ctx, id))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *MongoWebhookStore) WebhooksFor(ctx context.Context, event string) ([]models.Webhook, error) {
+	filter := tenantFilter(ctx)
+	filter["active"] = true
+	filter["events"] = event
+	return findAll[models.Webhook](ctx, s.webhooks, filter, options.Find())
+}
+
+func (s *MongoWebhookStore) SaveDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	_, err := s.deliveries.ReplaceOne(ctx, bson.M{"_id": delivery.ID}, delivery, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *MongoWebhookStore) GetDelivery(ctx context.Context, id bson.ObjectID) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := s.deliveries.FindOne(ctx, bson.M{"_id": id}).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return delivery, ErrDeliveryNotFound
+	}
+	return delivery, err
+}
+
+func (s *MongoWebhookStore) ListDeliveries(ctx context.Context, webhookID bson.ObjectID, limit int64) ([]models.WebhookDelivery, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(limit)
+	return findAll[models.WebhookDelivery](ctx, s.deliveries, bson.M{"webhookId": webhookID}, opts)
+}
+
+func (s *MongoWebhookStore) ClaimDueDelivery(ctx context.Context, now time.Time, lease time.Duration) (models.WebhookDelivery, bool, error) {
+	var delivery models.WebhookDelivery
+	filter := bson.M{
+		"status":        models.DeliveryPending,
+		"nextAttemptAt": bson.M{"$lte": now},
+		"lockedUntil":   bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"lockedUntil": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetReturnDocument(options.After)
+	err := s.deliveries.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return delivery, false, nil
+	}
+	if err != nil {
+		return delivery, false, err
+	}
+	return delivery, true, nil
+}
+
+func findAll[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, opts *options.FindOptionsBuilder) ([]T, error) {
+	cur, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	items := make([]T, 0)
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"framework-api/models"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// Webhook event types, the recipe event type prefixed with "recipe.".
+const (
+	WebhookRecipeCreated = "recipe." + EventRecipeCreated
+	WebhookRecipeUpdated = "recipe." + EventRecipeUpdated
+	WebhookRecipeDeleted = "recipe." + EventRecipeDeleted
+)
+
+// WebhookEvents are the event types a webhook can subscribe to.
+var WebhookEvents = []string{WebhookRecipeCreated, WebhookRecipeUpdated, WebhookRecipeDeleted}
+
+// Headers sent with every delivery. The signature is "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+const (
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookConfig tunes delivery. Zero values fall back to the defaults.
+type WebhookConfig struct {
+	// MaxAttempts before a delivery is marked failed, default 8.
+	MaxAttempts int
+	// BaseBackoff is the wait after the first failure, doubled on every further failure up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Timeout of one HTTP request, default 10s.
+	Timeout time.Duration
+	// PollInterval of the worker looking for due retries, default 5s.
+	PollInterval time.Duration
+	// Lease is how long a claimed delivery is locked against other instances, default 1m.
+	Lease time.Duration
+	// AllowPrivateNetworks lets webhooks reach loopback, private and link-local addresses, for local development
+	// and tests only: tenant admins register the URLs.
+	AllowPrivateNetworks bool
+}
+
+// WebhookPayload is the JSON body of a delivery. ID stays the same across retries and redeliveries,
+// receivers can use it to drop duplicates.
+type WebhookPayload struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	OccurredAt time.Time      `json:"occurredAt"`
+	RecipeID   string         `json:"recipeId"`
+	Recipe     *models.Recipe `json:"recipe,omitempty"`
+}
+
+// WebhookDispatcher turns recipe events into signed deliveries and retries them with exponential backoff.
+// It is registered as a RecipeEventPublisher, so only the instance that made a change enqueues its deliveries.
+type WebhookDispatcher struct {
+	store  WebhookStore
+	cfg    WebhookConfig
+	client *http.Client
+	wake   chan struct{}
+	now    func() time.Time
+}
+
+func NewWebhookDispatcher(store WebhookStore, cfg WebhookConfig) *WebhookDispatcher {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 30 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Hour
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+	//Redirects are not followed, a 3xx is the response of the attempt
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	if !cfg.AllowPrivateNetworks {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		//Deliveries go straight to the receiver, so the dialer sees its address
+		transport.Proxy = nil
+		transport.DialContext = publicDialer().DialContext
+		client.Transport = transport
+	}
+	return &WebhookDispatcher{
+		store:  store,
+		cfg:    cfg,
+		client: client,
+		wake:   make(chan struct{}, 1),
+		now:    time.Now,
+	}
+}
+
+// Publish enqueues one delivery per subscribed webhook and wakes the worker.
+func (d *WebhookDispatcher) Publish(ctx context.Context, event RecipeEvent) error {
+	eventType := "recipe." + event.Type
+	webhooks, err := d.store.WebhooksFor(ctx, eventType)
+	if err != nil {
+		return err
+	}
+	now := d.now()
+	for _, webhook := range webhooks {
+		delivery := models.WebhookDelivery{
+			ID:            bson.NewObjectID(),
+			WebhookID:     webhook.ID,
+			Event:         eventType,
+			Status:        models.DeliveryPending,
+			Attempts:      make([]models.WebhookAttempt, 0),
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			Tenant:        webhook.Tenant,
+		}
+		payload, err := json.Marshal(WebhookPayload{
+			ID:         delivery.ID.Hex(),
+			Type:       eventType,
+			OccurredAt: event.OccurredAt,
+			RecipeID:   event.RecipeID,
+			Recipe:     event.Recipe,
+		})
+		if err != nil {
+			return err
+		}
+		delivery.Payload = string(payload)
+		if err := d.store.SaveDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	if len(webhooks) > 0 {
+		d.notify()
+	}
+	return nil
+}
+
+// Redeliver sends the payload of an earlier delivery again as a new delivery, the original log is kept.
+func (d *WebhookDispatcher) Redeliver(ctx context.Context, deliveryID bson.ObjectID) (models.WebhookDelivery, error) {
+	original, err := d.store.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return original, err
+	}
+	now := d.now()
+	delivery := models.WebhookDelivery{
+		ID:            bson.NewObjectID(),
+		WebhookID:     original.WebhookID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		Attempts:      make([]models.WebhookAttempt, 0),
+		NextAttemptAt: now,
+		RedeliveryOf:  &original.ID,
+		CreatedAt:     now,
+		Tenant:        original.Tenant,
+	}
+	if err := d.store.SaveDelivery(ctx, delivery); err != nil {
+		return delivery, err
+	}
+	d.notify()
+	return delivery, nil
+}
+
+// Run sends due deliveries until ctx is done. It runs on every instance, the store lease keeps them apart.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		d.DeliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DeliverDue attempts every delivery that is due now and returns how many were attempted.
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context) int {
+	attempted := 0
+	for ctx.Err() == nil {
+		delivery, ok, err := d.store.ClaimDueDelivery(ctx, d.now(), d.cfg.Lease)
+		if err != nil {
+			zap.L().Error("Failed to claim webhook delivery", zap.Error(err))
+			return attempted
+		}
+		if !ok {
+			return attempted
+		}
+		d.attempt(ctx, delivery)
+		attempted++
+	}
+	return attempted
+}
+
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery models.WebhookDelivery) {
+	webhook, err := d.store.GetWebhook(ContextWithTenant(ctx, delivery.Tenant), delivery.WebhookID)
+	if err != nil {
+		//The webhook was deleted, there is nobody left to deliver to
+		zap.L().Warn("Dropping delivery of missing webhook", zap.String("delivery_id", delivery.ID.Hex()), zap.Error(err))
+		delivery.Status = models.DeliveryFailed
+		d.save(ctx, delivery)
+		return
+	}
+
+	start := d.now()
+	result := models.WebhookAttempt{At: start}
+	statusCode, err := d.send(ctx, webhook, delivery, start)
+	result.StatusCode = statusCode
+	result.DurationMs = d.now().Sub(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	}
+	delivery.Attempts = append(delivery.Attempts, result)
+	delivery.LockedUntil = time.Time{}
+
+	switch {
+	case err == nil && statusCode >= 200 && statusCode < 300:
+		delivery.Status = models.DeliverySucceeded
+	case len(delivery.Attempts) >= d.cfg.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		zap.L().Warn("Webhook delivery failed", zap.String("delivery_id", delivery.ID.Hex()), zap.String("url", webhook.URL), zap.Int("attempts", len(delivery.Attempts)))
+	default:
+		delivery.NextAttemptAt = d.now().Add(d.Backoff(len(delivery.Attempts)))
+	}
+	d.save(ctx, delivery)
+}
+
+func (d *WebhookDispatcher) send(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery, at time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "recipes-webhooks/1.0")
+	req.Header.Set(WebhookIDHeader, webhook.ID.Hex())
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, timestamp, body))
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	return res.StatusCode, nil
+}
+
+func (d *WebhookDispatcher) save(ctx context.Context, delivery models.WebhookDelivery) {
+	if err := d.store.SaveDelivery(ctx, delivery); err != nil {
+		zap.L().Error("Failed to save webhook delivery", zap.String("delivery_id", delivery.ID.Hex()), zap.Error(err))
+	}
+}
+
+// Backoff is the wait before the next attempt after the given number of failed attempts.
+func (d *WebhookDispatcher) Backoff(failures int) time.Duration {
+	wait := d.cfg.BaseBackoff
+	for i := 1; i < failures && wait < d.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.cfg.MaxBackoff)
+}
+
+func (d *WebhookDispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SignWebhook returns the X-Webhook-Signature value for body sent at timestamp.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks a received signature in constant time, receivers written in Go can use it as is.
+func VerifyWebhookSignature(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature))
+}
+
+// allowsURL reports whether deliveries may be sent to u, see WebhookConfig.AllowPrivateNetworks. Names resolving
+// to private addresses are refused when the connection is dialed.
+func (d *WebhookDispatcher) allowsURL(u *url.URL) bool {
+	return (d != nil && d.cfg.AllowPrivateNetworks) || !privateHost(u.Hostname())
+}
@@ -0,0 +1,370 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"framework-api/handlers"
+	"framework-api/handlers/storetest"
+	"framework-api/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// receivedRequest is one request seen by the test receiver.
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver is a local httptest server answering with the queued status codes, then 200.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+	server   *httptest.Server
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	t.Helper()
+	r := &webhookReceiver{statuses: statuses}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *webhookReceiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.requests)
+}
+
+// newTestDispatcher returns a dispatcher whose clock only moves when the test advances it. It delivers to the
+// local receivers, private networks are allowed.
+func newTestDispatcher(store handlers.WebhookStore) (*handlers.WebhookDispatcher, *time.Time) {
+	dispatcher := handlers.NewWebhookDispatcher(store, handlers.WebhookConfig{MaxAttempts: 3, BaseBackoff: time.Minute, MaxBackoff: 10 * time.Minute, AllowPrivateNetworks: true})
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	dispatcher.SetClock(func() time.Time { return now })
+	return dispatcher, &now
+}
+
+func newWebhookEngine(store handlers.WebhookStore, dispatcher *handlers.WebhookDispatcher) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewWebhookHandler(store, dispatcher)
+	engine := gin.New()
+	engine.POST("/admin/webhooks", h.CreateWebhook)
+	engine.GET("/admin/webhooks", h.ListWebhooks)
+	engine.GET("/admin/webhooks/:id/deliveries", h.ListDeliveries)
+	engine.POST("/admin/webhooks/:id/deliveries/:deliveryId/redeliver", h.RedeliverDelivery)
+	return engine
+}
+
+func createWebhook(t *testing.T, engine *gin.Engine, body string) (int, handlers.WebhookCreated) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/admin/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	var created handlers.WebhookCreated
+	json.Unmarshal(w.Body.Bytes(), &created)
+	return w.Code, created
+}
+
+func publishRecipeEvent(t *testing.T, dispatcher *handlers.WebhookDispatcher, eventType string) models.Recipe {
+	t.Helper()
+	recipe := models.Recipe{ID: bson.NewObjectID(), Name: "Shakshuka"}
+	err := dispatcher.Publish(context.Background(), handlers.RecipeEvent{Type: eventType, RecipeID: recipe.ID.Hex(), Recipe: &recipe, OccurredAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Unexpected error publishing event: %s", err)
+	}
+	return recipe
+}
+
+func onlyDelivery(t *testing.T, store *storetest.WebhookStore) models.WebhookDelivery {
+	t.Helper()
+	deliveries := store.Deliveries()
+	if len(deliveries) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(deliveries))
+	}
+	return deliveries[0]
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	store := storetest.NewWebhookStore()
+	dispatcher, _ := newTestDispatcher(store)
+	engine := newWebhookEngine(store, dispatcher)
+	receiver := newWebhookReceiver(t)
+
+	code, created := createWebhook(t, engine, `{"url":"`+receiver.server.URL+`","events":["recipe.created"]}`)
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, code)
+	}
+	if !strings.HasPrefix(created.Secret, "whsec_") {
+		t.Fatalf("Expected a generated secret, got %q", created.Secret)
+	}
+	recipe := publishRecipeEvent(t, dispatcher, handlers.EventRecipeCreated)
+	//Not subscribed, no delivery
+	publishRecipeEvent(t, dispatcher, handlers.EventRecipeDeleted)
+
+	if n := dispatcher.DeliverDue(context.Background()); n != 1 {
+		t.Fatalf("Expected 1 attempted delivery, got %d", n)
+	}
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request at the receiver, got %d", len(requests))
+	}
+	req := requests[0]
+	if !handlers.VerifyWebhookSignature(created.Secret, req.header.Get(handlers.WebhookTimestampHeader), req.body, req.header.Get(handlers.WebhookSignatureHeader)) {
+		t.Errorf("Signature %s does not verify", req.header.Get(handlers.WebhookSignatureHeader))
+	}
+	if handlers.VerifyWebhookSignature("another-secret", req.header.Get(handlers.WebhookTimestampHeader), req.body, req.header.Get(handlers.WebhookSignatureHeader)) {
+		t.Errorf("Signature verified with the wrong secret")
+	}
+	if got := req.header.Get(handlers.WebhookEventHeader); got != handlers.WebhookRecipeCreated {
+		t.Errorf("Expected event header %s, got %s", handlers.WebhookRecipeCreated, got)
+	}
+	var payload handlers.WebhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("Unexpected error decoding payload: %s", err)
+	}
+	if payload.RecipeID != recipe.ID.Hex() || payload.Recipe == nil || payload.Recipe.Name != "Shakshuka" {
+		t.Errorf("Unexpected payload %s", req.body)
+	}
+	delivery := onlyDelivery(t, store)
+	if delivery.Status != models.DeliverySucceeded || len(delivery.Attempts) != 1 || delivery.Attempts[0].StatusCode != http.StatusOK {
+		t.Errorf("Expected one successful attempt, got status %s and attempts %+v", delivery.Status, delivery.Attempts)
+	}
+	if payload.ID != delivery.ID.Hex() || req.header.Get(handlers.WebhookDeliveryHeader) != delivery.ID.Hex() {
+		t.Errorf("Expected payload id and delivery header %s, got %s and %s", delivery.ID.Hex(), payload.ID, req.header.Get(handlers.WebhookDeliveryHeader))
+	}
+}
+
+func TestWebhookRetriesWithBackoff(t *testing.T) {
+	store := storetest.NewWebhookStore()
+	dispatcher, now := newTestDispatcher(store)
+	engine := newWebhookEngine(store, dispatcher)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+	createWebhook(t, engine, `{"url":"`+receiver.server.URL+`","events":["recipe.updated"],"secret":"s3cret"}`)
+	publishRecipeEvent(t, dispatcher, handlers.EventRecipeUpdated)
+
+	ts := []struct {
+		text      string
+		advance   time.Duration
+		attempted int
+		status    string
+	}{
+		{text: "first attempt fails", advance: 0, attempted: 1, status: models.DeliveryPending},
+		{text: "retry not due yet", advance: 30 * time.Second, attempted: 0, status: models.DeliveryPending},
+		{text: "second attempt after 1m fails", advance: 30 * time.Second, attempted: 1, status: models.DeliveryPending},
+		{text: "retry not due before 2m", advance: time.Minute, attempted: 0, status: models.DeliveryPending},
+		{text: "third attempt after 2m succeeds", advance: time.Minute, attempted: 1, status: models.DeliverySucceeded},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		*now = now.Add(tc.advance)
+		if n := dispatcher.DeliverDue(context.Background()); n != tc.attempted {
+			t.Errorf("%s: expected %d attempts, got %d", tc.text, tc.attempted, n)
+		}
+		if got := onlyDelivery(t, store).Status; got != tc.status {
+			t.Errorf("%s: expected status %s, got %s", tc.text, tc.status, got)
+		}
+	}
+	delivery := onlyDelivery(t, store)
+	codes := make([]int, 0)
+	for _, attempt := range delivery.Attempts {
+		codes = append(codes, attempt.StatusCode)
+	}
+	if exp := []int{500, 503, 200}; !slices.Equal(codes, exp) {
+		t.Errorf("Expected attempt log %v, got %v", exp, codes)
+	}
+}
+
+func TestWebhookFailsAfterMaxAttemptsAndRedelivers(t *testing.T) {
+	store := storetest.NewWebhookStore()
+	dispatcher, now := newTestDispatcher(store)
+	engine := newWebhookEngine(store, dispatcher)
+	receiver := newWebhookReceiver(t, 500, 500, 500)
+	_, created := createWebhook(t, engine, `{"url":"`+receiver.server.URL+`","events":["recipe.deleted"]}`)
+	publishRecipeEvent(t, dispatcher, handlers.EventRecipeDeleted)
+	for i := 0; i < 3; i++ {
+		dispatcher.DeliverDue(context.Background())
+		*now = now.Add(time.Hour)
+	}
+	failed := onlyDelivery(t, store)
+	if failed.Status != models.DeliveryFailed || len(failed.Attempts) != 3 {
+		t.Fatalf("Expected failed after 3 attempts, got %s after %d", failed.Status, len(failed.Attempts))
+	}
+
+	//The receiver is back, an admin redelivers
+	path := "/admin/webhooks/" + created.ID.Hex() + "/deliveries/" + failed.ID.Hex() + "/redeliver"
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+	var redelivery models.WebhookDelivery
+	json.Unmarshal(w.Body.Bytes(), &redelivery)
+	if redelivery.RedeliveryOf == nil || *redelivery.RedeliveryOf != failed.ID {
+		t.Errorf("Expected redeliveryOf %s, got %v", failed.ID.Hex(), redelivery.RedeliveryOf)
+	}
+	if n := dispatcher.DeliverDue(context.Background()); n != 1 {
+		t.Fatalf("Expected 1 attempted delivery, got %d", n)
+	}
+	requests := receiver.received()
+	if string(requests[len(requests)-1].body) != failed.Payload {
+		t.Errorf("Expected the original payload to be sent again")
+	}
+
+	//The log lists both deliveries, the original stays failed
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/webhooks/"+created.ID.Hex()+"/deliveries", nil))
+	var log []models.WebhookDelivery
+	json.Unmarshal(w.Body.Bytes(), &log)
+	if len(log) != 2 {
+		t.Fatalf("Expected 2 deliveries in the log, got %d", len(log))
+	}
+	statuses := []string{log[0].Status, log[1].Status}
+	slices.Sort(statuses)
+	if exp := []string{models.DeliveryFailed, models.DeliverySucceeded}; !slices.Equal(statuses, exp) {
+		t.Errorf("Expected statuses %v, got %v", exp, statuses)
+	}
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+	store := storetest.NewWebhookStore()
+	engine := newWebhookEngine(store, handlers.NewWebhookDispatcher(store, handlers.WebhookConfig{}))
+	ts := []struct {
+		text string
+		body string
+		exp  int
+	}{
+		{text: "valid", body: `{"url":"https://partner.example.com/hooks","events":["recipe.created","recipe.created"]}`, exp: http.StatusCreated},
+		{text: "relative url", body: `{"url":"/hooks","events":["recipe.created"]}`, exp: http.StatusBadRequest},
+		{text: "ftp url", body: `{"url":"ftp://partner.example.com","events":["recipe.created"]}`, exp: http.StatusBadRequest},
+		{text: "unknown event", body: `{"url":"https://partner.example.com","events":["recipe.eaten"]}`, exp: http.StatusBadRequest},
+		{text: "no events", body: `{"url":"https://partner.example.com","events":[]}`, exp: http.StatusBadRequest},
+		{text: "loopback", body: `{"url":"http://127.0.0.1:8088/admin","events":["recipe.created"]}`, exp: http.StatusBadRequest},
+		{text: "localhost", body: `{"url":"http://localhost/hooks","events":["recipe.created"]}`, exp: http.StatusBadRequest},
+		{text: "metadata", body: `{"url":"http://169.254.169.254/latest/meta-data/","events":["recipe.created"]}`, exp: http.StatusBadRequest},
+		{text: "private", body: `{"url":"https://10.0.0.12/hooks","events":["recipe.created"]}`, exp: http.StatusBadRequest},
+		{text: "ipv6 loopback", body: `{"url":"http://[::1]/hooks","events":["recipe.created"]}`, exp: http.StatusBadRequest},
+		{text: "ipv4 mapped loopback", body: `{"url":"http://[::ffff:127.0.0.1]/hooks","events":["recipe.created"]}`, exp: http.StatusBadRequest},
+		{text: "public address", body: `{"url":"https://203.0.113.7/hooks","events":["recipe.created"]}`, exp: http.StatusCreated},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if code, _ := createWebhook(t, engine, tc.body); code != tc.exp {
+			t.Errorf("%s: expected status %d, got %d", tc.text, tc.exp, code)
+		}
+	}
+	//Secrets are never listed
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/webhooks", nil))
+	if strings.Contains(w.Body.String(), "whsec_") || strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("Expected no secret in the webhook list, got %s", w.Body.String())
+	}
+}
+
+func TestWebhookDeliveryRefusesPrivateAddresses(t *testing.T) {
+	store := storetest.NewWebhookStore()
+	dispatcher := handlers.NewWebhookDispatcher(store, handlers.WebhookConfig{MaxAttempts: 3})
+	receiver := newWebhookReceiver(t)
+	//A name can resolve to a private address after the webhook was accepted, the dialer checks again
+	store.CreateWebhook(context.Background(), models.Webhook{ID: bson.NewObjectID(), URL: receiver.server.URL, Events: []string{handlers.WebhookRecipeCreated}, Active: true})
+	publishRecipeEvent(t, dispatcher, handlers.EventRecipeCreated)
+
+	dispatcher.DeliverDue(context.Background())
+	if n := len(receiver.received()); n != 0 {
+		t.Errorf("Expected no request at the private receiver, got %d", n)
+	}
+	delivery := onlyDelivery(t, store)
+	if len(delivery.Attempts) != 1 || !strings.Contains(delivery.Attempts[0].Error, handlers.ErrPrivateAddress.Error()) || delivery.Status == models.DeliverySucceeded {
+		t.Errorf("Expected a refused attempt, got status %s and attempts %+v", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestWebhookDeliveryDoesNotFollowRedirects(t *testing.T) {
+	store := storetest.NewWebhookStore()
+	dispatcher, _ := newTestDispatcher(store)
+	engine := newWebhookEngine(store, dispatcher)
+	internal := newWebhookReceiver(t)
+	redirecting := httptest.NewServer(http.RedirectHandler(internal.server.URL, http.StatusFound))
+	t.Cleanup(redirecting.Close)
+	if code, _ := createWebhook(t, engine, `{"url":"`+redirecting.URL+`","events":["recipe.created"]}`); code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, code)
+	}
+	publishRecipeEvent(t, dispatcher, handlers.EventRecipeCreated)
+
+	dispatcher.DeliverDue(context.Background())
+	if n := len(internal.received()); n != 0 {
+		t.Errorf("Expected the redirect not to be followed, got %d requests", n)
+	}
+	delivery := onlyDelivery(t, store)
+	if len(delivery.Attempts) != 1 || delivery.Attempts[0].StatusCode != http.StatusFound || delivery.Status == models.DeliverySucceeded {
+		t.Errorf("Expected a failed attempt with the redirect status, got status %s and attempts %+v", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	ts := []struct {
+		text string
+		addr string
+		exp  bool
+	}{
+		{text: "public ipv4", addr: "203.0.113.7", exp: true},
+		{text: "public ipv6", addr: "2001:db8::1", exp: true},
+		{text: "loopback", addr: "127.0.0.2"},
+		{text: "private", addr: "192.168.1.10"},
+		{text: "metadata", addr: "169.254.169.254"},
+		{text: "carrier-grade nat", addr: "100.64.1.1"},
+		{text: "this network", addr: "0.1.2.3"},
+		{text: "unspecified", addr: "::"},
+		{text: "unique local", addr: "fd00::1"},
+		{text: "ipv6 link-local", addr: "fe80::1"},
+		{text: "ipv4 mapped private", addr: "::ffff:10.1.2.3"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if got := handlers.PublicAddr(netip.MustParseAddr(tc.addr)); got != tc.exp {
+			t.Errorf("Expected %t for %s, got %t", tc.exp, tc.addr, got)
+		}
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	dispatcher := handlers.NewWebhookDispatcher(nil, handlers.WebhookConfig{BaseBackoff: 30 * time.Second, MaxBackoff: 5 * time.Minute})
+	ts := []struct {
+		failures int
+		exp      time.Duration
+	}{
+		{failures: 1, exp: 30 * time.Second},
+		{failures: 2, exp: time.Minute},
+		{failures: 4, exp: 4 * time.Minute},
+		{failures: 5, exp: 5 * time.Minute},
+		{failures: 50, exp: 5 * time.Minute},
+	}
+	for _, tc := range ts {
+		if got := dispatcher.Backoff(tc.failures); got != tc.exp {
+			t.Errorf("Backoff after %d failures: expected %s, got %s", tc.failures, tc.exp, got)
+		}
+	}
+}
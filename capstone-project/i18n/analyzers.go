@@ -0,0 +1,29 @@
+package i18n
+
+import "golang.org/x/text/language"
+
+// analyzers maps languages to the built-in Elasticsearch language analyzers, regional variants are looked up first.
+var analyzers = map[string]string{
+	"ar": "arabic", "hy": "armenian", "eu": "basque", "bn": "bengali", "pt-BR": "brazilian", "bg": "bulgarian",
+	"ca": "catalan", "zh": "cjk", "ja": "cjk", "ko": "cjk", "cs": "czech", "da": "danish", "nl": "dutch",
+	"en": "english", "et": "estonian", "fi": "finnish", "fr": "french", "gl": "galician", "de": "german",
+	"el": "greek", "hi": "hindi", "hu": "hungarian", "id": "indonesian", "ga": "irish", "it": "italian",
+	"lv": "latvian", "lt": "lithuanian", "nb": "norwegian", "no": "norwegian", "fa": "persian", "pt": "portuguese",
+	"ro": "romanian", "ru": "russian", "ckb": "sorani", "es": "spanish", "sv": "swedish", "tr": "turkish", "th": "thai",
+}
+
+// Analyzer returns the Elasticsearch analyzer for text in locale, "standard" for languages without a built-in one.
+func Analyzer(locale string) string {
+	for _, candidate := range Fallbacks(locale) {
+		if analyzer, ok := analyzers[candidate]; ok {
+			return analyzer
+		}
+	}
+	if tag, err := language.Parse(locale); err == nil {
+		base, _ := tag.Base()
+		if analyzer, ok := analyzers[base.String()]; ok {
+			return analyzer
+		}
+	}
+	return "standard"
+}
@@ -0,0 +1,102 @@
+// Package i18n picks the locale of localized recipe content. Locales are BCP 47 tags such as "fr" or "pt-BR",
+// the request locale is negotiated from Accept-Language against the locales the server supports.
+package i18n
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is the locale of recipes stored without one, and the fallback of every negotiation.
+const DefaultLocale = "en"
+
+// DefaultSupported are the locales served when SUPPORTED_LOCALES is not set.
+var DefaultSupported = []string{DefaultLocale, "de", "es", "fr", "hi", "it", "pt", "pt-BR"}
+
+var ErrInvalidLocale = errors.New("invalid locale")
+
+// Normalize returns the canonical form of a BCP 47 tag, "EN_us" becomes "en-US".
+func Normalize(locale string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if err != nil || tag == language.Und {
+		return "", ErrInvalidLocale
+	}
+	return tag.String(), nil
+}
+
+// Fallbacks returns locale followed by its parents, "pt-BR" gives ["pt-BR", "pt"].
+func Fallbacks(locale string) []string {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil
+	}
+	res := make([]string, 0, 2)
+	for ; tag != language.Und; tag = tag.Parent() {
+		if s := tag.String(); !slices.Contains(res, s) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// Matcher negotiates Accept-Language headers against the supported locales.
+type Matcher struct {
+	supported []string
+	matcher   language.Matcher
+}
+
+// NewMatcher normalizes the supported locales, DefaultLocale is always supported and wins when nothing matches.
+func NewMatcher(supported []string) (*Matcher, error) {
+	locales := []string{DefaultLocale}
+	for _, locale := range supported {
+		normalized, err := Normalize(locale)
+		if err != nil {
+			return nil, errors.New("invalid locale " + locale)
+		}
+		if !slices.Contains(locales, normalized) {
+			locales = append(locales, normalized)
+		}
+	}
+	tags := make([]language.Tag, len(locales))
+	for i, locale := range locales {
+		tags[i] = language.MustParse(locale)
+	}
+	return &Matcher{supported: locales, matcher: language.NewMatcher(tags)}, nil
+}
+
+// Default returns a matcher for DefaultSupported.
+func Default() *Matcher {
+	m, _ := NewMatcher(DefaultSupported)
+	return m
+}
+
+// Supported returns the supported locales, DefaultLocale first.
+func (m *Matcher) Supported() []string {
+	return slices.Clone(m.supported)
+}
+
+// Match returns the supported locale closest to the preferences of an Accept-Language header.
+// Quality values are honored and "fr-CA" falls back to "fr", a missing or invalid header gives DefaultLocale.
+func (m *Matcher) Match(acceptLanguage string) string {
+	_, i := language.MatchStrings(m.matcher, acceptLanguage)
+	return m.supported[i]
+}
+
+type localeKey struct{}
+
+// WithLocale returns a context carrying the request locale.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext returns the request locale of ctx, DefaultLocale when none was set.
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	m, err := NewMatcher([]string{"fr", "de", "pt-BR", "pt"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating matcher: %s", err)
+	}
+	ts := []struct {
+		text   string
+		header string
+		exp    string
+	}{
+		{text: "no header", header: "", exp: "en"},
+		{text: "exact", header: "de", exp: "de"},
+		{text: "region falls back to language", header: "fr-CA,fr;q=0.9", exp: "fr"},
+		{text: "quality values", header: "de;q=0.5, fr;q=0.8", exp: "fr"},
+		{text: "regional variant", header: "pt-BR", exp: "pt-BR"},
+		{text: "unsupported", header: "ja", exp: "en"},
+		{text: "first supported preference", header: "ja, de;q=0.7, en;q=0.5", exp: "de"},
+		{text: "invalid header", header: ";;;", exp: "en"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if got := m.Match(tc.header); got != tc.exp {
+			t.Errorf("Expected %s, got %s", tc.exp, got)
+		}
+	}
+	if supported := m.Supported(); supported[0] != DefaultLocale {
+		t.Errorf("Expected %s first, got %v", DefaultLocale, supported)
+	}
+	if _, err := NewMatcher([]string{"not a locale!"}); err == nil {
+		t.Errorf("Expected an error for an invalid locale")
+	}
+}
+
+func TestNormalizeAndFallbacks(t *testing.T) {
+	if got, err := Normalize(" pt_br "); err != nil || got != "pt-BR" {
+		t.Errorf("Expected pt-BR, got %s (%v)", got, err)
+	}
+	if _, err := Normalize("und"); err != ErrInvalidLocale {
+		t.Errorf("Expected ErrInvalidLocale, got %v", err)
+	}
+	if got := Fallbacks("pt-BR"); !slices.Equal(got, []string{"pt-BR", "pt"}) {
+		t.Errorf("Expected [pt-BR pt], got %v", got)
+	}
+	if got := FromContext(WithLocale(context.Background(), "fr")); got != "fr" {
+		t.Errorf("Expected fr, got %s", got)
+	}
+	if got := FromContext(context.Background()); got != DefaultLocale {
+		t.Errorf("Expected %s, got %s", DefaultLocale, got)
+	}
+}
+
+func TestAnalyzer(t *testing.T) {
+	ts := []struct {
+		locale string
+		exp    string
+	}{
+		{locale: "fr", exp: "french"},
+		{locale: "fr-CA", exp: "french"},
+		{locale: "pt-BR", exp: "brazilian"},
+		{locale: "pt-PT", exp: "portuguese"},
+		{locale: "sw", exp: "standard"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.locale)
+		if got := Analyzer(tc.locale); got != tc.exp {
+			t.Errorf("Expected %s, got %s", tc.exp, got)
+		}
+	}
+}
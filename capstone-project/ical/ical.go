@@ -0,0 +1,91 @@
+// Package ical writes iCalendar files (RFC 5545), enough for calendar apps to import or subscribe to a meal plan.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event start and end are written as floating local times, they are shown at the same wall clock time
+// in every time zone. Stamp is when the event was last changed.
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Stamp       time.Time
+	Summary     string
+	Description string
+}
+
+// Calendar is a VCALENDAR with its events.
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+const (
+	localFormat = "20060102T150405"
+	utcFormat   = "20060102T150405Z"
+	// Lines are folded after 75 octets, continuation lines start with a space.
+	maxLineOctets = 75
+)
+
+// Write writes the calendar with CRLF line endings.
+func (c Calendar) Write(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", c.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", Escape(c.Name))
+	}
+	for _, event := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", event.Stamp.UTC().Format(utcFormat))
+		line("DTSTART", event.Start.Format(localFormat))
+		line("DTEND", event.End.Format(localFormat))
+		line("SUMMARY", Escape(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", Escape(event.Description))
+		}
+		line("TRANSP", "TRANSPARENT")
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.Flush()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// Escape escapes a TEXT value.
+func Escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// writeFolded writes a content line, folded without splitting UTF-8 sequences.
+func writeFolded(b *bufio.Writer, content string) {
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		//The leading space counts towards the next line
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(content)
+	b.WriteString("\r\n")
+}
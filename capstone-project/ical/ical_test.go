@@ -0,0 +1,72 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	ts := []struct {
+		text string
+		exp  string
+	}{
+		{text: "Tomato Soup", exp: "Tomato Soup"},
+		{text: "Salt, pepper; oil", exp: `Salt\, pepper\; oil`},
+		{text: "line one\nline two", exp: `line one\nline two`},
+		{text: `back\slash`, exp: `back\\slash`},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if got := Escape(tc.text); got != tc.exp {
+			t.Errorf("Expected %q, got %q", tc.exp, got)
+		}
+	}
+}
+
+func TestWriteCalendar(t *testing.T) {
+	start := time.Date(2026, 10, 19, 19, 0, 0, 0, time.UTC)
+	cal := Calendar{
+		ProdID: "-//test//EN",
+		Name:   "Week of 2026-10-19",
+		Events: []Event{{
+			UID:         "1@test",
+			Start:       start,
+			End:         start.Add(time.Hour),
+			Stamp:       time.Date(2026, 10, 18, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600)),
+			Summary:     "Dinner: Crème brûlée",
+			Description: strings.Repeat("Crème fraîche, ", 10),
+		}},
+	}
+	var b strings.Builder
+	if err := cal.Write(&b); err != nil {
+		t.Fatalf("Unexpected error writing calendar: %s", err)
+	}
+	out := b.String()
+	if !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Errorf("Expected CRLF terminated calendar, got %q", out)
+	}
+	for _, exp := range []string{"DTSTART:20261019T190000\r\n", "DTEND:20261019T200000\r\n", "DTSTAMP:20261018T100000Z\r\n", "SUMMARY:Dinner: Crème brûlée\r\n"} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %q in %q", exp, out)
+		}
+	}
+	unfolded := ""
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("Line longer than %d octets: %q", maxLineOctets, line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("Folding split a UTF-8 sequence: %q", line)
+		}
+		if strings.HasPrefix(line, " ") {
+			unfolded += line[1:]
+		} else {
+			unfolded += "\n" + line
+		}
+	}
+	if !strings.Contains(unfolded, "DESCRIPTION:"+Escape(cal.Events[0].Description)) {
+		t.Errorf("Expected the folded description to unfold to the original, got %q", unfolded)
+	}
+}
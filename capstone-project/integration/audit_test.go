@@ -0,0 +1,143 @@
+package integration
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"framework-api/handlers"
+	"framework-api/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAuditLog(t *testing.T) {
+	h := newHarness(t)
+	chef := h.token("chef", jwt.MapClaims{"scope": "recipes:write", "username": "=HYPERLINK(\"x\")"})
+	admin := h.token("admin", jwt.MapClaims{"cognito:groups": []string{"admin"}})
+	owner := h.token("owner", jwt.MapClaims{"custom:tenant": "bistro", "cognito:groups": []string{"admin:bistro"}})
+
+	//The request ID of the client is echoed and recorded
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/recipe", strings.NewReader(`{"name": "Audited Stew", "ingredients": ["2 carrots"], "instructions": ["Simmer."], "servings": 2}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+chef)
+	req.Header.Set(handlers.RequestIDHeader, "req-create-1")
+	w := httptest.NewRecorder()
+	h.Engine.ServeHTTP(w, req)
+	expect(t, w, http.StatusCreated)
+	if got := w.Header().Get(handlers.RequestIDHeader); got != "req-create-1" {
+		t.Errorf("Expected the request ID echoed, got %q", got)
+	}
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Unexpected error decoding created recipe: %s", err)
+	}
+	id := created.Data.ID
+	expect(t, h.do(http.MethodPatch, "/api/v1/recipe/"+id, map[string]interface{}{"servings": 4}, chef), http.StatusOK)
+	expect(t, h.do(http.MethodDelete, "/api/v2/recipe/"+id, nil, admin), http.StatusNoContent)
+	expect(t, h.do(http.MethodPost, "/api/v2/recipe", map[string]interface{}{"name": "Forged"}, "not-a-jwt"), http.StatusUnauthorized)
+	expect(t, h.do(http.MethodGet, "/admin/audit", nil, chef), http.StatusForbidden)
+
+	w = h.do(http.MethodGet, "/admin/audit?resource=recipe&resourceId="+id, nil, admin)
+	expect(t, w, http.StatusOK)
+	var entries []models.AuditEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("Unexpected error decoding audit entries: %s", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d: %s", len(entries), w.Body.String())
+	}
+	deleted, updated, create := entries[0], entries[1], entries[2]
+	ts := []struct {
+		text   string
+		entry  models.AuditEntry
+		action string
+		actor  string
+	}{
+		{text: "create", entry: create, action: models.AuditRecipeCreate, actor: "chef"},
+		{text: "update", entry: updated, action: models.AuditRecipeUpdate, actor: "chef"},
+		{text: "delete", entry: deleted, action: models.AuditRecipeDelete, actor: "admin"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if tc.entry.Action != tc.action || tc.entry.Actor != tc.actor || tc.entry.Outcome != models.AuditSuccess {
+			t.Errorf("Expected %s by %s, got %+v", tc.action, tc.actor, tc.entry)
+		}
+		if tc.entry.IP == "" || tc.entry.RequestID == "" {
+			t.Errorf("Expected the IP and request ID, got %+v", tc.entry)
+		}
+	}
+	if create.RequestID != "req-create-1" || create.ActorName != `=HYPERLINK("x")` {
+		t.Errorf("Expected the client's request ID and the username, got %+v", create)
+	}
+	if create.BeforeHash != "" || create.AfterHash == "" {
+		t.Errorf("Expected only an after hash on create, got %+v", create)
+	}
+	//The hashes chain up: every write starts from the state the previous one left
+	if updated.BeforeHash != create.AfterHash || updated.AfterHash == updated.BeforeHash {
+		t.Errorf("Expected the update to start from the created recipe and change it, got %+v", updated)
+	}
+	if deleted.BeforeHash != updated.AfterHash || deleted.AfterHash != "" {
+		t.Errorf("Expected the delete to start from the updated recipe, got %+v", deleted)
+	}
+
+	w = h.do(http.MethodGet, "/admin/audit?action=auth.rejected&outcome=failure", nil, admin)
+	expect(t, w, http.StatusOK)
+	entries = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("Unexpected error decoding audit entries: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Actor != "" || entries[0].Detail != "Invalid token" {
+		t.Errorf("Expected the forged token only, got %+v", entries)
+	}
+
+	//Accepted tokens are recorded once per caller, not per request
+	w = h.do(http.MethodGet, "/admin/audit?action=auth.accepted", nil, admin)
+	expect(t, w, http.StatusOK)
+	entries = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("Unexpected error decoding audit entries: %s", err)
+	}
+	actors := make([]string, 0)
+	for _, entry := range entries {
+		actors = append(actors, entry.Actor)
+	}
+	if strings.Join(actors, ",") != "admin,chef" || entries[0].Detail != Issuer {
+		t.Errorf("Expected admin and chef once each, got %+v", entries)
+	}
+
+	//Tenant admins only see their tenant, where nothing happened but their own sign-in
+	w = h.doIn("bistro", http.MethodGet, "/admin/audit", nil, owner)
+	expect(t, w, http.StatusOK)
+	entries = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("Unexpected error decoding audit entries: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Action != models.AuditAuthAccepted || entries[0].Actor != "owner" {
+		t.Errorf("Expected only the owner's sign-in in another tenant, got %s", w.Body.String())
+	}
+
+	//CSV export, formulas are defused
+	req = httptest.NewRequest(http.MethodGet, "/admin/audit?action=recipe.create", nil)
+	req.Header.Set("Authorization", "Bearer "+admin)
+	req.Header.Set("Accept", "text/csv")
+	w = httptest.NewRecorder()
+	h.Engine.ServeHTTP(w, req)
+	expect(t, w, http.StatusOK)
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") || !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment") {
+		t.Errorf("Expected a CSV attachment, got %v", w.Header())
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV: %s", err)
+	}
+	if len(rows) != 2 || rows[0][1] != "action" || rows[1][1] != models.AuditRecipeCreate || rows[1][4] != `'=HYPERLINK("x")` {
+		t.Errorf("Expected a header and the create, got %v", rows)
+	}
+}
@@ -0,0 +1,51 @@
+package integration
+
+import (
+	"framework-api/fixtures"
+	"net/http"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestWriteAuthorization(t *testing.T) {
+	h := newHarness(t)
+	reader := h.token("reader", nil)
+	writer := h.token("chef", jwt.MapClaims{"scope": "recipes:write"})
+	admin := h.token("admin", jwt.MapClaims{"cognito:groups": []string{"admin"}})
+	recipe := map[string]interface{}{"name": "Toast", "tags": []string{"breakfast"}, "ingredients": []string{"1 slice bread"}, "instructions": []string{"Toast it."}, "servings": 1}
+	id := fixtures.RecipeID("guacamole").Hex()
+
+	ts := []struct {
+		text   string
+		method string
+		path   string
+		body   interface{}
+		token  string
+		status int
+	}{
+		{text: "create without token", method: http.MethodPost, path: "/api/v2/recipe", body: recipe, status: http.StatusUnauthorized},
+		{text: "create with a bad token", method: http.MethodPost, path: "/api/v2/recipe", body: recipe, token: "not-a-jwt", status: http.StatusUnauthorized},
+		{text: "create without scope", method: http.MethodPost, path: "/api/v2/recipe", body: recipe, token: reader, status: http.StatusForbidden},
+		{text: "create as admin, admins have every scope", method: http.MethodPost, path: "/recipe", body: recipe, token: admin, status: http.StatusCreated},
+		{text: "update without token", method: http.MethodPatch, path: "/api/v2/recipe/" + id, body: map[string]interface{}{"servings": 8}, status: http.StatusUnauthorized},
+		{text: "update without scope", method: http.MethodPatch, path: "/api/v1/recipe/" + id, body: map[string]interface{}{"servings": 8}, token: reader, status: http.StatusForbidden},
+		{text: "update with scope", method: http.MethodPatch, path: "/api/v2/recipe/" + id, body: map[string]interface{}{"servings": 8}, token: writer, status: http.StatusOK},
+		{text: "delete without token", method: http.MethodDelete, path: "/api/v2/recipe/" + id, status: http.StatusUnauthorized},
+		{text: "delete with scope but not admin", method: http.MethodDelete, path: "/api/v2/recipe/" + id, token: writer, status: http.StatusForbidden},
+		{text: "delete as admin", method: http.MethodDelete, path: "/api/v2/recipe/" + id, token: admin, status: http.StatusNoContent},
+		{text: "read without token", method: http.MethodGet, path: "/api/v2/recipes", status: http.StatusOK},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		before := h.Store.Calls()
+		w := h.do(tc.method, tc.path, tc.body, tc.token)
+		if w.Code != tc.status {
+			t.Errorf("Expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+		}
+		//Rejected requests must not reach the store
+		if (w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden) && h.Store.Calls() != before {
+			t.Errorf("Expected no store calls for a rejected request, got %d", h.Store.Calls()-before)
+		}
+	}
+}
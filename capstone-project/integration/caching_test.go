@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"framework-api/fixtures"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestConditionalReads(t *testing.T) {
+	h := newHarness(t)
+	writer := h.token("chef", jwt.MapClaims{"scope": "recipes:write"})
+	admin := h.token("admin", jwt.MapClaims{"scope": "recipes:write", "cognito:groups": []string{"admin"}})
+	id := fixtures.RecipeID("tomato-soup").Hex()
+
+	ts := []struct {
+		text string
+		path string
+	}{
+		{text: "v1 list", path: "/api/v1/recipes"},
+		{text: "v2 page", path: "/api/v2/recipes?limit=2"},
+		{text: "v1 recipe", path: "/api/v1/recipe/" + id},
+		{text: "v2 recipe", path: "/api/v2/recipe/" + id},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		w := h.get(tc.path, nil)
+		expect(t, w, http.StatusOK)
+		etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+		if etag == "" || lastModified == "" || w.Header().Get("Cache-Control") != "public, max-age=0, must-revalidate" {
+			t.Fatalf("Expected validators and Cache-Control, got %v", w.Header())
+		}
+		//Even with the recipes no longer cached, conditional requests are answered from the validators in Redis
+		h.Redis.Del("recipes")
+		h.Redis.Del("recipe:" + id)
+		before := h.Store.Calls()
+		for _, header := range []map[string]string{{"If-None-Match": etag}, {"If-Modified-Since": lastModified}} {
+			w = h.get(tc.path, header)
+			if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+				t.Errorf("Expected 304 with the ETag for %v, got %d %v", header, w.Code, w.Header())
+			}
+		}
+		if calls := h.Store.Calls() - before; calls != 0 {
+			t.Errorf("Expected no store calls, got %d", calls)
+		}
+	}
+
+	//Every locale is a representation of its own
+	w := h.get("/api/v2/recipe/"+id, nil)
+	expect(t, w, http.StatusOK)
+	etag := w.Header().Get("ETag")
+	w = h.get("/api/v2/recipe/"+id, map[string]string{"If-None-Match": etag, "Accept-Language": "fr"})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("Expected another locale to be another representation, got %d %v", w.Code, w.Header())
+	}
+	list := h.get("/api/v1/recipes", nil)
+	expect(t, list, http.StatusOK)
+
+	//Writes change the validators of the recipe and of the catalog
+	expect(t, h.do(http.MethodPatch, "/api/v2/recipe/"+id, map[string]interface{}{"servings": 3}, writer), http.StatusOK)
+	w = h.get("/api/v2/recipe/"+id, map[string]string{"If-None-Match": etag})
+	expect(t, w, http.StatusOK)
+	var updated struct {
+		Data struct {
+			UpdatedAt string `json:"updatedAt"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil || updated.Data.UpdatedAt == "" {
+		t.Errorf("Expected updatedAt after the update, got %s", w.Body.String())
+	}
+	expect(t, h.get("/api/v1/recipes", map[string]string{"If-None-Match": list.Header().Get("ETag")}), http.StatusOK)
+	etag = w.Header().Get("ETag")
+	expect(t, h.do(http.MethodDelete, "/api/v2/recipe/"+id, nil, admin), http.StatusNoContent)
+	expect(t, h.get("/api/v2/recipe/"+id, map[string]string{"If-None-Match": etag}), http.StatusNotFound)
+
+	//Other tenants are not cached by shared caches
+	owner := h.token("owner", jwt.MapClaims{"custom:tenant": "bistro", "cognito:groups": []string{"admin:bistro"}})
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/recipes", nil)
+	req.Header.Set("Authorization", "Bearer "+owner)
+	req.Header.Set("X-Tenant-ID", "bistro")
+	w = httptest.NewRecorder()
+	h.Engine.ServeHTTP(w, req)
+	expect(t, w, http.StatusOK)
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=0, must-revalidate" {
+		t.Errorf("Expected a private Cache-Control, got %q", got)
+	}
+}
+
+func TestCompressedReads(t *testing.T) {
+	h := newHarness(t)
+	plain := h.get("/api/v1/recipes", nil)
+	expect(t, plain, http.StatusOK)
+
+	ts := []struct {
+		text     string
+		accept   string
+		encoding string
+	}{
+		{text: "gzip", accept: "gzip, deflate", encoding: "gzip"},
+		{text: "brotli", accept: "gzip, deflate, br", encoding: "br"},
+		{text: "identity", accept: "identity", encoding: ""},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		w := h.get("/api/v1/recipes", map[string]string{"Accept-Encoding": tc.accept})
+		expect(t, w, http.StatusOK)
+		if got := w.Header().Get("Content-Encoding"); got != tc.encoding {
+			t.Errorf("Expected Content-Encoding %q, got %q", tc.encoding, got)
+		}
+		if w.Header().Get("ETag") != plain.Header().Get("ETag") {
+			t.Errorf("Expected the ETag of the uncompressed response, got %q", w.Header().Get("ETag"))
+		}
+		var body io.Reader = w.Body
+		switch tc.encoding {
+		case "gzip":
+			r, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error reading gzip: %s", err)
+			}
+			body = r
+		case "br":
+			body = brotli.NewReader(w.Body)
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("Unexpected error decoding body: %s", err)
+		}
+		if string(data) != plain.Body.String() {
+			t.Errorf("Expected the uncompressed body, got %d bytes", len(data))
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package integration runs the API in-process: Server wires the Gin engine like main, with the recipe fixtures
+// in an in-memory store, miniredis and a fake Elasticsearch. The tests of the package check it end to end
+// against golden responses in testdata, go test ./integration needs no MongoDB, Redis or Elasticsearch.
+package integration
@@ -0,0 +1,254 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Elasticsearch is a fake cluster answering the requests the recipe handler sends to the recipe indexes. Documents
+// are kept as JSON by index; search only knows the bool query of SearchRecipes, with a prefix match standing in for
+// the analyzers. Other queries, like more_like_this, fail, so the handler takes its fallback path.
+type Elasticsearch struct {
+	*httptest.Server
+	mu       sync.Mutex
+	indexes  map[string]map[string]map[string]interface{}
+	searches int
+}
+
+// NewElasticsearch starts a fake cluster on a local port, Close stops it.
+func NewElasticsearch() *Elasticsearch {
+	es := &Elasticsearch{indexes: make(map[string]map[string]map[string]interface{})}
+	es.Server = httptest.NewServer(http.HandlerFunc(es.serve))
+	return es
+}
+
+// Searches returns the number of search requests, to check when Elasticsearch is not asked.
+func (es *Elasticsearch) Searches() int {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	return es.searches
+}
+
+// Docs returns the IDs of the documents in index, sorted.
+func (es *Elasticsearch) Docs(index string) []string {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	return slices.Sorted(maps.Keys(es.indexes[index]))
+}
+
+func (es *Elasticsearch) serve(w http.ResponseWriter, r *http.Request) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	//The client refuses to talk to servers without the product header
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	docs, exists := es.indexes[path[0]]
+	switch {
+	case len(path) == 1 && r.Method == http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case len(path) == 1 && r.Method == http.MethodPut:
+		es.indexes[path[0]] = make(map[string]map[string]interface{})
+		fmt.Fprint(w, `{"acknowledged": true}`)
+	case len(path) == 2 && path[1] == "_mapping":
+		fmt.Fprint(w, `{"acknowledged": true}`)
+	case len(path) == 3 && path[1] == "_doc" && r.Method == http.MethodPut:
+		var doc map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+			es.fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		//Like a cluster with automatic index creation
+		if !exists {
+			docs = make(map[string]map[string]interface{})
+			es.indexes[path[0]] = docs
+		}
+		docs[path[2]] = doc
+		fmt.Fprintf(w, `{"_id": %q, "result": "updated"}`, path[2])
+	case len(path) == 3 && path[1] == "_doc" && r.Method == http.MethodDelete:
+		if _, ok := docs[path[2]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"_id": %q, "result": "not_found"}`, path[2])
+			return
+		}
+		delete(docs, path[2])
+		fmt.Fprintf(w, `{"_id": %q, "result": "deleted"}`, path[2])
+	case !exists:
+		es.fail(w, http.StatusNotFound, "no such index ["+path[0]+"]")
+	case len(path) == 2 && path[1] == "_delete_by_query":
+		deleted := len(docs)
+		es.indexes[path[0]] = make(map[string]map[string]interface{})
+		fmt.Fprintf(w, `{"deleted": %d}`, deleted)
+	case len(path) == 2 && path[1] == "_search":
+		es.searches++
+		es.search(w, r, docs)
+	default:
+		es.fail(w, http.StatusBadRequest, "unsupported request "+r.Method+" "+r.URL.Path)
+	}
+}
+
+func (es *Elasticsearch) fail(w http.ResponseWriter, status int, reason string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"type": "fake_exception", "reason": reason}, "status": status})
+}
+
+// searchRequest is the subset of the search DSL SearchRecipes sends.
+type searchRequest struct {
+	Query struct {
+		Bool *struct {
+			Should []struct {
+				Match struct {
+					Name struct {
+						Query string `json:"query"`
+					} `json:"name"`
+				} `json:"match"`
+			} `json:"should"`
+			Filter []struct {
+				Term *struct {
+					Tag string `json:"tags.keyword"`
+				} `json:"term"`
+				Range *struct {
+					Calories struct {
+						Lte float64 `json:"lte"`
+					} `json:"caloriesPerServing"`
+				} `json:"range"`
+			} `json:"filter"`
+			MustNot []struct {
+				Terms struct {
+					Allergens []string `json:"allergens.keyword"`
+				} `json:"terms"`
+			} `json:"must_not"`
+		} `json:"bool"`
+	} `json:"query"`
+	Aggs struct {
+		Tags struct {
+			Terms struct {
+				Size int `json:"size"`
+			} `json:"terms"`
+		} `json:"tags"`
+	} `json:"aggs"`
+}
+
+func (es *Elasticsearch) search(w http.ResponseWriter, r *http.Request, docs map[string]map[string]interface{}) {
+	var req searchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query.Bool == nil {
+		es.fail(w, http.StatusBadRequest, "only bool queries are supported")
+		return
+	}
+	q := req.Query.Bool
+	type hit struct {
+		id    string
+		score int
+	}
+	hits := make([]hit, 0)
+	for id, doc := range docs {
+		score := 0
+		if len(q.Should) > 0 {
+			if score = matches(q.Should[0].Match.Name.Query, doc); score == 0 {
+				continue
+			}
+		}
+		ok := true
+		for _, filter := range q.Filter {
+			if filter.Term != nil && !slices.Contains(stringsOf(doc["tags"]), filter.Term.Tag) {
+				ok = false
+			}
+			if calories, found := doc["caloriesPerServing"].(float64); filter.Range != nil && (!found || calories > filter.Range.Calories.Lte) {
+				ok = false
+			}
+		}
+		for _, mustNot := range q.MustNot {
+			for _, allergen := range mustNot.Terms.Allergens {
+				if slices.Contains(stringsOf(doc["allergens"]), allergen) {
+					ok = false
+				}
+			}
+		}
+		if ok {
+			hits = append(hits, hit{id: id, score: score})
+		}
+	}
+	slices.SortFunc(hits, func(a, b hit) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return strings.Compare(a.id, b.id)
+	})
+
+	res := map[string]interface{}{}
+	found := make([]interface{}, 0, len(hits))
+	counts := make(map[string]int)
+	for _, hit := range hits {
+		found = append(found, map[string]interface{}{"_id": hit.id, "_score": hit.score, "_source": docs[hit.id]})
+		for _, tag := range stringsOf(docs[hit.id]["tags"]) {
+			counts[tag]++
+		}
+	}
+	res["hits"] = map[string]interface{}{"total": map[string]interface{}{"value": len(hits)}, "hits": found}
+	if size := req.Aggs.Tags.Terms.Size; size > 0 {
+		tags := make([]string, 0, len(counts))
+		for tag := range counts {
+			tags = append(tags, tag)
+		}
+		slices.SortFunc(tags, func(a, b string) int {
+			if counts[a] != counts[b] {
+				return counts[b] - counts[a]
+			}
+			return strings.Compare(a, b)
+		})
+		buckets := make([]interface{}, 0, size)
+		for _, tag := range tags[:min(size, len(tags))] {
+			buckets = append(buckets, map[string]interface{}{"key": tag, "doc_count": counts[tag]})
+		}
+		res["aggregations"] = map[string]interface{}{"tags": map[string]interface{}{"buckets": buckets}}
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// matches counts the words of query found in the name, tags and localized fields of doc. Words match when
+// one is a prefix of the other, at least 4 letters long, so "tomatoes" finds "tomato" like a stemmer would.
+func matches(query string, doc map[string]interface{}) int {
+	text := append(stringsOf(doc["name"]), stringsOf(doc["tags"])...)
+	if translations, ok := doc["i18n"].(map[string]interface{}); ok {
+		for _, translation := range translations {
+			if fields, ok := translation.(map[string]interface{}); ok {
+				text = append(text, stringsOf(fields["name"])...)
+				text = append(text, stringsOf(fields["ingredients"])...)
+			}
+		}
+	}
+	words := strings.Fields(strings.ToLower(strings.Join(text, " ")))
+	score := 0
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if slices.ContainsFunc(words, func(word string) bool {
+			return word == term || (min(len(word), len(term)) >= 4 && (strings.HasPrefix(word, term) || strings.HasPrefix(term, word)))
+		}) {
+			score++
+		}
+	}
+	return score
+}
+
+func stringsOf(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
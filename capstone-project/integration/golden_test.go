@@ -0,0 +1,42 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata with the current responses")
+
+// assertGolden compares the indented JSON body with testdata/<name>.golden.json, after replacing the values that
+// change on every run, like the ID of a created recipe, with their placeholders. -update rewrites the file.
+func assertGolden(t *testing.T, name string, body []byte, placeholders map[string]string) {
+	t.Helper()
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		t.Fatalf("Unexpected error indenting %s: %s", body, err)
+	}
+	got := indented.String()
+	for val, placeholder := range placeholders {
+		got = strings.ReplaceAll(got, val, placeholder)
+	}
+	got += "\n"
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("Unexpected error writing %s: %s", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reading %s, run go test ./integration -update to create it: %s", path, err)
+	}
+	if got != string(want) {
+		t.Errorf("Response does not match %s, run go test ./integration -update if the change is expected.\nExpected:\n%s\nGot:\n%s", path, want, got)
+	}
+}
@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"framework-api/handlers"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// harness is a Server for one test, torn down with it.
+type harness struct {
+	*Server
+	t *testing.T
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s, err := NewServer("../templates/*.html")
+	if err != nil {
+		t.Fatalf("Unexpected error starting the server: %s", err)
+	}
+	t.Cleanup(s.Close)
+	return &harness{Server: s, t: t}
+}
+
+// token signs an access token for sub with the given claims added.
+func (h *harness) token(sub string, claims jwt.MapClaims) string {
+	h.t.Helper()
+	token, err := h.Token(sub, claims)
+	if err != nil {
+		h.t.Fatalf("Unexpected error signing token: %s", err)
+	}
+	return token
+}
+
+// do sends a request with an optional JSON body and bearer token.
+func (h *harness) do(method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	h.t.Helper()
+	return h.doIn("", method, path, body, token)
+}
+
+// doIn sends a request like do, in the tenant of the X-Tenant-ID header when tenant is not empty.
+func (h *harness) doIn(tenant, method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	h.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatalf("Unexpected error encoding body: %s", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if tenant != "" {
+		req.Header.Set(handlers.TenantHeader, tenant)
+	}
+	w := httptest.NewRecorder()
+	h.Engine.ServeHTTP(w, req)
+	return w
+}
+
+// get sends a GET with the given headers.
+func (h *harness) get(path string, header map[string]string) *httptest.ResponseRecorder {
+	h.t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for key, val := range header {
+		req.Header.Set(key, val)
+	}
+	w := httptest.NewRecorder()
+	h.Engine.ServeHTTP(w, req)
+	return w
+}
+
+// expect fails the test unless the response has status.
+func expect(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("Expected status %d, got %d: %s", status, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,210 @@
+package integration
+
+import (
+	"encoding/json"
+	"framework-api/fixtures"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRecipeLifecycleV2(t *testing.T) {
+	h := newHarness(t)
+	writer := h.token("chef", jwt.MapClaims{"scope": "recipes:write"})
+	admin := h.token("admin", jwt.MapClaims{"scope": "recipes:write", "cognito:groups": []string{"admin"}})
+
+	w := h.do(http.MethodGet, "/api/v2/recipes?limit=2", nil, "")
+	expect(t, w, http.StatusOK)
+	assertGolden(t, "v2_list_page", w.Body.Bytes(), nil)
+
+	w = h.do(http.MethodGet, "/api/v2/recipe/"+fixtures.RecipeID("tomato-soup").Hex(), nil, "")
+	expect(t, w, http.StatusOK)
+	assertGolden(t, "v2_get_tomato_soup", w.Body.Bytes(), nil)
+
+	w = h.do(http.MethodPost, "/api/v2/recipe", map[string]interface{}{
+		"name":         "Lentil Dal",
+		"tags":         []string{"indian", "vegan", "dinner"},
+		"ingredients":  []string{"1 cup red lentils", "1 onion", "2 cloves garlic", "1 tsp turmeric", "3 cups water"},
+		"instructions": []string{"Rinse the lentils.", "Simmer everything for 25 minutes.", "Season with salt."},
+		"servings":     4,
+	}, writer)
+	expect(t, w, http.StatusCreated)
+	var created struct {
+		Data struct {
+			ID          string `json:"id"`
+			PublishedAt string `json:"publishedAt"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Unexpected error decoding created recipe: %s", err)
+	}
+	if location := w.Header().Get("Location"); location != "/api/v2/recipe/"+created.Data.ID {
+		t.Errorf("Expected Location of the created recipe, got %q", location)
+	}
+	publishedAt, err := time.Parse(time.RFC3339Nano, created.Data.PublishedAt)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing publishedAt: %s", err)
+	}
+	//Reads come back from the store with the millisecond precision of BSON dates
+	placeholders := map[string]string{
+		created.Data.ID:          "<created-id>",
+		created.Data.PublishedAt: "<published-at>",
+		publishedAt.Truncate(time.Millisecond).Format(time.RFC3339Nano): "<published-at>",
+	}
+	assertGolden(t, "v2_create", w.Body.Bytes(), placeholders)
+
+	//An update in the same millisecond would give updatedAt the value, and the placeholder, of publishedAt
+	time.Sleep(time.Until(publishedAt.Truncate(time.Millisecond).Add(time.Millisecond)))
+	w = h.do(http.MethodPatch, "/api/v2/recipe/"+created.Data.ID, map[string]interface{}{"servings": 6, "tags": []string{"indian", "vegan"}}, writer)
+	expect(t, w, http.StatusOK)
+	var updated struct {
+		Data struct {
+			UpdatedAt string `json:"updatedAt"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("Unexpected error decoding updated recipe: %s", err)
+	}
+	if updated.Data.UpdatedAt == "" {
+		t.Fatalf("Expected updatedAt to be set, got %s", w.Body.String())
+	}
+	placeholders[updated.Data.UpdatedAt] = "<updated-at>"
+	assertGolden(t, "v2_patch", w.Body.Bytes(), placeholders)
+
+	w = h.do(http.MethodGet, "/api/v2/recipe/"+created.Data.ID, nil, "")
+	expect(t, w, http.StatusOK)
+	assertGolden(t, "v2_patch", w.Body.Bytes(), placeholders)
+
+	w = h.do(http.MethodDelete, "/api/v2/recipe/"+created.Data.ID, nil, admin)
+	expect(t, w, http.StatusNoContent)
+	w = h.do(http.MethodGet, "/api/v2/recipe/"+created.Data.ID, nil, "")
+	expect(t, w, http.StatusNotFound)
+	w = h.do(http.MethodDelete, "/api/v2/recipe/"+created.Data.ID, nil, admin)
+	expect(t, w, http.StatusNotFound)
+}
+
+func TestRecipeLifecycleV1(t *testing.T) {
+	h := newHarness(t)
+	writer := h.token("chef", jwt.MapClaims{"scope": "recipes:write"})
+	admin := h.token("admin", jwt.MapClaims{"cognito:groups": []string{"admin"}})
+
+	w := h.do(http.MethodGet, "/api/v1/recipes", nil, "")
+	expect(t, w, http.StatusOK)
+	if w.Header().Get("Deprecation") == "" {
+		t.Errorf("Expected a Deprecation header on v1")
+	}
+	assertGolden(t, "v1_list", w.Body.Bytes(), nil)
+
+	w = h.do(http.MethodPost, "/recipe", map[string]interface{}{"name": "Fruit Salad", "tags": []string{"vegan", "snack"}, "ingredients": []string{"1 apple", "1 banana"}, "instructions": []string{"Chop and mix."}, "servings": 2}, writer)
+	expect(t, w, http.StatusCreated)
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Unexpected error decoding created recipe: %s", err)
+	}
+
+	ts := []struct {
+		text    string
+		method  string
+		path    string
+		body    interface{}
+		token   string
+		status  int
+		message string
+	}{
+		{text: "update", method: http.MethodPatch, path: "/api/v1/recipe/" + created.ID, body: map[string]interface{}{"servings": 3}, token: writer, status: http.StatusOK, message: "Recipe Successfully Updated " + created.ID},
+		{text: "update invalid id", method: http.MethodPatch, path: "/api/v1/recipe/nope", body: map[string]interface{}{"servings": 3}, token: writer, status: http.StatusBadRequest},
+		{text: "update unknown id", method: http.MethodPatch, path: "/api/v1/recipe/" + fixtures.RecipeID("missing").Hex(), body: map[string]interface{}{"servings": 3}, token: writer, status: http.StatusNotFound},
+		{text: "delete", method: http.MethodDelete, path: "/api/v1/recipe/" + created.ID, token: admin, status: http.StatusOK, message: "Recipe deleted successfully"},
+		{text: "delete again", method: http.MethodDelete, path: "/api/v1/recipe/" + created.ID, token: admin, status: http.StatusNotFound},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		w := h.do(tc.method, tc.path, tc.body, tc.token)
+		if w.Code != tc.status {
+			t.Errorf("Expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			continue
+		}
+		if tc.message == "" {
+			continue
+		}
+		var res map[string]string
+		json.Unmarshal(w.Body.Bytes(), &res)
+		if res["message"] != tc.message {
+			t.Errorf("Expected message %q, got %q", tc.message, res["message"])
+		}
+	}
+}
+
+func TestRecipeCaching(t *testing.T) {
+	h := newHarness(t)
+	writer := h.token("chef", jwt.MapClaims{"scope": "recipes:write"})
+	id := fixtures.RecipeID("pancakes").Hex()
+
+	ts := []struct {
+		text  string
+		path  string
+		reads int
+	}{
+		{text: "list from the store", path: "/api/v2/recipes", reads: 1},
+		{text: "list from the cache", path: "/api/v2/recipes", reads: 0},
+		{text: "v1 list shares the cache", path: "/recipes", reads: 0},
+		{text: "recipe from the store", path: "/api/v2/recipe/" + id, reads: 1},
+		{text: "recipe from the cache", path: "/api/v2/recipe/" + id, reads: 0},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		before := h.Store.Calls()
+		expect(t, h.do(http.MethodGet, tc.path, nil, ""), http.StatusOK)
+		if reads := h.Store.Calls() - before; reads != tc.reads {
+			t.Errorf("Expected %d store reads, got %d", tc.reads, reads)
+		}
+	}
+
+	//A write drops the cached list and recipe, the next reads see the change
+	expect(t, h.do(http.MethodPatch, "/api/v2/recipe/"+id, map[string]interface{}{"name": "Fluffy Pancakes"}, writer), http.StatusOK)
+	if h.Redis.Exists("recipes") || h.Redis.Exists("recipe:"+id) {
+		t.Errorf("Expected the update to invalidate the cache, got keys %v", h.Redis.Keys())
+	}
+	ts = []struct {
+		text  string
+		path  string
+		reads int
+	}{
+		{text: "list after the update", path: "/api/v2/recipes", reads: 1},
+		{text: "recipe after the update", path: "/api/v2/recipe/" + id, reads: 1},
+		{text: "recipe cached again", path: "/api/v2/recipe/" + id, reads: 0},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		before := h.Store.Calls()
+		w := h.do(http.MethodGet, tc.path, nil, "")
+		expect(t, w, http.StatusOK)
+		if reads := h.Store.Calls() - before; reads != tc.reads {
+			t.Errorf("Expected %d store reads, got %d", tc.reads, reads)
+		}
+		var res map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &res)
+		if !containsName(res["data"], "Fluffy Pancakes") {
+			t.Errorf("Expected the updated name in %s", w.Body.String())
+		}
+	}
+}
+
+// containsName reports if data, a recipe or a list of recipes, has a recipe called name.
+func containsName(data interface{}, name string) bool {
+	switch data := data.(type) {
+	case map[string]interface{}:
+		return data["name"] == name
+	case []interface{}:
+		for _, item := range data {
+			if containsName(item, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
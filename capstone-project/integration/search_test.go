@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestSearchRecipes(t *testing.T) {
+	h := newHarness(t)
+
+	ts := []struct {
+		text   string
+		path   string
+		status int
+		golden string
+	}{
+		{text: "by name", path: "/api/v2/recipes/search?q=tomato", status: http.StatusOK, golden: "search_q_tomato"},
+		{text: "by tag", path: "/api/v2/recipes/search?tag=vegan", status: http.StatusOK, golden: "search_tag_vegan"},
+		{text: "by calories", path: "/api/v2/recipes/search?maxCalories=300", status: http.StatusOK, golden: "search_max_calories"},
+		{text: "tag and calories on v1", path: "/recipes/search?tag=dinner&maxCalories=600", status: http.StatusOK, golden: "search_v1_dinner"},
+		{text: "without allergens", path: "/api/v2/recipes/search?tag=vegan&excludeAllergens=fish", status: http.StatusOK, golden: "search_exclude_fish"},
+		{text: "no match", path: "/api/v2/recipes/search?q=lasagna", status: http.StatusOK, golden: "search_no_match"},
+		{text: "no query", path: "/api/v2/recipes/search", status: http.StatusBadRequest},
+		{text: "bad calories", path: "/api/v2/recipes/search?maxCalories=lots", status: http.StatusBadRequest},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		w := h.do(http.MethodGet, tc.path, nil, "")
+		if w.Code != tc.status {
+			t.Errorf("Expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			continue
+		}
+		if tc.golden != "" {
+			assertGolden(t, tc.golden, w.Body.Bytes(), nil)
+		}
+	}
+}
+
+func TestSearchFollowsWrites(t *testing.T) {
+	h := newHarness(t)
+	writer := h.token("chef", jwt.MapClaims{"scope": "recipes:write"})
+	admin := h.token("admin", jwt.MapClaims{"cognito:groups": []string{"admin"}})
+
+	w := h.do(http.MethodPost, "/api/v2/recipe", map[string]interface{}{"name": "Mushroom Risotto", "tags": []string{"italian", "dinner"}, "ingredients": []string{"1 cup arborio rice", "200 g mushrooms"}, "instructions": []string{"Stir slowly."}, "servings": 2}, writer)
+	expect(t, w, http.StatusCreated)
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	ids := func() []string {
+		w := h.do(http.MethodGet, "/api/v2/recipes/search?q=risotto", nil, "")
+		expect(t, w, http.StatusOK)
+		var res struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &res)
+		list := make([]string, 0, len(res.Data))
+		for _, hit := range res.Data {
+			list = append(list, hit.ID)
+		}
+		return list
+	}
+	if got := ids(); len(got) != 1 || got[0] != created.Data.ID {
+		t.Errorf("Expected the created recipe to be found, got %v", got)
+	}
+	expect(t, h.do(http.MethodDelete, "/api/v2/recipe/"+created.Data.ID, nil, admin), http.StatusNoContent)
+	if got := ids(); len(got) != 0 {
+		t.Errorf("Expected the deleted recipe to be gone from search, got %v", got)
+	}
+}
@@ -0,0 +1,137 @@
+package integration
+
+import (
+	"context"
+	"framework-api/fixtures"
+	"framework-api/handlers"
+	"framework-api/handlers/authtest"
+	"framework-api/handlers/storetest"
+	"framework-api/routes"
+	"framework-api/utils"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/elastic/go-elasticsearch/v9"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// Issuer and ClientID of the tokens Server accepts, signed by its Signer.
+const (
+	Issuer   = "https://issuer.test"
+	ClientID = "recipes-web"
+)
+
+// Server is the API as main wires it, backed by an in-memory recipe store, miniredis and a fake Elasticsearch,
+// seeded with the latest fixtures. Nothing is persisted, Close drops everything.
+type Server struct {
+	Engine   *gin.Engine
+	Store    *storetest.RecipeStore
+	Audit    *storetest.AuditStore
+	Redis    *miniredis.Miniredis
+	Elastic  *Elasticsearch
+	Signer   *authtest.Signer
+	Fixtures *fixtures.Set
+}
+
+// NewServer starts the backends and wires the routes, templates is the glob of the page templates, like
+// "templates/*.html" from the module root. The Redis cache starts cold.
+func NewServer(templates string) (*Server, error) {
+	ctx := context.Background()
+	set, err := fixtures.Load(fixtures.Latest)
+	if err != nil {
+		return nil, err
+	}
+	redisServer, err := miniredis.Run()
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{Store: storetest.NewRecipeStore(), Audit: storetest.NewAuditStore(), Redis: redisServer, Elastic: NewElasticsearch(), Fixtures: set}
+	if err := s.wire(ctx, templates); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Server) wire(ctx context.Context, templates string) error {
+	redisClient := redis.NewClient(&redis.Options{Addr: s.Redis.Addr()})
+	elasticClient, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{s.Elastic.URL}, MaxRetries: 1})
+	if err != nil {
+		return err
+	}
+	recipeHandler := handlers.NewRecipesHandler(ctx, s.Store, redisClient, elasticClient)
+	if err := recipeHandler.EnsureElasticIndex(ctx); err != nil {
+		return err
+	}
+	if err := recipeHandler.SeedRecipes(ctx, s.Fixtures.Recipes); err != nil {
+		return err
+	}
+	//Start from a cold cache, seeding filled it
+	s.Redis.FlushAll()
+	recipeEvents := handlers.NewRecipeEvents(redisClient, handlers.DefaultEventsMaxLen)
+	recipeHandler.AddEventPublisher(recipeEvents)
+	//Seeding is not audited, like migrations it is no user action
+	audit := handlers.NewAuditLog(s.Audit)
+	recipeHandler.SetAuditLog(audit)
+
+	if s.Signer, err = authtest.NewSigner(Issuer, ClientID); err != nil {
+		return err
+	}
+	keys, err := handlers.NewLocalKeyProvider(s.Signer.PublicKeys())
+	if err != nil {
+		return err
+	}
+	cognito, err := handlers.NewCognitoAuthenticator(Issuer, ClientID, keys, time.Minute)
+	if err != nil {
+		return err
+	}
+	graphqlHandler, err := handlers.NewGraphQLHandler(recipeHandler, nil)
+	if err != nil {
+		return err
+	}
+	authHandler := handlers.NewAuthHandler(cognito)
+	authHandler.SetAuditLog(audit)
+
+	s.Engine = gin.New()
+	s.Engine.LoadHTMLGlob(templates)
+	routes.SetupRouter(s.Engine, routes.Config{
+		CORS: utils.CORSConfig{
+			AllowOrigins: []string{"http://localhost:3000"},
+			AllowMethods: []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
+			AllowHeaders: []string{"Origin", "Content-Type", "Authorization", handlers.TenantHeader, handlers.RequestIDHeader},
+		},
+		V1Deprecation: handlers.DeprecationPolicy{
+			DeprecatedAt: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+			Sunset:       time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}, routes.Handlers{
+		Recipes:   recipeHandler,
+		Events:    recipeEvents,
+		Webhooks:  handlers.NewWebhookHandler(nil, nil),
+		GraphQL:   graphqlHandler,
+		MealPlans: handlers.NewMealPlanHandler(nil, recipeHandler),
+		Pages:     handlers.NewPageHandler(recipeHandler, "http://localhost:8088"),
+		Exports:   handlers.NewExportHandler(recipeHandler, nil, "http://localhost:8088"),
+		Imports:   handlers.NewImportHandler(recipeHandler, handlers.NewPageFetcher(nil, time.Second)),
+		Audit:     audit,
+	}, authHandler)
+	return nil
+}
+
+// Token signs an access token for sub valid for an hour, with the given claims added, such as scope or
+// cognito:groups.
+func (s *Server) Token(sub string, claims jwt.MapClaims) (string, error) {
+	all := s.Signer.Claims(sub, time.Hour)
+	for key, val := range claims {
+		all[key] = val
+	}
+	return s.Signer.Sign(all)
+}
+
+// Close stops the fake backends.
+func (s *Server) Close() {
+	s.Elastic.Close()
+	s.Redis.Close()
+}
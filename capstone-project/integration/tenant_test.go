@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"encoding/json"
+	"framework-api/fixtures"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	h := newHarness(t)
+	chef := h.token("chef", jwt.MapClaims{"scope": "recipes:write", "custom:tenant": "bistro"})
+	cook := h.token("cook", jwt.MapClaims{"scope": "recipes:write", "custom:tenant": "diner"})
+	owner := h.token("owner", jwt.MapClaims{"custom:tenant": "bistro", "cognito:groups": []string{"admin:bistro"}})
+	admin := h.token("admin", jwt.MapClaims{"cognito:groups": []string{"admin"}})
+	boundAdmin := h.token("bistro-admin", jwt.MapClaims{"custom:tenant": "bistro", "cognito:groups": []string{"admin"}})
+	fixtureID := fixtures.RecipeID("guacamole").Hex()
+
+	//Members write to the tenant of their token without a header
+	w := h.do(http.MethodPost, "/api/v2/recipe", map[string]interface{}{
+		"name":         "Bistro Onion Soup",
+		"tags":         []string{"french", "soup"},
+		"ingredients":  []string{"4 onions", "1 l beef stock", "2 slices bread", "100 g gruyere"},
+		"instructions": []string{"Caramelize the onions.", "Add the stock and simmer.", "Gratinate with bread and cheese."},
+		"servings":     4,
+	}, chef)
+	expect(t, w, http.StatusCreated)
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Unexpected error decoding created recipe: %s", err)
+	}
+	id := created.Data.ID
+
+	ts := []struct {
+		text   string
+		tenant string
+		method string
+		path   string
+		body   interface{}
+		token  string
+		status int
+		names  []string
+	}{
+		{text: "get in the tenant", method: http.MethodGet, path: "/api/v2/recipe/" + id, token: chef, status: http.StatusOK},
+		{text: "get in the default tenant", method: http.MethodGet, path: "/api/v2/recipe/" + id, status: http.StatusNotFound},
+		{text: "get as a member of another tenant", method: http.MethodGet, path: "/api/v1/recipe/" + id, token: cook, status: http.StatusNotFound},
+		{text: "get as admin in another tenant", tenant: "diner", method: http.MethodGet, path: "/api/v2/recipe/" + id, token: admin, status: http.StatusNotFound},
+		{text: "get a default recipe in the tenant", tenant: "bistro", method: http.MethodGet, path: "/api/v2/recipe/" + fixtureID, token: chef, status: http.StatusNotFound},
+		{text: "list in the tenant", method: http.MethodGet, path: "/api/v2/recipes", token: chef, status: http.StatusOK, names: []string{"Bistro Onion Soup"}},
+		{text: "search in the tenant", method: http.MethodGet, path: "/api/v2/recipes/search?q=onion", token: chef, status: http.StatusOK, names: []string{"Bistro Onion Soup"}},
+		{text: "search in a tenant without recipes", method: http.MethodGet, path: "/api/v2/recipes/search?q=onion", token: cook, status: http.StatusOK, names: []string{}},
+		{text: "update from another tenant", method: http.MethodPatch, path: "/api/v2/recipe/" + id, body: map[string]interface{}{"servings": 2}, token: cook, status: http.StatusNotFound},
+		{text: "header of another tenant", tenant: "diner", method: http.MethodGet, path: "/api/v2/recipes", token: chef, status: http.StatusForbidden},
+		{text: "anonymous in a tenant", tenant: "bistro", method: http.MethodGet, path: "/api/v2/recipes", status: http.StatusUnauthorized},
+		{text: "invalid header", tenant: "Bistro", method: http.MethodGet, path: "/api/v2/recipes", status: http.StatusBadRequest},
+		{text: "delete as a member", method: http.MethodDelete, path: "/api/v2/recipe/" + id, token: chef, status: http.StatusForbidden},
+		{text: "delete as tenant admin in the default tenant", tenant: "default", method: http.MethodDelete, path: "/api/v2/recipe/" + fixtureID, token: owner, status: http.StatusForbidden},
+		{text: "audit of another tenant as an admin bound to a tenant", tenant: "diner", method: http.MethodGet, path: "/admin/audit", token: boundAdmin, status: http.StatusForbidden},
+		{text: "webhooks of the default tenant as an admin bound to a tenant", tenant: "default", method: http.MethodGet, path: "/admin/webhooks", token: boundAdmin, status: http.StatusForbidden},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		w := h.doIn(tc.tenant, tc.method, tc.path, tc.body, tc.token)
+		if w.Code != tc.status {
+			t.Errorf("Expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			continue
+		}
+		if tc.names == nil {
+			continue
+		}
+		var res struct {
+			Data []struct {
+				Name string `json:"name"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Unexpected error decoding recipes: %s", err)
+		}
+		names := make([]string, 0, len(res.Data))
+		for _, recipe := range res.Data {
+			names = append(names, recipe.Name)
+		}
+		if !slices.Equal(names, tc.names) {
+			t.Errorf("Expected %v, got %v", tc.names, names)
+		}
+	}
+
+	//The default tenant does not see the recipe
+	w = h.do(http.MethodGet, "/api/v2/recipes?limit=100", nil, "")
+	expect(t, w, http.StatusOK)
+	if body := w.Body.String(); strings.Contains(body, id) || strings.Contains(body, "Bistro Onion Soup") {
+		t.Errorf("Expected the default list without the tenant's recipe, got %s", body)
+	}
+	w = h.do(http.MethodGet, "/api/v2/recipes/search?q=bistro", nil, "")
+	expect(t, w, http.StatusOK)
+	if strings.Contains(w.Body.String(), id) {
+		t.Errorf("Expected the default search without the tenant's recipe, got %s", w.Body.String())
+	}
+
+	//Every backend keeps the tenant apart
+	if !h.Redis.Exists("tenant:bistro:recipe:"+id) || h.Redis.Exists("recipe:"+id) {
+		t.Errorf("Expected the recipe cached under the tenant prefix only, got keys %v", h.Redis.Keys())
+	}
+	if docs := h.Elastic.Docs("recipe-bistro"); !slices.Equal(docs, []string{id}) {
+		t.Errorf("Expected the recipe indexed in recipe-bistro, got %v", docs)
+	}
+	if slices.Contains(h.Elastic.Docs("recipe"), id) {
+		t.Errorf("Expected the default index without the tenant's recipe")
+	}
+
+	w = h.do(http.MethodDelete, "/api/v2/recipe/"+id, nil, owner)
+	expect(t, w, http.StatusNoContent)
+	if len(h.Elastic.Docs("recipe-bistro")) != 0 || h.Redis.Exists("tenant:bistro:recipe:"+id) {
+		t.Errorf("Expected the recipe removed from Elasticsearch and Redis")
+	}
+	w = h.do(http.MethodGet, "/api/v2/recipe/"+fixtureID, nil, "")
+	expect(t, w, http.StatusOK)
+}
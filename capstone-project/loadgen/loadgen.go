@@ -0,0 +1,342 @@
+// Package loadgen replays a weighted mix of recipe API requests at a target rate and measures the latencies,
+// error rates and throughput per kind of request.
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Op is one kind of request.
+type Op string
+
+const (
+	OpList   Op = "list"
+	OpGet    Op = "get"
+	OpSearch Op = "search"
+	OpCreate Op = "create"
+)
+
+// Ops are all kinds of request, in report order.
+var Ops = []Op{OpList, OpGet, OpSearch, OpCreate}
+
+// Mix is the relative weight of each kind of request, {list: 3, get: 1} sends three lists for every get.
+type Mix map[Op]int
+
+// DefaultMix is mostly reads, like the recipe UI.
+var DefaultMix = Mix{OpList: 40, OpGet: 40, OpSearch: 15, OpCreate: 5}
+
+// ParseMix reads a mix like "list=40,get=40,search=15,create=5", missing ops get no requests.
+func ParseMix(s string) (Mix, error) {
+	mix := make(Mix)
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(part, "=")
+		op := Op(strings.TrimSpace(name))
+		if !ok || !slices.Contains(Ops, op) {
+			return nil, fmt.Errorf("invalid mix entry %q, expected <op>=<weight> with op one of list, get, search, create", part)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight in %q, expected a non negative integer", part)
+		}
+		mix[op] += weight
+	}
+	if mix.total() == 0 {
+		return nil, errors.New("the mix has no request with a positive weight")
+	}
+	return mix, nil
+}
+
+func (m Mix) total() int {
+	total := 0
+	for _, weight := range m {
+		total += weight
+	}
+	return total
+}
+
+// String formats the mix like ParseMix reads it.
+func (m Mix) String() string {
+	parts := make([]string, 0, len(m))
+	for _, op := range Ops {
+		if m[op] > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", op, m[op]))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Config describes one run.
+type Config struct {
+	// BaseURL is the API root, like http://localhost:8088.
+	BaseURL string
+	// Prefix is the API version the requests go to, /api/v2 by default.
+	Prefix string
+	Mix    Mix
+	// RPS is the target rate over all kinds of request, 0 sends as fast as the workers answer.
+	RPS float64
+	// Workers is the number of concurrent requests.
+	Workers  int
+	Duration time.Duration
+	// Token is the bearer token of the create requests, it needs the recipes:write scope.
+	Token string
+	// Timeout of each request, 10s by default.
+	Timeout time.Duration
+	// Seed makes the sequence of requests repeatable.
+	Seed   uint64
+	Client *http.Client
+}
+
+// target is what one request asks for.
+type target struct {
+	op  Op
+	arg string
+}
+
+// result is the outcome of one request, status 0 when no response arrived.
+type result struct {
+	op      Op
+	latency time.Duration
+	status  int
+}
+
+// Run sends requests until cfg.Duration has passed or ctx is done, then waits for the requests in flight.
+// Get and search requests pick their recipe IDs and words from the recipes listed before the run starts.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, err
+	}
+	catalog, err := discover(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if (cfg.Mix[OpGet] > 0 && len(catalog.ids) == 0) || (cfg.Mix[OpSearch] > 0 && len(catalog.words) == 0) {
+		return nil, fmt.Errorf("%s%s/recipes has no recipes to get or search, seed some or remove get and search from the mix", cfg.BaseURL, cfg.Prefix)
+	}
+
+	jobs := make(chan target)
+	results := make(chan result, cfg.Workers)
+	var workers sync.WaitGroup
+	for range cfg.Workers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				results <- cfg.send(job)
+			}
+		}()
+	}
+	recorder := newRecorder()
+	recorded := make(chan struct{})
+	go func() {
+		for res := range results {
+			recorder.add(res)
+		}
+		close(recorded)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+	started := time.Now()
+	dropped := cfg.pace(ctx, catalog, jobs)
+	close(jobs)
+	workers.Wait()
+	close(results)
+	<-recorded
+	return recorder.report(cfg, time.Since(started), dropped), nil
+}
+
+func (cfg *Config) defaults() error {
+	if _, err := url.ParseRequestURI(cfg.BaseURL); err != nil {
+		return fmt.Errorf("invalid base URL %q: %w", cfg.BaseURL, err)
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Prefix == "" {
+		cfg.Prefix = "/api/v2"
+	}
+	cfg.Prefix = "/" + strings.Trim(cfg.Prefix, "/")
+	if cfg.Mix == nil {
+		cfg.Mix = DefaultMix
+	}
+	if cfg.Mix.total() == 0 {
+		return errors.New("the mix has no request with a positive weight")
+	}
+	if cfg.Mix[OpCreate] > 0 && cfg.Token == "" {
+		return errors.New("create requests need a token with the recipes:write scope")
+	}
+	if cfg.RPS < 0 || cfg.Workers < 1 || cfg.Duration <= 0 {
+		return errors.New("rps must not be negative, workers and duration must be positive")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: cfg.Timeout}
+	}
+	return nil
+}
+
+// pace hands out requests at cfg.RPS until ctx is done. A request that finds every worker busy is dropped
+// instead of queued, so a slow server shows up as dropped requests rather than as a lower rate.
+func (cfg *Config) pace(ctx context.Context, catalog *catalog, jobs chan<- target) int {
+	rng := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed))
+	if cfg.RPS == 0 {
+		for {
+			select {
+			case <-ctx.Done():
+				return 0
+			case jobs <- catalog.pick(rng, cfg.Mix):
+			}
+		}
+	}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.RPS))
+	defer ticker.Stop()
+	dropped := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return dropped
+		case <-ticker.C:
+			select {
+			case jobs <- catalog.pick(rng, cfg.Mix):
+			default:
+				dropped++
+			}
+		}
+	}
+}
+
+func (cfg *Config) send(job target) result {
+	var req *http.Request
+	var err error
+	switch job.op {
+	case OpList:
+		req, err = http.NewRequest(http.MethodGet, cfg.BaseURL+cfg.Prefix+"/recipes", nil)
+	case OpGet:
+		req, err = http.NewRequest(http.MethodGet, cfg.BaseURL+cfg.Prefix+"/recipe/"+job.arg, nil)
+	case OpSearch:
+		req, err = http.NewRequest(http.MethodGet, cfg.BaseURL+cfg.Prefix+"/recipes/search?q="+url.QueryEscape(job.arg), nil)
+	case OpCreate:
+		req, err = http.NewRequest(http.MethodPost, cfg.BaseURL+cfg.Prefix+"/recipe", bytes.NewReader(newRecipe(job.arg)))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+cfg.Token)
+		}
+	}
+	if err != nil {
+		return result{op: job.op}
+	}
+	started := time.Now()
+	res, err := cfg.Client.Do(req)
+	if err != nil {
+		return result{op: job.op, latency: time.Since(started)}
+	}
+	//The latency includes reading the body, a large list is only done once it has arrived
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	return result{op: job.op, latency: time.Since(started), status: res.StatusCode}
+}
+
+// newRecipe is the body of a create request, tagged loadgen so the recipes are easy to find and remove.
+func newRecipe(n string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"name":         "Loadgen Recipe " + n,
+		"tags":         []string{"loadgen"},
+		"ingredients":  []string{"200 g rice", "1 onion", "2 cloves garlic"},
+		"instructions": []string{"Cook the rice.", "Fry the onion and garlic.", "Mix."},
+		"servings":     2,
+	})
+	return data
+}
+
+// catalog is what get and search requests can ask for.
+type catalog struct {
+	ids     []string
+	words   []string
+	created int
+}
+
+// discover lists the recipes once, from a v1 list or a v2 envelope.
+func discover(cfg Config) (*catalog, error) {
+	c := &catalog{}
+	if cfg.Mix[OpGet] == 0 && cfg.Mix[OpSearch] == 0 {
+		return c, nil
+	}
+	res, err := cfg.Client.Get(cfg.BaseURL + cfg.Prefix + "/recipes?limit=100")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	//v1 answers 404 when there are no recipes
+	if res.StatusCode == http.StatusNotFound {
+		return c, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing the recipes answered %s", res.Status)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	type recipe struct {
+		ID   string   `json:"id"`
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	var recipes []recipe
+	if err := json.Unmarshal(data, &recipes); err != nil {
+		var envelope struct {
+			Data []recipe `json:"data"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return nil, fmt.Errorf("listing the recipes answered neither a list nor an envelope: %w", err)
+		}
+		recipes = envelope.Data
+	}
+	for _, r := range recipes {
+		c.ids = append(c.ids, r.ID)
+		for _, word := range append(strings.Fields(strings.ToLower(r.Name)), r.Tags...) {
+			if len(word) >= 3 && !slices.Contains(c.words, word) {
+				c.words = append(c.words, word)
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c *catalog) pick(rng *rand.Rand, mix Mix) target {
+	n := rng.IntN(mix.total())
+	op := OpList
+	for _, candidate := range Ops {
+		if n < mix[candidate] {
+			op = candidate
+			break
+		}
+		n -= mix[candidate]
+	}
+	switch op {
+	case OpGet:
+		return target{op: op, arg: c.ids[rng.IntN(len(c.ids))]}
+	case OpSearch:
+		return target{op: op, arg: c.words[rng.IntN(len(c.words))]}
+	case OpCreate:
+		c.created++
+		return target{op: op, arg: strconv.Itoa(c.created)}
+	}
+	return target{op: op}
+}
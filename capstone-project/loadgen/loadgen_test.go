@@ -0,0 +1,196 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"framework-api/integration"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestParseMix(t *testing.T) {
+	ts := []struct {
+		text string
+		mix  string
+		exp  Mix
+		err  bool
+	}{
+		{text: "all ops", mix: "list=40,get=40,search=15,create=5", exp: Mix{OpList: 40, OpGet: 40, OpSearch: 15, OpCreate: 5}},
+		{text: "spaces and repeats", mix: " list = 1 , get=2,list=1,", exp: Mix{OpList: 2, OpGet: 2}},
+		{text: "zero weights are kept", mix: "list=1,create=0", exp: Mix{OpList: 1, OpCreate: 0}},
+		{text: "unknown op", mix: "list=1,delete=1", err: true},
+		{text: "missing weight", mix: "list", err: true},
+		{text: "negative weight", mix: "list=-1", err: true},
+		{text: "nothing to send", mix: "list=0", err: true},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		mix, err := ParseMix(tc.mix)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Expected an error, got %v", mix)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			continue
+		}
+		if !reflect.DeepEqual(mix, tc.exp) {
+			t.Errorf("Expected %v, got %v", tc.exp, mix)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 0, 100)
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	ts := []struct {
+		text   string
+		sorted []time.Duration
+		p      float64
+		exp    time.Duration
+	}{
+		{text: "median", sorted: sorted, p: 50, exp: 50 * time.Millisecond},
+		{text: "p99", sorted: sorted, p: 99, exp: 99 * time.Millisecond},
+		{text: "max", sorted: sorted, p: 100, exp: 100 * time.Millisecond},
+		{text: "p0 is the min", sorted: sorted, p: 0, exp: time.Millisecond},
+		{text: "nearest rank rounds up", sorted: sorted[:3], p: 50, exp: 2 * time.Millisecond},
+		{text: "empty", p: 50, exp: 0},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if got := Percentile(tc.sorted, tc.p); got != tc.exp {
+			t.Errorf("Expected %s, got %s", tc.exp, got)
+		}
+	}
+}
+
+func TestRunInMemory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server, err := integration.NewServer("../templates/*.html")
+	if err != nil {
+		t.Fatalf("Unexpected error starting the server: %s", err)
+	}
+	defer server.Close()
+	httpServer := httptest.NewServer(server.Engine)
+	defer httpServer.Close()
+	token, err := server.Token("loadgen", jwt.MapClaims{"scope": "recipes:write"})
+	if err != nil {
+		t.Fatalf("Unexpected error signing token: %s", err)
+	}
+
+	for _, prefix := range []string{"/api/v2", "/api/v1"} {
+		t.Logf("Testing %s", prefix)
+		report, err := Run(context.Background(), Config{BaseURL: httpServer.URL, Prefix: prefix, RPS: 200, Workers: 4, Duration: 300 * time.Millisecond, Token: token})
+		if err != nil {
+			t.Fatalf("Unexpected error running: %s", err)
+		}
+		if len(report.Ops) != len(Ops) {
+			t.Errorf("Expected stats for %d ops, got %d", len(Ops), len(report.Ops))
+		}
+		if report.Total.Requests == 0 || report.Total.Errors != 0 {
+			t.Errorf("Expected requests without errors, got %+v", report.Total)
+		}
+		sum := 0
+		for _, s := range report.Ops {
+			sum += s.Requests
+			if s.Requests > 0 && (s.Latency.P50 <= 0 || s.Latency.P50 > s.Latency.P99 || s.Latency.P99 > s.Latency.Max) {
+				t.Errorf("Expected ordered percentiles for %s, got %+v", s.Op, s.Latency)
+			}
+		}
+		if sum != report.Total.Requests {
+			t.Errorf("Expected the total to sum the ops, got %d and %d", sum, report.Total.Requests)
+		}
+
+		var table bytes.Buffer
+		report.WriteTable(&table)
+		if !strings.Contains(table.String(), "P99 MS") || !strings.Contains(table.String(), "total") {
+			t.Errorf("Expected a table with a total row, got %s", table.String())
+		}
+		var buf bytes.Buffer
+		report.WriteJSON(&buf)
+		var decoded Report
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("Unexpected error decoding the JSON report: %s", err)
+		}
+		if !reflect.DeepEqual(&decoded, report) {
+			t.Errorf("Expected the JSON report to round trip, got %+v", decoded)
+		}
+	}
+}
+
+func TestRunCountsErrors(t *testing.T) {
+	//Lists work, every get fails and search does not answer in time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v2/recipes":
+			w.Write([]byte(`{"data": [{"id": "1", "name": "Tomato Soup", "tags": ["soup"]}]}`))
+		case strings.HasPrefix(r.URL.Path, "/api/v2/recipe/"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	report, err := Run(context.Background(), Config{BaseURL: server.URL, Mix: Mix{OpList: 1, OpGet: 1, OpSearch: 1}, Workers: 4, Duration: 300 * time.Millisecond, Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unexpected error running: %s", err)
+	}
+	ts := []struct {
+		text      string
+		stats     OpStats
+		errorRate float64
+		status    string
+	}{
+		{text: "list", stats: report.Ops[0], errorRate: 0, status: "200"},
+		{text: "get", stats: report.Ops[1], errorRate: 1, status: "500"},
+		{text: "search", stats: report.Ops[2], errorRate: 1, status: "error"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if tc.stats.Requests == 0 {
+			t.Errorf("Expected requests, got none")
+			continue
+		}
+		if tc.stats.ErrorRate != tc.errorRate {
+			t.Errorf("Expected error rate %v, got %v", tc.errorRate, tc.stats.ErrorRate)
+		}
+		if tc.stats.Statuses[tc.status] != tc.stats.Requests {
+			t.Errorf("Expected every request to end with %s, got %v", tc.status, tc.stats.Statuses)
+		}
+	}
+}
+
+func TestRunChecksConfig(t *testing.T) {
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": []}`))
+	}))
+	defer empty.Close()
+	ts := []struct {
+		text string
+		cfg  Config
+	}{
+		{text: "bad url", cfg: Config{BaseURL: "localhost", Workers: 1, Duration: time.Second}},
+		{text: "create without token", cfg: Config{BaseURL: empty.URL, Mix: Mix{OpCreate: 1}, Workers: 1, Duration: time.Second}},
+		{text: "no workers", cfg: Config{BaseURL: empty.URL, Mix: Mix{OpList: 1}, Duration: time.Second}},
+		{text: "nothing to get", cfg: Config{BaseURL: empty.URL, Mix: Mix{OpGet: 1}, Workers: 1, Duration: time.Second}},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if _, err := Run(context.Background(), tc.cfg); err == nil {
+			t.Errorf("Expected an error")
+		}
+	}
+}
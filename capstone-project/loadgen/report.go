@@ -0,0 +1,172 @@
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is the outcome of a run. Latencies are in milliseconds.
+type Report struct {
+	BaseURL   string  `json:"baseUrl"`
+	Prefix    string  `json:"prefix"`
+	Mix       string  `json:"mix"`
+	TargetRPS float64 `json:"targetRps"`
+	Workers   int     `json:"workers"`
+	Elapsed   float64 `json:"elapsedSeconds"`
+	// Dropped counts the requests not sent because every worker was busy, the target rate was not reached.
+	Dropped int       `json:"dropped"`
+	Ops     []OpStats `json:"ops"`
+	Total   OpStats   `json:"total"`
+}
+
+// OpStats describes the requests of one kind. A request is an error when it got no response or a status of
+// 400 or more; Statuses counts them by status code, "error" for the ones without a response.
+type OpStats struct {
+	Op         Op             `json:"op"`
+	Requests   int            `json:"requests"`
+	Errors     int            `json:"errors"`
+	ErrorRate  float64        `json:"errorRate"`
+	Throughput float64        `json:"throughput"`
+	Statuses   map[string]int `json:"statuses"`
+	Latency    Latency        `json:"latencyMs"`
+}
+
+type Latency struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// recorder collects the results per kind of request.
+type recorder struct {
+	latencies map[Op][]time.Duration
+	statuses  map[Op]map[string]int
+	errors    map[Op]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{latencies: make(map[Op][]time.Duration), statuses: make(map[Op]map[string]int), errors: make(map[Op]int)}
+}
+
+func (r *recorder) add(res result) {
+	r.latencies[res.op] = append(r.latencies[res.op], res.latency)
+	if r.statuses[res.op] == nil {
+		r.statuses[res.op] = make(map[string]int)
+	}
+	status := "error"
+	if res.status != 0 {
+		status = strconv.Itoa(res.status)
+	}
+	r.statuses[res.op][status]++
+	if res.status == 0 || res.status >= 400 {
+		r.errors[res.op]++
+	}
+}
+
+func (r *recorder) report(cfg Config, elapsed time.Duration, dropped int) *Report {
+	report := &Report{
+		BaseURL:   cfg.BaseURL,
+		Prefix:    cfg.Prefix,
+		Mix:       cfg.Mix.String(),
+		TargetRPS: cfg.RPS,
+		Workers:   cfg.Workers,
+		Elapsed:   round(elapsed.Seconds()),
+		Dropped:   dropped,
+		Ops:       make([]OpStats, 0, len(Ops)),
+	}
+	all := make([]time.Duration, 0)
+	statuses := make(map[string]int)
+	errors := 0
+	for _, op := range Ops {
+		if cfg.Mix[op] == 0 {
+			continue
+		}
+		report.Ops = append(report.Ops, stats(op, r.latencies[op], r.statuses[op], r.errors[op], elapsed))
+		all = append(all, r.latencies[op]...)
+		for status, n := range r.statuses[op] {
+			statuses[status] += n
+		}
+		errors += r.errors[op]
+	}
+	report.Total = stats("total", all, statuses, errors, elapsed)
+	return report
+}
+
+func stats(op Op, latencies []time.Duration, statuses map[string]int, errors int, elapsed time.Duration) OpStats {
+	s := OpStats{Op: op, Requests: len(latencies), Errors: errors, Statuses: statuses}
+	if s.Statuses == nil {
+		s.Statuses = make(map[string]int)
+	}
+	if len(latencies) == 0 {
+		return s
+	}
+	s.ErrorRate = round(float64(errors) / float64(len(latencies)))
+	s.Throughput = round(float64(len(latencies)) / elapsed.Seconds())
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	var sum time.Duration
+	for _, latency := range sorted {
+		sum += latency
+	}
+	s.Latency = Latency{
+		Mean: ms(sum / time.Duration(len(sorted))),
+		P50:  ms(Percentile(sorted, 50)),
+		P90:  ms(Percentile(sorted, 90)),
+		P95:  ms(Percentile(sorted, 95)),
+		P99:  ms(Percentile(sorted, 99)),
+		Max:  ms(sorted[len(sorted)-1]),
+	}
+	return s
+}
+
+// Percentile returns the nearest-rank p-th percentile of sorted, 0 when it is empty.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+func ms(d time.Duration) float64 {
+	return round(float64(d) / float64(time.Millisecond))
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
+
+// WriteTable writes one row per kind of request and a total row.
+func (r *Report) WriteTable(w io.Writer) error {
+	fmt.Fprintf(w, "%s%s  mix %s  target %s rps  %d workers  %.1fs  %d dropped\n\n", r.BaseURL, r.Prefix, r.Mix, targetRPS(r.TargetRPS), r.Workers, r.Elapsed, r.Dropped)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OP\tREQUESTS\tERRORS\tERROR RATE\tREQ/S\tMEAN MS\tP50 MS\tP90 MS\tP95 MS\tP99 MS\tMAX MS\t")
+	for _, s := range append(r.Ops, r.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f%%\t%.1f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t\n", s.Op, s.Requests, s.Errors, s.ErrorRate*100, s.Throughput,
+			s.Latency.Mean, s.Latency.P50, s.Latency.P90, s.Latency.P95, s.Latency.P99, s.Latency.Max)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func targetRPS(rps float64) string {
+	if rps == 0 {
+		return "unlimited"
+	}
+	return strconv.FormatFloat(rps, 'f', -1, 64)
+}
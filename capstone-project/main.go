@@ -5,35 +5,24 @@ import (
 	"framework-api/utils"
 
 	//"crypto/tls"
+	"framework-api/grpcapi"
 	"framework-api/handlers"
+	"framework-api/i18n"
+	"framework-api/migrations"
+	"framework-api/routes"
+	"net"
 	"os"
 
-	_ "framework-api/docs"
-	"net/http"
 	"time"
 
-	"github.com/MicahParks/keyfunc/v2"
 	"github.com/elastic/go-elasticsearch/v9"
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-	"go.uber.org/zap"
-	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
 )
 
-type Recipe struct {
-	ID           bson.ObjectID `json:"id" bson:"_id"`
-	Name         string        `json:"name" bson:"name"`
-	Tags         []string      `json:"tags" bson:"tags"`
-	Ingredients  []string      `json:"ingredients" bson:"ingredients"`
-	Instructions []string      `json:"instructions" bson:"instructions"`
-	PublishedAt  time.Time     `json:"publishedAt" bson:"publishedAt"`
-}
-
 // mongodb connection
 var ctx context.Context
 var err error
@@ -44,17 +33,37 @@ var redisClient *redis.Client
 // From RecipeHandler
 var recipeHandler *handlers.RecipeHandler
 
+// Recipe change feed, published to Redis and streamed to SSE clients
+var recipeEvents *handlers.RecipeEvents
+
+// Outbound webhooks, deliveries are sent by a background worker
+var webhookDispatcher *handlers.WebhookDispatcher
+var webhookHandler *handlers.WebhookHandler
+
+// Weekly meal plans of the users
+var mealPlanHandler *handlers.MealPlanHandler
+
+// Server rendered recipe pages, sitemap.xml and robots.txt
+var pageHandler *handlers.PageHandler
+
+// PDF, Markdown and plain text exports of recipes and cookbooks
+var exportHandler *handlers.ExportHandler
+
+// Previews of recipes imported from the schema.org JSON-LD of web pages
+var importHandler *handlers.ImportHandler
+
+// GraphQL endpoint over the recipe catalog
+var graphqlHandler *handlers.GraphQLHandler
+
+// Append-only audit trail, served to the tenant admins at /admin/audit
+var auditLog *handlers.AuditLog
+
 // From AuthHandler
 var authHandler *handlers.AuthHandler
 
-// For AWS Service
-var region string
-var userPoolID string
-var clientID string
-var issuer string
-var jwks *keyfunc.JWKS
 var logger *zap.Logger
 var loggerCleanup func()
+var routerConfig routes.Config
 
 func init() {
 	logger, loggerCleanup, err = utils.InitLogger()
@@ -80,6 +89,12 @@ func init() {
 	logger.Info("Connected to mongodb client")
 	collectionRecipes = client.Database("recipeDB").Collection("recipes")
 	logger.Info("Connected to mongodb collection recipes")
+	//Indexes and backfills, also run by go run ./cmd/migrate
+	if utils.GetEnvBool("MIGRATE_ON_STARTUP", true) {
+		if err := migrateRecipeDB(client.Database("recipeDB")); err != nil {
+			logger.Fatal("Failed to migrate recipeDB", zap.Error(err))
+		}
+	}
 
 	//Setup Redis
 	redisClient = redis.NewClient(&redis.Options{
@@ -107,35 +122,103 @@ func init() {
 		logger.Info("Connected to elasticsearch")
 	}
 
-	//Setup AWS
-	region = os.Getenv("AWS_REGION")
-	userPoolID = os.Getenv("AWS_USER_POOL_ID")
-	clientID = os.Getenv("AWS_CLIENT_ID")
-	issuer = os.Getenv("AWS_ISSUER")
-	jwks, err = handlers.InitCognitoJWKS(region, userPoolID)
+	//Setup authentication, by default only AWS Cognito, AUTH_CONFIG_FILE adds OIDC/local issuers and API keys
+	authenticators, err := initAuthenticators()
+	if err != nil {
+		logger.Fatal("Failed to initialize authenticators", zap.Error(err))
+	}
+	recipeHandler = handlers.NewRecipesHandler(ctx, handlers.NewMongoRecipeStore(collectionRecipes), redisClient, elasticsearchClient)
+	locales, err := i18n.NewMatcher(utils.GetEnvList("SUPPORTED_LOCALES", i18n.DefaultSupported))
+	if err != nil {
+		logger.Fatal("Invalid SUPPORTED_LOCALES", zap.Error(err))
+	}
+	recipeHandler.SetLocales(locales)
+	//Language analyzers for the localized fields, search still works with the dynamic mapping without them
+	if err := recipeHandler.EnsureElasticIndex(ctx); err != nil {
+		logger.Error("Failed to set up the recipe index mapping", zap.Error(err))
+	}
+	//Append-only audit trail of the writes, rejected credentials and admin actions
+	auditLog = handlers.NewAuditLog(handlers.NewMongoAuditStore(client.Database("recipeDB")))
+	recipeHandler.SetAuditLog(auditLog)
+	recipeEvents = handlers.NewRecipeEvents(redisClient, int64(utils.GetEnvInt("RECIPE_EVENTS_MAXLEN", handlers.DefaultEventsMaxLen)))
+	recipeHandler.AddEventPublisher(recipeEvents)
+	webhookStore := handlers.NewMongoWebhookStore(client.Database("recipeDB"))
+	webhookDispatcher = handlers.NewWebhookDispatcher(webhookStore, handlers.WebhookConfig{
+		MaxAttempts: utils.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		BaseBackoff: utils.GetEnvDuration("WEBHOOK_BASE_BACKOFF", 30*time.Second),
+		MaxBackoff:  utils.GetEnvDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+		Timeout:     utils.GetEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		//Only for local receivers in development, webhooks must not reach internal services
+		AllowPrivateNetworks: utils.GetEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+	})
+	webhookHandler = handlers.NewWebhookHandler(webhookStore, webhookDispatcher)
+	webhookHandler.SetAuditLog(auditLog)
+	recipeHandler.AddEventPublisher(webhookDispatcher)
+	mealPlanStore := handlers.NewMongoMealPlanStore(client.Database("recipeDB"))
+	mealPlanHandler = handlers.NewMealPlanHandler(mealPlanStore, recipeHandler)
+	//Deleted recipes are flagged in the meal plans referencing them
+	recipeHandler.AddEventPublisher(mealPlanHandler)
+	publicBaseURL := utils.GetEnv("PUBLIC_BASE_URL", "http://localhost:8088")
+	pageHandler = handlers.NewPageHandler(recipeHandler, publicBaseURL)
+	//Remote recipe images are only embedded in PDFs from these hosts
+	exportHandler = handlers.NewExportHandler(recipeHandler, handlers.NewImageLoader("static", utils.GetEnvList("EXPORT_IMAGE_HOSTS", nil)), publicBaseURL)
+	//Recipe pages are only fetched from these hosts, uploads work without
+	importHandler = handlers.NewImportHandler(recipeHandler, handlers.NewPageFetcher(utils.GetEnvList("IMPORT_ALLOWED_HOSTS", nil), utils.GetEnvDuration("IMPORT_FETCH_TIMEOUT", 10*time.Second)))
+	graphqlHandler, err = handlers.NewGraphQLHandler(recipeHandler, handlers.NewMongoReviewStore(client.Database("recipeDB")))
 	if err != nil {
-		logger.Fatal("Failed to initialize AWS Cognito JWKS", zap.Error(err))
+		logger.Fatal("Failed to parse GraphQL schema", zap.Error(err))
 	}
-	recipeHandler = handlers.NewRecipesHandler(ctx, collectionRecipes, redisClient, elasticsearchClient)
 	logger.Info("Initialize Authentication Handler")
-	authHandler = handlers.NewAuthHandler()
+	authHandler = handlers.NewAuthHandler(authenticators...)
+	authHandler.SetAuditLog(auditLog)
+	logger.Info("Accepted token issuers", zap.Strings("issuers", authHandler.Issuers()))
+	routerConfig = routes.Config{
+		CORS: utils.LoadCORSConfig(),
+		V1Deprecation: handlers.DeprecationPolicy{
+			DeprecatedAt: utils.GetEnvTime("API_V1_DEPRECATED_AT", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)),
+			Sunset:       utils.GetEnvTime("API_V1_SUNSET", time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+	logger.Info("Loaded CORS config", zap.Strings("origins", routerConfig.CORS.AllowOrigins), zap.Strings("methods", routerConfig.CORS.AllowMethods))
+	logger.Info("API v1 deprecation", zap.Time("deprecatedAt", routerConfig.V1Deprecation.DeprecatedAt), zap.Time("sunset", routerConfig.V1Deprecation.Sunset))
 }
 
-// Swagger Documentation
-// @title Recipe API
-// @version 1.0
-// @description This is a sample recipe management API.
-// @termsOfService http://swagger.io/terms/
-
-// @contact.name API Support
-// @contact.url http://www.swagger.io/support
-// @contact.email support@swagger.io
-
-// @license.name Apache 2.0
-// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+func migrateRecipeDB(db *mongo.Database) error {
+	migrator, err := migrations.New(db, migrations.NewMongoRecordStore(db), migrations.RecipeDB)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	logger.Info("Migrated recipeDB", zap.Int("applied", len(applied)))
+	return err
+}
 
-// @host localhost:8088
-// @BasePath /
+func initAuthenticators() ([]handlers.Authenticator, error) {
+	jwksConfig := handlers.JWKSConfig{
+		RefreshInterval:  utils.GetEnvDuration("AUTH_JWKS_REFRESH_INTERVAL", time.Hour),
+		RefreshRateLimit: utils.GetEnvDuration("AUTH_JWKS_REFRESH_RATE_LIMIT", 5*time.Minute),
+	}
+	if path := os.Getenv("AUTH_CONFIG_FILE"); path != "" {
+		authConfig, err := handlers.LoadAuthConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		return authConfig.Authenticators(jwksConfig)
+	}
+	//Setup AWS
+	region := os.Getenv("AWS_REGION")
+	userPoolID := os.Getenv("AWS_USER_POOL_ID")
+	jwksConfig.URL = utils.GetEnv("AUTH_JWKS_URL", handlers.CognitoJWKSURL(region, userPoolID))
+	jwks, err := handlers.NewJWKSKeyProvider(jwksConfig)
+	if err != nil {
+		return nil, err
+	}
+	cognito, err := handlers.NewCognitoAuthenticator(os.Getenv("AWS_ISSUER"), os.Getenv("AWS_CLIENT_ID"), jwks, utils.GetEnvDuration("AUTH_CLOCK_SKEW", time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	return []handlers.Authenticator{cognito}, nil
+}
 
 func main() {
 	if loggerCleanup != nil {
@@ -143,38 +226,39 @@ func main() {
 	}
 	logger.Info("Initializing server-main now...")
 	engine := gin.Default()
-	engine.LoadHTMLGlob("static/*.html")
+	engine.LoadHTMLGlob("templates/*.html")
 	engine.Static("/static", "static")
 	engine.StaticFile("/favicon.ico", "static/images/cooking.png")
-	//Setting up CORS
-	engine.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "OPTIONS", "HEAD"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           24 * time.Hour,
-	}))
-	//Check Server API status
-	engine.GET("/", recipeHandler.HomePageHandler)
-	engine.GET("/ping", func(c *gin.Context) {
-		c.JSON(http.StatusOK, "pong")
-	})
-
-	//RECIPE APIs
-	engine.GET("/recipes", recipeHandler.GetRecipes)
-	engine.GET("/recipe/:id", recipeHandler.GetRecipeById)
-	engine.GET("/recipes/search", recipeHandler.SearchRecipeInElasticStore)
+	//Versioned recipe routes (/api/v1, /api/v2 and the deprecated root aliases) and CORS
+	routes.SetupRouter(engine, routerConfig, routes.Handlers{
+		Recipes:   recipeHandler,
+		Events:    recipeEvents,
+		Webhooks:  webhookHandler,
+		GraphQL:   graphqlHandler,
+		MealPlans: mealPlanHandler,
+		Pages:     pageHandler,
+		Exports:   exportHandler,
+		Imports:   importHandler,
+		Audit:     auditLog,
+	}, authHandler)
 
-	//Swagger Route
-	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	//Deliver webhooks in the background, every instance runs a worker
+	go webhookDispatcher.Run(ctx)
 
-	//AUTH Middleware (Protects the routes below)
-	//engine.Use(authHandler.AuthMiddleware(jwks, issuer, clientID))
-	engine.POST("/recipe", recipeHandler.InsertRecipe)
-	engine.PATCH("/recipe/:id", recipeHandler.UpdateRecipeById)
-	engine.DELETE("/recipe/:id", recipeHandler.DeleteRecipeById)
-	//Setting up CORS
+	//gRPC API for internal services on its own port, sharing the handlers above
+	grpcAddr := utils.GetEnv("GRPC_ADDR", ":9090")
+	listener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		logger.Fatal("Failed to listen for gRPC", zap.String("addr", grpcAddr), zap.Error(err))
+	}
+	grpcServer := grpcapi.NewServer(authHandler, recipeHandler, recipeEvents)
+	defer grpcServer.GracefulStop()
+	go func() {
+		logger.Info("Serving gRPC", zap.String("addr", grpcAddr))
+		if err := grpcServer.Serve(listener); err != nil {
+			logger.Fatal("Failed to serve gRPC", zap.Error(err))
+		}
+	}()
 
 	//start the server
 	if err := engine.Run(":8088"); err != nil {
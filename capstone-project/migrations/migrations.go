@@ -0,0 +1,158 @@
+// Package migrations applies versioned, ordered changes to the recipe database, such as indexes and data
+// backfills, and records the applied versions in the schema_migrations collection.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+)
+
+// Migration is one change of the database. Up and Down must be idempotent, a step interrupted before it was
+// recorded runs again. A nil Down means there is nothing to revert, such as a backfill.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+// Record is an applied migration, stored in schema_migrations with the version as _id.
+type Record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// Status is a migration with the time it was applied, nil when it is pending.
+// Unknown migrations were applied by a newer build and are not in its list.
+type Status struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+	Unknown     bool
+}
+
+// RecordStore keeps the applied migrations.
+type RecordStore interface {
+	// Applied returns the applied migrations by version.
+	Applied(ctx context.Context) ([]Record, error)
+	// Insert succeeds when the version was already recorded, by another instance migrating at the same time.
+	Insert(ctx context.Context, record Record) error
+	Delete(ctx context.Context, version int) error
+}
+
+// Migrator runs migrations against a database.
+type Migrator struct {
+	db         *mongo.Database
+	records    RecordStore
+	migrations []Migration
+}
+
+// New checks that migrations have increasing positive versions and an Up step.
+func New(db *mongo.Database, records RecordStore, migrations []Migration) (*Migrator, error) {
+	for i, m := range migrations {
+		if m.Version <= 0 || m.Up == nil {
+			return nil, fmt.Errorf("migration %d needs a positive version and an Up step", m.Version)
+		}
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			return nil, fmt.Errorf("migration %d is not after %d, versions must increase", m.Version, migrations[i-1].Version)
+		}
+	}
+	return &Migrator{db: db, records: records, migrations: migrations}, nil
+}
+
+// Up applies the pending migrations in version order, also those older than the latest applied one, and
+// returns what it applied. It stops at the first failure, the migrations before it stay applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range m.migrations {
+		if slices.Contains(applied, migration.Version) {
+			continue
+		}
+		zap.L().Info("Applying migration", zap.Int("version", migration.Version), zap.String("description", migration.Description))
+		if err := migration.Up(ctx, m.db); err != nil {
+			return done, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+		if err := m.records.Insert(ctx, Record{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now().UTC()}); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down reverts the latest steps applied migrations, newest first, and returns what it reverted.
+// Applied migrations unknown to this build can not be reverted and fail before anything changes.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var todo []Migration
+	for i := len(applied) - 1; i >= 0 && len(todo) < steps; i-- {
+		idx := slices.IndexFunc(m.migrations, func(migration Migration) bool { return migration.Version == applied[i] })
+		if idx < 0 {
+			return nil, fmt.Errorf("migration %d was applied by a newer build and can not be reverted", applied[i])
+		}
+		todo = append(todo, m.migrations[idx])
+	}
+	var done []Migration
+	for _, migration := range todo {
+		zap.L().Info("Reverting migration", zap.Int("version", migration.Version), zap.String("description", migration.Description))
+		if migration.Down != nil {
+			if err := migration.Down(ctx, m.db); err != nil {
+				return done, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+			}
+		}
+		if err := m.records.Delete(ctx, migration.Version); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Status lists every migration by version, applied or pending, and the applied ones unknown to this build.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	records, err := m.records.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, Status{Version: migration.Version, Description: migration.Description})
+	}
+	for _, record := range records {
+		appliedAt := record.AppliedAt
+		idx := slices.IndexFunc(statuses, func(s Status) bool { return s.Version == record.Version })
+		if idx < 0 {
+			statuses = append(statuses, Status{Version: record.Version, Description: record.Description, AppliedAt: &appliedAt, Unknown: true})
+			continue
+		}
+		statuses[idx].AppliedAt = &appliedAt
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return a.Version - b.Version })
+	return statuses, nil
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) ([]int, error) {
+	records, err := m.records.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int, 0, len(records))
+	for _, record := range records {
+		versions = append(versions, record.Version)
+	}
+	slices.Sort(versions)
+	return versions, nil
+}
@@ -0,0 +1,145 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type fakeRecordStore struct {
+	records []Record
+}
+
+func (s *fakeRecordStore) Applied(ctx context.Context) ([]Record, error) {
+	return s.records, nil
+}
+
+func (s *fakeRecordStore) Insert(ctx context.Context, record Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *fakeRecordStore) Delete(ctx context.Context, version int) error {
+	s.records = slices.DeleteFunc(s.records, func(r Record) bool { return r.Version == version })
+	return nil
+}
+
+func (s *fakeRecordStore) versions() []int {
+	versions := make([]int, 0)
+	for _, r := range s.records {
+		versions = append(versions, r.Version)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// fakeMigrations log their steps to calls, the Up step of failing returns an error.
+func fakeMigrations(calls *[]string, failing int) []Migration {
+	step := func(name string, version int) func(ctx context.Context, db *mongo.Database) error {
+		return func(ctx context.Context, db *mongo.Database) error {
+			if version == failing {
+				return errors.New("boom")
+			}
+			*calls = append(*calls, name)
+			return nil
+		}
+	}
+	return []Migration{
+		{Version: 1, Description: "one", Up: step("up1", 1), Down: step("down1", -1)},
+		{Version: 2, Description: "two", Up: step("up2", 2)},
+		{Version: 5, Description: "five", Up: step("up5", 5), Down: step("down5", -1)},
+	}
+}
+
+func TestNew(t *testing.T) {
+	up := func(ctx context.Context, db *mongo.Database) error { return nil }
+	ts := []struct {
+		text       string
+		migrations []Migration
+		valid      bool
+	}{
+		{text: "recipeDB", migrations: RecipeDB, valid: true},
+		{text: "decreasing versions", migrations: []Migration{{Version: 2, Up: up}, {Version: 1, Up: up}}},
+		{text: "duplicate versions", migrations: []Migration{{Version: 1, Up: up}, {Version: 1, Up: up}}},
+		{text: "zero version", migrations: []Migration{{Version: 0, Up: up}}},
+		{text: "missing up", migrations: []Migration{{Version: 1}}},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if _, err := New(nil, &fakeRecordStore{}, tc.migrations); (err == nil) != tc.valid {
+			t.Errorf("Expected valid %t, got %v", tc.valid, err)
+		}
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	records := &fakeRecordStore{records: []Record{{Version: 2, Description: "two"}}}
+	migrator, _ := New(nil, records, fakeMigrations(&calls, 0))
+
+	//Pending migrations older than the latest applied one are applied too
+	applied, err := migrator.Up(ctx)
+	if err != nil || len(applied) != 2 || !reflect.DeepEqual(calls, []string{"up1", "up5"}) {
+		t.Fatalf("Expected 1 and 5 applied, got %v, %v: %v", calls, applied, err)
+	}
+	if !reflect.DeepEqual(records.versions(), []int{1, 2, 5}) {
+		t.Errorf("Expected versions 1, 2 and 5 recorded, got %v", records.versions())
+	}
+	if applied, _ := migrator.Up(ctx); len(applied) != 0 {
+		t.Errorf("Expected nothing to apply, got %v", applied)
+	}
+
+	//2 has no Down step, it is only unrecorded
+	calls = nil
+	reverted, err := migrator.Down(ctx, 2)
+	if err != nil || len(reverted) != 2 || reverted[0].Version != 5 || !reflect.DeepEqual(calls, []string{"down5"}) {
+		t.Errorf("Expected 5 then 2 reverted, got %v, %v: %v", calls, reverted, err)
+	}
+	if !reflect.DeepEqual(records.versions(), []int{1}) {
+		t.Errorf("Expected version 1 left, got %v", records.versions())
+	}
+	if reverted, _ := migrator.Down(ctx, 5); len(reverted) != 1 {
+		t.Errorf("Expected only 1 reverted, got %v", reverted)
+	}
+}
+
+func TestUpStopsAtFailure(t *testing.T) {
+	var calls []string
+	records := &fakeRecordStore{}
+	migrator, _ := New(nil, records, fakeMigrations(&calls, 2))
+	applied, err := migrator.Up(context.Background())
+	if err == nil || len(applied) != 1 || !reflect.DeepEqual(records.versions(), []int{1}) {
+		t.Errorf("Expected an error after applying 1, got %v with %v", applied, err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	var calls []string
+	appliedAt := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	records := &fakeRecordStore{records: []Record{{Version: 1, Description: "one", AppliedAt: appliedAt}, {Version: 9, Description: "nine", AppliedAt: appliedAt}}}
+	migrator, _ := New(nil, records, fakeMigrations(&calls, 0))
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error getting status: %s", err)
+	}
+	exp := []Status{
+		{Version: 1, Description: "one", AppliedAt: &appliedAt},
+		{Version: 2, Description: "two"},
+		{Version: 5, Description: "five"},
+		{Version: 9, Description: "nine", AppliedAt: &appliedAt, Unknown: true},
+	}
+	if !reflect.DeepEqual(statuses, exp) {
+		t.Errorf("Expected %+v, got %+v", exp, statuses)
+	}
+
+	//Migrations of a newer build can not be reverted
+	if _, err := migrator.Down(context.Background(), 1); err == nil || len(records.records) != 2 {
+		t.Errorf("Expected an error reverting 9, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"framework-api/i18n"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ErrDuplicates is returned when a unique index can not be created because documents share the value.
+var ErrDuplicates = errors.New("duplicate values must be removed before creating the unique index")
+
+// RecipeDB are the migrations of recipeDB, shared with the mini recipes API which signs users up in users.
+// New migrations are appended with the next version, applied ones are never edited.
+var RecipeDB = []Migration{
+	{
+		Version:     1,
+		Description: "Unique index on users.username",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			err := createIndex(ctx, db.Collection("users"), mongo.IndexModel{
+				Keys:    bson.D{{Key: "username", Value: 1}},
+				Options: options.Index().SetName("username_unique").SetUnique(true),
+			})
+			if mongo.IsDuplicateKeyError(err) {
+				return fmt.Errorf("users.username: %w", ErrDuplicates)
+			}
+			return err
+		},
+		Down: dropIndex("users", "username_unique"),
+	},
+	{
+		Version:     2,
+		Description: "Index on recipes.publishedAt, newest first",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndex(ctx, db.Collection("recipes"), mongo.IndexModel{
+				Keys:    bson.D{{Key: "publishedAt", Value: -1}},
+				Options: options.Index().SetName("publishedAt_desc"),
+			})
+		},
+		Down: dropIndex("recipes", "publishedAt_desc"),
+	},
+	{
+		Version:     3,
+		Description: "Index on recipes.tags",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndex(ctx, db.Collection("recipes"), mongo.IndexModel{
+				Keys:    bson.D{{Key: "tags", Value: 1}},
+				Options: options.Index().SetName("tags"),
+			})
+		},
+		Down: dropIndex("recipes", "tags"),
+	},
+	{
+		Version:     4,
+		Description: "Backfill the locale of recipes stored before translations",
+		//Recipes without a locale are already served as i18n.DefaultLocale, there is nothing to revert
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("recipes").UpdateMany(ctx,
+				bson.M{"$or": bson.A{bson.M{"locale": bson.M{"$exists": false}}, bson.M{"locale": ""}}},
+				bson.M{"$set": bson.M{"locale": i18n.DefaultLocale}})
+			return err
+		},
+	},
+	{
+		Version:     5,
+		Description: "Backfill empty tags of recipes stored without tags",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("recipes").UpdateMany(ctx, bson.M{"tags": nil}, bson.M{"$set": bson.M{"tags": bson.A{}}})
+			return err
+		},
+	},
+	{
+		Version:     6,
+		Description: "Index on recipes.tenant and publishedAt, newest first",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndex(ctx, db.Collection("recipes"), mongo.IndexModel{
+				Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "publishedAt", Value: -1}},
+				Options: options.Index().SetName("tenant_publishedAt_desc"),
+			})
+		},
+		Down: dropIndex("recipes", "tenant_publishedAt_desc"),
+	},
+	{
+		Version:     7,
+		Description: "Index on auditLog.tenant and at, newest first",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndex(ctx, db.Collection("auditLog"), mongo.IndexModel{
+				Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "at", Value: -1}},
+				Options: options.Index().SetName("tenant_at_desc"),
+			})
+		},
+		Down: dropIndex("auditLog", "tenant_at_desc"),
+	},
+	{
+		Version:     8,
+		Description: "Index on reviews.tenant, recipeId and createdAt, newest first",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndex(ctx, db.Collection("reviews"), mongo.IndexModel{
+				Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "recipeId", Value: 1}, {Key: "createdAt", Value: -1}},
+				Options: options.Index().SetName("tenant_recipeId_createdAt_desc"),
+			})
+		},
+		Down: dropIndex("reviews", "tenant_recipeId_createdAt_desc"),
+	},
+	{
+		Version:     9,
+		Description: "Unique index on mealPlans.tenant, owner and weekStart, index on mealPlans.slots.recipeId",
+		//The owner/week index of the plans made before tenants would keep a user to one plan a week in all tenants. The
+		//names are the ones mongo gives by default, the indexes created by earlier releases on start are kept.
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndex("mealPlans", "owner_1_weekStart_-1")(ctx, db); err != nil {
+				return err
+			}
+			plans := db.Collection("mealPlans")
+			if err := createIndex(ctx, plans, mongo.IndexModel{
+				Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "owner", Value: 1}, {Key: "weekStart", Value: -1}},
+				Options: options.Index().SetName("tenant_1_owner_1_weekStart_-1").SetUnique(true),
+			}); err != nil {
+				return err
+			}
+			return createIndex(ctx, plans, mongo.IndexModel{
+				Keys:    bson.D{{Key: "slots.recipeId", Value: 1}},
+				Options: options.Index().SetName("slots.recipeId_1"),
+			})
+		},
+		//Fails while a user has plans for the same week in several tenants, remove them and run down again
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, name := range []string{"slots.recipeId_1", "tenant_1_owner_1_weekStart_-1"} {
+				if err := dropIndex("mealPlans", name)(ctx, db); err != nil {
+					return err
+				}
+			}
+			err := createIndex(ctx, db.Collection("mealPlans"), mongo.IndexModel{
+				Keys:    bson.D{{Key: "owner", Value: 1}, {Key: "weekStart", Value: -1}},
+				Options: options.Index().SetName("owner_1_weekStart_-1").SetUnique(true),
+			})
+			if mongo.IsDuplicateKeyError(err) {
+				return fmt.Errorf("mealPlans.owner/weekStart: %w", ErrDuplicates)
+			}
+			return err
+		},
+	},
+}
+
+// createIndex is idempotent, creating an index that exists with the same keys and options does nothing.
+func createIndex(ctx context.Context, collection *mongo.Collection, index mongo.IndexModel) error {
+	_, err := collection.Indexes().CreateOne(ctx, index)
+	return err
+}
+
+// dropIndex drops the named index, doing nothing when it or its collection does not exist.
+func dropIndex(collection, name string) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		err := db.Collection(collection).Indexes().DropOne(ctx, name)
+		var se mongo.ServerError
+		//26 is NamespaceNotFound, 27 IndexNotFound
+		if errors.As(err, &se) && (se.HasErrorCode(26) || se.HasErrorCode(27)) {
+			return nil
+		}
+		return err
+	}
+}
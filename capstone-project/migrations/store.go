@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MongoRecordStore keeps the applied migrations in the schema_migrations collection.
+type MongoRecordStore struct {
+	records *mongo.Collection
+}
+
+func NewMongoRecordStore(db *mongo.Database) *MongoRecordStore {
+	return &MongoRecordStore{records: db.Collection("schema_migrations")}
+}
+
+func (s *MongoRecordStore) Applied(ctx context.Context) ([]Record, error) {
+	cursor, err := s.records.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0)
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *MongoRecordStore) Insert(ctx context.Context, record Record) error {
+	_, err := s.records.InsertOne(ctx, record)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *MongoRecordStore) Delete(ctx context.Context, version int) error {
+	_, err := s.records.DeleteOne(ctx, bson.M{"_id": version})
+	return err
+}
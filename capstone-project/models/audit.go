@@ -0,0 +1,61 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Audited actions. The mini recipes API writes the sign-in and sign-up entries to the same collection.
+const (
+	AuditRecipeCreate     = "recipe.create"
+	AuditRecipeUpdate     = "recipe.update"
+	AuditRecipeDelete     = "recipe.delete"
+	AuditWebhookCreate    = "webhook.create"
+	AuditWebhookDelete    = "webhook.delete"
+	AuditWebhookRedeliver = "webhook.redeliver"
+	AuditAuthRejected     = "auth.rejected"
+	AuditAuthAccepted     = "auth.accepted"
+	AuditAuthSignIn       = "auth.signin"
+	AuditAuthSignUp       = "auth.signup"
+)
+
+// Audit outcomes.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEntry is one action in the append-only audit trail: who did what to which resource, when and from where.
+// BeforeHash and AfterHash fingerprint the resource around a write, see AuditHash, so an auditor can check that
+// the entries of a resource chain up without the trail holding copies of the data.
+type AuditEntry struct {
+	ID         bson.ObjectID `json:"id" bson:"_id"`
+	At         time.Time     `json:"at" bson:"at"`
+	Action     string        `json:"action" bson:"action"`
+	Outcome    string        `json:"outcome" bson:"outcome"`
+	Actor      string        `json:"actor,omitempty" bson:"actor,omitempty"`
+	ActorName  string        `json:"actorName,omitempty" bson:"actorName,omitempty"`
+	IP         string        `json:"ip,omitempty" bson:"ip,omitempty"`
+	RequestID  string        `json:"requestId,omitempty" bson:"requestId,omitempty"`
+	Resource   string        `json:"resource,omitempty" bson:"resource,omitempty"`
+	ResourceID string        `json:"resourceId,omitempty" bson:"resourceId,omitempty"`
+	BeforeHash string        `json:"beforeHash,omitempty" bson:"beforeHash,omitempty"`
+	AfterHash  string        `json:"afterHash,omitempty" bson:"afterHash,omitempty"`
+	Detail     string        `json:"detail,omitempty" bson:"detail,omitempty"`
+	// Tenant the action happened in, empty in the default tenant. Set by the audit log.
+	Tenant string `json:"-" bson:"tenant,omitempty"`
+}
+
+// AuditHash is the hex SHA-256 of the JSON encoding of v, "" when v can not be encoded.
+func AuditHash(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,10 @@
+package models
+
+// TagConflict is a tag naming a diet the ingredients break, like "vegan" on a recipe with butter.
+// Allergens, diets and conflicts are derived from the ingredients whenever a recipe is stored.
+type TagConflict struct {
+	Tag string `json:"tag" bson:"tag"`
+	// Contains lists the categories excluded by the diet, Ingredients the lines they were found in.
+	Contains    []string `json:"contains" bson:"contains"`
+	Ingredients []string `json:"ingredients" bson:"ingredients"`
+}
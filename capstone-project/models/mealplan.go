@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Weekdays are the days of a meal plan, Meals the slots of a day, both in calendar order.
+var (
+	Weekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+	Meals    = []string{"breakfast", "lunch", "snack", "dinner"}
+)
+
+// MealPlan is a user's plan for the week starting on WeekStart, a Monday formatted as 2006-01-02.
+// A user has at most one plan per week in each tenant.
+type MealPlan struct {
+	ID        bson.ObjectID `json:"id" bson:"_id"`
+	Owner     string        `json:"-" bson:"owner"`
+	Name      string        `json:"name" bson:"name"`
+	WeekStart string        `json:"weekStart" bson:"weekStart"`
+	Slots     []MealSlot    `json:"slots" bson:"slots"`
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt" bson:"updatedAt"`
+	// Tenant the plan was made in, empty in the default tenant. The store sets it, it is never sent to clients.
+	Tenant string `json:"-" bson:"tenant,omitempty"`
+}
+
+// MealSlot is a recipe planned for a meal of a day. RecipeName is copied when the plan is saved,
+// so the plan still reads well after the recipe was deleted, RecipeDeleted is set then.
+type MealSlot struct {
+	Day           string        `json:"day" bson:"day"`
+	Meal          string        `json:"meal" bson:"meal"`
+	RecipeID      bson.ObjectID `json:"recipeId" bson:"recipeId"`
+	RecipeName    string        `json:"recipeName" bson:"recipeName"`
+	Servings      int           `json:"servings" bson:"servings"`
+	RecipeDeleted bool          `json:"recipeDeleted" bson:"recipeDeleted"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// NutritionFacts are energy in kcal and macronutrients in grams.
+type NutritionFacts struct {
+	Calories      float64 `json:"calories" bson:"calories"`
+	Protein       float64 `json:"protein" bson:"protein"`
+	Carbohydrates float64 `json:"carbohydrates" bson:"carbohydrates"`
+	Fat           float64 `json:"fat" bson:"fat"`
+	Fiber         float64 `json:"fiber" bson:"fiber"`
+}
+
+// Nutrition is computed from the ingredients whenever a recipe is stored, clients can not set it.
+type Nutrition struct {
+	Total      NutritionFacts `json:"total" bson:"total"`
+	PerServing NutritionFacts `json:"perServing" bson:"perServing"`
+	// Unmatched ingredients are not counted, the values are a lower bound when it is not empty.
+	Unmatched []string `json:"unmatched" bson:"unmatched"`
+}
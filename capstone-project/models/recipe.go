@@ -13,7 +13,34 @@ type Recipe struct {
 	Ingredients  []string      `json:"ingredients" bson:"ingredients"`
 	Instructions []string      `json:"instructions" bson:"instructions"`
 	PublishedAt  time.Time     `json:"publishedAt" bson:"publishedAt"`
+	// UpdatedAt is the time of the last update, zero for recipes never updated.
+	UpdatedAt    time.Time     `json:"updatedAt,omitzero" bson:"updatedAt,omitempty"`
 	ImageURL     string        `json:"imageUrl" bson:"imageUrl"`
+	Servings     int           `json:"servings" bson:"servings"`
+	Nutrition    *Nutrition    `json:"nutrition,omitempty" bson:"nutrition,omitempty"`
+	Allergens    []string      `json:"allergens" bson:"allergens"`
+	Diets        []string      `json:"diets" bson:"diets"`
+	TagConflicts []TagConflict `json:"tagConflicts" bson:"tagConflicts"`
+	// Locale is the BCP 47 locale of Name, Ingredients and Instructions.
+	Locale       string                       `json:"locale" bson:"locale"`
+	Translations map[string]RecipeTranslation `json:"translations,omitempty" bson:"translations,omitempty"`
+	// Tenant owning the recipe, empty in the default tenant. The store sets it, it is never sent to clients.
+	Tenant string `json:"-" bson:"tenant,omitempty"`
+}
+
+// LastModified is the time the recipe last changed, its update or else its publish time.
+func (r Recipe) LastModified() time.Time {
+	if r.UpdatedAt.After(r.PublishedAt) {
+		return r.UpdatedAt
+	}
+	return r.PublishedAt
+}
+
+// RecipeTranslation is the content of a recipe in another locale, empty fields fall back to the recipe's own.
+type RecipeTranslation struct {
+	Name         string   `json:"name,omitempty" bson:"name,omitempty"`
+	Ingredients  []string `json:"ingredients,omitempty" bson:"ingredients,omitempty"`
+	Instructions []string `json:"instructions,omitempty" bson:"instructions,omitempty"`
 }
 
 type RecipeSearchResult struct {
@@ -21,4 +48,8 @@ type RecipeSearchResult struct {
 	Name     string   `json:"name"`
 	Tags     []string `json:"tags"`
 	ImageURL string   `json:"imageUrl"`
+	// CaloriesPerServing is missing for recipes stored before nutrition was computed.
+	CaloriesPerServing *float64 `json:"caloriesPerServing,omitempty"`
+	// Locale of Name, missing in results cached before recipes were localized.
+	Locale string `json:"locale,omitempty"`
 }
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Review is a user's rating of a recipe from 1 to 5, stored in the reviews collection by the seed command.
+type Review struct {
+	ID        bson.ObjectID `json:"id" bson:"_id"`
+	RecipeID  bson.ObjectID `json:"recipeId" bson:"recipeId"`
+	Username  string        `json:"username" bson:"username"`
+	Rating    int           `json:"rating" bson:"rating"`
+	Comment   string        `json:"comment" bson:"comment"`
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+	// Tenant of the reviewed recipe, empty in the default tenant.
+	Tenant string `json:"-" bson:"tenant,omitempty"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Webhook is a partner subscription to recipe lifecycle events.
+// The secret signs every delivery and is only returned when the webhook is created.
+type Webhook struct {
+	ID        bson.ObjectID `json:"id" bson:"_id"`
+	URL       string        `json:"url" bson:"url"`
+	Events    []string      `json:"events" bson:"events"`
+	Secret    string        `json:"-" bson:"secret"`
+	Active    bool          `json:"active" bson:"active"`
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+	// Tenant whose recipe events the webhook receives, empty in the default tenant. Set by the store.
+	Tenant string `json:"-" bson:"tenant,omitempty"`
+}
+
+// Delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event sent to one webhook, with the log of every attempt.
+type WebhookDelivery struct {
+	ID            bson.ObjectID    `json:"id" bson:"_id"`
+	WebhookID     bson.ObjectID    `json:"webhookId" bson:"webhookId"`
+	Event         string           `json:"event" bson:"event"`
+	Payload       string           `json:"payload" bson:"payload"`
+	Status        string           `json:"status" bson:"status"`
+	Attempts      []WebhookAttempt `json:"attempts" bson:"attempts"`
+	NextAttemptAt time.Time        `json:"nextAttemptAt,omitzero" bson:"nextAttemptAt"`
+	LockedUntil   time.Time        `json:"-" bson:"lockedUntil"`
+	RedeliveryOf  *bson.ObjectID   `json:"redeliveryOf,omitempty" bson:"redeliveryOf,omitempty"`
+	CreatedAt     time.Time        `json:"createdAt" bson:"createdAt"`
+	// Tenant of the webhook, the worker looks the webhook up in it.
+	Tenant string `json:"-" bson:"tenant,omitempty"`
+}
+
+// WebhookAttempt is one HTTP request of a delivery. StatusCode is 0 when no response was received.
+type WebhookAttempt struct {
+	At         time.Time `json:"at" bson:"at"`
+	StatusCode int       `json:"statusCode" bson:"statusCode"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs int64     `json:"durationMs" bson:"durationMs"`
+}
@@ -0,0 +1,161 @@
+// Package nutrition computes calories and macronutrients of recipes from their ingredient lines,
+// using a per-100g nutrient table bundled with the binary (nutrients.json).
+package nutrition
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"framework-api/models"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed nutrients.json
+var bundledTable []byte
+
+// Entry is one food of the table. Volumes are converted with GramsPerMl (water when unset),
+// counts like "2 eggs" or "3 cloves garlic" need GramsPerPiece.
+type Entry struct {
+	Name          string                `json:"name"`
+	Aliases       []string              `json:"aliases"`
+	Per100g       models.NutritionFacts `json:"per100g"`
+	GramsPerMl    float64               `json:"gramsPerMl"`
+	GramsPerPiece float64               `json:"gramsPerPiece"`
+}
+
+// Table matches ingredient text to entries, the longest matching name or alias wins,
+// so "olive oil" is not counted as "oil" and "peanut butter" not as "butter".
+type Table struct {
+	entries []Entry
+	keys    []tableKey
+}
+
+type tableKey struct {
+	words string
+	entry int
+}
+
+var defaultTable = sync.OnceValue(func() *Table {
+	table, err := Load(bytes.NewReader(bundledTable))
+	if err != nil {
+		panic("nutrition: invalid bundled nutrients.json: " + err.Error())
+	}
+	return table
+})
+
+// Default returns the bundled table.
+func Default() *Table {
+	return defaultTable()
+}
+
+// Load reads a table in the nutrients.json format.
+func Load(r io.Reader) (*Table, error) {
+	var entries []Entry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	t := &Table{entries: entries}
+	for i, entry := range entries {
+		for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+			if words := Normalize(name); words != "" {
+				t.keys = append(t.keys, tableKey{words: words, entry: i})
+			}
+		}
+	}
+	sort.SliceStable(t.keys, func(i, j int) bool { return len(t.keys[i].words) > len(t.keys[j].words) })
+	return t, nil
+}
+
+// Match returns the entry named in an ingredient line.
+func (t *Table) Match(text string) (Entry, bool) {
+	words := " " + Normalize(text) + " "
+	for _, key := range t.keys {
+		if strings.Contains(words, " "+key.words+" ") {
+			return t.entries[key.entry], true
+		}
+	}
+	return Entry{}, false
+}
+
+// Grams estimates the weight of an ingredient line. It returns false when the line names no known food
+// or has no usable quantity, "to taste" lines weigh 0.
+func (t *Table) Grams(text string) (Entry, float64, bool) {
+	entry, ok := t.Match(text)
+	if !ok {
+		return entry, 0, false
+	}
+	quantity, unit, rest := parseIngredient(text)
+	if quantity == 0 {
+		return entry, 0, strings.Contains(rest, "to taste")
+	}
+	switch unit.kind {
+	case unitMass:
+		return entry, quantity * unit.factor, true
+	case unitVolume:
+		density := entry.GramsPerMl
+		if density == 0 {
+			density = 1
+		}
+		return entry, quantity * unit.factor * density, true
+	default:
+		if entry.GramsPerPiece == 0 {
+			return entry, 0, false
+		}
+		return entry, quantity * entry.GramsPerPiece, true
+	}
+}
+
+// Compute sums the ingredients. Servings below 1 count as one serving.
+func (t *Table) Compute(ingredients []string, servings int) models.Nutrition {
+	result := models.Nutrition{Unmatched: make([]string, 0)}
+	for _, line := range ingredients {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, grams, ok := t.Grams(line)
+		if !ok {
+			result.Unmatched = append(result.Unmatched, line)
+			continue
+		}
+		result.Total = add(result.Total, scale(entry.Per100g, grams/100))
+	}
+	result.Total = round(result.Total)
+	result.PerServing = round(scale(result.Total, 1/float64(max(servings, 1))))
+	return result
+}
+
+func add(a, b models.NutritionFacts) models.NutritionFacts {
+	return models.NutritionFacts{
+		Calories:      a.Calories + b.Calories,
+		Protein:       a.Protein + b.Protein,
+		Carbohydrates: a.Carbohydrates + b.Carbohydrates,
+		Fat:           a.Fat + b.Fat,
+		Fiber:         a.Fiber + b.Fiber,
+	}
+}
+
+func scale(f models.NutritionFacts, by float64) models.NutritionFacts {
+	return models.NutritionFacts{
+		Calories:      f.Calories * by,
+		Protein:       f.Protein * by,
+		Carbohydrates: f.Carbohydrates * by,
+		Fat:           f.Fat * by,
+		Fiber:         f.Fiber * by,
+	}
+}
+
+// round keeps one decimal, the table is not more precise than that.
+func round(f models.NutritionFacts) models.NutritionFacts {
+	r := func(v float64) float64 { return math.Round(v*10) / 10 }
+	return models.NutritionFacts{
+		Calories:      r(f.Calories),
+		Protein:       r(f.Protein),
+		Carbohydrates: r(f.Carbohydrates),
+		Fat:           r(f.Fat),
+		Fiber:         r(f.Fiber),
+	}
+}
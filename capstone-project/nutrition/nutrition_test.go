@@ -0,0 +1,96 @@
+package nutrition
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseIngredient(t *testing.T) {
+	ts := []struct {
+		text     string
+		quantity float64
+		kind     unitKind
+		factor   float64
+		rest     string
+	}{
+		{text: "2 cups flour", quantity: 2, kind: unitVolume, factor: 236.6, rest: "flour"},
+		{text: "1 1/2 tbsp. olive oil", quantity: 1.5, kind: unitVolume, factor: 14.79, rest: "olive oil"},
+		{text: "½ tsp salt", quantity: 0.5, kind: unitVolume, factor: 4.93, rest: "salt"},
+		{text: "200g spaghetti", quantity: 200, kind: unitMass, factor: 1, rest: "spaghetti"},
+		{text: "2-3 tomatoes", quantity: 2.5, kind: unitPiece, factor: 1, rest: "tomatoes"},
+		{text: "3 eggs", quantity: 3, kind: unitPiece, factor: 1, rest: "eggs"},
+		{text: "a pinch of salt", quantity: 1, kind: unitVolume, factor: 0.31, rest: "of salt"},
+		{text: "Salt to taste", quantity: 0, kind: unitPiece, factor: 1, rest: "salt to taste"},
+		{text: "inf cups sugar", quantity: 0, kind: unitPiece, factor: 1, rest: "inf cups sugar"},
+		{text: "NaN cups sugar", quantity: 0, kind: unitPiece, factor: 1, rest: "nan cups sugar"},
+		{text: "1e400 cups sugar", quantity: 0, kind: unitPiece, factor: 1, rest: "1e400 cups sugar"},
+		{text: "infinity/2 cups sugar", quantity: 0, kind: unitPiece, factor: 1, rest: "infinity/2 cups sugar"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		quantity, unit, rest := parseIngredient(tc.text)
+		if quantity != tc.quantity || unit.kind != tc.kind || unit.factor != tc.factor || rest != tc.rest {
+			t.Errorf("Expected %v %v %v %q, got %v %v %v %q", tc.quantity, tc.kind, tc.factor, tc.rest, quantity, unit.kind, unit.factor, rest)
+		}
+	}
+}
+
+func TestMatchPrefersLongestName(t *testing.T) {
+	table := Default()
+	ts := []struct {
+		text string
+		name string
+	}{
+		{text: "2 tbsp olive oil", name: "olive oil"},
+		{text: "1 tbsp oil", name: "vegetable oil"},
+		{text: "3 tbsp peanut butter", name: "peanut butter"},
+		{text: "1 cup chicken stock", name: "chicken broth"},
+		{text: "2 chicken breasts", name: "chicken breast"},
+		{text: "4 large tomatoes", name: "tomato"},
+		{text: "1 can chickpeas", name: "chickpeas"},
+		{text: "2 green onions, sliced", name: "spring onion"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		entry, ok := table.Match(tc.text)
+		if !ok || entry.Name != tc.name {
+			t.Errorf("Expected %s, got %s (matched %v)", tc.name, entry.Name, ok)
+		}
+	}
+	if _, ok := table.Match("1 dragonfruit"); ok {
+		t.Errorf("Expected no match for an unknown food")
+	}
+}
+
+func TestCompute(t *testing.T) {
+	table := Default()
+	nutrition := table.Compute([]string{
+		"200 g spaghetti",
+		"2 tbsp olive oil",
+		"3 cloves garlic",
+		"salt to taste",
+		"1 bunch of something exotic",
+	}, 2)
+	//spaghetti 742 + olive oil 2*14.79*0.92*8.84 = 240.6 + garlic 3*3*1.49 = 13.4
+	if math.Abs(nutrition.Total.Calories-996) > 1 {
+		t.Errorf("Expected about 996 kcal, got %v", nutrition.Total.Calories)
+	}
+	if math.Abs(nutrition.PerServing.Calories-nutrition.Total.Calories/2) > 0.1 {
+		t.Errorf("Expected half the calories per serving, got %v of %v", nutrition.PerServing.Calories, nutrition.Total.Calories)
+	}
+	if len(nutrition.Unmatched) != 1 || nutrition.Unmatched[0] != "1 bunch of something exotic" {
+		t.Errorf("Expected only the exotic ingredient unmatched, got %v", nutrition.Unmatched)
+	}
+
+	//A quantity that is no finite number leaves the line unmatched instead of the totals infinite
+	infinite := table.Compute([]string{"inf cups sugar", "2 eggs"}, 2)
+	if infinite.Total.Calories != 143 || math.IsNaN(infinite.Total.Protein) || len(infinite.Unmatched) != 1 {
+		t.Errorf("Expected only the eggs counted, got %+v", infinite)
+	}
+
+	//Without servings the whole recipe is one serving
+	single := table.Compute([]string{"2 eggs"}, 0)
+	if single.PerServing != single.Total || single.Total.Calories != 143 {
+		t.Errorf("Expected 143 kcal in one serving, got %+v", single)
+	}
+}
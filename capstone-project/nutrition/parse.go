@@ -0,0 +1,151 @@
+package nutrition
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type unitKind int
+
+const (
+	unitPiece unitKind = iota
+	unitMass
+	unitVolume
+)
+
+// unit converts to grams (mass) or millilitres (volume), pieces are weighed by the entry.
+type unit struct {
+	kind   unitKind
+	factor float64
+}
+
+var units = map[string]unit{
+	"mg": {unitMass, 0.001}, "g": {unitMass, 1}, "gram": {unitMass, 1}, "gr": {unitMass, 1},
+	"kg": {unitMass, 1000}, "kilogram": {unitMass, 1000},
+	"oz": {unitMass, 28.35}, "ounce": {unitMass, 28.35},
+	"lb": {unitMass, 453.6}, "lbs": {unitMass, 453.6}, "pound": {unitMass, 453.6},
+	//A standard can of tomatoes, beans or coconut milk
+	"can": {unitMass, 400}, "tin": {unitMass, 400},
+
+	"ml": {unitVolume, 1}, "millilitre": {unitVolume, 1}, "milliliter": {unitVolume, 1},
+	"l": {unitVolume, 1000}, "litre": {unitVolume, 1000}, "liter": {unitVolume, 1000},
+	"tsp": {unitVolume, 4.93}, "teaspoon": {unitVolume, 4.93},
+	"tbsp": {unitVolume, 14.79}, "tablespoon": {unitVolume, 14.79}, "tbs": {unitVolume, 14.79},
+	"cup":   {unitVolume, 236.6},
+	"pinch": {unitVolume, 0.31}, "dash": {unitVolume, 0.62},
+
+	"piece": {unitPiece, 1}, "clove": {unitPiece, 1}, "slice": {unitPiece, 1}, "stalk": {unitPiece, 1},
+	"fillet": {unitPiece, 1}, "whole": {unitPiece, 1},
+}
+
+var unicodeFractions = map[rune]string{
+	'¼': " 1/4", '½': " 1/2", '¾': " 3/4", '⅓': " 1/3", '⅔': " 2/3", '⅛': " 1/8",
+}
+
+// parseIngredient splits "1 1/2 cups flour" into the quantity 1.5, the unit and the rest of the line.
+// Ranges like "2-3" count as their middle, "a" and "an" as 1, a line without a leading number has quantity 0.
+// Without a unit the quantity is a count of pieces.
+func parseIngredient(text string) (float64, unit, string) {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if frac, ok := unicodeFractions[r]; ok {
+			b.WriteString(frac)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	fields := strings.Fields(b.String())
+
+	quantity := 0.0
+	i := 0
+	if len(fields) > 0 && (fields[0] == "a" || fields[0] == "an" || fields[0] == "one") {
+		quantity, i = 1, 1
+	}
+	for ; i < len(fields); i++ {
+		n, ok := parseNumber(fields[i])
+		if !ok {
+			//"200g" and "2tbsp" carry the unit in the same field
+			num, suffix := splitNumberPrefix(fields[i])
+			if n, ok := parseNumber(num); ok && num != "" {
+				if _, isUnit := units[singular(suffix)]; isUnit {
+					quantity += n
+					fields[i] = suffix
+				}
+			}
+			break
+		}
+		quantity += n
+	}
+	rest := fields[i:]
+	found := unit{kind: unitPiece, factor: 1}
+	if quantity > 0 && len(rest) > 0 {
+		if u, ok := units[singular(strings.TrimSuffix(rest[0], "."))]; ok {
+			found = u
+			rest = rest[1:]
+		}
+	}
+	return quantity, found, strings.Join(rest, " ")
+}
+
+func parseNumber(field string) (float64, bool) {
+	if lo, hi, ok := strings.Cut(field, "-"); ok {
+		a, okA := parseNumber(lo)
+		b, okB := parseNumber(hi)
+		return (a + b) / 2, okA && okB
+	}
+	if num, den, ok := strings.Cut(field, "/"); ok {
+		a, errA := strconv.ParseFloat(num, 64)
+		b, errB := strconv.ParseFloat(den, 64)
+		if errA != nil || errB != nil || b == 0 || !finite(a/b) {
+			return 0, false
+		}
+		return a / b, true
+	}
+	n, err := strconv.ParseFloat(field, 64)
+	return n, err == nil && n >= 0 && finite(n)
+}
+
+// finite rejects the "inf" and "NaN" ParseFloat accepts, they would make the nutrition unencodable as JSON.
+func finite(n float64) bool {
+	return !math.IsInf(n, 0) && !math.IsNaN(n)
+}
+
+func splitNumberPrefix(field string) (string, string) {
+	for i, r := range field {
+		if !unicode.IsDigit(r) && r != '.' && r != '/' {
+			return field[:i], field[i:]
+		}
+	}
+	return field, ""
+}
+
+// Normalize lowercases text to singular words separated by single spaces, dropping digits and punctuation.
+// Tables matching ingredient text should normalize both sides with it.
+func Normalize(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	})
+	kept := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.Trim(word, "-"); word != "" {
+			kept = append(kept, singular(word))
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// singular strips common English plural endings. It is applied to the table and the text alike,
+// so it only has to be consistent, not correct.
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "oes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 3:
+		return word[:len(word)-1]
+	}
+	return word
+}
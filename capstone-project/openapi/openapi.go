@@ -0,0 +1,295 @@
+// Package openapi builds an OpenAPI 3.1 document in code.
+// Schemas are derived from the Go models by reflection, so the document follows the structs the handlers bind and return.
+// The mini recipes API builds its document with a generated copy of this file, run go generate ./openapi there after
+// changing it.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string   `json:"title"`
+	Version     string   `json:"version"`
+	Description string   `json:"description,omitempty"`
+	License     *License `json:"license,omitempty"`
+}
+
+type License struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps a lower case HTTP method to its operation.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+}
+
+// SecurityRequirement maps a security scheme name to the scopes it needs.
+type SecurityRequirement map[string][]string
+
+// Schema is the subset of JSON Schema 2020-12 used by the document.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+}
+
+// New creates an empty document.
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: make(map[string]SecurityScheme),
+		},
+	}
+}
+
+// AddSchema registers the schema of model under name and returns a reference to it.
+func (d *Document) AddSchema(name string, model interface{}) *Schema {
+	d.Components.Schemas[name] = SchemaOf(model)
+	return Ref(name)
+}
+
+// AddOperation documents method on a Gin style path, e.g. /recipe/:id becomes /recipe/{id}.
+func (d *Document) AddOperation(method, ginPath string, op Operation) {
+	path := OpenAPIPath(ginPath)
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = &op
+}
+
+// Handler serves the document as JSON.
+func (d *Document) Handler() gin.HandlerFunc {
+	data, err := json.Marshal(d)
+	return func(c *gin.Context) {
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render OpenAPI document"})
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+	}
+}
+
+// OpenAPIPath converts Gin path params (:id, *any) to OpenAPI templates ({id}, {any}).
+func OpenAPIPath(ginPath string) string {
+	parts := strings.Split(ginPath, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+func JSONContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// JSONResponse describes a JSON response body, a nil schema means no body.
+func JSONResponse(description string, schema *Schema) Response {
+	if schema == nil {
+		return Response{Description: description}
+	}
+	return Response{Description: description, Content: JSONContent(schema)}
+}
+
+func JSONBody(description string, schema *Schema) *RequestBody {
+	return &RequestBody{Description: description, Required: true, Content: JSONContent(schema)}
+}
+
+func PathParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "string"}}
+}
+
+func QueryParam(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func HeaderParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaOf derives a JSON schema from a Go value using its json tags.
+// Fields with binding:"required" are required. Structs without binding tags treat every field
+// without omitempty as required. bson ObjectIDs are 24 hex character strings.
+func SchemaOf(model interface{}) *Schema {
+	return schemaOfType(reflect.TypeOf(model))
+}
+
+func schemaOfType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t.Name() == "ObjectID" && strings.HasSuffix(t.PkgPath(), "/bson") {
+		return &Schema{Type: "string", Pattern: "^[0-9a-f]{24}$", Description: "MongoDB ObjectID"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return ArrayOf(schemaOfType(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOfType(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	hasBinding := false
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("binding"); ok {
+			hasBinding = true
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		//Embedded structs without a json name are flattened, like encoding/json does
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := structSchema(field.Type)
+			for prop, s := range embedded.Properties {
+				schema.Properties[prop] = s
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaOfType(field.Type)
+		if hasBinding {
+			if strings.Contains(field.Tag.Get("binding"), "required") {
+				schema.Required = append(schema.Required, name)
+			}
+		} else if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
@@ -0,0 +1,1306 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: recipes/v1/recipes.proto
+
+package recipesv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RecipeEvent_Type int32
+
+const (
+	RecipeEvent_TYPE_UNSPECIFIED RecipeEvent_Type = 0
+	RecipeEvent_TYPE_CREATED     RecipeEvent_Type = 1
+	RecipeEvent_TYPE_UPDATED     RecipeEvent_Type = 2
+	RecipeEvent_TYPE_DELETED     RecipeEvent_Type = 3
+	// The replay history no longer reaches last_event_id, the client has to reload the catalog.
+	RecipeEvent_TYPE_RESET RecipeEvent_Type = 4
+)
+
+// Enum value maps for RecipeEvent_Type.
+var (
+	RecipeEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_CREATED",
+		2: "TYPE_UPDATED",
+		3: "TYPE_DELETED",
+		4: "TYPE_RESET",
+	}
+	RecipeEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_CREATED":     1,
+		"TYPE_UPDATED":     2,
+		"TYPE_DELETED":     3,
+		"TYPE_RESET":       4,
+	}
+)
+
+func (x RecipeEvent_Type) Enum() *RecipeEvent_Type {
+	p := new(RecipeEvent_Type)
+	*p = x
+	return p
+}
+
+func (x RecipeEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RecipeEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_recipes_v1_recipes_proto_enumTypes[0].Descriptor()
+}
+
+func (RecipeEvent_Type) Type() protoreflect.EnumType {
+	return &file_recipes_v1_recipes_proto_enumTypes[0]
+}
+
+func (x RecipeEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RecipeEvent_Type.Descriptor instead.
+func (RecipeEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{15, 0}
+}
+
+type Recipe struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name         string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Tags         []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	Ingredients  []string               `protobuf:"bytes,4,rep,name=ingredients,proto3" json:"ingredients,omitempty"`
+	Instructions []string               `protobuf:"bytes,5,rep,name=instructions,proto3" json:"instructions,omitempty"`
+	PublishedAt  *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=published_at,json=publishedAt,proto3" json:"published_at,omitempty"`
+	ImageUrl     string                 `protobuf:"bytes,7,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
+	Servings     int32                  `protobuf:"varint,8,opt,name=servings,proto3" json:"servings,omitempty"`
+	// Computed from the ingredients by the server, ignored in requests.
+	Nutrition *Nutrition `protobuf:"bytes,9,opt,name=nutrition,proto3" json:"nutrition,omitempty"`
+	// Derived from the ingredients by the server, ignored in requests.
+	Allergens     []string       `protobuf:"bytes,10,rep,name=allergens,proto3" json:"allergens,omitempty"`
+	Diets         []string       `protobuf:"bytes,11,rep,name=diets,proto3" json:"diets,omitempty"`
+	TagConflicts  []*TagConflict `protobuf:"bytes,12,rep,name=tag_conflicts,json=tagConflicts,proto3" json:"tag_conflicts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Recipe) Reset() {
+	*x = Recipe{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Recipe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Recipe) ProtoMessage() {}
+
+func (x *Recipe) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Recipe.ProtoReflect.Descriptor instead.
+func (*Recipe) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Recipe) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Recipe) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Recipe) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Recipe) GetIngredients() []string {
+	if x != nil {
+		return x.Ingredients
+	}
+	return nil
+}
+
+func (x *Recipe) GetInstructions() []string {
+	if x != nil {
+		return x.Instructions
+	}
+	return nil
+}
+
+func (x *Recipe) GetPublishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishedAt
+	}
+	return nil
+}
+
+func (x *Recipe) GetImageUrl() string {
+	if x != nil {
+		return x.ImageUrl
+	}
+	return ""
+}
+
+func (x *Recipe) GetServings() int32 {
+	if x != nil {
+		return x.Servings
+	}
+	return 0
+}
+
+func (x *Recipe) GetNutrition() *Nutrition {
+	if x != nil {
+		return x.Nutrition
+	}
+	return nil
+}
+
+func (x *Recipe) GetAllergens() []string {
+	if x != nil {
+		return x.Allergens
+	}
+	return nil
+}
+
+func (x *Recipe) GetDiets() []string {
+	if x != nil {
+		return x.Diets
+	}
+	return nil
+}
+
+func (x *Recipe) GetTagConflicts() []*TagConflict {
+	if x != nil {
+		return x.TagConflicts
+	}
+	return nil
+}
+
+// TagConflict is a tag naming a diet the ingredients break.
+type TagConflict struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Tag   string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	// Categories excluded by the diet and the ingredient lines they were found in.
+	Contains      []string `protobuf:"bytes,2,rep,name=contains,proto3" json:"contains,omitempty"`
+	Ingredients   []string `protobuf:"bytes,3,rep,name=ingredients,proto3" json:"ingredients,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TagConflict) Reset() {
+	*x = TagConflict{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TagConflict) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagConflict) ProtoMessage() {}
+
+func (x *TagConflict) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TagConflict.ProtoReflect.Descriptor instead.
+func (*TagConflict) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TagConflict) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *TagConflict) GetContains() []string {
+	if x != nil {
+		return x.Contains
+	}
+	return nil
+}
+
+func (x *TagConflict) GetIngredients() []string {
+	if x != nil {
+		return x.Ingredients
+	}
+	return nil
+}
+
+// NutritionFacts are energy in kcal and macronutrients in grams.
+type NutritionFacts struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Calories      float64                `protobuf:"fixed64,1,opt,name=calories,proto3" json:"calories,omitempty"`
+	Protein       float64                `protobuf:"fixed64,2,opt,name=protein,proto3" json:"protein,omitempty"`
+	Carbohydrates float64                `protobuf:"fixed64,3,opt,name=carbohydrates,proto3" json:"carbohydrates,omitempty"`
+	Fat           float64                `protobuf:"fixed64,4,opt,name=fat,proto3" json:"fat,omitempty"`
+	Fiber         float64                `protobuf:"fixed64,5,opt,name=fiber,proto3" json:"fiber,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NutritionFacts) Reset() {
+	*x = NutritionFacts{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NutritionFacts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NutritionFacts) ProtoMessage() {}
+
+func (x *NutritionFacts) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NutritionFacts.ProtoReflect.Descriptor instead.
+func (*NutritionFacts) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *NutritionFacts) GetCalories() float64 {
+	if x != nil {
+		return x.Calories
+	}
+	return 0
+}
+
+func (x *NutritionFacts) GetProtein() float64 {
+	if x != nil {
+		return x.Protein
+	}
+	return 0
+}
+
+func (x *NutritionFacts) GetCarbohydrates() float64 {
+	if x != nil {
+		return x.Carbohydrates
+	}
+	return 0
+}
+
+func (x *NutritionFacts) GetFat() float64 {
+	if x != nil {
+		return x.Fat
+	}
+	return 0
+}
+
+func (x *NutritionFacts) GetFiber() float64 {
+	if x != nil {
+		return x.Fiber
+	}
+	return 0
+}
+
+type Nutrition struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Total      *NutritionFacts        `protobuf:"bytes,1,opt,name=total,proto3" json:"total,omitempty"`
+	PerServing *NutritionFacts        `protobuf:"bytes,2,opt,name=per_serving,json=perServing,proto3" json:"per_serving,omitempty"`
+	// Ingredients not counted, the values are a lower bound when not empty.
+	Unmatched     []string `protobuf:"bytes,3,rep,name=unmatched,proto3" json:"unmatched,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Nutrition) Reset() {
+	*x = Nutrition{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Nutrition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Nutrition) ProtoMessage() {}
+
+func (x *Nutrition) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Nutrition.ProtoReflect.Descriptor instead.
+func (*Nutrition) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Nutrition) GetTotal() *NutritionFacts {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+func (x *Nutrition) GetPerServing() *NutritionFacts {
+	if x != nil {
+		return x.PerServing
+	}
+	return nil
+}
+
+func (x *Nutrition) GetUnmatched() []string {
+	if x != nil {
+		return x.Unmatched
+	}
+	return nil
+}
+
+type GetRecipeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRecipeRequest) Reset() {
+	*x = GetRecipeRequest{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRecipeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRecipeRequest) ProtoMessage() {}
+
+func (x *GetRecipeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRecipeRequest.ProtoReflect.Descriptor instead.
+func (*GetRecipeRequest) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetRecipeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListRecipesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Default 20, at most 100.
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous response, empty for the first page.
+	PageToken     string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRecipesRequest) Reset() {
+	*x = ListRecipesRequest{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRecipesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRecipesRequest) ProtoMessage() {}
+
+func (x *ListRecipesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRecipesRequest.ProtoReflect.Descriptor instead.
+func (*ListRecipesRequest) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListRecipesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListRecipesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListRecipesResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Recipes []*Recipe              `protobuf:"bytes,1,rep,name=recipes,proto3" json:"recipes,omitempty"`
+	// Empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	TotalSize     int32  `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRecipesResponse) Reset() {
+	*x = ListRecipesResponse{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRecipesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRecipesResponse) ProtoMessage() {}
+
+func (x *ListRecipesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRecipesResponse.ProtoReflect.Descriptor instead.
+func (*ListRecipesResponse) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListRecipesResponse) GetRecipes() []*Recipe {
+	if x != nil {
+		return x.Recipes
+	}
+	return nil
+}
+
+func (x *ListRecipesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListRecipesResponse) GetTotalSize() int32 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+type CreateRecipeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id and published_at are assigned by the server.
+	Recipe        *Recipe `protobuf:"bytes,1,opt,name=recipe,proto3" json:"recipe,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRecipeRequest) Reset() {
+	*x = CreateRecipeRequest{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRecipeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRecipeRequest) ProtoMessage() {}
+
+func (x *CreateRecipeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRecipeRequest.ProtoReflect.Descriptor instead.
+func (*CreateRecipeRequest) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateRecipeRequest) GetRecipe() *Recipe {
+	if x != nil {
+		return x.Recipe
+	}
+	return nil
+}
+
+type UpdateRecipeRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Recipe *Recipe                `protobuf:"bytes,2,opt,name=recipe,proto3" json:"recipe,omitempty"`
+	// Fields of recipe to set: name, tags, ingredients, instructions, image_url, servings.
+	// Without a mask every non-empty field is set.
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,3,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRecipeRequest) Reset() {
+	*x = UpdateRecipeRequest{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRecipeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRecipeRequest) ProtoMessage() {}
+
+func (x *UpdateRecipeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRecipeRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRecipeRequest) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateRecipeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateRecipeRequest) GetRecipe() *Recipe {
+	if x != nil {
+		return x.Recipe
+	}
+	return nil
+}
+
+func (x *UpdateRecipeRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type DeleteRecipeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRecipeRequest) Reset() {
+	*x = DeleteRecipeRequest{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRecipeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRecipeRequest) ProtoMessage() {}
+
+func (x *DeleteRecipeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRecipeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRecipeRequest) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteRecipeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type SearchRecipesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Recipes must have all of the tags.
+	Tags []string `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Number of tag facets to return, 0 for none.
+	Facets int32 `protobuf:"varint,3,opt,name=facets,proto3" json:"facets,omitempty"`
+	// Only recipes with at most this many calories per serving, 0 for no limit.
+	MaxCalories float64 `protobuf:"fixed64,4,opt,name=max_calories,json=maxCalories,proto3" json:"max_calories,omitempty"`
+	// Drops recipes containing any of these allergens.
+	ExcludeAllergens []string `protobuf:"bytes,5,rep,name=exclude_allergens,json=excludeAllergens,proto3" json:"exclude_allergens,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SearchRecipesRequest) Reset() {
+	*x = SearchRecipesRequest{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRecipesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRecipesRequest) ProtoMessage() {}
+
+func (x *SearchRecipesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRecipesRequest.ProtoReflect.Descriptor instead.
+func (*SearchRecipesRequest) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SearchRecipesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRecipesRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *SearchRecipesRequest) GetFacets() int32 {
+	if x != nil {
+		return x.Facets
+	}
+	return 0
+}
+
+func (x *SearchRecipesRequest) GetMaxCalories() float64 {
+	if x != nil {
+		return x.MaxCalories
+	}
+	return 0
+}
+
+func (x *SearchRecipesRequest) GetExcludeAllergens() []string {
+	if x != nil {
+		return x.ExcludeAllergens
+	}
+	return nil
+}
+
+type SearchHit struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name     string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Tags     []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	ImageUrl string                 `protobuf:"bytes,4,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
+	// Unset for recipes stored before nutrition was computed.
+	CaloriesPerServing *float64 `protobuf:"fixed64,5,opt,name=calories_per_serving,json=caloriesPerServing,proto3,oneof" json:"calories_per_serving,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *SearchHit) Reset() {
+	*x = SearchHit{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchHit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchHit) ProtoMessage() {}
+
+func (x *SearchHit) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchHit.ProtoReflect.Descriptor instead.
+func (*SearchHit) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SearchHit) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SearchHit) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SearchHit) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *SearchHit) GetImageUrl() string {
+	if x != nil {
+		return x.ImageUrl
+	}
+	return ""
+}
+
+func (x *SearchHit) GetCaloriesPerServing() float64 {
+	if x != nil && x.CaloriesPerServing != nil {
+		return *x.CaloriesPerServing
+	}
+	return 0
+}
+
+type TagFacet struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TagFacet) Reset() {
+	*x = TagFacet{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TagFacet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagFacet) ProtoMessage() {}
+
+func (x *TagFacet) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TagFacet.ProtoReflect.Descriptor instead.
+func (*TagFacet) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TagFacet) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *TagFacet) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type SearchRecipesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hits          []*SearchHit           `protobuf:"bytes,1,rep,name=hits,proto3" json:"hits,omitempty"`
+	Facets        []*TagFacet            `protobuf:"bytes,2,rep,name=facets,proto3" json:"facets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRecipesResponse) Reset() {
+	*x = SearchRecipesResponse{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRecipesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRecipesResponse) ProtoMessage() {}
+
+func (x *SearchRecipesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRecipesResponse.ProtoReflect.Descriptor instead.
+func (*SearchRecipesResponse) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SearchRecipesResponse) GetHits() []*SearchHit {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+func (x *SearchRecipesResponse) GetFacets() []*TagFacet {
+	if x != nil {
+		return x.Facets
+	}
+	return nil
+}
+
+type WatchRecipesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the last event received, the events after it are replayed first.
+	LastEventId   string `protobuf:"bytes,1,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRecipesRequest) Reset() {
+	*x = WatchRecipesRequest{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRecipesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRecipesRequest) ProtoMessage() {}
+
+func (x *WatchRecipesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRecipesRequest.ProtoReflect.Descriptor instead.
+func (*WatchRecipesRequest) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WatchRecipesRequest) GetLastEventId() string {
+	if x != nil {
+		return x.LastEventId
+	}
+	return ""
+}
+
+type RecipeEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Redis stream ID, pass it as last_event_id to resume.
+	Id       string           `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type     RecipeEvent_Type `protobuf:"varint,2,opt,name=type,proto3,enum=recipes.v1.RecipeEvent_Type" json:"type,omitempty"`
+	RecipeId string           `protobuf:"bytes,3,opt,name=recipe_id,json=recipeId,proto3" json:"recipe_id,omitempty"`
+	// Unset for deletes.
+	Recipe        *Recipe                `protobuf:"bytes,4,opt,name=recipe,proto3" json:"recipe,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecipeEvent) Reset() {
+	*x = RecipeEvent{}
+	mi := &file_recipes_v1_recipes_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecipeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecipeEvent) ProtoMessage() {}
+
+func (x *RecipeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_recipes_v1_recipes_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecipeEvent.ProtoReflect.Descriptor instead.
+func (*RecipeEvent) Descriptor() ([]byte, []int) {
+	return file_recipes_v1_recipes_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RecipeEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RecipeEvent) GetType() RecipeEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return RecipeEvent_TYPE_UNSPECIFIED
+}
+
+func (x *RecipeEvent) GetRecipeId() string {
+	if x != nil {
+		return x.RecipeId
+	}
+	return ""
+}
+
+func (x *RecipeEvent) GetRecipe() *Recipe {
+	if x != nil {
+		return x.Recipe
+	}
+	return nil
+}
+
+func (x *RecipeEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+var File_recipes_v1_recipes_proto protoreflect.FileDescriptor
+
+const file_recipes_v1_recipes_proto_rawDesc = "" +
+	"\n" +
+	"\x18recipes/v1/recipes.proto\x12\n" +
+	"recipes.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa5\x03\n" +
+	"\x06Recipe\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12 \n" +
+	"\vingredients\x18\x04 \x03(\tR\vingredients\x12\"\n" +
+	"\finstructions\x18\x05 \x03(\tR\finstructions\x12=\n" +
+	"\fpublished_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\vpublishedAt\x12\x1b\n" +
+	"\timage_url\x18\a \x01(\tR\bimageUrl\x12\x1a\n" +
+	"\bservings\x18\b \x01(\x05R\bservings\x123\n" +
+	"\tnutrition\x18\t \x01(\v2\x15.recipes.v1.NutritionR\tnutrition\x12\x1c\n" +
+	"\tallergens\x18\n" +
+	" \x03(\tR\tallergens\x12\x14\n" +
+	"\x05diets\x18\v \x03(\tR\x05diets\x12<\n" +
+	"\rtag_conflicts\x18\f \x03(\v2\x17.recipes.v1.TagConflictR\ftagConflicts\"]\n" +
+	"\vTagConflict\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x1a\n" +
+	"\bcontains\x18\x02 \x03(\tR\bcontains\x12 \n" +
+	"\vingredients\x18\x03 \x03(\tR\vingredients\"\x94\x01\n" +
+	"\x0eNutritionFacts\x12\x1a\n" +
+	"\bcalories\x18\x01 \x01(\x01R\bcalories\x12\x18\n" +
+	"\aprotein\x18\x02 \x01(\x01R\aprotein\x12$\n" +
+	"\rcarbohydrates\x18\x03 \x01(\x01R\rcarbohydrates\x12\x10\n" +
+	"\x03fat\x18\x04 \x01(\x01R\x03fat\x12\x14\n" +
+	"\x05fiber\x18\x05 \x01(\x01R\x05fiber\"\x98\x01\n" +
+	"\tNutrition\x120\n" +
+	"\x05total\x18\x01 \x01(\v2\x1a.recipes.v1.NutritionFactsR\x05total\x12;\n" +
+	"\vper_serving\x18\x02 \x01(\v2\x1a.recipes.v1.NutritionFactsR\n" +
+	"perServing\x12\x1c\n" +
+	"\tunmatched\x18\x03 \x03(\tR\tunmatched\"\"\n" +
+	"\x10GetRecipeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"P\n" +
+	"\x12ListRecipesRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"\x8a\x01\n" +
+	"\x13ListRecipesResponse\x12,\n" +
+	"\arecipes\x18\x01 \x03(\v2\x12.recipes.v1.RecipeR\arecipes\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x05R\ttotalSize\"A\n" +
+	"\x13CreateRecipeRequest\x12*\n" +
+	"\x06recipe\x18\x01 \x01(\v2\x12.recipes.v1.RecipeR\x06recipe\"\x8e\x01\n" +
+	"\x13UpdateRecipeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12*\n" +
+	"\x06recipe\x18\x02 \x01(\v2\x12.recipes.v1.RecipeR\x06recipe\x12;\n" +
+	"\vupdate_mask\x18\x03 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"%\n" +
+	"\x13DeleteRecipeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xa8\x01\n" +
+	"\x14SearchRecipesRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x12\n" +
+	"\x04tags\x18\x02 \x03(\tR\x04tags\x12\x16\n" +
+	"\x06facets\x18\x03 \x01(\x05R\x06facets\x12!\n" +
+	"\fmax_calories\x18\x04 \x01(\x01R\vmaxCalories\x12+\n" +
+	"\x11exclude_allergens\x18\x05 \x03(\tR\x10excludeAllergens\"\xb0\x01\n" +
+	"\tSearchHit\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12\x1b\n" +
+	"\timage_url\x18\x04 \x01(\tR\bimageUrl\x125\n" +
+	"\x14calories_per_serving\x18\x05 \x01(\x01H\x00R\x12caloriesPerServing\x88\x01\x01B\x17\n" +
+	"\x15_calories_per_serving\"2\n" +
+	"\bTagFacet\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"p\n" +
+	"\x15SearchRecipesResponse\x12)\n" +
+	"\x04hits\x18\x01 \x03(\v2\x15.recipes.v1.SearchHitR\x04hits\x12,\n" +
+	"\x06facets\x18\x02 \x03(\v2\x14.recipes.v1.TagFacetR\x06facets\"9\n" +
+	"\x13WatchRecipesRequest\x12\"\n" +
+	"\rlast_event_id\x18\x01 \x01(\tR\vlastEventId\"\xb9\x02\n" +
+	"\vRecipeEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x120\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x1c.recipes.v1.RecipeEvent.TypeR\x04type\x12\x1b\n" +
+	"\trecipe_id\x18\x03 \x01(\tR\brecipeId\x12*\n" +
+	"\x06recipe\x18\x04 \x01(\v2\x12.recipes.v1.RecipeR\x06recipe\x12;\n" +
+	"\voccurred_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\"b\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fTYPE_CREATED\x10\x01\x12\x10\n" +
+	"\fTYPE_UPDATED\x10\x02\x12\x10\n" +
+	"\fTYPE_DELETED\x10\x03\x12\x0e\n" +
+	"\n" +
+	"TYPE_RESET\x10\x042\x93\x04\n" +
+	"\rRecipeService\x12=\n" +
+	"\tGetRecipe\x12\x1c.recipes.v1.GetRecipeRequest\x1a\x12.recipes.v1.Recipe\x12N\n" +
+	"\vListRecipes\x12\x1e.recipes.v1.ListRecipesRequest\x1a\x1f.recipes.v1.ListRecipesResponse\x12C\n" +
+	"\fCreateRecipe\x12\x1f.recipes.v1.CreateRecipeRequest\x1a\x12.recipes.v1.Recipe\x12C\n" +
+	"\fUpdateRecipe\x12\x1f.recipes.v1.UpdateRecipeRequest\x1a\x12.recipes.v1.Recipe\x12G\n" +
+	"\fDeleteRecipe\x12\x1f.recipes.v1.DeleteRecipeRequest\x1a\x16.google.protobuf.Empty\x12T\n" +
+	"\rSearchRecipes\x12 .recipes.v1.SearchRecipesRequest\x1a!.recipes.v1.SearchRecipesResponse\x12J\n" +
+	"\fWatchRecipes\x12\x1f.recipes.v1.WatchRecipesRequest\x1a\x17.recipes.v1.RecipeEvent0\x01B*Z(framework-api/proto/recipes/v1;recipesv1b\x06proto3"
+
+var (
+	file_recipes_v1_recipes_proto_rawDescOnce sync.Once
+	file_recipes_v1_recipes_proto_rawDescData []byte
+)
+
+func file_recipes_v1_recipes_proto_rawDescGZIP() []byte {
+	file_recipes_v1_recipes_proto_rawDescOnce.Do(func() {
+		file_recipes_v1_recipes_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_recipes_v1_recipes_proto_rawDesc), len(file_recipes_v1_recipes_proto_rawDesc)))
+	})
+	return file_recipes_v1_recipes_proto_rawDescData
+}
+
+var file_recipes_v1_recipes_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_recipes_v1_recipes_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_recipes_v1_recipes_proto_goTypes = []any{
+	(RecipeEvent_Type)(0),         // 0: recipes.v1.RecipeEvent.Type
+	(*Recipe)(nil),                // 1: recipes.v1.Recipe
+	(*TagConflict)(nil),           // 2: recipes.v1.TagConflict
+	(*NutritionFacts)(nil),        // 3: recipes.v1.NutritionFacts
+	(*Nutrition)(nil),             // 4: recipes.v1.Nutrition
+	(*GetRecipeRequest)(nil),      // 5: recipes.v1.GetRecipeRequest
+	(*ListRecipesRequest)(nil),    // 6: recipes.v1.ListRecipesRequest
+	(*ListRecipesResponse)(nil),   // 7: recipes.v1.ListRecipesResponse
+	(*CreateRecipeRequest)(nil),   // 8: recipes.v1.CreateRecipeRequest
+	(*UpdateRecipeRequest)(nil),   // 9: recipes.v1.UpdateRecipeRequest
+	(*DeleteRecipeRequest)(nil),   // 10: recipes.v1.DeleteRecipeRequest
+	(*SearchRecipesRequest)(nil),  // 11: recipes.v1.SearchRecipesRequest
+	(*SearchHit)(nil),             // 12: recipes.v1.SearchHit
+	(*TagFacet)(nil),              // 13: recipes.v1.TagFacet
+	(*SearchRecipesResponse)(nil), // 14: recipes.v1.SearchRecipesResponse
+	(*WatchRecipesRequest)(nil),   // 15: recipes.v1.WatchRecipesRequest
+	(*RecipeEvent)(nil),           // 16: recipes.v1.RecipeEvent
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil), // 18: google.protobuf.FieldMask
+	(*emptypb.Empty)(nil),         // 19: google.protobuf.Empty
+}
+var file_recipes_v1_recipes_proto_depIdxs = []int32{
+	17, // 0: recipes.v1.Recipe.published_at:type_name -> google.protobuf.Timestamp
+	4,  // 1: recipes.v1.Recipe.nutrition:type_name -> recipes.v1.Nutrition
+	2,  // 2: recipes.v1.Recipe.tag_conflicts:type_name -> recipes.v1.TagConflict
+	3,  // 3: recipes.v1.Nutrition.total:type_name -> recipes.v1.NutritionFacts
+	3,  // 4: recipes.v1.Nutrition.per_serving:type_name -> recipes.v1.NutritionFacts
+	1,  // 5: recipes.v1.ListRecipesResponse.recipes:type_name -> recipes.v1.Recipe
+	1,  // 6: recipes.v1.CreateRecipeRequest.recipe:type_name -> recipes.v1.Recipe
+	1,  // 7: recipes.v1.UpdateRecipeRequest.recipe:type_name -> recipes.v1.Recipe
+	18, // 8: recipes.v1.UpdateRecipeRequest.update_mask:type_name -> google.protobuf.FieldMask
+	12, // 9: recipes.v1.SearchRecipesResponse.hits:type_name -> recipes.v1.SearchHit
+	13, // 10: recipes.v1.SearchRecipesResponse.facets:type_name -> recipes.v1.TagFacet
+	0,  // 11: recipes.v1.RecipeEvent.type:type_name -> recipes.v1.RecipeEvent.Type
+	1,  // 12: recipes.v1.RecipeEvent.recipe:type_name -> recipes.v1.Recipe
+	17, // 13: recipes.v1.RecipeEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	5,  // 14: recipes.v1.RecipeService.GetRecipe:input_type -> recipes.v1.GetRecipeRequest
+	6,  // 15: recipes.v1.RecipeService.ListRecipes:input_type -> recipes.v1.ListRecipesRequest
+	8,  // 16: recipes.v1.RecipeService.CreateRecipe:input_type -> recipes.v1.CreateRecipeRequest
+	9,  // 17: recipes.v1.RecipeService.UpdateRecipe:input_type -> recipes.v1.UpdateRecipeRequest
+	10, // 18: recipes.v1.RecipeService.DeleteRecipe:input_type -> recipes.v1.DeleteRecipeRequest
+	11, // 19: recipes.v1.RecipeService.SearchRecipes:input_type -> recipes.v1.SearchRecipesRequest
+	15, // 20: recipes.v1.RecipeService.WatchRecipes:input_type -> recipes.v1.WatchRecipesRequest
+	1,  // 21: recipes.v1.RecipeService.GetRecipe:output_type -> recipes.v1.Recipe
+	7,  // 22: recipes.v1.RecipeService.ListRecipes:output_type -> recipes.v1.ListRecipesResponse
+	1,  // 23: recipes.v1.RecipeService.CreateRecipe:output_type -> recipes.v1.Recipe
+	1,  // 24: recipes.v1.RecipeService.UpdateRecipe:output_type -> recipes.v1.Recipe
+	19, // 25: recipes.v1.RecipeService.DeleteRecipe:output_type -> google.protobuf.Empty
+	14, // 26: recipes.v1.RecipeService.SearchRecipes:output_type -> recipes.v1.SearchRecipesResponse
+	16, // 27: recipes.v1.RecipeService.WatchRecipes:output_type -> recipes.v1.RecipeEvent
+	21, // [21:28] is the sub-list for method output_type
+	14, // [14:21] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_recipes_v1_recipes_proto_init() }
+func file_recipes_v1_recipes_proto_init() {
+	if File_recipes_v1_recipes_proto != nil {
+		return
+	}
+	file_recipes_v1_recipes_proto_msgTypes[11].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_recipes_v1_recipes_proto_rawDesc), len(file_recipes_v1_recipes_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_recipes_v1_recipes_proto_goTypes,
+		DependencyIndexes: file_recipes_v1_recipes_proto_depIdxs,
+		EnumInfos:         file_recipes_v1_recipes_proto_enumTypes,
+		MessageInfos:      file_recipes_v1_recipes_proto_msgTypes,
+	}.Build()
+	File_recipes_v1_recipes_proto = out.File
+	file_recipes_v1_recipes_proto_goTypes = nil
+	file_recipes_v1_recipes_proto_depIdxs = nil
+}
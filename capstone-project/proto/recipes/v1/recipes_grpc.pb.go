@@ -0,0 +1,370 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: recipes/v1/recipes.proto
+
+package recipesv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RecipeService_GetRecipe_FullMethodName     = "/recipes.v1.RecipeService/GetRecipe"
+	RecipeService_ListRecipes_FullMethodName   = "/recipes.v1.RecipeService/ListRecipes"
+	RecipeService_CreateRecipe_FullMethodName  = "/recipes.v1.RecipeService/CreateRecipe"
+	RecipeService_UpdateRecipe_FullMethodName  = "/recipes.v1.RecipeService/UpdateRecipe"
+	RecipeService_DeleteRecipe_FullMethodName  = "/recipes.v1.RecipeService/DeleteRecipe"
+	RecipeService_SearchRecipes_FullMethodName = "/recipes.v1.RecipeService/SearchRecipes"
+	RecipeService_WatchRecipes_FullMethodName  = "/recipes.v1.RecipeService/WatchRecipes"
+)
+
+// RecipeServiceClient is the client API for RecipeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RecipeService is the gRPC counterpart of the /api/v2 REST API for internal services.
+// It shares storage, cache and search with the REST handlers and verifies the same JWTs,
+// sent as "authorization: Bearer <token>" or "x-api-key" metadata.
+type RecipeServiceClient interface {
+	GetRecipe(ctx context.Context, in *GetRecipeRequest, opts ...grpc.CallOption) (*Recipe, error)
+	ListRecipes(ctx context.Context, in *ListRecipesRequest, opts ...grpc.CallOption) (*ListRecipesResponse, error)
+	// CreateRecipe needs the recipes:write scope.
+	CreateRecipe(ctx context.Context, in *CreateRecipeRequest, opts ...grpc.CallOption) (*Recipe, error)
+	// UpdateRecipe needs the recipes:write scope.
+	UpdateRecipe(ctx context.Context, in *UpdateRecipeRequest, opts ...grpc.CallOption) (*Recipe, error)
+	// DeleteRecipe needs the admin group.
+	DeleteRecipe(ctx context.Context, in *DeleteRecipeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	SearchRecipes(ctx context.Context, in *SearchRecipesRequest, opts ...grpc.CallOption) (*SearchRecipesResponse, error)
+	// WatchRecipes streams every change to the catalog, resuming after last_event_id when set.
+	WatchRecipes(ctx context.Context, in *WatchRecipesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RecipeEvent], error)
+}
+
+type recipeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRecipeServiceClient(cc grpc.ClientConnInterface) RecipeServiceClient {
+	return &recipeServiceClient{cc}
+}
+
+func (c *recipeServiceClient) GetRecipe(ctx context.Context, in *GetRecipeRequest, opts ...grpc.CallOption) (*Recipe, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Recipe)
+	err := c.cc.Invoke(ctx, RecipeService_GetRecipe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recipeServiceClient) ListRecipes(ctx context.Context, in *ListRecipesRequest, opts ...grpc.CallOption) (*ListRecipesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRecipesResponse)
+	err := c.cc.Invoke(ctx, RecipeService_ListRecipes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recipeServiceClient) CreateRecipe(ctx context.Context, in *CreateRecipeRequest, opts ...grpc.CallOption) (*Recipe, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Recipe)
+	err := c.cc.Invoke(ctx, RecipeService_CreateRecipe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recipeServiceClient) UpdateRecipe(ctx context.Context, in *UpdateRecipeRequest, opts ...grpc.CallOption) (*Recipe, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Recipe)
+	err := c.cc.Invoke(ctx, RecipeService_UpdateRecipe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recipeServiceClient) DeleteRecipe(ctx context.Context, in *DeleteRecipeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, RecipeService_DeleteRecipe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recipeServiceClient) SearchRecipes(ctx context.Context, in *SearchRecipesRequest, opts ...grpc.CallOption) (*SearchRecipesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchRecipesResponse)
+	err := c.cc.Invoke(ctx, RecipeService_SearchRecipes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recipeServiceClient) WatchRecipes(ctx context.Context, in *WatchRecipesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RecipeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RecipeService_ServiceDesc.Streams[0], RecipeService_WatchRecipes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRecipesRequest, RecipeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RecipeService_WatchRecipesClient = grpc.ServerStreamingClient[RecipeEvent]
+
+// RecipeServiceServer is the server API for RecipeService service.
+// All implementations must embed UnimplementedRecipeServiceServer
+// for forward compatibility.
+//
+// RecipeService is the gRPC counterpart of the /api/v2 REST API for internal services.
+// It shares storage, cache and search with the REST handlers and verifies the same JWTs,
+// sent as "authorization: Bearer <token>" or "x-api-key" metadata.
+type RecipeServiceServer interface {
+	GetRecipe(context.Context, *GetRecipeRequest) (*Recipe, error)
+	ListRecipes(context.Context, *ListRecipesRequest) (*ListRecipesResponse, error)
+	// CreateRecipe needs the recipes:write scope.
+	CreateRecipe(context.Context, *CreateRecipeRequest) (*Recipe, error)
+	// UpdateRecipe needs the recipes:write scope.
+	UpdateRecipe(context.Context, *UpdateRecipeRequest) (*Recipe, error)
+	// DeleteRecipe needs the admin group.
+	DeleteRecipe(context.Context, *DeleteRecipeRequest) (*emptypb.Empty, error)
+	SearchRecipes(context.Context, *SearchRecipesRequest) (*SearchRecipesResponse, error)
+	// WatchRecipes streams every change to the catalog, resuming after last_event_id when set.
+	WatchRecipes(*WatchRecipesRequest, grpc.ServerStreamingServer[RecipeEvent]) error
+	mustEmbedUnimplementedRecipeServiceServer()
+}
+
+// UnimplementedRecipeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRecipeServiceServer struct{}
+
+func (UnimplementedRecipeServiceServer) GetRecipe(context.Context, *GetRecipeRequest) (*Recipe, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetRecipe not implemented")
+}
+func (UnimplementedRecipeServiceServer) ListRecipes(context.Context, *ListRecipesRequest) (*ListRecipesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListRecipes not implemented")
+}
+func (UnimplementedRecipeServiceServer) CreateRecipe(context.Context, *CreateRecipeRequest) (*Recipe, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateRecipe not implemented")
+}
+func (UnimplementedRecipeServiceServer) UpdateRecipe(context.Context, *UpdateRecipeRequest) (*Recipe, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateRecipe not implemented")
+}
+func (UnimplementedRecipeServiceServer) DeleteRecipe(context.Context, *DeleteRecipeRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteRecipe not implemented")
+}
+func (UnimplementedRecipeServiceServer) SearchRecipes(context.Context, *SearchRecipesRequest) (*SearchRecipesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SearchRecipes not implemented")
+}
+func (UnimplementedRecipeServiceServer) WatchRecipes(*WatchRecipesRequest, grpc.ServerStreamingServer[RecipeEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchRecipes not implemented")
+}
+func (UnimplementedRecipeServiceServer) mustEmbedUnimplementedRecipeServiceServer() {}
+func (UnimplementedRecipeServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeRecipeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RecipeServiceServer will
+// result in compilation errors.
+type UnsafeRecipeServiceServer interface {
+	mustEmbedUnimplementedRecipeServiceServer()
+}
+
+func RegisterRecipeServiceServer(s grpc.ServiceRegistrar, srv RecipeServiceServer) {
+	// If the following call panics, it indicates UnimplementedRecipeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RecipeService_ServiceDesc, srv)
+}
+
+func _RecipeService_GetRecipe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecipeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecipeServiceServer).GetRecipe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecipeService_GetRecipe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecipeServiceServer).GetRecipe(ctx, req.(*GetRecipeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecipeService_ListRecipes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRecipesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecipeServiceServer).ListRecipes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecipeService_ListRecipes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecipeServiceServer).ListRecipes(ctx, req.(*ListRecipesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecipeService_CreateRecipe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRecipeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecipeServiceServer).CreateRecipe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecipeService_CreateRecipe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecipeServiceServer).CreateRecipe(ctx, req.(*CreateRecipeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecipeService_UpdateRecipe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRecipeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecipeServiceServer).UpdateRecipe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecipeService_UpdateRecipe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecipeServiceServer).UpdateRecipe(ctx, req.(*UpdateRecipeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecipeService_DeleteRecipe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRecipeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecipeServiceServer).DeleteRecipe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecipeService_DeleteRecipe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecipeServiceServer).DeleteRecipe(ctx, req.(*DeleteRecipeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecipeService_SearchRecipes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRecipesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecipeServiceServer).SearchRecipes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecipeService_SearchRecipes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecipeServiceServer).SearchRecipes(ctx, req.(*SearchRecipesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecipeService_WatchRecipes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRecipesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RecipeServiceServer).WatchRecipes(m, &grpc.GenericServerStream[WatchRecipesRequest, RecipeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RecipeService_WatchRecipesServer = grpc.ServerStreamingServer[RecipeEvent]
+
+// RecipeService_ServiceDesc is the grpc.ServiceDesc for RecipeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RecipeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "recipes.v1.RecipeService",
+	HandlerType: (*RecipeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRecipe",
+			Handler:    _RecipeService_GetRecipe_Handler,
+		},
+		{
+			MethodName: "ListRecipes",
+			Handler:    _RecipeService_ListRecipes_Handler,
+		},
+		{
+			MethodName: "CreateRecipe",
+			Handler:    _RecipeService_CreateRecipe_Handler,
+		},
+		{
+			MethodName: "UpdateRecipe",
+			Handler:    _RecipeService_UpdateRecipe_Handler,
+		},
+		{
+			MethodName: "DeleteRecipe",
+			Handler:    _RecipeService_DeleteRecipe_Handler,
+		},
+		{
+			MethodName: "SearchRecipes",
+			Handler:    _RecipeService_SearchRecipes_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRecipes",
+			Handler:       _RecipeService_WatchRecipes_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "recipes/v1/recipes.proto",
+}
@@ -10,6 +10,7 @@ function App() {
     const [searchError, setSearchError] = useState("");
     const [isSearching, setIsSearching] = useState(false);
     const [isLoadingDetail, setIsLoadingDetail] = useState(false);
+    const [similarRecipes, setSimilarRecipes] = useState([]);
 
     useEffect(() => {
         fetch("http://localhost:8088/recipes")
@@ -20,6 +21,38 @@ function App() {
             .catch(err => console.error("Error fetching recipes:", err));
     }, []);
 
+    // Live updates, EventSource reconnects on its own and resumes with Last-Event-ID
+    useEffect(() => {
+        const events = new EventSource("http://localhost:8088/recipes/events");
+        const upsert = (e) => {
+            const { recipe } = JSON.parse(e.data);
+            setRecipes(prev => [...prev.filter(r => r.id !== recipe.id), recipe]);
+        };
+        events.addEventListener("created", upsert);
+        events.addEventListener("updated", upsert);
+        events.addEventListener("deleted", (e) => {
+            const { recipeId } = JSON.parse(e.data);
+            setRecipes(prev => prev.filter(r => r.id !== recipeId));
+        });
+        events.addEventListener("reset", () => {
+            fetch("http://localhost:8088/recipes")
+                .then(res => res.json())
+                .then(data => setRecipes(data))
+                .catch(err => console.error("Error fetching recipes:", err));
+        });
+        return () => events.close();
+    }, []);
+
+    // "You might also like" for the open recipe, the list is optional so failures are only logged
+    useEffect(() => {
+        setSimilarRecipes([]);
+        if (!searchedRecipe) return;
+        fetch(`http://localhost:8088/recipe/${searchedRecipe.id}/similar`)
+            .then(res => res.ok ? res.json() : [])
+            .then(data => setSimilarRecipes(Array.isArray(data) ? data : []))
+            .catch(err => console.error("Error fetching similar recipes:", err));
+    }, [searchedRecipe]);
+
     const handleSearch = async () => {
         const query = searchText.trim();
         if (!query) return;
@@ -154,9 +187,47 @@ function App() {
                                         <span key={tag} className="tag">{tag}</span>
                                     )) : <span className="tag">No tags</span>}
                                 </div>
+                                <div className="recipe-export">
+                                    <a href={`http://localhost:8088/recipe/${searchedRecipe.id}/export?format=pdf`}>Print (PDF)</a>
+                                    <a href={`http://localhost:8088/recipe/${searchedRecipe.id}/export?format=md`}>Markdown</a>
+                                    <a href={`http://localhost:8088/recipe/${searchedRecipe.id}/export?format=txt`}>Text</a>
+                                </div>
                             </div>
                         </div>
                     </div>
+                    {similarRecipes.length > 0 && (
+                        <div>
+                            <h2 className="section-title">You might also like</h2>
+                            <div className="recipe-grid">
+                                {similarRecipes.map((recipe) => (
+                                    <div key={recipe.id} className="recipe-card">
+                                        <img
+                                            src={recipe.imageUrl ? `http://localhost:8088/${recipe.imageUrl}` : getRecipeImage(recipe.id)}
+                                            alt={recipe.name}
+                                            className="recipe-image"
+                                        />
+                                        <div className="recipe-content">
+                                            <h3 className="recipe-title">
+                                                <button
+                                                    type="button"
+                                                    className="recipe-id-link"
+                                                    onClick={() => handleRecipeIdClick(recipe.id)}
+                                                    disabled={isLoadingDetail}
+                                                >
+                                                    {recipe.name}
+                                                </button>
+                                            </h3>
+                                            <div className="recipe-tags">
+                                                {recipe.tags ? recipe.tags.map(tag => (
+                                                    <span key={tag} className="tag">{tag}</span>
+                                                )) : <span className="tag">No tags</span>}
+                                            </div>
+                                        </div>
+                                    </div>
+                                ))}
+                            </div>
+                        </div>
+                    )}
                 </div>
             ) : searchResults.length > 0 ? (
                 <div>
@@ -0,0 +1,923 @@
+package routes
+
+import (
+	"fmt"
+	"framework-api/handlers"
+	"framework-api/models"
+	"framework-api/openapi"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+// Security requirements shared by the write routes, a bearer token or a service account API key.
+var (
+	writeSecurity = []openapi.SecurityRequirement{
+		{"bearerAuth": {handlers.ScopeRecipesWrite}},
+		{"apiKeyAuth": {handlers.ScopeRecipesWrite}},
+	}
+	adminSecurity = []openapi.SecurityRequirement{
+		{"bearerAuth": {handlers.GroupAdmin}},
+		{"apiKeyAuth": {handlers.GroupAdmin}},
+	}
+	userSecurity = []openapi.SecurityRequirement{
+		{"bearerAuth": {}},
+		{"apiKeyAuth": {}},
+	}
+)
+
+// recipeSchemas are the component references and responses shared by the recipe operations of every version.
+type recipeSchemas struct {
+	recipe       *openapi.Schema
+	searchResult *openapi.Schema
+	errorSchema  *openapi.Schema
+	message      *openapi.Schema
+	badRequest   openapi.Response
+	notFound     openapi.Response
+	serverError  openapi.Response
+	unauthorized openapi.Response
+	forbidden    openapi.Response
+	idParam      openapi.Parameter
+}
+
+// deprecationHeaders are sent on every v1 and root alias response.
+var deprecationHeaders = map[string]openapi.Header{
+	"Deprecation": {Description: "Unix time the version was deprecated, e.g. @1793491200 (RFC 9745)", Schema: &openapi.Schema{Type: "string"}},
+	"Sunset":      {Description: "HTTP date after which the version is no longer served (RFC 8594)", Schema: &openapi.Schema{Type: "string"}},
+	"Link":        {Description: "The same resource under /api/v2, rel=\"successor-version\"", Schema: &openapi.Schema{Type: "string"}},
+}
+
+// OpenAPISpec documents every route registered by SetupRouter.
+// routes/openapi_test.go fails when a route is added to one but not the other.
+func OpenAPISpec() *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:       "Recipe API",
+		Version:     "2.0",
+		Description: "Recipe management API backed by MongoDB, cached in Redis and searched with Elasticsearch.",
+		License:     &openapi.License{Name: "Apache 2.0", URL: "http://www.apache.org/licenses/LICENSE-2.0.html"},
+	})
+	doc.Servers = []openapi.Server{{URL: "http://localhost:8088", Description: "Local development"}}
+	doc.Tags = []openapi.Tag{
+		{Name: "recipes", Description: "Recipe catalog, v2 responses wrapped in the data/meta/links envelope"},
+		{Name: "recipes-v1", Description: "Deprecated recipe catalog with bare responses, also served on the root paths"},
+		{Name: "webhooks", Description: "Admin management of outbound webhooks"},
+		{Name: "audit", Description: "Append-only trail of the write, auth and admin actions"},
+		{Name: "mealplans", Description: "Weekly meal plans of the authenticated user"},
+		{Name: "pages", Description: "Server rendered HTML pages for browsers and crawlers"},
+		{Name: "system", Description: "Health and documentation"},
+	}
+	doc.Components.SecuritySchemes["bearerAuth"] = openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "Access token from Cognito or another configured issuer. Scopes are read from `scope`, groups from `cognito:groups`.",
+	}
+	doc.Components.SecuritySchemes["apiKeyAuth"] = openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        handlers.APIKeyHeader,
+		Description: "Static API key of a service account.",
+	}
+
+	recipe := doc.AddSchema("Recipe", models.Recipe{})
+	errorSchema := doc.AddSchema("Error", errorResponse{})
+	recipeInput := recipeInputSchema(doc.Components.Schemas["Recipe"])
+	doc.Components.Schemas["RecipeInput"] = recipeInput
+	doc.Components.Schemas["RecipePatch"] = &openapi.Schema{
+		Type:        "object",
+		Description: "Any subset of the RecipeInput fields, id and publishedAt are ignored.",
+		Properties:  recipeInput.Properties,
+	}
+	r := recipeSchemas{
+		recipe:       recipe,
+		searchResult: doc.AddSchema("RecipeSearchResult", models.RecipeSearchResult{}),
+		errorSchema:  errorSchema,
+		message:      doc.AddSchema("Message", messageResponse{}),
+		badRequest:   openapi.JSONResponse("Invalid request", errorSchema),
+		notFound:     openapi.JSONResponse("Recipe not found", errorSchema),
+		serverError:  openapi.JSONResponse("Database, cache or search failure", errorSchema),
+		unauthorized: openapi.JSONResponse("Missing or invalid credentials", errorSchema),
+		forbidden:    openapi.JSONResponse("Missing required scope or group", errorSchema),
+		idParam:      openapi.PathParam("id", "Recipe ID, a 24 character hex MongoDB ObjectID"),
+	}
+
+	doc.AddOperation(http.MethodGet, "/ping", openapi.Operation{
+		OperationID: "ping",
+		Summary:     "Health check",
+		Tags:        []string{"system"},
+		Responses:   map[string]openapi.Response{"200": openapi.JSONResponse("pong", &openapi.Schema{Type: "string"})},
+	})
+	doc.AddOperation(http.MethodGet, "/openapi.json", openapi.Operation{
+		OperationID: "openAPISpec",
+		Summary:     "This OpenAPI document",
+		Tags:        []string{"system"},
+		Responses:   map[string]openapi.Response{"200": openapi.JSONResponse("OpenAPI 3.1 document", &openapi.Schema{Type: "object"})},
+	})
+
+	addPageOperations(doc, r)
+	addEventOperations(doc, r)
+	addExportOperations(doc, r)
+	addImportOperations(doc, r)
+	addWebhookOperations(doc, r)
+	addAuditOperation(doc, r)
+	addMealPlanOperations(doc, r)
+	addGraphQLOperation(doc, r)
+	addRecipeOperationsV1(doc, r, V1Prefix, "V1")
+	addRecipeOperationsV1(doc, r, "", "")
+	addRecipeOperationsV2(doc, r)
+	//Everything but the system routes runs in the tenant of the request
+	for path, item := range doc.Paths {
+		if path == "/ping" || path == "/openapi.json" {
+			continue
+		}
+		for _, op := range item {
+			op.Parameters = append(slices.Clip(op.Parameters), tenantParam)
+		}
+	}
+	return doc
+}
+
+const similarDescription = "Elasticsearch `more_like_this` over name, ingredients and tags. Without Elasticsearch the recipes are ranked by ingredient TF-IDF and tag overlap. Cached in Redis until a recipe changes."
+
+var similarLimitParam = openapi.QueryParam("limit", "Number of recipes, 1 to 20, default 5", &openapi.Schema{Type: "integer"})
+
+func similarResponse(schema *openapi.Schema) openapi.Response {
+	res := openapi.JSONResponse("Similar recipes, best first", schema)
+	res.Headers = map[string]openapi.Header{handlers.SimilarSourceHeader: {Description: "`elasticsearch` or `local`", Schema: &openapi.Schema{Type: "string"}}}
+	return res
+}
+
+func htmlResponse(description string) openapi.Response {
+	return openapi.Response{Description: description, Content: map[string]openapi.MediaType{"text/html": {Schema: &openapi.Schema{Type: "string"}}}}
+}
+
+// addPageOperations documents the server rendered pages, they are HTML for browsers and crawlers.
+func addPageOperations(doc *openapi.Document, r recipeSchemas) {
+	langParam := openapi.QueryParam("lang", "Locale of the page, overrides Accept-Language. Every translation has its own URL this way", &openapi.Schema{Type: "string"})
+	doc.AddOperation(http.MethodGet, "/", openapi.Operation{
+		OperationID: "homePage",
+		Summary:     "Recipe list page",
+		Description: "Server rendered recipe list with an ItemList JSON-LD, OpenGraph tags and a canonical URL, 24 recipes per page.",
+		Tags:        []string{"pages"},
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("page", "Page number, starting at 1", &openapi.Schema{Type: "integer"}),
+			langParam,
+			acceptLanguageParam,
+		},
+		Responses: map[string]openapi.Response{
+			"200": htmlResponse("HTML recipe list"),
+			"404": htmlResponse("Invalid or empty page"),
+			"500": htmlResponse("Recipes unavailable"),
+		},
+	})
+	recipePage := openapi.Operation{
+		OperationID: "recipePage",
+		Summary:     "Recipe page",
+		Description: "Server rendered recipe with schema.org Recipe JSON-LD, OpenGraph tags, a canonical URL and hreflang links to its translations. Requests with any other slug are redirected to the canonical /r/{id}/{slug}.",
+		Tags:        []string{"pages"},
+		Parameters: []openapi.Parameter{
+			r.idParam,
+			langParam,
+			acceptLanguageParam,
+		},
+		Responses: map[string]openapi.Response{
+			"200": htmlResponse("HTML recipe page"),
+			"301": {Description: "Redirect to the canonical URL"},
+			"404": htmlResponse("Recipe not found"),
+			"500": htmlResponse("Recipe unavailable"),
+		},
+	}
+	doc.AddOperation(http.MethodGet, "/r/:id", recipePage)
+	recipePage.OperationID = "recipePageWithSlug"
+	recipePage.Parameters = append([]openapi.Parameter{openapi.PathParam("slug", "Recipe name slug, e.g. palak-paneer")}, recipePage.Parameters...)
+	doc.AddOperation(http.MethodGet, "/r/:id/:slug", recipePage)
+	doc.AddOperation(http.MethodGet, "/search", openapi.Operation{
+		OperationID: "searchPage",
+		Summary:     "Search page",
+		Description: "Server rendered search results, marked noindex. Without q it shows the search form.",
+		Tags:        []string{"pages"},
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("q", "Free text matched against name and tags", &openapi.Schema{Type: "string"}),
+			langParam,
+			acceptLanguageParam,
+		},
+		Responses: map[string]openapi.Response{
+			"200": htmlResponse("HTML search results"),
+			"503": htmlResponse("Search unavailable"),
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/sitemap.xml", openapi.Operation{
+		OperationID: "sitemap",
+		Summary:     "Sitemap",
+		Description: "The home page and every recipe page read from MongoDB, newest first, with hreflang alternates of translated recipes. At most 50,000 URLs.",
+		Tags:        []string{"pages"},
+		Responses: map[string]openapi.Response{
+			"200": {Description: "Sitemap 0.9 document", Content: map[string]openapi.MediaType{"application/xml": {Schema: &openapi.Schema{Type: "string"}}}},
+			"500": {Description: "MongoDB failure"},
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/robots.txt", openapi.Operation{
+		OperationID: "robots",
+		Summary:     "robots.txt",
+		Description: "Points crawlers at the sitemap and keeps them out of the API.",
+		Tags:        []string{"pages"},
+		Responses: map[string]openapi.Response{
+			"200": {Description: "robots.txt", Content: map[string]openapi.MediaType{"text/plain": {Schema: &openapi.Schema{Type: "string"}}}},
+		},
+	})
+}
+
+// addRecipeOperationsV1 documents the deprecated bare response routes under prefix, suffix keeps operation IDs unique.
+func addRecipeOperationsV1(doc *openapi.Document, r recipeSchemas, prefix, suffix string) {
+	add := func(method, path string, op openapi.Operation) {
+		op.OperationID += suffix
+		op.Tags = []string{"recipes-v1"}
+		op.Deprecated = true
+		for code, res := range op.Responses {
+			headers := maps.Clone(deprecationHeaders)
+			maps.Copy(headers, res.Headers)
+			res.Headers = headers
+			op.Responses[code] = res
+		}
+		doc.AddOperation(method, prefix+path, op)
+	}
+	add(http.MethodGet, "/recipes", openapi.Operation{
+		OperationID: "listRecipes",
+		Summary:     "List all recipes",
+		Description: "Returns every recipe from MongoDB, cached in Redis under the `recipes` key. " + conditionalDescription,
+		Parameters:  append([]openapi.Parameter{acceptLanguageParam}, conditionalParams...),
+		Responses: map[string]openapi.Response{
+			"200": cachedResponse(openapi.JSONResponse("All recipes", openapi.ArrayOf(r.recipe))),
+			"304": notModifiedResponse,
+			"404": openapi.JSONResponse("No recipes found", r.errorSchema),
+			"500": r.serverError,
+		},
+	})
+	add(http.MethodGet, "/recipes/search", openapi.Operation{
+		OperationID: "searchRecipes",
+		Summary:     "Search recipes",
+		Description: "Full text search in Elasticsearch. `q` fuzzy matches name and tags, `tag` filters on an exact tag, `maxCalories` on calories per serving, `excludeAllergens` drops recipes containing any of the listed allergens. At least one is required.",
+		Parameters:  searchParams(),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Matching recipes", openapi.ArrayOf(r.searchResult)),
+			"400": openapi.JSONResponse("No search criterion given, invalid maxCalories or unknown allergen", r.errorSchema),
+			"500": r.serverError,
+		},
+	})
+	add(http.MethodGet, "/recipe/:id", openapi.Operation{
+		OperationID: "getRecipe",
+		Summary:     "Get a recipe by ID",
+		Description: "Reads from the Redis `recipe:<id>` cache, falling back to MongoDB. Content-Language is the locale served. " + conditionalDescription,
+		Parameters:  append([]openapi.Parameter{r.idParam, acceptLanguageParam}, conditionalParams...),
+		Responses: map[string]openapi.Response{
+			"200": cachedResponse(openapi.JSONResponse("The recipe", r.recipe)),
+			"304": notModifiedResponse,
+			"400": r.badRequest,
+			"404": r.notFound,
+			"500": r.serverError,
+		},
+	})
+	add(http.MethodGet, "/recipe/:id/similar", openapi.Operation{
+		OperationID: "similarRecipes",
+		Summary:     "Recipes similar to a recipe",
+		Description: similarDescription,
+		Parameters:  []openapi.Parameter{r.idParam, similarLimitParam, acceptLanguageParam},
+		Responses: map[string]openapi.Response{
+			"200": similarResponse(openapi.ArrayOf(r.searchResult)),
+			"400": openapi.JSONResponse("Invalid recipe ID or limit", r.errorSchema),
+			"404": r.notFound,
+			"500": r.serverError,
+		},
+	})
+	add(http.MethodPost, "/recipe", openapi.Operation{
+		OperationID: "createRecipe",
+		Summary:     "Create a recipe",
+		Description: "Stores the recipe in MongoDB, indexes it in Elasticsearch and invalidates the list cache.",
+		RequestBody: openapi.JSONBody("Recipe to create, id and publishedAt are assigned by the server", openapi.Ref("RecipeInput")),
+		Security:    writeSecurity,
+		Responses: map[string]openapi.Response{
+			"201": openapi.JSONResponse("Created recipe", r.recipe),
+			"400": r.badRequest,
+			"401": r.unauthorized,
+			"403": r.forbidden,
+			"500": r.serverError,
+		},
+	})
+	add(http.MethodPatch, "/recipe/:id", openapi.Operation{
+		OperationID: "updateRecipe",
+		Summary:     "Update a recipe",
+		Description: "Sets the given fields on the recipe and invalidates its cache entries.",
+		Parameters:  []openapi.Parameter{r.idParam},
+		RequestBody: openapi.JSONBody("Fields to update", openapi.Ref("RecipePatch")),
+		Security:    writeSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Update confirmation", r.message),
+			"400": r.badRequest,
+			"401": r.unauthorized,
+			"403": r.forbidden,
+			"404": r.notFound,
+			"500": r.serverError,
+		},
+	})
+	add(http.MethodDelete, "/recipe/:id", openapi.Operation{
+		OperationID: "deleteRecipe",
+		Summary:     "Delete a recipe",
+		Description: "Removes the recipe from MongoDB, Redis and Elasticsearch.",
+		Parameters:  []openapi.Parameter{r.idParam},
+		Security:    adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Delete confirmation", r.message),
+			"400": r.badRequest,
+			"401": r.unauthorized,
+			"403": r.forbidden,
+			"404": r.notFound,
+			"500": r.serverError,
+		},
+	})
+}
+
+// addRecipeOperationsV2 documents the /api/v2 routes, every success body is an envelope.
+func addRecipeOperationsV2(doc *openapi.Document, r recipeSchemas) {
+	meta := doc.AddSchema("Meta", handlers.Meta{})
+	links := doc.AddSchema("Links", handlers.Links{})
+	doc.Components.Schemas["RecipeEnvelope"] = envelopeSchema(r.recipe, meta, links)
+	doc.Components.Schemas["RecipeListEnvelope"] = envelopeSchema(openapi.ArrayOf(r.recipe), meta, links)
+	doc.Components.Schemas["RecipeSearchEnvelope"] = envelopeSchema(openapi.ArrayOf(r.searchResult), meta, links)
+	recipeEnvelope := openapi.Ref("RecipeEnvelope")
+
+	doc.AddOperation(http.MethodGet, V2Prefix+"/recipes", openapi.Operation{
+		OperationID: "listRecipesV2",
+		Summary:     "List recipes, paginated",
+		Description: "One page of recipes. `meta` holds count, total, limit and offset, `links` the next and prev pages. An empty catalog is an empty page. " + conditionalDescription,
+		Tags:        []string{"recipes"},
+		Parameters: append([]openapi.Parameter{
+			openapi.QueryParam("limit", "Page size, 1 to 100, default 20", &openapi.Schema{Type: "integer"}),
+			openapi.QueryParam("offset", "Number of recipes to skip, default 0", &openapi.Schema{Type: "integer"}),
+			acceptLanguageParam,
+		}, conditionalParams...),
+		Responses: map[string]openapi.Response{
+			"200": cachedResponse(openapi.JSONResponse("A page of recipes", openapi.Ref("RecipeListEnvelope"))),
+			"304": notModifiedResponse,
+			"400": openapi.JSONResponse("Invalid limit or offset", r.errorSchema),
+			"500": r.serverError,
+		},
+	})
+	doc.AddOperation(http.MethodGet, V2Prefix+"/recipes/search", openapi.Operation{
+		OperationID: "searchRecipesV2",
+		Summary:     "Search recipes",
+		Description: "Full text search in Elasticsearch. `q` fuzzy matches name and tags, `tag` filters on an exact tag, `maxCalories` on calories per serving, `excludeAllergens` drops recipes containing any of the listed allergens. At least one is required.",
+		Tags:        []string{"recipes"},
+		Parameters:  searchParams(),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Matching recipes", openapi.Ref("RecipeSearchEnvelope")),
+			"400": openapi.JSONResponse("No search criterion given, invalid maxCalories or unknown allergen", r.errorSchema),
+			"500": r.serverError,
+		},
+	})
+	doc.AddOperation(http.MethodGet, V2Prefix+"/recipe/:id", openapi.Operation{
+		OperationID: "getRecipeV2",
+		Summary:     "Get a recipe by ID",
+		Description: "Content-Language is the locale served. " + conditionalDescription,
+		Tags:        []string{"recipes"},
+		Parameters:  append([]openapi.Parameter{r.idParam, acceptLanguageParam}, conditionalParams...),
+		Responses: map[string]openapi.Response{
+			"200": cachedResponse(openapi.JSONResponse("The recipe", recipeEnvelope)),
+			"304": notModifiedResponse,
+			"400": r.badRequest,
+			"404": r.notFound,
+			"500": r.serverError,
+		},
+	})
+	doc.AddOperation(http.MethodGet, V2Prefix+"/recipe/:id/similar", openapi.Operation{
+		OperationID: "similarRecipesV2",
+		Summary:     "Recipes similar to a recipe",
+		Description: similarDescription,
+		Tags:        []string{"recipes"},
+		Parameters:  []openapi.Parameter{r.idParam, similarLimitParam, acceptLanguageParam},
+		Responses: map[string]openapi.Response{
+			"200": similarResponse(openapi.Ref("RecipeSearchEnvelope")),
+			"400": openapi.JSONResponse("Invalid recipe ID or limit", r.errorSchema),
+			"404": r.notFound,
+			"500": r.serverError,
+		},
+	})
+	doc.AddOperation(http.MethodPost, V2Prefix+"/recipe", openapi.Operation{
+		OperationID: "createRecipeV2",
+		Summary:     "Create a recipe",
+		Description: "The Location header points at the created recipe.",
+		Tags:        []string{"recipes"},
+		RequestBody: openapi.JSONBody("Recipe to create, id and publishedAt are assigned by the server", openapi.Ref("RecipeInput")),
+		Security:    writeSecurity,
+		Responses: map[string]openapi.Response{
+			"201": {
+				Description: "Created recipe",
+				Headers:     map[string]openapi.Header{"Location": {Description: "URL of the created recipe", Schema: &openapi.Schema{Type: "string"}}},
+				Content:     openapi.JSONContent(recipeEnvelope),
+			},
+			"400": r.badRequest,
+			"401": r.unauthorized,
+			"403": r.forbidden,
+			"500": r.serverError,
+		},
+	})
+	doc.AddOperation(http.MethodPatch, V2Prefix+"/recipe/:id", openapi.Operation{
+		OperationID: "updateRecipeV2",
+		Summary:     "Update a recipe",
+		Description: "Sets the given fields on the recipe and returns the updated recipe.",
+		Tags:        []string{"recipes"},
+		Parameters:  []openapi.Parameter{r.idParam},
+		RequestBody: openapi.JSONBody("Fields to update", openapi.Ref("RecipePatch")),
+		Security:    writeSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Updated recipe", recipeEnvelope),
+			"400": r.badRequest,
+			"401": r.unauthorized,
+			"403": r.forbidden,
+			"404": r.notFound,
+			"500": r.serverError,
+		},
+	})
+	doc.AddOperation(http.MethodDelete, V2Prefix+"/recipe/:id", openapi.Operation{
+		OperationID: "deleteRecipeV2",
+		Summary:     "Delete a recipe",
+		Tags:        []string{"recipes"},
+		Parameters:  []openapi.Parameter{r.idParam},
+		Security:    adminSecurity,
+		Responses: map[string]openapi.Response{
+			"204": openapi.JSONResponse("Recipe deleted", nil),
+			"400": r.badRequest,
+			"401": r.unauthorized,
+			"403": r.forbidden,
+			"404": r.notFound,
+			"500": r.serverError,
+		},
+	})
+}
+
+// addEventOperations documents the Server-Sent Events feed, served on the root path and under /api/v2.
+func addEventOperations(doc *openapi.Document, r recipeSchemas) {
+	event := doc.AddSchema("RecipeEvent", handlers.RecipeEvent{})
+	doc.Components.Schemas["RecipeEvent"].Properties["type"].Enum = []string{handlers.EventRecipeCreated, handlers.EventRecipeUpdated, handlers.EventRecipeDeleted}
+	for _, path := range []string{"/recipes/events", V2Prefix + "/recipes/events"} {
+		operationID := "streamRecipeEvents"
+		if path != "/recipes/events" {
+			operationID += "V2"
+		}
+		doc.AddOperation(http.MethodGet, path, openapi.Operation{
+			OperationID: operationID,
+			Summary:     "Stream recipe changes",
+			Description: "Server-Sent Events stream of `created`, `updated` and `deleted` events, each `data` line is a RecipeEvent. " +
+				"The `id` of each event can be sent back as `Last-Event-ID` to resume; events older than the bounded Redis stream " +
+				"are answered with a `reset` event, the client should then reload the catalog.",
+			Tags: []string{"recipes"},
+			Parameters: []openapi.Parameter{
+				openapi.HeaderParam("Last-Event-ID", "Resume after this event ID, sent automatically by EventSource on reconnect"),
+				openapi.QueryParam("lastEventId", "Same as Last-Event-ID, for the first connection", &openapi.Schema{Type: "string"}),
+			},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "Event stream", Content: map[string]openapi.MediaType{"text/event-stream": {Schema: event}}},
+				"400": openapi.JSONResponse("Malformed Last-Event-ID", r.errorSchema),
+				"500": r.serverError,
+			},
+		})
+	}
+}
+
+func fileResponse(description, contentType string) openapi.Response {
+	return openapi.Response{
+		Description: description,
+		Headers:     map[string]openapi.Header{"Content-Disposition": {Description: "`attachment` with the file name", Schema: &openapi.Schema{Type: "string"}}},
+		Content:     map[string]openapi.MediaType{contentType: {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+	}
+}
+
+// addExportOperations documents the printable exports, served on the root path and under /api/v2.
+func addExportOperations(doc *openapi.Document, r recipeSchemas) {
+	request := doc.AddSchema("CookbookRequest", handlers.CookbookRequest{})
+	doc.Components.Schemas["CookbookRequest"].Properties["ids"].Description = fmt.Sprintf("Recipe IDs in print order, 1 to %d", handlers.MaxCookbookRecipes)
+	doc.Components.Schemas["CookbookRequest"].Properties["title"].Description = "Cover title, at most 200 characters, default Cookbook"
+	for _, prefix := range []string{"", V2Prefix} {
+		suffix := ""
+		if prefix != "" {
+			suffix = "V2"
+		}
+		recipeFile := fileResponse("The recipe as PDF, Markdown or plain text", "application/pdf")
+		recipeFile.Content["text/markdown"] = openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+		recipeFile.Content["text/plain"] = openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+		doc.AddOperation(http.MethodGet, prefix+"/recipe/:id/export", openapi.Operation{
+			OperationID: "exportRecipe" + suffix,
+			Summary:     "Export a recipe to print or share",
+			Description: "The recipe with its image, ingredients, numbered instructions and nutrition, in the Accept-Language locale. " +
+				"The image is embedded in PDFs when it is served under `/static` or hosted on one of `EXPORT_IMAGE_HOSTS`, Markdown links to it.",
+			Tags: []string{"recipes"},
+			Parameters: []openapi.Parameter{
+				r.idParam,
+				openapi.QueryParam("format", "`pdf` (default), `md` or `txt`", &openapi.Schema{Type: "string", Enum: []string{"pdf", "md", "txt"}}),
+				acceptLanguageParam,
+			},
+			Responses: map[string]openapi.Response{
+				"200": recipeFile,
+				"400": r.badRequest,
+				"404": r.notFound,
+				"500": r.serverError,
+			},
+		})
+		doc.AddOperation(http.MethodPost, prefix+"/recipes/export", openapi.Operation{
+			OperationID: "exportCookbook" + suffix,
+			Summary:     "Export recipes as a cookbook PDF",
+			Description: "A cover with the title, a table of contents linking to every recipe with its page number, then one recipe per page in the order of `ids`. " +
+				"An unknown recipe fails the export with its `id` in the error.",
+			Tags:        []string{"recipes"},
+			Parameters:  []openapi.Parameter{acceptLanguageParam},
+			RequestBody: openapi.JSONBody("Title and recipe IDs", request),
+			Security:    userSecurity,
+			Responses: map[string]openapi.Response{
+				"200": fileResponse("Cookbook PDF", "application/pdf"),
+				"400": r.badRequest,
+				"401": r.unauthorized,
+				"404": r.notFound,
+				"500": r.serverError,
+			},
+		})
+	}
+}
+
+// addImportOperations documents the recipe import preview, served on the root path and under /api/v2.
+func addImportOperations(doc *openapi.Document, r recipeSchemas) {
+	request := doc.AddSchema("ImportRequest", handlers.ImportRequest{})
+	preview := doc.AddSchema("ImportPreview", handlers.ImportPreview{})
+	body := &openapi.RequestBody{
+		Description: "The page: JSON with its `url` or `html`, a multipart `file`, or the HTML itself",
+		Required:    true,
+		Content:     openapi.JSONContent(request),
+	}
+	body.Content["multipart/form-data"] = openapi.MediaType{Schema: &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"file": {Type: "string", Format: "binary"}},
+		Required:   []string{"file"},
+	}}
+	body.Content["text/html"] = openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+	for _, prefix := range []string{"", V2Prefix} {
+		suffix := ""
+		if prefix != "" {
+			suffix = "V2"
+		}
+		doc.AddOperation(http.MethodPost, prefix+"/recipes/import-url", openapi.Operation{
+			OperationID: "importRecipes" + suffix,
+			Summary:     "Preview recipes from a web page",
+			Description: "Reads the schema.org Recipe JSON-LD of an HTML page of at most 2 MB: name, recipeIngredient, recipeInstructions, image, keywords (as tags), recipeYield and inLanguage. " +
+				"Recipes are returned as they would be stored, with nutrition and allergens, and nothing is saved: review them, then POST them to `/recipe`. " +
+				"URLs are only fetched from `IMPORT_ALLOWED_HOSTS`.",
+			Tags:        []string{"recipes"},
+			RequestBody: body,
+			Security:    adminSecurity,
+			Responses: map[string]openapi.Response{
+				"200": openapi.JSONResponse("Recipes found in the page, with warnings to review", preview),
+				"400": openapi.JSONResponse("Invalid request or host not allowed", r.errorSchema),
+				"401": r.unauthorized,
+				"403": r.forbidden,
+				"413": openapi.JSONResponse("Page larger than 2 MB", r.errorSchema),
+				"415": openapi.JSONResponse("Unsupported request content type", r.errorSchema),
+				"422": openapi.JSONResponse("No schema.org Recipe in the page, or not an HTML page", r.errorSchema),
+				"502": openapi.JSONResponse("The page could not be fetched", r.errorSchema),
+				"504": openapi.JSONResponse("Timed out fetching the page", r.errorSchema),
+			},
+		})
+	}
+}
+
+// addWebhookOperations documents the admin webhook routes, all of them need the admin group.
+func addWebhookOperations(doc *openapi.Document, r recipeSchemas) {
+	webhook := doc.AddSchema("Webhook", models.Webhook{})
+	doc.Components.Schemas["Webhook"].Properties["events"].Items.Enum = handlers.WebhookEvents
+	delivery := doc.AddSchema("WebhookDelivery", models.WebhookDelivery{})
+	doc.Components.Schemas["WebhookDelivery"].Properties["status"].Enum = []string{models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed}
+	request := doc.AddSchema("WebhookRequest", handlers.WebhookRequest{})
+	doc.Components.Schemas["WebhookRequest"].Properties["events"].Items.Enum = handlers.WebhookEvents
+	created := doc.AddSchema("WebhookCreated", handlers.WebhookCreated{})
+	webhookNotFound := openapi.JSONResponse("Webhook not found", r.errorSchema)
+	webhookID := openapi.PathParam("id", "Webhook ID")
+	admin := func(responses map[string]openapi.Response) map[string]openapi.Response {
+		responses["401"] = r.unauthorized
+		responses["403"] = r.forbidden
+		responses["500"] = r.serverError
+		return responses
+	}
+
+	doc.AddOperation(http.MethodPost, "/admin/webhooks", openapi.Operation{
+		OperationID: "createWebhook",
+		Summary:     "Register a webhook",
+		Description: "Deliveries are POSTed as JSON and signed with `" + handlers.WebhookSignatureHeader + ": sha256=<hex HMAC-SHA256(secret, timestamp + \".\" + body)>`, " +
+			"the timestamp is sent in `" + handlers.WebhookTimestampHeader + "`. The secret is generated when omitted and only returned by this call.",
+		Tags:        []string{"webhooks"},
+		RequestBody: openapi.JSONBody("URL and event types", request),
+		Security:    adminSecurity,
+		Responses: admin(map[string]openapi.Response{
+			"201": openapi.JSONResponse("Created webhook including its secret", created),
+			"400": r.badRequest,
+		}),
+	})
+	doc.AddOperation(http.MethodGet, "/admin/webhooks", openapi.Operation{
+		OperationID: "listWebhooks",
+		Summary:     "List webhooks",
+		Tags:        []string{"webhooks"},
+		Security:    adminSecurity,
+		Responses:   admin(map[string]openapi.Response{"200": openapi.JSONResponse("All webhooks", openapi.ArrayOf(webhook))}),
+	})
+	doc.AddOperation(http.MethodGet, "/admin/webhooks/:id", openapi.Operation{
+		OperationID: "getWebhook",
+		Summary:     "Get a webhook",
+		Tags:        []string{"webhooks"},
+		Parameters:  []openapi.Parameter{webhookID},
+		Security:    adminSecurity,
+		Responses: admin(map[string]openapi.Response{
+			"200": openapi.JSONResponse("The webhook", webhook),
+			"400": r.badRequest,
+			"404": webhookNotFound,
+		}),
+	})
+	doc.AddOperation(http.MethodDelete, "/admin/webhooks/:id", openapi.Operation{
+		OperationID: "deleteWebhook",
+		Summary:     "Delete a webhook",
+		Tags:        []string{"webhooks"},
+		Parameters:  []openapi.Parameter{webhookID},
+		Security:    adminSecurity,
+		Responses: admin(map[string]openapi.Response{
+			"200": openapi.JSONResponse("Delete confirmation", r.message),
+			"400": r.badRequest,
+			"404": webhookNotFound,
+		}),
+	})
+	doc.AddOperation(http.MethodGet, "/admin/webhooks/:id/deliveries", openapi.Operation{
+		OperationID: "listWebhookDeliveries",
+		Summary:     "Delivery log of a webhook",
+		Description: "Latest deliveries first, each with every attempt's status code, error and duration.",
+		Tags:        []string{"webhooks"},
+		Parameters: []openapi.Parameter{
+			webhookID,
+			openapi.QueryParam("limit", "Number of deliveries, 1 to 100, default 20", &openapi.Schema{Type: "integer"}),
+		},
+		Security: adminSecurity,
+		Responses: admin(map[string]openapi.Response{
+			"200": openapi.JSONResponse("Deliveries", openapi.ArrayOf(delivery)),
+			"400": r.badRequest,
+			"404": webhookNotFound,
+		}),
+	})
+	doc.AddOperation(http.MethodPost, "/admin/webhooks/:id/deliveries/:deliveryId/redeliver", openapi.Operation{
+		OperationID: "redeliverWebhookDelivery",
+		Summary:     "Send a delivery again",
+		Description: "Queues the same payload as a new delivery, `redeliveryOf` points at the original.",
+		Tags:        []string{"webhooks"},
+		Parameters:  []openapi.Parameter{webhookID, openapi.PathParam("deliveryId", "Delivery ID")},
+		Security:    adminSecurity,
+		Responses: admin(map[string]openapi.Response{
+			"202": openapi.JSONResponse("Queued delivery", delivery),
+			"400": r.badRequest,
+			"404": openapi.JSONResponse("Delivery not found", r.errorSchema),
+		}),
+	})
+}
+
+// addAuditOperation documents the audit trail of the tenant, readable by its admins.
+func addAuditOperation(doc *openapi.Document, r recipeSchemas) {
+	entry := doc.AddSchema("AuditEntry", models.AuditEntry{})
+	doc.Components.Schemas["AuditEntry"].Properties["outcome"].Enum = []string{models.AuditSuccess, models.AuditFailure}
+	csvFile := fileResponse("Matching entries, as JSON or as CSV with a header row", "text/csv")
+	csvFile.Content["application/json"] = openapi.MediaType{Schema: openapi.ArrayOf(entry)}
+	text := &openapi.Schema{Type: "string"}
+	doc.AddOperation(http.MethodGet, "/admin/audit", openapi.Operation{
+		OperationID: "listAuditEntries",
+		Summary:     "Search the audit log",
+		Description: "Who changed what and when in the tenant, newest first: recipe and webhook writes, webhook redeliveries and rejected credentials. " +
+			"`beforeHash` and `afterHash` are the SHA-256 of the JSON of the resource around a write, the after hash of a write is the before hash of the next one. " +
+			"CSV is returned with `format=csv` or `Accept: text/csv`; cells starting with `=`, `+`, `-` or `@` are prefixed with `'`.",
+		Tags: []string{"audit"},
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("action", "Action, e.g. `recipe.update` or `auth.rejected`", text),
+			openapi.QueryParam("actor", "Subject of the caller", text),
+			openapi.QueryParam("resource", "`recipe` or `webhook`", text),
+			openapi.QueryParam("resourceId", "ID of the resource", text),
+			openapi.QueryParam("outcome", "`success` or `failure`", &openapi.Schema{Type: "string", Enum: []string{models.AuditSuccess, models.AuditFailure}}),
+			openapi.QueryParam("from", "Entries at or after this RFC 3339 time", &openapi.Schema{Type: "string", Format: "date-time"}),
+			openapi.QueryParam("to", "Entries before this RFC 3339 time", &openapi.Schema{Type: "string", Format: "date-time"}),
+			openapi.QueryParam("limit", fmt.Sprintf("Number of entries, 1 to %d, default %d", handlers.MaxAuditLimit, handlers.DefaultAuditLimit), &openapi.Schema{Type: "integer"}),
+			openapi.QueryParam("format", "`json` (default) or `csv`", &openapi.Schema{Type: "string", Enum: []string{"json", "csv"}}),
+		},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": csvFile,
+			"400": r.badRequest,
+			"401": r.unauthorized,
+			"403": r.forbidden,
+			"500": r.serverError,
+		},
+	})
+}
+
+// addMealPlanOperations documents the meal plan routes, any authenticated user manages their own plans.
+func addMealPlanOperations(doc *openapi.Document, r recipeSchemas) {
+	plan := doc.AddSchema("MealPlan", models.MealPlan{})
+	slot := doc.Components.Schemas["MealPlan"].Properties["slots"].Items
+	slot.Properties["day"].Enum = models.Weekdays
+	slot.Properties["meal"].Enum = models.Meals
+	request := doc.AddSchema("MealPlanRequest", handlers.MealPlanRequest{})
+	slotRequest := doc.Components.Schemas["MealPlanRequest"].Properties["slots"].Items
+	slotRequest.Properties["day"].Enum = models.Weekdays
+	slotRequest.Properties["meal"].Enum = models.Meals
+	copyRequest := doc.AddSchema("CopyMealPlanRequest", handlers.CopyMealPlanRequest{})
+	planNotFound := openapi.JSONResponse("Meal plan not found", r.errorSchema)
+	weekTaken := openapi.JSONResponse("The user has a meal plan for the week", r.errorSchema)
+	planID := openapi.PathParam("id", "Meal plan ID")
+	user := func(responses map[string]openapi.Response) map[string]openapi.Response {
+		responses["401"] = r.unauthorized
+		responses["500"] = r.serverError
+		return responses
+	}
+
+	doc.AddOperation(http.MethodPost, "/mealplans", openapi.Operation{
+		OperationID: "createMealPlan",
+		Summary:     "Create a weekly meal plan",
+		Description: "`weekStart` is a Monday, every recipe must exist. A user has one plan per week.",
+		Tags:        []string{"mealplans"},
+		RequestBody: openapi.JSONBody("Week and slots", request),
+		Security:    userSecurity,
+		Responses: user(map[string]openapi.Response{
+			"201": openapi.JSONResponse("Created meal plan", plan),
+			"400": r.badRequest,
+			"409": weekTaken,
+		}),
+	})
+	doc.AddOperation(http.MethodGet, "/mealplans", openapi.Operation{
+		OperationID: "listMealPlans",
+		Summary:     "List meal plans",
+		Description: "Plans of the user, latest week first.",
+		Tags:        []string{"mealplans"},
+		Parameters:  []openapi.Parameter{openapi.QueryParam("week", "Only the plan of the week starting on this Monday", &openapi.Schema{Type: "string", Format: "date"})},
+		Security:    userSecurity,
+		Responses: user(map[string]openapi.Response{
+			"200": openapi.JSONResponse("Meal plans", openapi.ArrayOf(plan)),
+			"400": r.badRequest,
+		}),
+	})
+	doc.AddOperation(http.MethodPost, "/mealplans/copy-last-week", openapi.Operation{
+		OperationID: "copyLastWeekMealPlan",
+		Summary:     "Copy the plan of the previous week",
+		Description: "Creates the plan of `weekStart` (default: the current week) from the plan of the week before. Slots of deleted recipes are not copied.",
+		Tags:        []string{"mealplans"},
+		RequestBody: &openapi.RequestBody{Description: "Optional target week", Content: openapi.JSONContent(copyRequest)},
+		Security:    userSecurity,
+		Responses: user(map[string]openapi.Response{
+			"201": openapi.JSONResponse("Created meal plan", plan),
+			"400": r.badRequest,
+			"404": openapi.JSONResponse("No meal plan for the previous week", r.errorSchema),
+			"409": weekTaken,
+		}),
+	})
+	doc.AddOperation(http.MethodGet, "/mealplans/:id", openapi.Operation{
+		OperationID: "getMealPlan",
+		Summary:     "Get a meal plan",
+		Description: "Slots of recipes deleted since the plan was saved have `recipeDeleted` set.",
+		Tags:        []string{"mealplans"},
+		Parameters:  []openapi.Parameter{planID},
+		Security:    userSecurity,
+		Responses: user(map[string]openapi.Response{
+			"200": openapi.JSONResponse("The meal plan", plan),
+			"400": r.badRequest,
+			"404": planNotFound,
+		}),
+	})
+	doc.AddOperation(http.MethodPut, "/mealplans/:id", openapi.Operation{
+		OperationID: "replaceMealPlan",
+		Summary:     "Replace a meal plan",
+		Tags:        []string{"mealplans"},
+		Parameters:  []openapi.Parameter{planID},
+		RequestBody: openapi.JSONBody("Week and slots", request),
+		Security:    userSecurity,
+		Responses: user(map[string]openapi.Response{
+			"200": openapi.JSONResponse("The updated meal plan", plan),
+			"400": r.badRequest,
+			"404": planNotFound,
+			"409": weekTaken,
+		}),
+	})
+	doc.AddOperation(http.MethodDelete, "/mealplans/:id", openapi.Operation{
+		OperationID: "deleteMealPlan",
+		Summary:     "Delete a meal plan",
+		Tags:        []string{"mealplans"},
+		Parameters:  []openapi.Parameter{planID},
+		Security:    userSecurity,
+		Responses: user(map[string]openapi.Response{
+			"204": {Description: "Deleted"},
+			"400": r.badRequest,
+			"404": planNotFound,
+		}),
+	})
+	doc.AddOperation(http.MethodGet, "/mealplans/:id/ics", openapi.Operation{
+		OperationID: "exportMealPlanICS",
+		Summary:     "Export a meal plan as iCalendar",
+		Description: "One event per slot, at floating local times: breakfast 08:00, lunch 12:30, snack 16:00 and dinner 19:00.",
+		Tags:        []string{"mealplans"},
+		Parameters:  []openapi.Parameter{planID},
+		Security:    userSecurity,
+		Responses: user(map[string]openapi.Response{
+			"200": {Description: "iCalendar file", Content: map[string]openapi.MediaType{"text/calendar": {Schema: &openapi.Schema{Type: "string"}}}},
+			"400": r.badRequest,
+			"404": planNotFound,
+		}),
+	})
+}
+
+// addGraphQLOperation documents the GraphQL endpoint, the schema itself is handlers.GraphQLSchema.
+func addGraphQLOperation(doc *openapi.Document, r recipeSchemas) {
+	request := doc.AddSchema("GraphQLRequest", handlers.GraphQLRequest{})
+	//Credentials are optional, only mutations need them
+	optionalSecurity := append([]openapi.SecurityRequirement{{}}, writeSecurity...)
+	doc.AddOperation(http.MethodPost, "/graphql", openapi.Operation{
+		OperationID: "graphql",
+		Summary:     "GraphQL endpoint",
+		Description: "Queries `recipe(id)`, `recipes(first, after, filter)` and `search(q, tags)`, mutations `createRecipe`, `updateRecipe` (scope `recipes:write`) and `deleteRecipe` (group `admin`). " +
+			"Recipe lookups of one request are batched into one Redis/MongoDB round trip. Errors are returned in `errors` with status 200, `extensions.code` is one of UNAUTHENTICATED, FORBIDDEN, BAD_USER_INPUT or NOT_FOUND.",
+		Tags:        []string{"recipes"},
+		RequestBody: openapi.JSONBody("GraphQL query", request),
+		Security:    optionalSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("GraphQL response with data and errors", &openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"data":   {Type: "object"},
+					"errors": openapi.ArrayOf(&openapi.Schema{Type: "object"}),
+				},
+			}),
+			"400": openapi.JSONResponse("Body is not a GraphQL request", r.errorSchema),
+			"401": r.unauthorized,
+		},
+	})
+}
+
+func searchParams() []openapi.Parameter {
+	return []openapi.Parameter{
+		openapi.QueryParam("q", "Free text matched against name and tags", &openapi.Schema{Type: "string"}),
+		openapi.QueryParam("tag", "Exact tag filter", &openapi.Schema{Type: "string"}),
+		openapi.QueryParam("maxCalories", "Maximum calories per serving, recipes without computed nutrition are excluded", &openapi.Schema{Type: "number"}),
+		openapi.QueryParam("excludeAllergens", "Comma separated allergens to exclude: gluten, dairy, eggs, tree-nuts, peanuts, soy, fish, shellfish, sesame", &openapi.Schema{Type: "string"}),
+		acceptLanguageParam,
+	}
+}
+
+// tenantParam selects the tenant, see handlers.ResolveTenant.
+var tenantParam = openapi.HeaderParam(handlers.TenantHeader, "Tenant (kitchen) of the request, default `default`. Members of a tenant get theirs from the token. "+
+	"Every other tenant needs a member or an admin of it: 400 for an invalid ID, 401 without credentials, 403 for others")
+
+// acceptLanguageParam selects the locale of names, ingredients and instructions in a response.
+var acceptLanguageParam = openapi.HeaderParam("Accept-Language", "Preferred locales, e.g. `fr-CA,fr;q=0.9`. Recipes without a matching translation are returned in their own locale")
+
+const conditionalDescription = "Carries `ETag` and `Last-Modified`, conditional requests for an unchanged representation are answered with 304 from Redis without reading MongoDB."
+
+// conditionalParams revalidate a cached response, If-None-Match wins when both are sent.
+var conditionalParams = []openapi.Parameter{
+	openapi.HeaderParam("If-None-Match", "ETags of cached copies, or `*`"),
+	openapi.HeaderParam("If-Modified-Since", "Last-Modified of a cached copy"),
+}
+
+var notModifiedResponse = openapi.Response{Description: "The cached copy is current"}
+
+// cachedResponse documents the validators handlers.RecipeHandler sets on recipe reads.
+func cachedResponse(res openapi.Response) openapi.Response {
+	res.Headers = map[string]openapi.Header{
+		"ETag":          {Description: "Weak validator of the representation, it differs per locale and tenant", Schema: &openapi.Schema{Type: "string"}},
+		"Last-Modified": {Description: "Latest publish or update time of the recipes served", Schema: &openapi.Schema{Type: "string"}},
+		"Cache-Control": {Description: "`public, max-age=0, must-revalidate`, `private` in tenants other than the default one", Schema: &openapi.Schema{Type: "string"}},
+	}
+	return res
+}
+
+// envelopeSchema is the v2 envelope around data, see handlers.Envelope.
+func envelopeSchema(data, meta, links *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"data": data, "meta": meta, "links": links},
+		Required:   []string{"data", "meta", "links"},
+	}
+}
+
+// recipeInputSchema is the Recipe schema without the server assigned and computed fields, only name is required.
+func recipeInputSchema(recipe *openapi.Schema) *openapi.Schema {
+	input := &openapi.Schema{Type: "object", Properties: make(map[string]*openapi.Schema), Required: []string{"name"}}
+	for name, prop := range recipe.Properties {
+		switch name {
+		case "id", "publishedAt", "nutrition", "allergens", "diets", "tagConflicts":
+			continue
+		}
+		input.Properties[name] = prop
+	}
+	return input
+}
@@ -0,0 +1,172 @@
+package routes
+
+import (
+	"framework-api/handlers"
+	"framework-api/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// API versions mounted by SetupRouter. The root paths are deprecated aliases of v1.
+const (
+	V1Prefix = "/api/v1"
+	V2Prefix = "/api/v2"
+)
+
+// Config holds the router settings loaded at startup.
+type Config struct {
+	CORS utils.CORSConfig
+	// V1Deprecation dates are announced on /api/v1 and the root aliases, the successor Link is filled in by SetupRouter.
+	V1Deprecation handlers.DeprecationPolicy
+}
+
+// Handlers are the request handlers wired by SetupRouter.
+type Handlers struct {
+	Recipes   *handlers.RecipeHandler
+	Events    *handlers.RecipeEvents
+	Webhooks  *handlers.WebhookHandler
+	GraphQL   *handlers.GraphQLHandler
+	MealPlans *handlers.MealPlanHandler
+	Pages     *handlers.PageHandler
+	Exports   *handlers.ExportHandler
+	Imports   *handlers.ImportHandler
+	Audit     *handlers.AuditLog
+}
+
+// SetupRouter registers CORS and all the recipe routes on the engine.
+// Public read routes and authenticated write routes are declared in separate groups,
+// so a write route can never be registered without the auth middleware in front of it.
+func SetupRouter(engine *gin.Engine, cfg Config, h Handlers, auth *handlers.AuthHandler) {
+	recipeHandler := h.Recipes
+	authMiddleware := auth.AuthMiddleware()
+	//Setting up CORS
+	engine.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.CORS.AllowOrigins,
+		AllowMethods:     cfg.CORS.AllowMethods,
+		AllowHeaders:     cfg.CORS.AllowHeaders,
+		ExposeHeaders:    []string{"Content-Length", "Location", "Deprecation", "Sunset", "Link", "ETag", handlers.RequestIDHeader},
+		AllowCredentials: true,
+		MaxAge:           24 * time.Hour,
+	}))
+	//Every request gets an ID, echoed in the response and recorded in the audit log
+	engine.Use(handlers.RequestIDMiddleware())
+	//Responses are compressed with the encoding the client accepts
+	engine.Use(handlers.CompressionMiddleware())
+	//Every request runs in a tenant, its data is only visible in it
+	engine.Use(auth.TenantMiddleware())
+
+	//Server rendered pages for browsers without JavaScript and crawlers
+	engine.GET("/", h.Pages.Home)
+	engine.GET("/search", h.Pages.SearchPage)
+	engine.GET("/r/:id", h.Pages.RecipePage)
+	engine.GET("/r/:id/:slug", h.Pages.RecipePage)
+	engine.GET("/sitemap.xml", h.Pages.Sitemap)
+	engine.GET("/robots.txt", h.Pages.Robots)
+
+	//Check Server API status
+	engine.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "pong")
+	})
+
+	//OpenAPI document and Swagger UI rendering it
+	engine.GET("/openapi.json", OpenAPISpec().Handler())
+	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/openapi.json")))
+
+	//Live feed of recipe changes (Server-Sent Events), not versioned: the events carry their own schema
+	engine.GET("/recipes/events", h.Events.StreamRecipeEvents)
+	engine.GET(V2Prefix+"/recipes/events", h.Events.StreamRecipeEvents)
+
+	//Printable exports, not versioned: the files have their own format. Cookbooks take any authenticated user
+	engine.GET("/recipe/:id/export", h.Exports.ExportRecipe)
+	engine.GET(V2Prefix+"/recipe/:id/export", h.Exports.ExportRecipe)
+	engine.POST("/recipes/export", authMiddleware, h.Exports.ExportCookbook)
+	engine.POST(V2Prefix+"/recipes/export", authMiddleware, h.Exports.ExportCookbook)
+
+	//Recipe import previews fetch pages on behalf of the caller, admins of the tenant only
+	engine.POST("/recipes/import-url", authMiddleware, handlers.RequireTenantAdmin(), h.Imports.ImportRecipes)
+	engine.POST(V2Prefix+"/recipes/import-url", authMiddleware, handlers.RequireTenantAdmin(), h.Imports.ImportRecipes)
+
+	//GraphQL - queries are public, mutations check the principal set by the optional auth middleware
+	engine.POST("/graphql", auth.OptionalAuthMiddleware(), h.GraphQL.Serve)
+
+	//Admin APIs - admins of the tenant only
+	admin := engine.Group("/admin", authMiddleware, handlers.RequireTenantAdmin())
+	{
+		admin.POST("/webhooks", h.Webhooks.CreateWebhook)
+		admin.GET("/webhooks", h.Webhooks.ListWebhooks)
+		admin.GET("/webhooks/:id", h.Webhooks.GetWebhook)
+		admin.DELETE("/webhooks/:id", h.Webhooks.DeleteWebhook)
+		admin.GET("/webhooks/:id/deliveries", h.Webhooks.ListDeliveries)
+		admin.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", h.Webhooks.RedeliverDelivery)
+		admin.GET("/audit", h.Audit.ListAudit)
+	}
+
+	//Meal plans - any authenticated user, every plan is only visible to its owner
+	mealPlans := engine.Group("/mealplans", authMiddleware)
+	{
+		mealPlans.POST("", h.MealPlans.CreateMealPlan)
+		mealPlans.GET("", h.MealPlans.ListMealPlans)
+		mealPlans.POST("/copy-last-week", h.MealPlans.CopyLastWeek)
+		mealPlans.GET("/:id", h.MealPlans.GetMealPlan)
+		mealPlans.PUT("/:id", h.MealPlans.UpdateMealPlan)
+		mealPlans.DELETE("/:id", h.MealPlans.DeleteMealPlan)
+		mealPlans.GET("/:id/ics", h.MealPlans.ExportMealPlanICS)
+	}
+
+	//v1 keeps today's response shapes, the root paths stay as aliases until the sunset date
+	v1Deprecation := cfg.V1Deprecation
+	v1Deprecation.Successor = V2SuccessorPath
+	deprecated := handlers.Deprecation(v1Deprecation)
+	registerV1Routes(engine.Group(V1Prefix, deprecated), recipeHandler, authMiddleware)
+	registerV1Routes(engine.Group("/", deprecated), recipeHandler, authMiddleware)
+
+	//v2 wraps every response in the data/meta/links envelope
+	registerV2Routes(engine.Group(V2Prefix), recipeHandler, authMiddleware)
+}
+
+func registerV1Routes(router *gin.RouterGroup, recipeHandler *handlers.RecipeHandler, authMiddleware gin.HandlerFunc) {
+	//RECIPE APIs (Public)
+	public := router.Group("")
+	{
+		public.GET("/recipes", recipeHandler.GetRecipes)
+		public.GET("/recipe/:id", recipeHandler.GetRecipeById)
+		public.GET("/recipe/:id/similar", recipeHandler.GetSimilarRecipes)
+		public.GET("/recipes/search", recipeHandler.SearchRecipeInElasticStore)
+	}
+
+	//RECIPE APIs (Write) - protected by the auth middleware, each route declares its permission
+	authorized := router.Group("", authMiddleware)
+	{
+		authorized.POST("/recipe", handlers.RequireScopes(handlers.ScopeRecipesWrite), recipeHandler.InsertRecipe)
+		authorized.PATCH("/recipe/:id", handlers.RequireScopes(handlers.ScopeRecipesWrite), recipeHandler.UpdateRecipeById)
+		authorized.DELETE("/recipe/:id", handlers.RequireTenantAdmin(), recipeHandler.DeleteRecipeById)
+	}
+}
+
+func registerV2Routes(router *gin.RouterGroup, recipeHandler *handlers.RecipeHandler, authMiddleware gin.HandlerFunc) {
+	public := router.Group("")
+	{
+		public.GET("/recipes", recipeHandler.GetRecipesV2)
+		public.GET("/recipe/:id", recipeHandler.GetRecipeByIdV2)
+		public.GET("/recipe/:id/similar", recipeHandler.GetSimilarRecipesV2)
+		public.GET("/recipes/search", recipeHandler.SearchRecipesV2)
+	}
+
+	authorized := router.Group("", authMiddleware)
+	{
+		authorized.POST("/recipe", handlers.RequireScopes(handlers.ScopeRecipesWrite), recipeHandler.InsertRecipeV2)
+		authorized.PATCH("/recipe/:id", handlers.RequireScopes(handlers.ScopeRecipesWrite), recipeHandler.UpdateRecipeByIdV2)
+		authorized.DELETE("/recipe/:id", handlers.RequireTenantAdmin(), recipeHandler.DeleteRecipeByIdV2)
+	}
+}
+
+// V2SuccessorPath maps a v1 or root alias path to the same resource under /api/v2.
+func V2SuccessorPath(path string) string {
+	return V2Prefix + strings.TrimPrefix(path, V1Prefix)
+}
@@ -0,0 +1,222 @@
+package routes
+
+import (
+	"context"
+	"framework-api/handlers"
+	"framework-api/handlers/authtest"
+	"framework-api/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	testDeprecatedAt = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	testSunset       = time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func newTestEngine(t *testing.T) (*gin.Engine, *authtest.Signer) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	//Handlers are never reached for unauthenticated writes, so no backing stores are needed.
+	recipeHandler := handlers.NewRecipesHandler(context.Background(), nil, nil, nil)
+	signer, err := authtest.NewSigner("issuer", "client")
+	if err != nil {
+		t.Fatalf("Unexpected error creating signer: %s", err)
+	}
+	keys, err := handlers.NewLocalKeyProvider(signer.PublicKeys())
+	if err != nil {
+		t.Fatalf("Unexpected error creating key provider: %s", err)
+	}
+	cognito, err := handlers.NewCognitoAuthenticator("issuer", "client", keys, time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error creating authenticator: %s", err)
+	}
+	authHandler := handlers.NewAuthHandler(cognito)
+	graphqlHandler, err := handlers.NewGraphQLHandler(recipeHandler, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating graphql handler: %s", err)
+	}
+	cfg := Config{
+		CORS: utils.CORSConfig{
+			AllowOrigins: []string{"http://localhost:3000"},
+			AllowMethods: []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS", "HEAD"},
+			AllowHeaders: []string{"Origin", "Content-Type", "Authorization"},
+		},
+		V1Deprecation: handlers.DeprecationPolicy{
+			DeprecatedAt: testDeprecatedAt,
+			Sunset:       testSunset,
+		},
+	}
+	SetupRouter(engine, cfg, Handlers{
+		Recipes:   recipeHandler,
+		Events:    handlers.NewRecipeEvents(nil, 0),
+		Webhooks:  handlers.NewWebhookHandler(nil, nil),
+		GraphQL:   graphqlHandler,
+		Pages:     handlers.NewPageHandler(recipeHandler, "http://localhost:8088"),
+		Exports:   handlers.NewExportHandler(recipeHandler, nil, "http://localhost:8088"),
+		Imports:   handlers.NewImportHandler(recipeHandler, handlers.NewPageFetcher(nil, time.Second)),
+		MealPlans: handlers.NewMealPlanHandler(nil, recipeHandler),
+	}, authHandler)
+	return engine, signer
+}
+
+func TestUnauthenticatedWritesReturn401(t *testing.T) {
+	engine, _ := newTestEngine(t)
+	ts := []struct {
+		text   string
+		method string
+		path   string
+		header string
+	}{
+		{text: "create without token", method: http.MethodPost, path: "/recipe"},
+		{text: "v1 create without token", method: http.MethodPost, path: "/api/v1/recipe"},
+		{text: "v2 create without token", method: http.MethodPost, path: "/api/v2/recipe"},
+		{text: "v2 update without token", method: http.MethodPatch, path: "/api/v2/recipe/65f1c0ffee0000000000abcd"},
+		{text: "create webhook without token", method: http.MethodPost, path: "/admin/webhooks"},
+		{text: "list webhooks without token", method: http.MethodGet, path: "/admin/webhooks"},
+		{text: "audit log without token", method: http.MethodGet, path: "/admin/audit"},
+		{text: "list meal plans without token", method: http.MethodGet, path: "/mealplans"},
+		{text: "export meal plan without token", method: http.MethodGet, path: "/mealplans/65f1c0ffee0000000000abcd/ics"},
+		{text: "v2 delete without token", method: http.MethodDelete, path: "/api/v2/recipe/65f1c0ffee0000000000abcd"},
+		{text: "update without token", method: http.MethodPatch, path: "/recipe/65f1c0ffee0000000000abcd"},
+		{text: "delete without token", method: http.MethodDelete, path: "/recipe/65f1c0ffee0000000000abcd"},
+		{text: "create with malformed token", method: http.MethodPost, path: "/recipe", header: "Bearer not-a-jwt"},
+		{text: "delete with malformed token", method: http.MethodDelete, path: "/recipe/65f1c0ffee0000000000abcd", header: "Bearer not-a-jwt"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"name":"test"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status %d, got %d", tc.text, http.StatusUnauthorized, w.Code)
+		}
+	}
+}
+
+func TestCORSPreflightAllowsWriteMethods(t *testing.T) {
+	engine, _ := newTestEngine(t)
+	for _, method := range []string{http.MethodPost, http.MethodPatch, http.MethodDelete} {
+		req := httptest.NewRequest(http.MethodOptions, "/recipe", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", method)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Errorf("preflight %s: expected status %d, got %d", method, http.StatusNoContent, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+			t.Errorf("preflight %s: unexpected Access-Control-Allow-Origin %q", method, got)
+		}
+	}
+}
+
+func TestCORSRejectsUnknownOrigin(t *testing.T) {
+	engine, _ := newTestEngine(t)
+	req := httptest.NewRequest(http.MethodOptions, "/recipe", nil)
+	req.Header.Set("Origin", "http://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for unknown origin, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestWritesRequirePermissions(t *testing.T) {
+	engine, signer := newTestEngine(t)
+	ts := []struct {
+		text   string
+		method string
+		path   string
+		claims map[string]interface{}
+	}{
+		{text: "create without scope", method: http.MethodPost, path: "/recipe", claims: map[string]interface{}{"scope": "openid"}},
+		{text: "update without scope", method: http.MethodPatch, path: "/recipe/65f1c0ffee0000000000abcd", claims: map[string]interface{}{"scope": "openid"}},
+		{text: "delete with write scope only", method: http.MethodDelete, path: "/recipe/65f1c0ffee0000000000abcd", claims: map[string]interface{}{"scope": "recipes:write"}},
+		{text: "v2 delete with write scope only", method: http.MethodDelete, path: "/api/v2/recipe/65f1c0ffee0000000000abcd", claims: map[string]interface{}{"scope": "recipes:write"}},
+		{text: "create webhook with write scope only", method: http.MethodPost, path: "/admin/webhooks", claims: map[string]interface{}{"scope": "recipes:write"}},
+		{text: "audit log with write scope only", method: http.MethodGet, path: "/admin/audit", claims: map[string]interface{}{"scope": "recipes:write"}},
+		{text: "import with write scope only", method: http.MethodPost, path: "/recipes/import-url", claims: map[string]interface{}{"scope": "recipes:write"}},
+		{text: "v2 import with write scope only", method: http.MethodPost, path: "/api/v2/recipes/import-url", claims: map[string]interface{}{"scope": "recipes:write"}},
+		{text: "delete in non admin group", method: http.MethodDelete, path: "/recipe/65f1c0ffee0000000000abcd", claims: map[string]interface{}{"cognito:groups": []string{"cooks"}}},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		claims := signer.Claims("user-1", time.Minute)
+		for k, v := range tc.claims {
+			claims[k] = v
+		}
+		token, err := signer.Sign(claims)
+		if err != nil {
+			t.Fatalf("Unexpected error signing token: %s", err)
+		}
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"name":"test"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected status %d, got %d", tc.text, http.StatusForbidden, w.Code)
+		}
+	}
+}
+
+func TestV1RoutesAnnounceDeprecation(t *testing.T) {
+	engine, _ := newTestEngine(t)
+	//Requests that fail validation never reach the stores, the headers are set before the handler runs
+	ts := []struct {
+		text      string
+		path      string
+		exp       bool
+		successor string
+	}{
+		{text: "v1 search", path: "/api/v1/recipes/search", exp: true, successor: "</api/v2/recipes/search>; rel=\"successor-version\""},
+		{text: "root alias search", path: "/recipes/search", exp: true, successor: "</api/v2/recipes/search>; rel=\"successor-version\""},
+		{text: "v2 search", path: "/api/v2/recipes/search", exp: false},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", tc.text, http.StatusBadRequest, w.Code)
+		}
+		if !tc.exp {
+			if got := w.Header().Get("Deprecation"); got != "" {
+				t.Errorf("%s: expected no Deprecation header, got %q", tc.text, got)
+			}
+			continue
+		}
+		if got := w.Header().Get("Deprecation"); got != "@1793491200" {
+			t.Errorf("%s: expected Deprecation @1793491200, got %q", tc.text, got)
+		}
+		if got := w.Header().Get("Sunset"); got != "Sat, 01 May 2027 00:00:00 GMT" {
+			t.Errorf("%s: unexpected Sunset %q", tc.text, got)
+		}
+		if got := w.Header().Get("Link"); got != tc.successor {
+			t.Errorf("%s: expected Link %q, got %q", tc.text, tc.successor, got)
+		}
+	}
+}
+
+func TestV2RejectsInvalidPagination(t *testing.T) {
+	engine, _ := newTestEngine(t)
+	for _, query := range []string{"limit=0", "limit=abc", "offset=-1"} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/recipes?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package schemaorg
+
+import (
+	"encoding/json"
+	"errors"
+	"framework-api/models"
+	"io"
+	"mime"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNoRecipe is returned by ExtractRecipes for pages without a schema.org Recipe.
+var ErrNoRecipe = errors.New("No schema.org Recipe found in the page")
+
+// ExtractRecipes returns the schema.org Recipes of the JSON-LD scripts of an HTML page, in page order, mapped to
+// models.Recipe: name, recipeIngredient, recipeInstructions (text, HowToStep and HowToSection), the first image,
+// keywords as tags, recipeYield as servings and inLanguage as the locale, which is not normalized.
+// Relative image URLs are resolved against base when it is not nil. Malformed scripts are skipped.
+func ExtractRecipes(page io.Reader, base *url.URL) ([]models.Recipe, error) {
+	scripts, err := jsonLDScripts(page)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []map[string]interface{}
+	for _, script := range scripts {
+		var doc interface{}
+		if json.Unmarshal([]byte(script), &doc) != nil {
+			continue
+		}
+		nodes = collectRecipes(doc, nodes)
+	}
+	if len(nodes) == 0 {
+		return nil, ErrNoRecipe
+	}
+	recipes := make([]models.Recipe, 0, len(nodes))
+	for _, node := range nodes {
+		recipes = append(recipes, toRecipe(node, base))
+	}
+	return recipes, nil
+}
+
+// jsonLDScripts returns the content of the <script type="application/ld+json"> elements.
+func jsonLDScripts(page io.Reader) ([]string, error) {
+	var scripts []string
+	z := html.NewTokenizer(page)
+	inScript := false
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return scripts, nil
+			}
+			return nil, z.Err()
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+			inScript = false
+			if atom.Lookup(name) != atom.Script {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "type" {
+					mediaType, _, _ := mime.ParseMediaType(string(val))
+					inScript = mediaType == "application/ld+json"
+				}
+			}
+		case html.TextToken:
+			if inScript {
+				scripts = append(scripts, string(z.Text()))
+			}
+		case html.EndTagToken:
+			inScript = false
+		}
+	}
+}
+
+// collectRecipes appends the Recipe nodes of a JSON-LD document: a node, an array of nodes, an @graph,
+// or the mainEntity of a page.
+func collectRecipes(doc interface{}, recipes []map[string]interface{}) []map[string]interface{} {
+	switch v := doc.(type) {
+	case []interface{}:
+		for _, item := range v {
+			recipes = collectRecipes(item, recipes)
+		}
+	case map[string]interface{}:
+		if hasType(v, "Recipe") {
+			return append(recipes, v)
+		}
+		recipes = collectRecipes(v["@graph"], recipes)
+		recipes = collectRecipes(v["mainEntity"], recipes)
+	}
+	return recipes
+}
+
+// hasType reports whether @type is name, also written as a URL or within an array of types.
+func hasType(node map[string]interface{}, name string) bool {
+	matches := func(t interface{}) bool {
+		s, _ := t.(string)
+		return s == name || strings.HasSuffix(s, "schema.org/"+name)
+	}
+	if types, ok := node["@type"].([]interface{}); ok {
+		return slices.ContainsFunc(types, matches)
+	}
+	return matches(node["@type"])
+}
+
+func toRecipe(node map[string]interface{}, base *url.URL) models.Recipe {
+	recipe := models.Recipe{
+		Name:         plainText(firstText(node["name"])),
+		Ingredients:  texts(node["recipeIngredient"]),
+		Instructions: instructions(node["recipeInstructions"]),
+		ImageURL:     imageURL(node["image"], base),
+		Tags:         keywords(node["keywords"]),
+		Servings:     servings(node["recipeYield"]),
+		Locale:       strings.TrimSpace(firstText(node["inLanguage"])),
+	}
+	//Older pages use the deprecated "ingredients"
+	if len(recipe.Ingredients) == 0 {
+		recipe.Ingredients = texts(node["ingredients"])
+	}
+	return recipe
+}
+
+// texts returns the non empty strings of a value that is a string or an array of strings.
+func texts(v interface{}) []string {
+	var res []string
+	switch v := v.(type) {
+	case string:
+		if text := plainText(v); text != "" {
+			res = append(res, text)
+		}
+	case []interface{}:
+		for _, item := range v {
+			res = append(res, texts(item)...)
+		}
+	}
+	return res
+}
+
+// instructions flattens recipeInstructions: one text with a step per line, an array of texts,
+// HowToStep and HowToSection nodes, or an ItemList of them.
+func instructions(v interface{}) []string {
+	var res []string
+	switch v := v.(type) {
+	case string:
+		for _, line := range strings.Split(strings.ReplaceAll(v, "<br", "\n<br"), "\n") {
+			if text := plainText(line); text != "" {
+				res = append(res, text)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			res = append(res, instructions(item)...)
+		}
+	case map[string]interface{}:
+		if steps, ok := v["itemListElement"]; ok {
+			return instructions(steps)
+		}
+		text := plainText(firstText(v["text"]))
+		if text == "" {
+			text = plainText(firstText(v["name"]))
+		}
+		if text != "" {
+			res = append(res, text)
+		}
+	}
+	return res
+}
+
+// imageURL returns the first image: a URL, an ImageObject or an array of either.
+func imageURL(v interface{}, base *url.URL) string {
+	var link string
+	switch v := v.(type) {
+	case string:
+		link = v
+	case []interface{}:
+		for _, item := range v {
+			if link = imageURL(item, base); link != "" {
+				return link
+			}
+		}
+	case map[string]interface{}:
+		for _, key := range []string{"url", "contentUrl", "@id"} {
+			if link = firstText(v[key]); link != "" {
+				break
+			}
+		}
+	}
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+// keywords splits comma separated keywords into lowercase tags, without duplicates.
+func keywords(v interface{}) []string {
+	var res []string
+	for _, text := range texts(v) {
+		for _, keyword := range strings.Split(text, ",") {
+			keyword = strings.ToLower(strings.TrimSpace(keyword))
+			if keyword != "" && !slices.Contains(res, keyword) {
+				res = append(res, keyword)
+			}
+		}
+	}
+	return res
+}
+
+// servings reads the number of a recipeYield such as 4, "4", "4 servings" or ["4", "4 bowls"].
+func servings(v interface{}) int {
+	switch v := v.(type) {
+	case float64:
+		if v >= 1 {
+			return int(v)
+		}
+	case string:
+		digits := strings.TrimLeftFunc(v, func(r rune) bool { return !unicode.IsDigit(r) })
+		end := strings.IndexFunc(digits, func(r rune) bool { return !unicode.IsDigit(r) })
+		if end >= 0 {
+			digits = digits[:end]
+		}
+		n, _ := strconv.Atoi(digits)
+		return n
+	case []interface{}:
+		for _, item := range v {
+			if n := servings(item); n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// firstText returns a string value, or the first string of an array.
+func firstText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// plainText removes the markup and entities pages leave in JSON-LD strings and collapses white space.
+func plainText(s string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt == html.TextToken {
+			b.Write(z.Text())
+		}
+		//Block elements separate words
+		if tt == html.StartTagToken || tt == html.EndTagToken || tt == html.SelfClosingTagToken {
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
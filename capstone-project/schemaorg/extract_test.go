@@ -0,0 +1,87 @@
+package schemaorg
+
+import (
+	"framework-api/models"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func extractFixture(t *testing.T, name string, base string) ([]models.Recipe, error) {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatalf("Unexpected error opening fixture: %s", err)
+	}
+	defer f.Close()
+	var baseURL *url.URL
+	if base != "" {
+		baseURL, _ = url.Parse(base)
+	}
+	return ExtractRecipes(f, baseURL)
+}
+
+func TestExtractRecipes(t *testing.T) {
+	ts := []struct {
+		text    string
+		fixture string
+		base    string
+		exp     []models.Recipe
+	}{
+		{
+			text:    "@graph with sections, ImageObject and markup",
+			fixture: "graph.html",
+			base:    "https://blog.example.com/palak-paneer/",
+			exp: []models.Recipe{{
+				Name:        "Palak Paneer & Rice",
+				Ingredients: []string{"200 g paneer", "2 bunches spinach", "1 onion"},
+				Instructions: []string{
+					"Blanch the spinach.",
+					"Blend it to a puree.",
+					"Fry the onion",
+					"Add the puree and the paneer, simmer for 5 minutes.",
+				},
+				ImageURL: "https://blog.example.com/images/palak-paneer.jpg",
+				Tags:     []string{"indian", "paneer", "vegetarian"},
+				Servings: 4,
+				Locale:   "en-US",
+			}},
+		},
+		{
+			text:    "array with text instructions and mainEntity",
+			fixture: "multiple.html",
+			exp: []models.Recipe{
+				{
+					Name:         "Tomato Soup",
+					Ingredients:  []string{"4 tomatoes", "1 onion"},
+					Instructions: []string{"Chop the tomatoes.", "Simmer for 20 minutes.", "Blend."},
+					ImageURL:     "https://cdn.example.com/tomato-soup.jpg",
+					Tags:         []string{"soup", "quick"},
+					Servings:     2,
+					Locale:       "fr",
+				},
+				{
+					Name:         "Garlic Bread",
+					Instructions: []string{"Slice the bread", "Bake"},
+					Servings:     8,
+				},
+			},
+		},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		recipes, err := extractFixture(t, tc.fixture, tc.base)
+		if err != nil {
+			t.Errorf("Unexpected error extracting recipes: %s", err)
+			continue
+		}
+		if !reflect.DeepEqual(recipes, tc.exp) {
+			t.Errorf("Expected %+v, got %+v", tc.exp, recipes)
+		}
+	}
+
+	if _, err := extractFixture(t, "none.html", ""); err != ErrNoRecipe {
+		t.Errorf("Expected ErrNoRecipe, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Package schemaorg converts recipes to schema.org Recipe JSON-LD, the structured data search engines
+// read to show recipe rich results.
+package schemaorg
+
+import (
+	"encoding/json"
+	"fmt"
+	"framework-api/models"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// Context is the @context of every document.
+const Context = "https://schema.org"
+
+// Recipe is a schema.org Recipe, https://schema.org/Recipe.
+type Recipe struct {
+	Context            string                `json:"@context,omitempty"`
+	Type               string                `json:"@type"`
+	Name               string                `json:"name"`
+	URL                string                `json:"url,omitempty"`
+	Image              []string              `json:"image,omitempty"`
+	DatePublished      string                `json:"datePublished,omitempty"`
+	InLanguage         string                `json:"inLanguage,omitempty"`
+	Keywords           string                `json:"keywords,omitempty"`
+	RecipeYield        string                `json:"recipeYield,omitempty"`
+	RecipeIngredient   []string              `json:"recipeIngredient"`
+	RecipeInstructions []HowToStep           `json:"recipeInstructions"`
+	Nutrition          *NutritionInformation `json:"nutrition,omitempty"`
+	SuitableForDiet    []string              `json:"suitableForDiet,omitempty"`
+}
+
+// HowToStep is one instruction of a recipe.
+type HowToStep struct {
+	Type string `json:"@type"`
+	Text string `json:"text"`
+}
+
+// NutritionInformation is given per serving, values carry their unit as schema.org expects.
+type NutritionInformation struct {
+	Type                string `json:"@type"`
+	Calories            string `json:"calories"`
+	ProteinContent      string `json:"proteinContent"`
+	CarbohydrateContent string `json:"carbohydrateContent"`
+	FatContent          string `json:"fatContent"`
+	FiberContent        string `json:"fiberContent"`
+}
+
+// ItemList is an ordered list of recipe URLs, used on list and search pages.
+type ItemList struct {
+	Context         string     `json:"@context"`
+	Type            string     `json:"@type"`
+	ItemListElement []ListItem `json:"itemListElement"`
+}
+
+// ListItem is one entry of an ItemList, Position starts at 1.
+type ListItem struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	URL      string `json:"url"`
+	Name     string `json:"name,omitempty"`
+}
+
+// diets maps the derived diets to the schema.org RestrictedDiet values, diets without one are left out.
+var diets = map[string]string{
+	"vegan":       "https://schema.org/VeganDiet",
+	"vegetarian":  "https://schema.org/VegetarianDiet",
+	"gluten-free": "https://schema.org/GlutenFreeDiet",
+}
+
+// FromRecipe describes a (localized) recipe published at url, image is the absolute URL of its picture.
+func FromRecipe(recipe models.Recipe, url, image string) Recipe {
+	doc := Recipe{
+		Context:            Context,
+		Type:               "Recipe",
+		Name:               recipe.Name,
+		URL:                url,
+		InLanguage:         recipe.Locale,
+		Keywords:           strings.Join(recipe.Tags, ", "),
+		RecipeIngredient:   append([]string{}, recipe.Ingredients...),
+		RecipeInstructions: make([]HowToStep, 0, len(recipe.Instructions)),
+	}
+	if image != "" {
+		doc.Image = []string{image}
+	}
+	if !recipe.PublishedAt.IsZero() {
+		doc.DatePublished = recipe.PublishedAt.UTC().Format(time.RFC3339)
+	}
+	if recipe.Servings > 0 {
+		doc.RecipeYield = fmt.Sprintf("%d servings", recipe.Servings)
+	}
+	for _, instruction := range recipe.Instructions {
+		doc.RecipeInstructions = append(doc.RecipeInstructions, HowToStep{Type: "HowToStep", Text: instruction})
+	}
+	if recipe.Nutrition != nil {
+		facts := recipe.Nutrition.PerServing
+		doc.Nutrition = &NutritionInformation{
+			Type:                "NutritionInformation",
+			Calories:            fmt.Sprintf("%.0f calories", facts.Calories),
+			ProteinContent:      fmt.Sprintf("%.1f g", facts.Protein),
+			CarbohydrateContent: fmt.Sprintf("%.1f g", facts.Carbohydrates),
+			FatContent:          fmt.Sprintf("%.1f g", facts.Fat),
+			FiberContent:        fmt.Sprintf("%.1f g", facts.Fiber),
+		}
+	}
+	for _, diet := range recipe.Diets {
+		if value, ok := diets[diet]; ok {
+			doc.SuitableForDiet = append(doc.SuitableForDiet, value)
+		}
+	}
+	return doc
+}
+
+// NewItemList returns an empty list, add entries with Add.
+func NewItemList() *ItemList {
+	return &ItemList{Context: Context, Type: "ItemList", ItemListElement: make([]ListItem, 0)}
+}
+
+// Add appends a recipe page to the list.
+func (l *ItemList) Add(url, name string) {
+	l.ItemListElement = append(l.ItemListElement, ListItem{Type: "ListItem", Position: len(l.ItemListElement) + 1, URL: url, Name: name})
+}
+
+// Script marshals a document for a <script type="application/ld+json"> element. json.Marshal escapes
+// <, > and &, so recipe content can not close the script element.
+func Script(doc interface{}) (template.JS, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(data), nil
+}
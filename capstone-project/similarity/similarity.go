@@ -0,0 +1,118 @@
+// Package similarity ranks recipes by how much their ingredients and tags overlap, without Elasticsearch.
+// Ingredient words are weighted by TF-IDF over the candidates, so "salt" counts for less than "paneer".
+package similarity
+
+import (
+	"framework-api/models"
+	"framework-api/nutrition"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Weights of the two scores, ingredients matter more than tags.
+const (
+	ingredientWeight = 0.75
+	tagWeight        = 0.25
+)
+
+// stopWords are preparation words that say nothing about the dish, units are left to IDF.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "or": true, "of": true, "to": true, "taste": true, "for": true,
+	"the": true, "fresh": true, "chopped": true, "sliced": true, "diced": true, "minced": true,
+	"large": true, "small": true, "medium": true, "optional": true,
+}
+
+// Match is a candidate with its score between 0 and 1.
+type Match struct {
+	Recipe models.Recipe
+	Score  float64
+}
+
+// Rank scores every candidate against target and returns the best limit matches with a score above 0,
+// highest first. The target itself is skipped when it is among the candidates.
+func Rank(target models.Recipe, candidates []models.Recipe, limit int) []Match {
+	docs := make([]map[string]float64, len(candidates))
+	df := map[string]int{}
+	for i, recipe := range candidates {
+		docs[i] = termCounts(recipe.Ingredients)
+		for term := range docs[i] {
+			df[term]++
+		}
+	}
+	idf := func(term string) float64 {
+		//Smoothed, terms of the target missing from the candidates still get a weight
+		return math.Log(float64(len(candidates)+1)/float64(df[term]+1)) + 1
+	}
+	targetVector := weigh(termCounts(target.Ingredients), idf)
+
+	matches := make([]Match, 0)
+	for i, recipe := range candidates {
+		if recipe.ID == target.ID {
+			continue
+		}
+		score := ingredientWeight*cosine(targetVector, weigh(docs[i], idf)) + tagWeight*jaccard(target.Tags, recipe.Tags)
+		if score > 0 {
+			matches = append(matches, Match{Recipe: recipe, Score: math.Round(score*1000) / 1000})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches[:min(limit, len(matches))]
+}
+
+// termCounts counts the normalized words of the ingredient lines.
+func termCounts(ingredients []string) map[string]float64 {
+	counts := map[string]float64{}
+	for _, line := range ingredients {
+		for _, word := range strings.Fields(nutrition.Normalize(line)) {
+			if !stopWords[word] {
+				counts[word]++
+			}
+		}
+	}
+	return counts
+}
+
+func weigh(counts map[string]float64, idf func(string) float64) map[string]float64 {
+	vector := make(map[string]float64, len(counts))
+	for term, count := range counts {
+		vector[term] = count * idf(term)
+	}
+	return vector
+}
+
+func cosine(a, b map[string]float64) float64 {
+	dot, normA, normB := 0.0, 0.0, 0.0
+	for term, weight := range a {
+		dot += weight * b[term]
+		normA += weight * weight
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// jaccard compares tags case insensitively.
+func jaccard(a, b []string) float64 {
+	set := map[string]int{}
+	for _, tag := range a {
+		set[strings.ToLower(tag)] |= 1
+	}
+	for _, tag := range b {
+		set[strings.ToLower(tag)] |= 2
+	}
+	both := 0
+	for _, in := range set {
+		if in == 3 {
+			both++
+		}
+	}
+	if len(set) == 0 {
+		return 0
+	}
+	return float64(both) / float64(len(set))
+}
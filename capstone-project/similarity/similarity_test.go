@@ -0,0 +1,64 @@
+package similarity
+
+import (
+	"framework-api/models"
+	"math"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestJaccard(t *testing.T) {
+	ts := []struct {
+		text string
+		a    []string
+		b    []string
+		exp  float64
+	}{
+		{text: "same tags", a: []string{"soup", "vegan"}, b: []string{"Vegan", "soup"}, exp: 1},
+		{text: "one of three", a: []string{"soup", "vegan"}, b: []string{"soup", "quick"}, exp: 1.0 / 3},
+		{text: "disjoint", a: []string{"soup"}, b: []string{"salad"}, exp: 0},
+		{text: "no tags", exp: 0},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		if got := jaccard(tc.a, tc.b); math.Abs(got-tc.exp) > 1e-9 {
+			t.Errorf("Expected %v, got %v", tc.exp, got)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	recipe := func(name string, tags []string, ingredients ...string) models.Recipe {
+		return models.Recipe{ID: bson.NewObjectID(), Name: name, Tags: tags, Ingredients: ingredients}
+	}
+	target := recipe("Palak Paneer", []string{"indian", "vegetarian"}, "200g paneer", "2 cups spinach", "1 onion", "salt to taste")
+	candidates := []models.Recipe{
+		target,
+		recipe("Pancakes", []string{"breakfast"}, "2 cups flour", "2 eggs", "1 cup milk", "salt to taste"),
+		recipe("Paneer Butter Masala", []string{"indian", "vegetarian"}, "250g paneer", "2 tbsp butter", "1 onion", "2 tomatoes"),
+		recipe("Spinach Salad", []string{"salad"}, "3 cups spinach", "1 red onion"),
+		recipe("Toast", nil, "2 slices bread"),
+	}
+
+	matches := Rank(target, candidates, 3)
+	if len(matches) != 3 {
+		t.Fatalf("Expected 3 matches, got %d", len(matches))
+	}
+	if matches[0].Recipe.Name != "Paneer Butter Masala" || matches[1].Recipe.Name != "Spinach Salad" {
+		t.Errorf("Expected the paneer dish, then the spinach salad, got %s (%v), %s (%v)", matches[0].Recipe.Name, matches[0].Score, matches[1].Recipe.Name, matches[1].Score)
+	}
+	for i, match := range matches {
+		if match.Recipe.ID == target.ID {
+			t.Errorf("Expected the target to be skipped")
+		}
+		if i > 0 && match.Score > matches[i-1].Score {
+			t.Errorf("Expected matches ordered by score, got %v after %v", match.Score, matches[i-1].Score)
+		}
+	}
+	for _, match := range Rank(target, candidates, 10) {
+		if match.Recipe.Name == "Toast" {
+			t.Errorf("Expected no match without common ingredients or tags, got %v", match.Score)
+		}
+	}
+}
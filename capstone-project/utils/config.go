@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig holds the cross origin settings used by the Gin CORS middleware.
+type CORSConfig struct {
+	AllowOrigins []string
+	AllowMethods []string
+	AllowHeaders []string
+}
+
+var defaultCORSOrigins = []string{"http://localhost:3000"}
+var defaultCORSMethods = []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS", "HEAD"}
+var defaultCORSHeaders = []string{"Origin", "Content-Type", "Authorization", "X-Tenant-ID", "X-Request-ID", "If-None-Match", "If-Modified-Since"}
+
+// LoadCORSConfig reads CORS_ALLOW_ORIGINS, CORS_ALLOW_METHODS and CORS_ALLOW_HEADERS
+// as comma separated lists and falls back to the local React app defaults.
+func LoadCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowOrigins: GetEnvList("CORS_ALLOW_ORIGINS", defaultCORSOrigins),
+		AllowMethods: GetEnvList("CORS_ALLOW_METHODS", defaultCORSMethods),
+		AllowHeaders: GetEnvList("CORS_ALLOW_HEADERS", defaultCORSHeaders),
+	}
+}
+
+// GetEnv returns the value of key or fallback when it is unset or empty.
+func GetEnv(key, fallback string) string {
+	if val := strings.TrimSpace(os.Getenv(key)); val != "" {
+		return val
+	}
+	return fallback
+}
+
+// GetEnvList splits a comma separated env var, ignoring empty entries.
+func GetEnvList(key string, fallback []string) []string {
+	val := os.Getenv(key)
+	if strings.TrimSpace(val) == "" {
+		return fallback
+	}
+	list := make([]string, 0)
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return fallback
+	}
+	return list
+}
+
+// GetEnvDuration parses key as a time.Duration (e.g. "90s", "1h") and returns fallback when unset or invalid.
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// GetEnvInt parses key as an integer and returns fallback when unset or invalid.
+func GetEnvInt(key string, fallback int) int {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// GetEnvBool parses key as a boolean (1, t, true, 0, f, false...) and returns fallback when unset or invalid.
+func GetEnvBool(key string, fallback bool) bool {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
+// GetEnvTime parses key as an RFC 3339 timestamp or a 2006-01-02 date (midnight UTC) and returns fallback when unset or invalid.
+func GetEnvTime(key string, fallback time.Time) time.Time {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t
+	}
+	if t, err := time.Parse(time.DateOnly, val); err == nil {
+		return t
+	}
+	return fallback
+}
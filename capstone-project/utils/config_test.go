@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadCORSConfig(t *testing.T) {
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://recipes.example.com, http://localhost:3000 ,")
+	t.Setenv("CORS_ALLOW_METHODS", "")
+	cfg := LoadCORSConfig()
+	expOrigins := []string{"https://recipes.example.com", "http://localhost:3000"}
+	if !reflect.DeepEqual(cfg.AllowOrigins, expOrigins) {
+		t.Errorf("Expected origins %v, got %v", expOrigins, cfg.AllowOrigins)
+	}
+	if !reflect.DeepEqual(cfg.AllowMethods, defaultCORSMethods) {
+		t.Errorf("Expected default methods %v, got %v", defaultCORSMethods, cfg.AllowMethods)
+	}
+}
+
+func TestGetEnvTime(t *testing.T) {
+	fallback := time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC)
+	ts := []struct {
+		text string
+		val  string
+		exp  time.Time
+	}{
+		{text: "unset", val: "", exp: fallback},
+		{text: "date only", val: "2027-01-31", exp: time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{text: "rfc3339", val: "2027-01-31T12:30:00Z", exp: time.Date(2027, 1, 31, 12, 30, 0, 0, time.UTC)},
+		{text: "invalid", val: "next summer", exp: fallback},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.text)
+		t.Setenv("TEST_TIME", tc.val)
+		if got := GetEnvTime("TEST_TIME", fallback); !got.Equal(tc.exp) {
+			t.Errorf("Expected %s, got %s", tc.exp, got)
+		}
+	}
+}
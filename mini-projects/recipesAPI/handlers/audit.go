@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/xid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Audited auth actions, named like the capstone API's models.Audit* constants.
+const (
+	AuditSignIn = "auth.signin"
+	AuditSignUp = "auth.signup"
+)
+
+// RequestIDHeader carries the ID of a request, echoed in the response and recorded in the audit log.
+const RequestIDHeader = "X-Request-ID"
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// AuditEntry is one document of the auditLog collection, the same shape the capstone API writes and serves at
+// GET /admin/audit. Entries written here have no tenant, they belong to its default tenant. audit_test.go checks the
+// fields against the capstone's models.AuditEntry.
+type AuditEntry struct {
+	ID        bson.ObjectID `bson:"_id"`
+	At        time.Time     `bson:"at"`
+	Action    string        `bson:"action"`
+	Outcome   string        `bson:"outcome"`
+	Actor     string        `bson:"actor,omitempty"`
+	ActorName string        `bson:"actorName,omitempty"`
+	IP        string        `bson:"ip,omitempty"`
+	RequestID string        `bson:"requestId,omitempty"`
+	Resource  string        `bson:"resource,omitempty"`
+	Detail    string        `bson:"detail,omitempty"`
+}
+
+// AuditLog appends the sign-in and sign-up events to the auditLog collection. It never updates or deletes
+// an entry. A nil AuditLog records nothing.
+type AuditLog struct {
+	collection *mongo.Collection
+	ctx        context.Context
+}
+
+func NewAuditLog(ctx context.Context, collection *mongo.Collection) *AuditLog {
+	return &AuditLog{
+		collection: collection,
+		ctx:        ctx,
+	}
+}
+
+// Record appends the outcome of action by username, with the client IP and the request ID. Call it before
+// writing the response, the request ID is echoed in its headers.
+func (a *AuditLog) Record(c *gin.Context, action, username string, success bool, detail string) {
+	requestID := c.GetHeader(RequestIDHeader)
+	if !requestIDPattern.MatchString(requestID) {
+		requestID = xid.New().String()
+	}
+	c.Header(RequestIDHeader, requestID)
+	if a == nil {
+		return
+	}
+	outcome := "failure"
+	if success {
+		outcome = "success"
+	}
+	entry := AuditEntry{
+		ID:        bson.NewObjectID(),
+		At:        time.Now().UTC(),
+		Action:    action,
+		Outcome:   outcome,
+		Actor:     username,
+		ActorName: username,
+		IP:        c.ClientIP(),
+		RequestID: requestID,
+		Resource:  "user",
+		Detail:    detail,
+	}
+	//The sign in already happened, a failing audit log is only logged
+	if _, err := a.collection.InsertOne(a.ctx, entry); err != nil {
+		log.Printf("Failed to record audit entry %v for %v: %v", action, username, err)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// capstoneAudit is the capstone API's models/audit.go, which defines the entries of the shared auditLog collection.
+const capstoneAudit = "../../../capstone-project/models/audit.go"
+
+type capstoneField struct {
+	name string
+	typ  string
+	bson string
+}
+
+// parseCapstoneAudit returns the fields of the capstone's AuditEntry in order and the values of its constants.
+func parseCapstoneAudit(t *testing.T) ([]capstoneField, map[string]string) {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), capstoneAudit, nil, 0)
+	if os.IsNotExist(err) {
+		t.Skip("capstone-project is not checked out next to mini-projects")
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %s: %s", capstoneAudit, err)
+	}
+	fields := make([]capstoneField, 0)
+	consts := make(map[string]string)
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.TypeSpec:
+			st, ok := n.Type.(*ast.StructType)
+			if !ok || n.Name.Name != "AuditEntry" {
+				return false
+			}
+			for _, f := range st.Fields.List {
+				tag, _ := strconv.Unquote(f.Tag.Value)
+				for _, name := range f.Names {
+					fields = append(fields, capstoneField{name: name.Name, typ: exprString(f.Type), bson: reflect.StructTag(tag).Get("bson")})
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if i >= len(n.Values) {
+					break
+				}
+				if lit, ok := n.Values[i].(*ast.BasicLit); ok {
+					consts[name.Name], _ = strconv.Unquote(lit.Value)
+				}
+			}
+		}
+		return true
+	})
+	if len(fields) == 0 {
+		t.Fatalf("Expected an AuditEntry struct in %s", capstoneAudit)
+	}
+	return fields, consts
+}
+
+// exprString names a field type like reflect does, "bson.ObjectID" or "time.Time".
+func exprString(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	}
+	return ""
+}
+
+func TestAuditEntryEncodesLikeCapstone(t *testing.T) {
+	fields, _ := parseCapstoneAudit(t)
+	entry := reflect.TypeOf(AuditEntry{})
+	last := -1
+	for i := range entry.NumField() {
+		f := entry.Field(i)
+		t.Logf("Testing %s", f.Name)
+		at := slices.IndexFunc(fields, func(c capstoneField) bool { return c.name == f.Name })
+		if at < 0 {
+			t.Errorf("Expected %s in the capstone AuditEntry", f.Name)
+			continue
+		}
+		if c := fields[at]; c.bson != f.Tag.Get("bson") || c.typ != f.Type.String() {
+			t.Errorf("Expected %s %s `bson:%q`, got %s `bson:%q`", f.Name, c.typ, c.bson, f.Type, f.Tag.Get("bson"))
+		}
+		//Fields are encoded in order, the same entry must give the same document
+		if at < last {
+			t.Errorf("Expected %s after the fields before it, as in the capstone AuditEntry", f.Name)
+		}
+		last = at
+	}
+	//Fields only the capstone writes are left out of the documents written here
+	for _, c := range fields {
+		if _, ok := entry.FieldByName(c.name); !ok && !strings.HasSuffix(c.bson, ",omitempty") {
+			t.Errorf("Expected the capstone field %s to be omitempty, got `bson:%q`", c.name, c.bson)
+		}
+	}
+}
+
+func TestAuditActionsMatchCapstone(t *testing.T) {
+	_, consts := parseCapstoneAudit(t)
+	ts := []struct {
+		action   string
+		capstone string
+	}{
+		{action: AuditSignIn, capstone: "AuditAuthSignIn"},
+		{action: AuditSignUp, capstone: "AuditAuthSignUp"},
+		{action: "success", capstone: "AuditSuccess"},
+		{action: "failure", capstone: "AuditFailure"},
+	}
+	for _, tc := range ts {
+		t.Logf("Testing %s", tc.capstone)
+		if got := consts[tc.capstone]; got != tc.action {
+			t.Errorf("Expected models.%s %q, got %q", tc.capstone, tc.action, got)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 type AuthHandler struct {
 	collection *mongo.Collection
 	ctx        context.Context
+	audit      *AuditLog
 }
 type Claims struct {
 	Username string `json:"username"`
@@ -32,10 +33,12 @@ type JWTOutput struct {
 	Expires time.Time `json:"expires"`
 }
 
-func NewAuthHandler(ctx context.Context, collection *mongo.Collection) *AuthHandler {
+// NewAuthHandler stores the users in collection and records every sign in and sign up in audit, nil for none.
+func NewAuthHandler(ctx context.Context, collection *mongo.Collection, audit *AuditLog) *AuthHandler {
 	return &AuthHandler{
 		collection: collection,
 		ctx:        ctx,
+		audit:      audit,
 	}
 }
 
@@ -61,6 +64,7 @@ func (h *AuthHandler) SignInHandler(c *gin.Context) {
 	curr := h.collection.FindOne(h.ctx, bson.M{"username": userCreds.Username, "password": givenHashedPassword})
 	if curr.Err() != nil {
 		log.Printf("User not found or invalid credentials: %v", curr.Err())
+		h.audit.Record(c, AuditSignIn, userCreds.Username, false, "invalid credentials")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
@@ -78,6 +82,7 @@ func (h *AuthHandler) SignInHandler(c *gin.Context) {
 	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
 	if err != nil {
 		log.Printf("Error generating token: %v", err)
+		h.audit.Record(c, AuditSignIn, userCreds.Username, false, "could not generate token")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
 		return
 	}
@@ -87,6 +92,7 @@ func (h *AuthHandler) SignInHandler(c *gin.Context) {
 		Expires: expirationTime,
 	}
 	//log.Printf("JWTOutput: %v", jwtOutput)
+	h.audit.Record(c, AuditSignIn, userCreds.Username, true, "")
 	c.JSON(http.StatusOK, jwtOutput)
 
 }
@@ -125,6 +131,7 @@ func (h *AuthHandler) SignUpHandler(c *gin.Context) {
 	curr := h.collection.FindOne(h.ctx, bson.M{"username": user.Username})
 	if curr.Err() == nil {
 		log.Printf("User already exists: %v", user.Username)
+		h.audit.Record(c, AuditSignUp, user.Username, false, "username already exists")
 		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
 		return
 	}
@@ -143,11 +150,20 @@ func (h *AuthHandler) SignUpHandler(c *gin.Context) {
 	}
 
 	_, err := h.collection.InsertOne(h.ctx, newUserData)
+	//The unique username index catches sign ups racing past the check above
+	if mongo.IsDuplicateKeyError(err) {
+		log.Printf("User already exists: %v", user.Username)
+		h.audit.Record(c, AuditSignUp, user.Username, false, "username already exists")
+		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+		return
+	}
 	if err != nil {
-		log.Print("Error inserting new user: %v", err)
+		log.Printf("Error inserting new user: %v", err)
+		h.audit.Record(c, AuditSignUp, user.Username, false, "could not store the user")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
 	msg := fmt.Sprintf("User %s created successfully", user.Username)
+	h.audit.Record(c, AuditSignUp, user.Username, true, "")
 	c.JSON(http.StatusOK, gin.H{"message": msg})
 }
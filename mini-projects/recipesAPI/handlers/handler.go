@@ -31,16 +31,7 @@ func NewRecipesHandler(ctx context.Context, collection *mongo.Collection, redisC
 	}
 }
 
-// Swagger Documentation
-// getRecipes godoc
-// @Summary Get All Recipes
-// @Description Gets all the recipes from the in-memory store
-// @Tags recipes
-// @Accept json
-// @Produce json
-// @Success 200 {array} main.Recipe
-// @Failure 400 {object} map[string]string "error"
-// @Router /recipes [get]
+// GetRecipes returns all recipes from MongoDB, cached in Redis under "recipes".
 func (h *RecipeHandler) GetRecipes(c *gin.Context) {
 	log.Print("Fetching all recipes")
 	//Check Redis first
@@ -89,17 +80,7 @@ func (h *RecipeHandler) GetRecipes(c *gin.Context) {
 	}
 }
 
-// Swagger Documentation
-// getRecipesById godoc
-// @Summary Get Recipe by ID
-// @Description Gets the recipe by ID from the in-memory store
-// @Tags recipes
-// @Accept json
-// @Produce json
-// @Param id path string true "Recipe ID"
-// @Success 200 {object} Recipe
-// @Failure 400 {object} map[string]string "error"
-// @Router /recipe/{id} [get]
+// GetRecipeById returns one recipe by its ObjectID.
 func (h *RecipeHandler) GetRecipeById(c *gin.Context) {
 	recipeId := c.Param("id")
 	log.Printf("Updating recipe with id: %v", recipeId)
@@ -124,17 +105,7 @@ func (h *RecipeHandler) GetRecipeById(c *gin.Context) {
 	c.JSON(http.StatusOK, recipe)
 }
 
-// Swagger Documentation
-// insertRecipe godoc
-// @Summary Create a new recipe
-// @Description Adds a new recipe to the in-memory store
-// @Tags recipes
-// @Accept json
-// @Produce json
-// @Param recipe body Recipe true "Recipe Data"
-// @Success 201 {object} Recipe
-// @Failure 400 {object} map[string]string "error"
-// @Router /recipe [post]
+// InsertRecipe stores a new recipe and invalidates the list cache.
 func (h *RecipeHandler) InsertRecipe(c *gin.Context) {
 	var Recipe models.Recipe
 	if err := c.ShouldBindJSON(&Recipe); err != nil {
@@ -154,17 +125,7 @@ func (h *RecipeHandler) InsertRecipe(c *gin.Context) {
 	c.JSON(http.StatusCreated, Recipe)
 }
 
-// Swagger Documentation
-// updateRecipeById godoc
-// @Summary UPDATE Recipe by ID
-// @Description Update the recipe by ID from the in-memory store
-// @Tags recipes
-// @Accept json
-// @Produce json
-// @Param id path string true "Recipe ID"
-// @Success 200 {object} Recipe
-// @Failure 400 {object} map[string]string "error"
-// @Router /recipe/{id} [put]
+// UpdateRecipeById replaces the name, tags, ingredients and instructions of a recipe.
 func (h *RecipeHandler) UpdateRecipeById(c *gin.Context) {
 	recipeId := c.Param("id")
 	var recipe models.Recipe
@@ -203,17 +164,7 @@ func (h *RecipeHandler) UpdateRecipeById(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": msg})
 }
 
-// Swagger Documentation
-// deleteRecipeById godoc
-// @Summary Delete Recipe by ID from Recipes
-// @Description Delete the recipe by ID from the in-memory store
-// @Tags recipes
-// @Accept json
-// @Produce json
-// @Param id path string true "Recipe ID"
-// @Success 200 {object} Recipe
-// @Failure 400 {object} map[string]string "error"
-// @Router /recipe/{id} [delete]
+// DeleteRecipeById removes a recipe.
 func (h *RecipeHandler) DeleteRecipeById(c *gin.Context) {
 	recipeId := c.Param("id")
 	log.Printf("Deleting recipe with id: %v", recipeId)
@@ -1,3 +1,8 @@
+//go:build ignore
+
+// The Swagger sample of learnGin.md, run on its own with `go run learn-samples/first-recipe.go` after `swag init`
+// generated the docs package it imports. The module serves a code-built OpenAPI document instead and has no docs
+// package, so the sample is left out of the build.
 package main
 
 import (
@@ -3,31 +3,17 @@ package main
 import (
 	"context"
 	"framework-api/handlers"
+	"framework-api/routes"
 	"os"
 
-	_ "framework-api/docs"
 	"log"
-	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
-type Recipe struct {
-	ID           bson.ObjectID `json:"id" bson:"_id"`
-	Name         string        `json:"name" bson:"name"`
-	Tags         []string      `json:"tags" bson:"tags"`
-	Ingredients  []string      `json:"ingredients" bson:"ingredients"`
-	Instructions []string      `json:"instructions" bson:"instructions"`
-	PublishedAt  time.Time     `json:"publishedAt" bson:"publishedAt"`
-}
-
 // mongodb connection
 var ctx context.Context
 var err error
@@ -42,6 +28,9 @@ var recipeHandler *handlers.RecipeHandler
 // From AuthHandler
 var authHandler *handlers.AuthHandler
 
+// Sign in and sign up events, in the audit log the capstone API serves
+var auditLog *handlers.AuditLog
+
 func init() {
 	log.Println("Initializing the init() function...")
 	ctx = context.Background()
@@ -78,56 +67,16 @@ func init() {
 	recipeHandler = handlers.NewRecipesHandler(ctx, collectionRecipes, redisClient)
 	log.Println("Initialize Authentication Handler")
 	collectionUsers = client.Database("recipeDB").Collection("users")
-	authHandler = handlers.NewAuthHandler(ctx, collectionUsers)
+	auditLog = handlers.NewAuditLog(ctx, client.Database("recipeDB").Collection("auditLog"))
+	authHandler = handlers.NewAuthHandler(ctx, collectionUsers, auditLog)
 }
 
-// Swagger Documentation
-// @title Recipe API
-// @version 1.0
-// @description This is a sample recipe management API.
-// @termsOfService http://swagger.io/terms/
-
-// @contact.name API Support
-// @contact.url http://www.swagger.io/support
-// @contact.email support@swagger.io
-
-// @license.name Apache 2.0
-// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
-
-// @host localhost:8088
-// @BasePath /
-
 func main() {
 	log.Println("Initializing server...")
 	engine := gin.Default()
 
-	//Check Server API status
-	engine.GET("/", func(c *gin.Context) {
-		c.JSON(http.StatusOK, "Welcome to Recipe APIs")
-	})
-	engine.GET("/ping", func(c *gin.Context) {
-		c.JSON(http.StatusOK, "pong")
-	})
-
-	//RECIPE APIs
-	engine.GET("/recipes", recipeHandler.GetRecipes)
-	engine.GET("/recipe/:id", recipeHandler.GetRecipeById)
-	engine.POST("/signup", authHandler.SignUpHandler)
-	//engine.POST("/recipe", recipeHandler.InsertRecipe)
-	//engine.PATCH("/recipe/:id", recipeHandler.UpdateRecipeById)
-	//engine.DELETE("/recipe/:id", recipeHandler.DeleteRecipeById)
-
-	//Swagger Route
-	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	//AUTH APIs
-	engine.POST("/signin", authHandler.SignInHandler)
-
-	//AUTH Middleware
-	engine.Use(authHandler.AuthMiddleware())
-	engine.POST("/recipe", recipeHandler.InsertRecipe)
-	engine.PATCH("/recipe/:id", recipeHandler.UpdateRecipeById)
-	engine.DELETE("/recipe/:id", recipeHandler.DeleteRecipeById)
+	//Public, auth and protected write routes
+	routes.SetupRouter(engine, recipeHandler, authHandler)
 
 	//start the server
 	if err := engine.Run(":8088"); err != nil {
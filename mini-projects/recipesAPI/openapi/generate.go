@@ -0,0 +1,5 @@
+package openapi
+
+// The builder is kept in the capstone project, openapi_gen.go is a copy of it. Run go generate ./openapi after
+// changing it there, openapi_test.go fails while the copy is behind.
+//go:generate sh -c "{ echo '// Code generated from capstone-project/openapi/openapi.go by go generate. DO NOT EDIT.'; echo; cat ../../../capstone-project/openapi/openapi.go; } > openapi_gen.go"
@@ -0,0 +1,25 @@
+package openapi
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestGeneratedCopyMatchesCapstone(t *testing.T) {
+	source, err := os.ReadFile("../../../capstone-project/openapi/openapi.go")
+	if os.IsNotExist(err) {
+		t.Skip("capstone-project is not checked out next to mini-projects")
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error reading the builder: %s", err)
+	}
+	generated, err := os.ReadFile("openapi_gen.go")
+	if err != nil {
+		t.Fatalf("Unexpected error reading the copy: %s", err)
+	}
+	_, copied, ok := bytes.Cut(generated, []byte("DO NOT EDIT.\n\n"))
+	if !ok || !bytes.Equal(copied, source) {
+		t.Errorf("Expected openapi_gen.go to match capstone-project/openapi/openapi.go, run go generate ./openapi")
+	}
+}
@@ -0,0 +1,179 @@
+package routes
+
+import (
+	"framework-api/handlers"
+	"framework-api/models"
+	"framework-api/openapi"
+	"net/http"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+var tokenSecurity = []openapi.SecurityRequirement{{"tokenAuth": {}}}
+
+// OpenAPISpec documents every route registered by SetupRouter.
+// routes/openapi_test.go fails when a route is added to one but not the other.
+func OpenAPISpec() *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:       "Recipe API",
+		Version:     "1.0",
+		Description: "Recipe management API backed by MongoDB and cached in Redis, with signup/signin issued JWTs.",
+		License:     &openapi.License{Name: "Apache 2.0", URL: "http://www.apache.org/licenses/LICENSE-2.0.html"},
+	})
+	doc.Servers = []openapi.Server{{URL: "http://localhost:8088", Description: "Local development"}}
+	doc.Tags = []openapi.Tag{
+		{Name: "recipes", Description: "Recipe catalog"},
+		{Name: "auth", Description: "Signup and signin"},
+		{Name: "system", Description: "Health and documentation"},
+	}
+	doc.Components.SecuritySchemes["tokenAuth"] = openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        "Authorization",
+		Description: "HS256 JWT returned by /signin, sent as the raw header value without a Bearer prefix.",
+	}
+
+	recipe := doc.AddSchema("Recipe", models.Recipe{})
+	userProfile := doc.AddSchema("UserProfile", models.UserProfile{})
+	userCreds := doc.AddSchema("UserCreds", models.UserCreds{})
+	jwtOutput := doc.AddSchema("JWTOutput", handlers.JWTOutput{})
+	errorSchema := doc.AddSchema("Error", errorResponse{})
+	message := doc.AddSchema("Message", messageResponse{})
+	doc.Components.Schemas["RecipeInput"] = recipeInputSchema(doc.Components.Schemas["Recipe"])
+
+	badRequest := openapi.JSONResponse("Invalid request", errorSchema)
+	notFound := openapi.JSONResponse("Recipe not found", errorSchema)
+	serverError := openapi.JSONResponse("Database or cache failure", errorSchema)
+	unauthorized := openapi.JSONResponse("Missing or invalid token", errorSchema)
+	idParam := openapi.PathParam("id", "Recipe ID, a 24 character hex MongoDB ObjectID")
+
+	doc.AddOperation(http.MethodGet, "/", openapi.Operation{
+		OperationID: "welcome",
+		Summary:     "Welcome message",
+		Tags:        []string{"system"},
+		Responses:   map[string]openapi.Response{"200": openapi.JSONResponse("Welcome message", &openapi.Schema{Type: "string"})},
+	})
+	doc.AddOperation(http.MethodGet, "/ping", openapi.Operation{
+		OperationID: "ping",
+		Summary:     "Health check",
+		Tags:        []string{"system"},
+		Responses:   map[string]openapi.Response{"200": openapi.JSONResponse("pong", &openapi.Schema{Type: "string"})},
+	})
+	doc.AddOperation(http.MethodGet, "/openapi.json", openapi.Operation{
+		OperationID: "openAPISpec",
+		Summary:     "This OpenAPI document",
+		Tags:        []string{"system"},
+		Responses:   map[string]openapi.Response{"200": openapi.JSONResponse("OpenAPI 3.1 document", &openapi.Schema{Type: "object"})},
+	})
+
+	doc.AddOperation(http.MethodPost, "/signup", openapi.Operation{
+		OperationID: "signUp",
+		Summary:     "Register a new user",
+		Tags:        []string{"auth"},
+		RequestBody: openapi.JSONBody("New user profile", userProfile),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("User created", message),
+			"400": badRequest,
+			"409": openapi.JSONResponse("Username already exists", errorSchema),
+			"500": serverError,
+		},
+	})
+	doc.AddOperation(http.MethodPost, "/signin", openapi.Operation{
+		OperationID: "signIn",
+		Summary:     "Sign in and receive a JWT",
+		Description: "The token expires after 30 minutes.",
+		Tags:        []string{"auth"},
+		RequestBody: openapi.JSONBody("Username and password", userCreds),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Signed token", jwtOutput),
+			"400": badRequest,
+			"401": openapi.JSONResponse("Invalid credentials", errorSchema),
+			"500": serverError,
+		},
+	})
+
+	doc.AddOperation(http.MethodGet, "/recipes", openapi.Operation{
+		OperationID: "listRecipes",
+		Summary:     "List all recipes",
+		Description: "Returns every recipe from MongoDB, cached in Redis under the `recipes` key.",
+		Tags:        []string{"recipes"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("All recipes", openapi.ArrayOf(recipe)),
+			"404": openapi.JSONResponse("No recipes found", errorSchema),
+			"500": serverError,
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/recipe/:id", openapi.Operation{
+		OperationID: "getRecipe",
+		Summary:     "Get a recipe by ID",
+		Tags:        []string{"recipes"},
+		Parameters:  []openapi.Parameter{idParam},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("The recipe", recipe),
+			"400": badRequest,
+			"404": notFound,
+			"500": serverError,
+		},
+	})
+	doc.AddOperation(http.MethodPost, "/recipe", openapi.Operation{
+		OperationID: "createRecipe",
+		Summary:     "Create a recipe",
+		Tags:        []string{"recipes"},
+		RequestBody: openapi.JSONBody("Recipe to create, id and publishedAt are assigned by the server", openapi.Ref("RecipeInput")),
+		Security:    tokenSecurity,
+		Responses: map[string]openapi.Response{
+			"201": openapi.JSONResponse("Created recipe", recipe),
+			"400": badRequest,
+			"401": unauthorized,
+			"500": serverError,
+		},
+	})
+	doc.AddOperation(http.MethodPatch, "/recipe/:id", openapi.Operation{
+		OperationID: "updateRecipe",
+		Summary:     "Update a recipe",
+		Description: "Replaces name, tags, ingredients and instructions, omitted fields are cleared.",
+		Tags:        []string{"recipes"},
+		Parameters:  []openapi.Parameter{idParam},
+		RequestBody: openapi.JSONBody("New recipe content", openapi.Ref("RecipeInput")),
+		Security:    tokenSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Update confirmation", message),
+			"400": badRequest,
+			"401": unauthorized,
+			"500": serverError,
+		},
+	})
+	doc.AddOperation(http.MethodDelete, "/recipe/:id", openapi.Operation{
+		OperationID: "deleteRecipe",
+		Summary:     "Delete a recipe",
+		Tags:        []string{"recipes"},
+		Parameters:  []openapi.Parameter{idParam},
+		Security:    tokenSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Delete confirmation", message),
+			"400": badRequest,
+			"401": unauthorized,
+			"404": notFound,
+			"500": serverError,
+		},
+	})
+	return doc
+}
+
+// recipeInputSchema is the Recipe schema without the server assigned fields, only name is required.
+func recipeInputSchema(recipe *openapi.Schema) *openapi.Schema {
+	input := &openapi.Schema{Type: "object", Properties: make(map[string]*openapi.Schema), Required: []string{"name"}}
+	for name, prop := range recipe.Properties {
+		if name == "id" || name == "publishedAt" {
+			continue
+		}
+		input.Properties[name] = prop
+	}
+	return input
+}
@@ -0,0 +1,169 @@
+package routes
+
+import (
+	"encoding/json"
+	"framework-api/models"
+	"framework-api/openapi"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// undocumentedRoutes are served by the engine but are not part of the API.
+var undocumentedRoutes = map[string]bool{
+	"GET /swagger/*any":  true,
+	"HEAD /swagger/*any": true,
+}
+
+func TestOpenAPIMatchesRegisteredRoutes(t *testing.T) {
+	engine := newTestEngine(t)
+	doc := OpenAPISpec()
+
+	registered := make(map[string]bool)
+	for _, route := range engine.Routes() {
+		key := route.Method + " " + route.Path
+		if undocumentedRoutes[key] {
+			continue
+		}
+		registered[key] = true
+		item, ok := doc.Paths[openapi.OpenAPIPath(route.Path)]
+		if !ok || item[strings.ToLower(route.Method)] == nil {
+			t.Errorf("Route %s is registered but not documented", key)
+		}
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			key := strings.ToUpper(method) + " " + ginPath(path)
+			if !registered[key] {
+				t.Errorf("Operation %s is documented but not registered", key)
+			}
+		}
+	}
+}
+
+func TestOpenAPIWriteRoutesDeclareSecurity(t *testing.T) {
+	doc := OpenAPISpec()
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			//signup and signin are how a caller gets a token
+			if method == "get" || path == "/signup" || path == "/signin" {
+				continue
+			}
+			if len(op.Security) == 0 {
+				t.Errorf("Write operation %s %s has no security requirement", method, path)
+			}
+			for _, req := range op.Security {
+				for scheme := range req {
+					if _, ok := doc.Components.SecuritySchemes[scheme]; !ok {
+						t.Errorf("Operation %s %s uses undeclared security scheme %s", method, path, scheme)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestOpenAPISchemasFollowModels(t *testing.T) {
+	doc := OpenAPISpec()
+	ts := []struct {
+		name  string
+		model interface{}
+	}{
+		{name: "Recipe", model: models.Recipe{}},
+		{name: "UserProfile", model: models.UserProfile{}},
+		{name: "UserCreds", model: models.UserCreds{}},
+	}
+	for _, tc := range ts {
+		schema, ok := doc.Components.Schemas[tc.name]
+		if !ok {
+			t.Errorf("Schema %s is missing", tc.name)
+			continue
+		}
+		exp := jsonFieldNames(reflect.TypeOf(tc.model))
+		got := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			got = append(got, name)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(exp, got) {
+			t.Errorf("Schema %s: expected properties %v, got %v", tc.name, exp, got)
+		}
+	}
+}
+
+func TestOpenAPIDocumentIsServed(t *testing.T) {
+	engine := newTestEngine(t)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var doc struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Unexpected error decoding document: %s", err)
+	}
+	if doc.OpenAPI != openapi.Version {
+		t.Errorf("Expected openapi %s, got %s", openapi.Version, doc.OpenAPI)
+	}
+	//Every $ref must point at a declared component schema
+	spec := OpenAPISpec()
+	for _, ref := range findRefs(w.Body.Bytes()) {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("Unresolved reference %s", ref)
+		}
+	}
+}
+
+func ginPath(openAPIPath string) string {
+	parts := strings.Split(openAPIPath, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			parts[i] = ":" + strings.Trim(part, "{}")
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func jsonFieldNames(t reflect.Type) []string {
+	names := make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func findRefs(data []byte) []string {
+	refs := make([]string, 0)
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, child := range val {
+				if ref, ok := child.(string); ok && k == "$ref" {
+					refs = append(refs, ref)
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range val {
+				walk(child)
+			}
+		}
+	}
+	var root interface{}
+	json.Unmarshal(data, &root)
+	walk(root)
+	return refs
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"framework-api/handlers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// SetupRouter registers the recipe and auth routes on the engine.
+// Write routes are declared in a group behind the auth middleware.
+func SetupRouter(engine *gin.Engine, recipeHandler *handlers.RecipeHandler, authHandler *handlers.AuthHandler) {
+	//Check Server API status
+	engine.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "Welcome to Recipe APIs")
+	})
+	engine.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "pong")
+	})
+
+	//RECIPE APIs
+	engine.GET("/recipes", recipeHandler.GetRecipes)
+	engine.GET("/recipe/:id", recipeHandler.GetRecipeById)
+
+	//OpenAPI document and Swagger UI rendering it
+	engine.GET("/openapi.json", OpenAPISpec().Handler())
+	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/openapi.json")))
+
+	//AUTH APIs
+	engine.POST("/signup", authHandler.SignUpHandler)
+	engine.POST("/signin", authHandler.SignInHandler)
+
+	//AUTH Middleware
+	authorized := engine.Group("/", authHandler.AuthMiddleware())
+	{
+		authorized.POST("/recipe", recipeHandler.InsertRecipe)
+		authorized.PATCH("/recipe/:id", recipeHandler.UpdateRecipeById)
+		authorized.DELETE("/recipe/:id", recipeHandler.DeleteRecipeById)
+	}
+}
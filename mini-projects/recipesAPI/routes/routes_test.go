@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"context"
+	"framework-api/handlers"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	//Handlers are never reached for unauthenticated writes, so no backing stores are needed.
+	SetupRouter(engine, handlers.NewRecipesHandler(context.Background(), nil, nil), handlers.NewAuthHandler(context.Background(), nil, nil))
+	return engine
+}
+
+func TestUnauthenticatedWritesReturn401(t *testing.T) {
+	engine := newTestEngine(t)
+	for _, method := range []string{http.MethodPost, http.MethodPatch, http.MethodDelete} {
+		path := "/recipe"
+		if method != http.MethodPost {
+			path = "/recipe/65f1c0ffee0000000000abcd"
+		}
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"name":"test"}`))
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: expected status %d, got %d", method, path, http.StatusUnauthorized, w.Code)
+		}
+	}
+}